@@ -7,18 +7,30 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"path"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/fawad1985/go-job-orchestrator/internal/api/grpcapi"
 	"github.com/fawad1985/go-job-orchestrator/internal/api/routes"
+	"github.com/fawad1985/go-job-orchestrator/internal/metrics"
 	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/publishers"
 	"github.com/fawad1985/go-job-orchestrator/internal/storage"
 	"github.com/fawad1985/go-job-orchestrator/internal/task_functions"
+	"github.com/fawad1985/go-job-orchestrator/internal/webhooks"
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
 
 	"github.com/go-chi/chi/v5"
@@ -26,34 +38,164 @@ import (
 )
 
 func main() {
-	// Initialize BoltDB storage layer with a local file "jobs.db"
+	// Initialize the storage layer, by default BoltDB with a local file "jobs.db"
 	// This database will store job definitions, executions, and queue state
-	db, err := storage.NewBoltDB("jobs.db")
+	// JSON_USE_NUMBER keeps numbers in job data as json.Number instead of float64
+	useNumber := os.Getenv("JSON_USE_NUMBER") == "true"
+
+	// QUEUE_DISCIPLINE=lifo dequeues the newest job first instead of the oldest
+	discipline := storage.QueueFIFO
+	if strings.EqualFold(os.Getenv("QUEUE_DISCIPLINE"), "lifo") {
+		discipline = storage.QueueLIFO
+	}
+
+	// COMPRESS_EXECUTIONS=true gzips stored execution records, which pays off for large job data
+	compress := os.Getenv("COMPRESS_EXECUTIONS") == "true"
+
+	// STORAGE_BACKEND=postgres stores everything in PostgreSQL at POSTGRES_DSN instead
+	// INSTANCE_ID names this instance in the execution leases of shared storage
+	var db storage.DB
+	var err error
+	storeOpts := storage.Options{UseNumber: useNumber, Discipline: discipline, CompressExecutions: compress, InstanceID: os.Getenv("INSTANCE_ID")}
+	switch backend := os.Getenv("STORAGE_BACKEND"); strings.ToLower(backend) {
+	case "", "bolt":
+		db, err = storage.NewBoltDBWithOptions("jobs.db", storeOpts)
+	case "postgres":
+		db, err = storage.NewPostgresDBWithOptions(os.Getenv("POSTGRES_DSN"), storeOpts)
+	default:
+		err = fmt.Errorf("unknown storage backend %q", backend)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer db.Close()
+
+	// Optionally mirror all writes to a secondary database
+	// Replication is asynchronous, failures are logged and never block the primary
+	if mirrorPath := os.Getenv("MIRROR_DB_PATH"); mirrorPath != "" {
+		secondary, err := storage.NewBoltDBWithOptions(mirrorPath, storage.Options{UseNumber: useNumber, CompressExecutions: compress})
+		if err != nil {
+			log.Fatalf("Failed to initialize mirror database: %v", err)
+		}
+		db = storage.NewMirrorDB(db, secondary, envInt("MIRROR_BACKLOG", 10000), func(op string, err error) {
+			log.Printf("Mirror %s failed: %v", op, err)
+		})
+	}
+
+	// Optionally front the queue with a write-behind memory buffer
+	// Enabled by setting QUEUE_BUFFER_SIZE to the flush batch size
+	if size := envInt("QUEUE_BUFFER_SIZE", 0); size > 0 {
+		db = storage.NewBufferedQueue(db, size, envDuration("QUEUE_FLUSH_INTERVAL", 100*time.Millisecond))
+	}
 
 	// Create a new orchestrator instance with 10 concurrent job slots
 	// The orchestrator manages job execution and task scheduling
-	orch, err := orchestrator.New(db, 10)
+	// TASK_LOG_MAX_BYTES bounds the task log output captured per execution
+	opts := []orchestrator.Option{orchestrator.WithMaxLogBytes(envInt("TASK_LOG_MAX_BYTES", 0))}
+	if useNumber {
+		opts = append(opts, orchestrator.WithUseNumber())
+	}
+	// REQUEUE_INTERRUPTED queues jobs interrupted by the last shutdown instead of resuming them
+	if os.Getenv("REQUEUE_INTERRUPTED") == "true" {
+		opts = append(opts, orchestrator.WithRequeueInterrupted())
+	}
+	// MAX_RETRY_BACKOFF rejects definitions whose retries could wait longer in total
+	if d := envDuration("MAX_RETRY_BACKOFF", 0); d > 0 {
+		opts = append(opts, orchestrator.WithMaxRetryBackoff(d))
+	}
+	// HOLD_MISSING_FUNCTIONS keeps jobs with unregistered task functions queued this long
+	if d := envDuration("HOLD_MISSING_FUNCTIONS", 0); d > 0 {
+		opts = append(opts, orchestrator.WithHoldMissingFunctions(d))
+	}
+	// INPUT_URL_HOSTS restricts inputUrl fetches to a comma separated list of hosts
+	var inputHosts []string
+	for _, host := range strings.Split(os.Getenv("INPUT_URL_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			inputHosts = append(inputHosts, host)
+		}
+	}
+	if len(inputHosts) > 0 {
+		opts = append(opts, orchestrator.WithInputHosts(inputHosts...))
+	}
+	// WORKER_POOLS adds labeled pools for pinned definitions, e.g. "gpu=2,io=8"
+	pools, err := parsePools(os.Getenv("WORKER_POOLS"))
+	if err != nil {
+		log.Fatalf("Invalid WORKER_POOLS: %v", err)
+	}
+	for label, size := range pools {
+		opts = append(opts, orchestrator.WithPool(label, size))
+	}
+	orch, err := orchestrator.New(db, 10, opts...)
 	if err != nil {
 		log.Fatalf("Failed to initialize orchestrator: %v", err)
 	}
 
-	// Load all available task functions dynamically using reflection
+	// Export the queue depth as a Prometheus gauge
+	if err := metrics.RegisterQueueDepth(metrics.DefaultQueue, db.GetQueuedJobCount); err != nil {
+		log.Fatalf("Failed to register queue metrics: %v", err)
+	}
+
+	// Load all available task functions from the registered providers
+	// The built-in task_functions package is discovered using reflection
 	// These functions will be matched with task definitions in jobs
-	taskFunctions, err := loadTaskFunctions()
+	taskFunctions, err := orchestrator.MergeFunctionProviders(
+		orchestrator.FunctionProviderFunc(loadTaskFunctions),
+	)
 	if err != nil {
 		log.Fatalf("Failed to load task functions: %v", err)
 	}
 
+	// Register every loaded function by name
+	// Allows tasks to be resolved by function name and run ad hoc
+	// Outputs returned by a function are passed on to the tasks after it
+	for name, fn := range taskFunctions {
+		orch.RegisterOutputFunction(name, fn)
+	}
+
 	// Load job definitions from JSON files and register them with the orchestrator
-	// Also registers corresponding task functions for each task in the jobs
-	if err := loadJobDefinitions(orch, taskFunctions); err != nil {
+	// Fails before the server starts if any task references an unknown function
+	// ORCHESTRATOR_ENV skips definitions meant for other environments
+	if err := loadJobDefinitions(orch, os.DirFS("job_definitions"), os.Getenv("ORCHESTRATOR_ENV"), taskFunctions); err != nil {
 		log.Fatalf("Failed to load job definitions: %v", err)
 	}
 
+	// Optionally deliver orchestrator events to a webhook
+	// EVENT_WEBHOOK_TYPES restricts delivery to a comma separated list of event types
+	// EVENT_WEBHOOK_WORKERS and EVENT_WEBHOOK_QUEUE_SIZE bound concurrent and pending deliveries
+	if url := os.Getenv("EVENT_WEBHOOK_URL"); url != "" {
+		var types []models.EventType
+		for _, t := range strings.Split(os.Getenv("EVENT_WEBHOOK_TYPES"), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, models.EventType(t))
+			}
+		}
+		events, unsubscribe := orch.Events().Subscribe(100)
+		defer unsubscribe()
+		opts := webhooks.Options{
+			Workers:   envInt("EVENT_WEBHOOK_WORKERS", 0),
+			QueueSize: envInt("EVENT_WEBHOOK_QUEUE_SIZE", 0),
+			Overflow:  webhooks.OverflowPolicy(os.Getenv("EVENT_WEBHOOK_OVERFLOW")),
+		}
+		if opts.Overflow != "" && opts.Overflow != webhooks.OverflowDrop && opts.Overflow != webhooks.OverflowDefer {
+			log.Fatalf("Invalid EVENT_WEBHOOK_OVERFLOW %q, expected drop or defer", opts.Overflow)
+		}
+		go webhooks.NewDispatcherWithOptions(url, opts, types...).Run(events)
+	}
+
+	// Optionally publish execution outcomes to NATS
+	// Outcomes are retried on failure and may be delivered more than once
+	if url := os.Getenv("NATS_URL"); url != "" {
+		subject := os.Getenv("NATS_OUTCOME_SUBJECT")
+		if subject == "" {
+			subject = "orchestrator.outcomes"
+		}
+		publisher, err := publishers.NewNATSPublisher(url, subject)
+		if err != nil {
+			log.Fatalf("Failed to initialize outcome publisher: %v", err)
+		}
+		defer publisher.Close()
+		orch.SetPublisher(publisher)
+	}
+
 	// Set up the Chi router with standard middleware
 	// Provides logging and panic recovery for the HTTP server
 	r := chi.NewRouter()
@@ -61,52 +203,145 @@ func main() {
 	r.Use(middleware.Recoverer)
 
 	// Configure all API routes for the application
-	// Routes are defined in the routes package
+	// Routes are defined in the routes package, including Prometheus metrics
 	routes.SetupRoutes(r, orch)
 
 	// Start the HTTP server on port 8080
 	// This provides the REST API for job management
-	log.Println("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Println("Server starting on :8080")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// Start the gRPC server on its own port
+	// Exposes the same operations as the REST API
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = ":9090"
+	}
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+	}
+	grpcSrv := grpcapi.Register(orch)
+	go func() {
+		log.Printf("gRPC server starting on %s", grpcAddr)
+		if err := grpcSrv.Serve(lis); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
+	// Wait for an interrupt or termination signal
+	// Then shut down the HTTP server and the orchestrator
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Println("Shutting down")
+
+	// Stop accepting requests, allowing in-flight requests to complete
+	grace := envDuration("SHUTDOWN_GRACE_PERIOD", 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown failed: %v", err)
+	}
+
+	// Let open gRPC calls and streams finish within the same grace period
+	stopped := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcSrv.Stop()
+	}
+
+	// Give running jobs the grace period to finish
+	// Unfinished jobs are recovered on the next start
+	// The orchestrator also closes the database
+	if err := orch.CloseWithTimeout(grace); err != nil {
+		log.Printf("Orchestrator shutdown: %v", err)
 	}
 }
 
 // loadTaskFunctions discovers and loads task functions using reflection
 // It examines the task_functions package for compatible method signatures
 // Returns a map of function names to their implementations
-func loadTaskFunctions() (map[string]orchestrator.TaskFunction, error) {
-	taskFunctions := make(map[string]orchestrator.TaskFunction)
-
+func loadTaskFunctions() (map[string]orchestrator.OutputTaskFunction, error) {
 	// Get the type information for the TaskFunctions interface
 	// This is used to find all available task function implementations
 	pkgType := reflect.TypeOf((*task_functions.TaskFunctions)(nil)).Elem()
+	return reflectTaskFunctions(pkgType, task_functions.GetTaskFunction)
+}
+
+// taskFunctionSignatures describes the method signatures accepted as task functions
+const taskFunctionSignatures = "func(context.Context, map[string]interface {}) error or func(context.Context, map[string]interface {}) (map[string]interface {}, error)"
+
+// reflectTaskFunctions loads an implementation of every method of an interface
+// lookup returns the implementation of a method by name, like GetTaskFunction
+// Methods returning only an error are adapted to produce no outputs
+// Returns one error listing every method with a wrong signature or implementation
+func reflectTaskFunctions(iface reflect.Type, lookup func(name string) interface{}) (map[string]orchestrator.OutputTaskFunction, error) {
+	taskFunctions := make(map[string]orchestrator.OutputTaskFunction)
+	var invalid []error
 
 	// Iterate through all methods in the interface
-	// Check each method for compatibility with the TaskFunction signature
-	for i := 0; i < pkgType.NumMethod(); i++ {
-		method := pkgType.Method(i)
+	// Check each method for compatibility with a task function signature
+	for i := 0; i < iface.NumMethod(); i++ {
+		method := iface.Method(i)
 
-		// Verify the method signature matches our TaskFunction type:
+		// Verify the method signature matches one of the task function types:
 		// - Takes context.Context and map[string]interface{}
-		// - Returns error
-		if method.Type.NumIn() == 2 &&
-			method.Type.In(0).String() == "context.Context" &&
-			method.Type.In(1).String() == "map[string]interface {}" &&
-			method.Type.NumOut() == 1 &&
-			method.Type.Out(0).String() == "error" {
+		// - Returns error, or outputs as map[string]interface{} and error
+		t := method.Type
+		if !(t.NumIn() == 2 &&
+			t.In(0).String() == "context.Context" &&
+			t.In(1).String() == "map[string]interface {}" &&
+			(t.NumOut() == 1 && t.Out(0).String() == "error" ||
+				t.NumOut() == 2 && t.Out(0).String() == "map[string]interface {}" && t.Out(1).String() == "error")) {
+			invalid = append(invalid, fmt.Errorf("method %s: signature %s, want %s", method.Name, t, taskFunctionSignatures))
+			continue
+		}
 
-			// Convert method name to expected function name in job definitions
-			// For example: "Process" becomes "processFunction"
-			functionName := fmt.Sprintf("%sFunction", strings.ToLower(method.Name))
+		// Get the actual function implementation
+		// A missing case in the lookup or a mistyped function would otherwise go unnoticed
+		var fn orchestrator.OutputTaskFunction
+		switch impl := lookup(method.Name).(type) {
+		case nil:
+			invalid = append(invalid, fmt.Errorf("method %s: no implementation returned by GetTaskFunction", method.Name))
+			continue
+		case func(context.Context, map[string]interface{}) error:
+			if impl != nil {
+				fn = func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+					return nil, impl(ctx, data)
+				}
+			}
+		case func(context.Context, map[string]interface{}) (map[string]interface{}, error):
+			fn = impl
+		default:
+			invalid = append(invalid, fmt.Errorf("method %s: implementation has type %T, want %s", method.Name, impl, taskFunctionSignatures))
+			continue
+		}
+		if fn == nil {
+			invalid = append(invalid, fmt.Errorf("method %s: implementation is a nil function", method.Name))
+			continue
+		}
 
-			// Get the actual function implementation
-			fn := reflect.ValueOf(task_functions.GetTaskFunction(method.Name)).Interface().(func(context.Context, map[string]interface{}) error)
+		// Convert method name to expected function name in job definitions
+		// For example: "Process" becomes "processFunction"
+		functionName := fmt.Sprintf("%sFunction", strings.ToLower(method.Name))
 
-			// Store the function in our map
-			taskFunctions[functionName] = fn
-			fmt.Printf("Loaded task function: %s\n", functionName)
-		}
+		// Store the function in our map
+		taskFunctions[functionName] = fn
+		fmt.Printf("Loaded task function: %s\n", functionName)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid task functions:\n%w", errors.Join(invalid...))
 	}
 
 	// Ensure at least one task function was loaded
@@ -118,25 +353,25 @@ func loadTaskFunctions() (map[string]orchestrator.TaskFunction, error) {
 }
 
 // loadJobDefinitions reads and registers job definitions from JSON files
-// It loads files from the job_definitions directory and validates them
-// Also associates task functions with each task in the job definitions
-func loadJobDefinitions(orch *orchestrator.Orchestrator, taskFunctions map[string]orchestrator.TaskFunction) error {
-	// Read all files from the job definitions directory
-	jobDefsDir := "job_definitions"
-	files, err := os.ReadDir(jobDefsDir)
+// It loads files from the root of fsys, e.g. os.DirFS or an embed.FS
+// Nothing is registered unless every task's function exists
+// Definitions restricted to other environments than env are skipped, env "" loads all
+func loadJobDefinitions(orch *orchestrator.Orchestrator, fsys fs.FS, env string, taskFunctions map[string]orchestrator.OutputTaskFunction) error {
+	// Read all files from the root of the definitions filesystem
+	files, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return err
 	}
 
-	// Process each JSON file in the directory
+	// Parse each JSON file in the directory
+	var definitions []*models.JobDefinition
 	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".json" {
+		if file.IsDir() || path.Ext(file.Name()) != ".json" {
 			continue
 		}
 
 		// Read and parse the job definition file
-		filePath := filepath.Join(jobDefsDir, file.Name())
-		data, err := os.ReadFile(filePath)
+		data, err := fs.ReadFile(fsys, file.Name())
 		if err != nil {
 			return err
 		}
@@ -144,26 +379,122 @@ func loadJobDefinitions(orch *orchestrator.Orchestrator, taskFunctions map[strin
 		// Unmarshal JSON into a JobDefinition struct
 		var jobDef models.JobDefinition
 		if err := json.Unmarshal(data, &jobDef); err != nil {
-			return err
+			return fmt.Errorf("%s: %w", file.Name(), err)
 		}
+		if !definedForEnvironment(&jobDef, env) {
+			log.Printf("Skipped job definition %s, not enabled in environment %q", jobDef.ID, env)
+			continue
+		}
+		definitions = append(definitions, &jobDef)
+	}
+
+	// Check all definitions before registering any of them
+	// Reports every missing function at once instead of the first one
+	if err := checkTaskFunctions(definitions, taskFunctions); err != nil {
+		return err
+	}
 
-		// Register the job definition with the orchestrator
-		if err := orch.RegisterJobDefinition(&jobDef); err != nil {
+	// Register the job definitions with the orchestrator
+	// Tasks resolve their functions by name, which were registered at startup
+	for _, jobDef := range basesFirst(definitions) {
+		if err := orch.RegisterJobDefinition(jobDef); err != nil {
 			return err
 		}
+		log.Printf("Loaded job definition: %s", jobDef.ID)
+	}
+
+	return nil
+}
+
+// basesFirst orders definitions so each comes after the base it extends
+// Definitions whose base is not among them keep their place, cycles are left
+// for registration to reject
+func basesFirst(definitions []*models.JobDefinition) []*models.JobDefinition {
+	byID := make(map[string]*models.JobDefinition, len(definitions))
+	for _, jobDef := range definitions {
+		byID[jobDef.ID] = jobDef
+	}
+
+	ordered := make([]*models.JobDefinition, 0, len(definitions))
+	placed := make(map[string]bool, len(definitions))
+	var place func(jobDef *models.JobDefinition)
+	place = func(jobDef *models.JobDefinition) {
+		if placed[jobDef.ID] {
+			return
+		}
+		placed[jobDef.ID] = true
+		if base, ok := byID[jobDef.BaseDefinition]; ok {
+			place(base)
+		}
+		ordered = append(ordered, jobDef)
+	}
+	for _, jobDef := range definitions {
+		place(jobDef)
+	}
+	return ordered
+}
 
-		// Register task functions for each task in the job
-		// Ensures all required functions exist for the job's tasks
+// definedForEnvironment reports whether a definition is registered in env
+// Definitions without environments are registered everywhere, restricted
+// definitions only where env is set and listed, so an unset env never loads them
+func definedForEnvironment(jobDef *models.JobDefinition, env string) bool {
+	if len(jobDef.Environments) == 0 {
+		return true
+	}
+	return env != "" && slices.Contains(jobDef.Environments, env)
+}
+
+// checkTaskFunctions verifies that every task references a loaded function
+// Returns one error listing all tasks with a missing function
+func checkTaskFunctions(definitions []*models.JobDefinition, taskFunctions map[string]orchestrator.OutputTaskFunction) error {
+	var missing []error
+	for _, jobDef := range definitions {
 		for _, task := range jobDef.Tasks {
-			fn, ok := taskFunctions[task.FunctionName]
-			if !ok {
-				return fmt.Errorf("no function found for task %s in job %s", task.ID, jobDef.ID)
+			if _, ok := taskFunctions[task.FunctionName]; !ok {
+				missing = append(missing, fmt.Errorf("job %s task %s: unknown function %q", jobDef.ID, task.ID, task.FunctionName))
 			}
-			orch.RegisterTaskFunction(task.ID, fn)
 		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing task functions:\n%w", errors.Join(missing...))
+	}
+	return nil
+}
 
-		log.Printf("Loaded job definition: %s", jobDef.ID)
+// parsePools reads labeled pool sizes from a list like "gpu=2,io=8"
+func parsePools(spec string) (map[string]int, error) {
+	pools := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		label, size, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(size)
+		if !ok || label == "" || err != nil || n <= 0 {
+			return nil, fmt.Errorf("expected label=size, got %q", entry)
+		}
+		pools[label] = n
 	}
+	return pools, nil
+}
 
-	return nil
+// envInt reads an integer setting from the environment
+// Returns the default when the variable is unset or invalid
+func envInt(name string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// envDuration reads a duration setting such as "250ms" from the environment
+// Returns the default when the variable is unset or invalid
+func envDuration(name string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return v
 }
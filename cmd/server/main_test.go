@@ -0,0 +1,303 @@
+// main_test.go tests the server's startup helpers
+// Covers discovering task functions and loading definitions from a filesystem,
+// checked against those functions and the current environment, without a server
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestDefinedForEnvironment checks which definitions load for ORCHESTRATOR_ENV
+// An unset environment must only load definitions without restrictions
+func TestDefinedForEnvironment(t *testing.T) {
+	unrestricted := &models.JobDefinition{ID: "everywhere"}
+	prodOnly := &models.JobDefinition{ID: "prod-only", Environments: []string{"prod"}}
+
+	tests := []struct {
+		name   string
+		jobDef *models.JobDefinition
+		env    string
+		want   bool
+	}{
+		{"unrestricted in unset env", unrestricted, "", true},
+		{"unrestricted in any env", unrestricted, "staging", true},
+		{"restricted in listed env", prodOnly, "prod", true},
+		{"restricted in other env", prodOnly, "staging", false},
+		{"restricted in unset env", prodOnly, "", false},
+	}
+	for _, tt := range tests {
+		if got := definedForEnvironment(tt.jobDef, tt.env); got != tt.want {
+			t.Errorf("%s: definedForEnvironment = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sampleFunctions is an interface of correctly typed task function methods
+type sampleFunctions interface {
+	Process(ctx context.Context, data map[string]interface{}) error
+	ExtractText(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error)
+}
+
+// mistypedFunctions has a method that can't be a task function
+type mistypedFunctions interface {
+	Process(ctx context.Context, data map[string]interface{}) error
+	Count(data map[string]interface{}) int
+}
+
+// emptyFunctions has no methods at all
+type emptyFunctions interface{}
+
+var errProcess = errors.New("process failed")
+
+// process and extractText are correctly typed implementations
+func process(ctx context.Context, data map[string]interface{}) error {
+	return errProcess
+}
+
+func extractText(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"text": data["doc"]}, nil
+}
+
+// lookupOf returns a lookup answering from impls, like GetTaskFunction
+func lookupOf(impls map[string]interface{}) func(name string) interface{} {
+	return func(name string) interface{} { return impls[name] }
+}
+
+// TestReflectTaskFunctions checks which interface methods load as task functions
+// Every wrong signature or implementation is reported and fails the load
+func TestReflectTaskFunctions(t *testing.T) {
+	var nilFunction func(context.Context, map[string]interface{}) error
+
+	tests := []struct {
+		name    string
+		iface   reflect.Type
+		impls   map[string]interface{}
+		want    []string // Function names loaded
+		wantErr []string // Substrings of the error, nil for success
+	}{
+		{
+			name:  "correctly wired",
+			iface: reflect.TypeOf((*sampleFunctions)(nil)).Elem(),
+			impls: map[string]interface{}{"Process": process, "ExtractText": extractText},
+			want:  []string{"extracttextFunction", "processFunction"},
+		},
+		{
+			name:    "wrong method signature",
+			iface:   reflect.TypeOf((*mistypedFunctions)(nil)).Elem(),
+			impls:   map[string]interface{}{"Process": process, "Count": func(map[string]interface{}) int { return 0 }},
+			wantErr: []string{"method Count: signature func(map[string]interface {}) int"},
+		},
+		{
+			name:    "missing implementation",
+			iface:   reflect.TypeOf((*sampleFunctions)(nil)).Elem(),
+			impls:   map[string]interface{}{"Process": process},
+			wantErr: []string{"method ExtractText: no implementation"},
+		},
+		{
+			name:    "nil implementation",
+			iface:   reflect.TypeOf((*sampleFunctions)(nil)).Elem(),
+			impls:   map[string]interface{}{"Process": nilFunction, "ExtractText": extractText},
+			wantErr: []string{"method Process: implementation is a nil function"},
+		},
+		{
+			name:  "mistyped implementations",
+			iface: reflect.TypeOf((*sampleFunctions)(nil)).Elem(),
+			impls: map[string]interface{}{"Process": func(ctx context.Context) error { return nil }, "ExtractText": "extract"},
+			wantErr: []string{
+				"method Process: implementation has type func(context.Context) error",
+				"method ExtractText: implementation has type string",
+			},
+		},
+		{
+			name:    "no methods",
+			iface:   reflect.TypeOf((*emptyFunctions)(nil)).Elem(),
+			wantErr: []string{"no task functions found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functions, err := reflectTaskFunctions(tt.iface, lookupOf(tt.impls))
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("loaded %d functions, want an error", len(functions))
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q doesn't report %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			var names []string
+			for name := range functions {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("loaded %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+// TestReflectTaskFunctionsAdapts checks both signatures run through the loaded functions
+// Functions returning only an error produce no outputs
+func TestReflectTaskFunctionsAdapts(t *testing.T) {
+	functions, err := reflectTaskFunctions(reflect.TypeOf((*sampleFunctions)(nil)).Elem(),
+		lookupOf(map[string]interface{}{"Process": process, "ExtractText": extractText}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	output, err := functions["processFunction"](context.Background(), nil)
+	if output != nil || !errors.Is(err, errProcess) {
+		t.Errorf("processFunction = %v, %v, want no outputs and its error", output, err)
+	}
+	output, err = functions["extracttextFunction"](context.Background(), map[string]interface{}{"doc": "a.pdf"})
+	if err != nil || output["text"] != "a.pdf" {
+		t.Errorf("extracttextFunction = %v, %v, want its outputs", output, err)
+	}
+}
+
+// TestCheckTaskFunctions checks definitions against the loaded functions
+// Every task referencing an unknown function is reported at once
+func TestCheckTaskFunctions(t *testing.T) {
+	loaded := map[string]orchestrator.OutputTaskFunction{"processFunction": nil, "notifyFunction": nil}
+	shared := &models.JobDefinition{ID: "shared", Tasks: []*models.Task{
+		{ID: "first", FunctionName: "processFunction"},
+		{ID: "second", FunctionName: "processFunction"},
+		{ID: "done", FunctionName: "notifyFunction"},
+	}}
+	if err := checkTaskFunctions([]*models.JobDefinition{shared}, loaded); err != nil {
+		t.Errorf("definitions with loaded functions: %v", err)
+	}
+
+	typo := &models.JobDefinition{ID: "typo", Tasks: []*models.Task{
+		{ID: "first", FunctionName: "proccessFunction"},
+		{ID: "done", FunctionName: "notifyFunction"},
+	}}
+	missing := &models.JobDefinition{ID: "missing", Tasks: []*models.Task{
+		{ID: "upload", FunctionName: "uploadFunction"},
+	}}
+	err := checkTaskFunctions([]*models.JobDefinition{shared, typo, missing}, loaded)
+	if err == nil {
+		t.Fatal("definitions with unknown functions passed the check")
+	}
+	for _, want := range []string{
+		`job typo task first: unknown function "proccessFunction"`,
+		`job missing task upload: unknown function "uploadFunction"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't report %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "notifyFunction") {
+		t.Errorf("error %q reports a loaded function", err)
+	}
+}
+
+// newTestOrchestrator returns an orchestrator on a fresh database
+func newTestOrchestrator(t *testing.T) *orchestrator.Orchestrator {
+	t.Helper()
+	db, err := storage.NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	orch, err := orchestrator.New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	t.Cleanup(func() { orch.Close() })
+	return orch
+}
+
+// registered returns the registered definitions by ID
+func registered(t *testing.T, orch *orchestrator.Orchestrator) map[string]*models.JobDefinition {
+	t.Helper()
+	definitions, err := orch.ListJobDefinitions()
+	if err != nil {
+		t.Fatalf("list definitions: %v", err)
+	}
+	byID := make(map[string]*models.JobDefinition, len(definitions))
+	for _, jobDef := range definitions {
+		byID[jobDef.ID] = jobDef
+	}
+	return byID
+}
+
+// TestLoadJobDefinitions loads definitions from an in-memory filesystem
+// Derived definitions sorting before their base still register after it,
+// other files and definitions of other environments are skipped
+func TestLoadJobDefinitions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a-nightly.json": {Data: []byte(`{"id": "nightly", "baseDefinition": "report",
+			"tasks": [{"id": "notify", "functionName": "notifyFunction"}]}`)},
+		"report.json": {Data: []byte(`{"id": "report", "name": "Report",
+			"tasks": [{"id": "build", "functionName": "processFunction"}]}`)},
+		"staging.json": {Data: []byte(`{"id": "staging-only", "environments": ["staging"],
+			"tasks": [{"id": "build", "functionName": "processFunction"}]}`)},
+		"README.md":        {Data: []byte("not a definition")},
+		"drafts/next.json": {Data: []byte("{")},
+	}
+	orch := newTestOrchestrator(t)
+	loaded := map[string]orchestrator.OutputTaskFunction{"processFunction": nil, "notifyFunction": nil}
+	if err := loadJobDefinitions(orch, fsys, "prod", loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	definitions := registered(t, orch)
+	if ids := slices.Sorted(maps.Keys(definitions)); !slices.Equal(ids, []string{"nightly", "report"}) {
+		t.Fatalf("registered %v, want nightly and report", ids)
+	}
+	var tasks []string
+	for _, task := range definitions["nightly"].Tasks {
+		tasks = append(tasks, task.ID)
+	}
+	if !slices.Equal(tasks, []string{"build", "notify"}) {
+		t.Errorf("nightly tasks = %v, want the base's build before its own notify", tasks)
+	}
+}
+
+// TestLoadJobDefinitionsRejects checks that a broken file or missing function
+// fails the load without registering any definition
+func TestLoadJobDefinitionsRejects(t *testing.T) {
+	valid := &fstest.MapFile{Data: []byte(`{"id": "report", "tasks": [{"id": "build", "functionName": "processFunction"}]}`)}
+	loaded := map[string]orchestrator.OutputTaskFunction{"processFunction": nil}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{"invalid JSON", fstest.MapFS{"report.json": valid, "broken.json": {Data: []byte(`{"id": `)}}, "broken.json"},
+		{"missing function", fstest.MapFS{"report.json": valid, "upload.json": {Data: []byte(
+			`{"id": "upload", "tasks": [{"id": "send", "functionName": "uploadFunction"}]}`)}}, `unknown function "uploadFunction"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := newTestOrchestrator(t)
+			err := loadJobDefinitions(orch, tt.fsys, "", loaded)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("load = %v, want an error reporting %s", err, tt.wantErr)
+			}
+			if definitions := registered(t, orch); len(definitions) != 0 {
+				t.Errorf("registered %v after a failed load", slices.Sorted(maps.Keys(definitions)))
+			}
+		})
+	}
+}
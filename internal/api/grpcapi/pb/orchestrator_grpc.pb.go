@@ -0,0 +1,295 @@
+// orchestrator.proto defines the gRPC API of the job orchestrator
+// Mirrors the core REST operations for services preferring gRPC
+// Generated Go code lives in internal/api/grpcapi/pb
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: orchestrator.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Orchestrator_RegisterDefinition_FullMethodName = "/orchestrator.v1.Orchestrator/RegisterDefinition"
+	Orchestrator_ExecuteJob_FullMethodName         = "/orchestrator.v1.Orchestrator/ExecuteJob"
+	Orchestrator_GetJobState_FullMethodName        = "/orchestrator.v1.Orchestrator/GetJobState"
+	Orchestrator_GetSystemState_FullMethodName     = "/orchestrator.v1.Orchestrator/GetSystemState"
+	Orchestrator_WatchJobState_FullMethodName      = "/orchestrator.v1.Orchestrator/WatchJobState"
+)
+
+// OrchestratorClient is the client API for Orchestrator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Orchestrator exposes job registration, execution, and monitoring
+type OrchestratorClient interface {
+	// RegisterDefinition stores a new or updated job definition
+	RegisterDefinition(ctx context.Context, in *RegisterDefinitionRequest, opts ...grpc.CallOption) (*RegisterDefinitionResponse, error)
+	// ExecuteJob queues an execution of a job definition
+	ExecuteJob(ctx context.Context, in *ExecuteJobRequest, opts ...grpc.CallOption) (*ExecuteJobResponse, error)
+	// GetJobState returns the current state of a job execution
+	GetJobState(ctx context.Context, in *GetJobStateRequest, opts ...grpc.CallOption) (*JobState, error)
+	// GetSystemState returns an overview of active and queued jobs
+	GetSystemState(ctx context.Context, in *GetSystemStateRequest, opts ...grpc.CallOption) (*SystemState, error)
+	// WatchJobState streams the state of a job execution until it finishes
+	WatchJobState(ctx context.Context, in *GetJobStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobState], error)
+}
+
+type orchestratorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrchestratorClient(cc grpc.ClientConnInterface) OrchestratorClient {
+	return &orchestratorClient{cc}
+}
+
+func (c *orchestratorClient) RegisterDefinition(ctx context.Context, in *RegisterDefinitionRequest, opts ...grpc.CallOption) (*RegisterDefinitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterDefinitionResponse)
+	err := c.cc.Invoke(ctx, Orchestrator_RegisterDefinition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorClient) ExecuteJob(ctx context.Context, in *ExecuteJobRequest, opts ...grpc.CallOption) (*ExecuteJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecuteJobResponse)
+	err := c.cc.Invoke(ctx, Orchestrator_ExecuteJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorClient) GetJobState(ctx context.Context, in *GetJobStateRequest, opts ...grpc.CallOption) (*JobState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobState)
+	err := c.cc.Invoke(ctx, Orchestrator_GetJobState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorClient) GetSystemState(ctx context.Context, in *GetSystemStateRequest, opts ...grpc.CallOption) (*SystemState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SystemState)
+	err := c.cc.Invoke(ctx, Orchestrator_GetSystemState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorClient) WatchJobState(ctx context.Context, in *GetJobStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobState], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Orchestrator_ServiceDesc.Streams[0], Orchestrator_WatchJobState_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetJobStateRequest, JobState]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orchestrator_WatchJobStateClient = grpc.ServerStreamingClient[JobState]
+
+// OrchestratorServer is the server API for Orchestrator service.
+// All implementations must embed UnimplementedOrchestratorServer
+// for forward compatibility.
+//
+// Orchestrator exposes job registration, execution, and monitoring
+type OrchestratorServer interface {
+	// RegisterDefinition stores a new or updated job definition
+	RegisterDefinition(context.Context, *RegisterDefinitionRequest) (*RegisterDefinitionResponse, error)
+	// ExecuteJob queues an execution of a job definition
+	ExecuteJob(context.Context, *ExecuteJobRequest) (*ExecuteJobResponse, error)
+	// GetJobState returns the current state of a job execution
+	GetJobState(context.Context, *GetJobStateRequest) (*JobState, error)
+	// GetSystemState returns an overview of active and queued jobs
+	GetSystemState(context.Context, *GetSystemStateRequest) (*SystemState, error)
+	// WatchJobState streams the state of a job execution until it finishes
+	WatchJobState(*GetJobStateRequest, grpc.ServerStreamingServer[JobState]) error
+	mustEmbedUnimplementedOrchestratorServer()
+}
+
+// UnimplementedOrchestratorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrchestratorServer struct{}
+
+func (UnimplementedOrchestratorServer) RegisterDefinition(context.Context, *RegisterDefinitionRequest) (*RegisterDefinitionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterDefinition not implemented")
+}
+func (UnimplementedOrchestratorServer) ExecuteJob(context.Context, *ExecuteJobRequest) (*ExecuteJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteJob not implemented")
+}
+func (UnimplementedOrchestratorServer) GetJobState(context.Context, *GetJobStateRequest) (*JobState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobState not implemented")
+}
+func (UnimplementedOrchestratorServer) GetSystemState(context.Context, *GetSystemStateRequest) (*SystemState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSystemState not implemented")
+}
+func (UnimplementedOrchestratorServer) WatchJobState(*GetJobStateRequest, grpc.ServerStreamingServer[JobState]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJobState not implemented")
+}
+func (UnimplementedOrchestratorServer) mustEmbedUnimplementedOrchestratorServer() {}
+func (UnimplementedOrchestratorServer) testEmbeddedByValue()                      {}
+
+// UnsafeOrchestratorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrchestratorServer will
+// result in compilation errors.
+type UnsafeOrchestratorServer interface {
+	mustEmbedUnimplementedOrchestratorServer()
+}
+
+func RegisterOrchestratorServer(s grpc.ServiceRegistrar, srv OrchestratorServer) {
+	// If the following call pancis, it indicates UnimplementedOrchestratorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Orchestrator_ServiceDesc, srv)
+}
+
+func _Orchestrator_RegisterDefinition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterDefinitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServer).RegisterDefinition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orchestrator_RegisterDefinition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServer).RegisterDefinition(ctx, req.(*RegisterDefinitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orchestrator_ExecuteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServer).ExecuteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orchestrator_ExecuteJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServer).ExecuteJob(ctx, req.(*ExecuteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orchestrator_GetJobState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServer).GetJobState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orchestrator_GetJobState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServer).GetJobState(ctx, req.(*GetJobStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orchestrator_GetSystemState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSystemStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServer).GetSystemState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orchestrator_GetSystemState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServer).GetSystemState(ctx, req.(*GetSystemStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orchestrator_WatchJobState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetJobStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrchestratorServer).WatchJobState(m, &grpc.GenericServerStream[GetJobStateRequest, JobState]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orchestrator_WatchJobStateServer = grpc.ServerStreamingServer[JobState]
+
+// Orchestrator_ServiceDesc is the grpc.ServiceDesc for Orchestrator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Orchestrator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orchestrator.v1.Orchestrator",
+	HandlerType: (*OrchestratorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterDefinition",
+			Handler:    _Orchestrator_RegisterDefinition_Handler,
+		},
+		{
+			MethodName: "ExecuteJob",
+			Handler:    _Orchestrator_ExecuteJob_Handler,
+		},
+		{
+			MethodName: "GetJobState",
+			Handler:    _Orchestrator_GetJobState_Handler,
+		},
+		{
+			MethodName: "GetSystemState",
+			Handler:    _Orchestrator_GetSystemState_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchJobState",
+			Handler:       _Orchestrator_WatchJobState_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "orchestrator.proto",
+}
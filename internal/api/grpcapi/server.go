@@ -0,0 +1,170 @@
+// server.go implements the gRPC interface of the job orchestration API
+// Mirrors the REST handlers on top of the same orchestrator
+// Service definition lives in proto/orchestrator.proto
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/api/grpcapi/pb"
+	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchInterval is how often WatchJobState polls the execution state
+const watchInterval = time.Second
+
+// Server implements the Orchestrator gRPC service
+// Encapsulates the orchestrator for job management operations
+type Server struct {
+	pb.UnimplementedOrchestratorServer
+	orch *orchestrator.Orchestrator // Reference to the orchestrator instance
+}
+
+// NewServer creates a new Server instance
+// Initializes with reference to orchestrator for job operations
+func NewServer(orch *orchestrator.Orchestrator) *Server {
+	return &Server{orch: orch}
+}
+
+// Register creates a gRPC server with the Orchestrator service registered
+// Used by main to serve gRPC on its own port
+func Register(orch *orchestrator.Orchestrator, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	pb.RegisterOrchestratorServer(s, NewServer(orch))
+	return s
+}
+
+// RegisterDefinition stores a job definition
+// The definition has the same shape as the REST request body
+func (s *Server) RegisterDefinition(ctx context.Context, req *pb.RegisterDefinitionRequest) (*pb.RegisterDefinitionResponse, error) {
+	// Round trip through JSON to reuse the model's field names
+	// Keeps the gRPC and REST definition formats identical
+	raw, err := json.Marshal(req.GetDefinition().AsMap())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid definition: %v", err)
+	}
+	var jd models.JobDefinition
+	if err := json.Unmarshal(raw, &jd); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid definition: %v", err)
+	}
+
+	if err := s.orch.RegisterJobDefinition(&jd); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RegisterDefinitionResponse{Id: jd.ID}, nil
+}
+
+// ExecuteJob queues an execution of a job definition
+// Returns the execution ID for tracking
+func (s *Server) ExecuteJob(ctx context.Context, req *pb.ExecuteJobRequest) (*pb.ExecuteJobResponse, error) {
+	data := req.GetData().AsMap()
+	executionID, err := s.orch.EnqueueJob(req.GetDefinitionId(), data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ExecuteJobResponse{ExecutionId: executionID}, nil
+}
+
+// GetJobState returns the current state of a job execution
+func (s *Server) GetJobState(ctx context.Context, req *pb.GetJobStateRequest) (*pb.JobState, error) {
+	state, err := s.orch.GetJobExecutionState(req.GetExecutionId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toJobState(state), nil
+}
+
+// GetSystemState returns an overview of active and queued jobs
+func (s *Server) GetSystemState(ctx context.Context, req *pb.GetSystemStateRequest) (*pb.SystemState, error) {
+	state, err := s.orch.GetSystemState()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.SystemState{
+		QueuedJobs:   state.QueuedJobs,
+		QueuedCount:  int32(state.QueuedCount),
+		ExecutedJobs: int32(state.ExecutedJobs),
+	}
+	for i := range state.ActiveJobs {
+		resp.ActiveJobs = append(resp.ActiveJobs, toJobState(&state.ActiveJobs[i]))
+	}
+	return resp, nil
+}
+
+// WatchJobState streams the state of a job execution
+// Sends the state whenever it changes and ends once the job finishes
+func (s *Server) WatchJobState(req *pb.GetJobStateRequest, stream pb.Orchestrator_WatchJobStateServer) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		state, err := s.orch.GetJobExecutionState(req.GetExecutionId())
+		if err != nil {
+			return toStatus(err)
+		}
+
+		// Only send states that differ from the previous one
+		// Avoids flooding clients while a long task runs
+		current, _ := json.Marshal(state)
+		if string(current) != string(last) {
+			if err := stream.Send(toJobState(state)); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		if state.Status.Finished() {
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// toJobState converts an execution state into its protobuf message
+func toJobState(state *models.JobExecutionState) *pb.JobState {
+	js := &pb.JobState{
+		Id:           state.ID,
+		DefinitionId: state.DefinitionID,
+		Status:       string(state.Status),
+		StartTime:    timestamppb.New(state.StartTime),
+	}
+	for _, t := range state.Tasks {
+		js.Tasks = append(js.Tasks, &pb.TaskState{
+			Id:     t.ID,
+			Name:   t.Name,
+			Status: string(t.Status),
+		})
+	}
+	return js
+}
+
+// toStatus maps orchestrator errors to gRPC status codes
+// Follows the same mapping as the REST handlers
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, orchestrator.ErrInvalidDefinition):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, orchestrator.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, orchestrator.ErrQueueFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
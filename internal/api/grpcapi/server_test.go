@@ -0,0 +1,171 @@
+// server_test.go tests the gRPC API against an in-process server
+// Clients talk to it over an in-memory listener, no port is opened
+// Covers the core operations, state streaming, and error codes
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/api/grpcapi/pb"
+	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testTimeout bounds each call and stream of a test
+const testTimeout = 10 * time.Second
+
+// newTestClient serves an orchestrator on an in-memory listener and connects to it
+// Everything is shut down when the test ends
+func newTestClient(t *testing.T) (pb.OrchestratorClient, *orchestrator.Orchestrator) {
+	t.Helper()
+	db, err := storage.NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	orch, err := orchestrator.New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	t.Cleanup(func() { orch.Close() })
+
+	lis := bufconn.Listen(1 << 20)
+	srv := Register(orch)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewOrchestratorClient(conn), orch
+}
+
+// definition builds a definition message from its JSON field names
+func definition(t *testing.T, fields map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		t.Fatalf("build definition: %v", err)
+	}
+	return s
+}
+
+// TestExecuteAndWatchJob registers a definition, executes it, and streams its
+// state until it completes
+func TestExecuteAndWatchJob(t *testing.T) {
+	client, orch := newTestClient(t)
+	orch.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	reg, err := client.RegisterDefinition(ctx, &pb.RegisterDefinitionRequest{Definition: definition(t, map[string]interface{}{
+		"id":    "greet",
+		"tasks": []interface{}{map[string]interface{}{"id": "hello", "functionName": "noop"}},
+	})})
+	if err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+	if reg.GetId() != "greet" {
+		t.Errorf("registered %q, want greet", reg.GetId())
+	}
+
+	data, _ := structpb.NewStruct(map[string]interface{}{"name": "world"})
+	exec, err := client.ExecuteJob(ctx, &pb.ExecuteJobRequest{DefinitionId: "greet", Data: data})
+	if err != nil {
+		t.Fatalf("execute job: %v", err)
+	}
+
+	stream, err := client.WatchJobState(ctx, &pb.GetJobStateRequest{ExecutionId: exec.GetExecutionId()})
+	if err != nil {
+		t.Fatalf("watch job state: %v", err)
+	}
+	var last *pb.JobState
+	for {
+		state, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("receive state: %v", err)
+		}
+		last = state
+	}
+	if last == nil || last.GetStatus() != "COMPLETED" {
+		t.Fatalf("last streamed state = %v, want COMPLETED", last)
+	}
+
+	state, err := client.GetJobState(ctx, &pb.GetJobStateRequest{ExecutionId: exec.GetExecutionId()})
+	if err != nil {
+		t.Fatalf("get job state: %v", err)
+	}
+	if state.GetDefinitionId() != "greet" || len(state.GetTasks()) != 1 || state.GetTasks()[0].GetStatus() != "COMPLETED" {
+		t.Errorf("job state = %v, want greet with its task COMPLETED", state)
+	}
+
+	system, err := client.GetSystemState(ctx, &pb.GetSystemStateRequest{})
+	if err != nil {
+		t.Fatalf("get system state: %v", err)
+	}
+	if system.GetExecutedJobs() != 1 || system.GetQueuedCount() != 0 {
+		t.Errorf("system state = %v, want one executed job and an empty queue", system)
+	}
+}
+
+// TestErrorCodes checks orchestrator errors reach clients as matching status codes
+func TestErrorCodes(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, err := client.RegisterDefinition(ctx, &pb.RegisterDefinitionRequest{Definition: definition(t, map[string]interface{}{
+		"tasks": []interface{}{},
+	})})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("register definition without id = %v, want InvalidArgument", err)
+	}
+	if _, err := client.ExecuteJob(ctx, &pb.ExecuteJobRequest{DefinitionId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("execute unknown definition = %v, want NotFound", err)
+	}
+	if _, err := client.GetJobState(ctx, &pb.GetJobStateRequest{ExecutionId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("get unknown execution = %v, want NotFound", err)
+	}
+}
+
+// TestToStatus checks the mapping of wrapped orchestrator errors to status codes
+func TestToStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want codes.Code
+	}{
+		{orchestrator.ErrInvalidDefinition, codes.InvalidArgument},
+		{orchestrator.ErrNotFound, codes.NotFound},
+		{orchestrator.ErrQueueFull, codes.ResourceExhausted},
+		{errors.New("boom"), codes.Internal},
+	} {
+		wrapped := fmt.Errorf("context: %w", tc.err)
+		if got := status.Code(toStatus(wrapped)); got != tc.want {
+			t.Errorf("toStatus(%v) = %s, want %s", wrapped, got, tc.want)
+		}
+	}
+}
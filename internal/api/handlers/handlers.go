@@ -5,7 +5,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
@@ -41,6 +48,10 @@ func (h *Handler) HandleRegisterJobDefinition(w http.ResponseWriter, r *http.Req
 	// Register the job definition with the orchestrator
 	// Returns error if registration fails
 	if err := h.orch.RegisterJobDefinition(&jd); err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidDefinition) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -53,6 +64,134 @@ func (h *Handler) HandleRegisterJobDefinition(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// HandleDeleteJobDefinition processes requests to remove a job definition
+// DELETE /job-definitions/{id}
+// Refuses with 409 while executions of the definition are queued or running
+func (h *Handler) HandleDeleteJobDefinition(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "id")
+
+	err := h.orch.DeleteJobDefinition(definitionID)
+	h.audit(r, "delete-definition", definitionID, err)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrDefinitionInUse):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListJobDefinitions processes requests to discover job definitions
+// GET /job-definitions?tag={tag}
+// Returns all definitions, or only those carrying the tag if given
+func (h *Handler) HandleListJobDefinitions(w http.ResponseWriter, r *http.Request) {
+	var definitions []*models.JobDefinition
+	var err error
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		definitions, err = h.orch.ListJobDefinitionsByTag(tag)
+	} else {
+		definitions, err = h.orch.ListJobDefinitions()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(definitions)
+}
+
+// HandleReorderTasks processes requests to reorder the tasks of a job definition
+// POST /job-definitions/{id}/reorder
+// Expects JSON body with the complete list of task IDs in their new order
+func (h *Handler) HandleReorderTasks(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "id")
+
+	// Parse the new task order from request body
+	var req struct {
+		TaskIDs []string `json:"taskIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Apply the new order
+	// Rejects orders that aren't a permutation of the existing tasks
+	jd, err := h.orch.ReorderTasks(definitionID, req.TaskIDs)
+	h.audit(r, "reorder-tasks", definitionID, err)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrInvalidTaskOrder):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Return the updated definition
+	json.NewEncoder(w).Encode(jd)
+}
+
+// HandleGetFailureReasons processes requests for the common failures of a definition
+// GET /job-definitions/{id}/failures
+// Returns distinct failure messages with counts, most common first
+func (h *Handler) HandleGetFailureReasons(w http.ResponseWriter, r *http.Request) {
+	reasons, err := h.orch.GetFailureReasons(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(reasons)
+}
+
+// HandleGetResolvedDefinition processes requests for the effective form of a definition
+// GET /job-definitions/{id}/resolved
+// Returns the definition with inherited settings and defaults filled in
+func (h *Handler) HandleGetResolvedDefinition(w http.ResponseWriter, r *http.Request) {
+	jd, err := h.orch.GetResolvedJobDefinition(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(jd)
+}
+
+// HandleGetTaskGraph processes requests for the task graph of a definition
+// GET /job-definitions/{id}/graph
+// Returns tasks as nodes, dependencies as edges, and the order the tasks run in
+func (h *Handler) HandleGetTaskGraph(w http.ResponseWriter, r *http.Request) {
+	graph, err := h.orch.GetTaskGraph(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(graph)
+}
+
 // HandleExecuteJob processes requests to execute a job
 // POST /jobs/{id}/execute
 // Takes optional JSON body with execution data
@@ -62,17 +201,47 @@ func (h *Handler) HandleExecuteJob(w http.ResponseWriter, r *http.Request) {
 	definitionID := chi.URLParam(r, "id")
 
 	// Parse optional execution data from request body
-	// If no data provided, initialize empty map
-	var data map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		data = make(map[string]interface{})
+	// An empty body is allowed, malformed JSON is rejected
+	data, err := h.decodeData(r)
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read execution options such as the deadline from the body
+	opts, err := enqueueOptions(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Replace the data with the document at inputUrl when given
+	// Lets clients pass large inputs by reference
+	if v, ok := data["inputUrl"]; ok {
+		url, _ := v.(string)
+		data, err = h.orch.FetchInput(r.Context(), url)
+		if err != nil {
+			if errors.Is(err, orchestrator.ErrInvalidInput) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
 	}
 
 	// Enqueue the job for execution
 	// Returns execution ID for tracking
-	executionID, err := h.orch.EnqueueJob(definitionID, data)
+	executionID, err := h.orch.EnqueueJobWithOptions(definitionID, data, opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrQueueFull):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -84,8 +253,594 @@ func (h *Handler) HandleExecuteJob(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleRunAdHocJob processes requests to run a job defined inline
+// POST /jobs/run-adhoc
+// Expects {"definition": {...}, "data": {...}}, the definition isn't registered
+func (h *Handler) HandleRunAdHocJob(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Definition *models.JobDefinition  `json:"definition"`
+		Data       map[string]interface{} `json:"data"`
+	}
+	if err := h.orch.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.Definition == nil {
+		http.Error(w, "Request body must contain a definition", http.StatusBadRequest)
+		return
+	}
+	if body.Data == nil {
+		body.Data = make(map[string]interface{})
+	}
+
+	// Read execution options from the data, as for registered definitions
+	opts, err := enqueueOptions(body.Data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the definition and enqueue it with the execution
+	executionID, err := h.orch.EnqueueInlineJob(body.Definition, body.Data, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrInvalidDefinition):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, orchestrator.ErrQueueFull):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"executionID": executionID,
+	})
+}
+
+// HandleBulkExecuteJob processes requests to enqueue many executions of a job
+// POST /jobs/{id}/bulk-execute
+// Expects {"items": [...]} with one data object per execution
+func (h *Handler) HandleBulkExecuteJob(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "id")
+
+	var body struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := h.orch.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start the bulk operation in the background
+	// Progress is reported through the operation ID
+	operationID, err := h.orch.BulkEnqueue(definitionID, body.Items)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrQueueFull):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// HTTP 202 Accepted as the operation runs asynchronously
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"operationID": operationID,
+	})
+}
+
+// HandleGetOperation processes requests to get the progress of a bulk operation
+// GET /operations/{id}
+// Returns counts of processed items and the executions created so far
+func (h *Handler) HandleGetOperation(w http.ResponseWriter, r *http.Request) {
+	op, err := h.orch.GetOperation(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(op)
+}
+
+// HandleCancelOperation processes requests to cancel a bulk operation
+// POST /operations/{id}/cancel
+// Jobs enqueued before the cancellation keep running
+func (h *Handler) HandleCancelOperation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := h.orch.CancelOperation(id)
+	h.audit(r, "cancel-operation", id, err)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrOperationFinished):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// HTTP 202 Accepted as the operation stops after its current item
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"operationID": id,
+	})
+}
+
+// HandleCancelByTag processes requests to cancel all executions with a tag
+// POST /jobs/cancel?tag={tag}
+// Cancels queued and running executions and returns how many were cancelled
+func (h *Handler) HandleCancelByTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		http.Error(w, "Missing tag query parameter", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.orch.CancelByTag(tag)
+	h.audit(r, "cancel-by-tag", tag, err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// HTTP 202 Accepted as running executions stop asynchronously
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int{
+		"cancelled": count,
+	})
+}
+
+// maxCancelBatch bounds the number of executions cancelled by one request
+const maxCancelBatch = 1000
+
+// HandleCancelBatch processes requests to cancel several executions at once
+// POST /jobs/cancel-batch
+// Expects {"executionIds": [...]} and returns the outcome for each ID
+func (h *Handler) HandleCancelBatch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ExecutionIDs []string `json:"executionIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.ExecutionIDs) == 0 {
+		http.Error(w, "Request body must list executionIds", http.StatusBadRequest)
+		return
+	}
+	if len(body.ExecutionIDs) > maxCancelBatch {
+		http.Error(w, fmt.Sprintf("At most %d executions can be cancelled at once", maxCancelBatch), http.StatusBadRequest)
+		return
+	}
+
+	// Record every execution in the audit log with its own outcome
+	results := h.orch.CancelJobs(body.ExecutionIDs)
+	for _, result := range results {
+		var err error
+		if result.Outcome != models.CancelOutcomeCancelled {
+			err = errors.New(result.Error)
+		}
+		h.audit(r, "cancel-batch", result.ExecutionID, err)
+	}
+
+	// HTTP 202 Accepted as running executions stop asynchronously
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string][]models.CancelResult{
+		"results": results,
+	})
+}
+
+// HandleCancelJob processes requests to cancel a running execution
+// POST /jobs/{id}/cancel
+// The execution stops asynchronously and ends CANCELLED
+func (h *Handler) HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	err := h.orch.CancelJob(executionID)
+	h.audit(r, "cancel", executionID, err)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// HTTP 202 Accepted as the execution stops asynchronously
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Job cancellation requested",
+	})
+}
+
+// HandleRetryTask processes requests to retry a failed execution from a task
+// POST /jobs/{id}/tasks/{taskId}/retry
+// Re-runs the task and the tasks depending on it using the persisted job data
+func (h *Handler) HandleRetryTask(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+	taskID := chi.URLParam(r, "taskId")
+
+	// Queue the execution again from the given task
+	// Only failed executions can be retried
+	err := h.orch.RetryFromTask(executionID, taskID)
+	h.audit(r, "retry-task", executionID+"/"+taskID, err)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrNotRetryable), errors.Is(err, orchestrator.ErrAlreadyQueued):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// HTTP 202 Accepted as the retry is queued, not completed
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"executionID": executionID,
+	})
+}
+
+// HandleReplayJob processes requests to replay a finished job execution
+// POST /jobs/{id}/replay
+// Starts a new execution with the same data that references the original
+func (h *Handler) HandleReplayJob(w http.ResponseWriter, r *http.Request) {
+	executionID, err := h.orch.ReplayJobExecution(chi.URLParam(r, "id"))
+	h.audit(r, "replay-execution", chi.URLParam(r, "id"), err)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, orchestrator.ErrNotRetryable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// HTTP 202 Accepted as the replay is queued, not completed
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"executionID": executionID,
+	})
+}
+
+// HandleListExecutions processes requests to list executions
+// GET /jobs?status={status}&from={time}&to={time}&limit={n}
+// Lists executions newest first by start time, 50 by default, optionally only those started
+// in the range. Both times are RFC 3339, from is inclusive, to is exclusive and defaults to now
+func (h *Handler) HandleListExecutions(w http.ResponseWriter, r *http.Request) {
+	status := models.JobStatus(r.URL.Query().Get("status"))
+	if status != "" && !status.Valid() {
+		http.Error(w, fmt.Sprintf("Unknown status %q", status), http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			http.Error(w, "Query parameter limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var executions []*models.JobExecution
+	var err error
+	if r.URL.Query().Get("from") == "" && r.URL.Query().Get("to") == "" {
+		if executions, err = h.orch.ListExecutions(status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+		if err != nil {
+			http.Error(w, "Invalid or missing from: must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		to := time.Now()
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "Invalid to: must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if executions, err = h.orch.ListExecutionsByTime(from, to); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if status != "" {
+			executions = slices.DeleteFunc(executions, func(je *models.JobExecution) bool {
+				return je.Status != status
+			})
+		}
+
+		// The range is read oldest first, list it in the same order as without one
+		slices.Reverse(executions)
+	}
+
+	if len(executions) > limit {
+		executions = executions[:limit]
+	}
+	json.NewEncoder(w).Encode(executions)
+}
+
+// HandleListFinishedExecutions processes requests for recently finished executions
+// GET /jobs/finished?limit={n}
+// Returns completed, failed, and cancelled executions, most recently ended first, 50 by default
+func (h *Handler) HandleListFinishedExecutions(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			http.Error(w, "Query parameter limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	executions, err := h.orch.ListFinishedExecutions(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(executions)
+}
+
+// HandleGetLogs processes requests for the captured task logs of an execution
+// GET /jobs/{id}/logs
+// Returns the log output as plain text
+func (h *Handler) HandleGetLogs(w http.ResponseWriter, r *http.Request) {
+	logs, err := h.orch.GetLogs(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, logs)
+}
+
+// HandleGetLineage processes requests to trace the attempts of an execution
+// GET /jobs/{id}/lineage
+// Returns the executions from the original attempt to the given one
+func (h *Handler) HandleGetLineage(w http.ResponseWriter, r *http.Request) {
+	lineage, err := h.orch.GetLineage(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(lineage)
+}
+
+// HandleRunTask processes requests to run a single task function ad hoc
+// POST /tasks/{functionName}/run
+// Takes optional JSON body with task data and waits for the run to finish
+func (h *Handler) HandleRunTask(w http.ResponseWriter, r *http.Request) {
+	functionName := chi.URLParam(r, "functionName")
+
+	// Parse optional task data from request body
+	// An empty body is allowed, malformed JSON is rejected
+	data, err := h.decodeData(r)
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Run the function and wait for its outcome
+	// Returns error if the function isn't registered
+	je, err := h.orch.RunTask(r.Context(), functionName, data)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return the recorded execution including the run outcome
+	json.NewEncoder(w).Encode(je)
+}
+
+// HandleReconcile processes requests to verify in-memory state against storage
+// GET /admin/reconcile?fix={true|false}
+// Reports discrepancies and, with fix=true, repairs them
+func (h *Handler) HandleReconcile(w http.ResponseWriter, r *http.Request) {
+	fix := false
+	if v := r.URL.Query().Get("fix"); v != "" {
+		var err error
+		if fix, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Query parameter fix must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.orch.Reconcile(fix)
+	if fix {
+		h.audit(r, "reconcile", "system", err)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleRequeueRunning processes requests to requeue executions stuck in RUNNING
+// POST /admin/requeue-running
+// Executions that are actually executing are left alone
+func (h *Handler) HandleRequeueRunning(w http.ResponseWriter, r *http.Request) {
+	requeued, err := h.orch.RequeueStuckRunning()
+	h.audit(r, "requeue-running", fmt.Sprintf("%d executions", len(requeued)), err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string][]string{
+		"requeued": requeued,
+	})
+}
+
+// HandlePause processes requests to pause job processing
+// POST /admin/pause?hold={true|false}
+// Stops dequeuing jobs, with hold also stops running jobs before their next task
+func (h *Handler) HandlePause(w http.ResponseWriter, r *http.Request) {
+	hold := r.URL.Query().Get("hold") == "true"
+	h.orch.Pause(hold)
+	h.audit(r, "pause", fmt.Sprintf("hold=%t", hold), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleResume processes requests to resume job processing
+// POST /admin/resume
+// Continues dequeuing jobs and releases jobs held by a pause
+func (h *Handler) HandleResume(w http.ResponseWriter, r *http.Request) {
+	h.orch.Resume()
+	h.audit(r, "resume", "processing", nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListAuditEntries processes requests to read the audit log
+// GET /admin/audit?offset={n}&limit={n}
+// Returns administrative actions, newest first, 50 per page by default
+func (h *Handler) HandleListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	offset, limit := 0, 50
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Query parameter offset must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			http.Error(w, "Query parameter limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.orch.ListAuditEntries(offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(entries)
+}
+
+// audit records an administrative action taken through the API
+// The actor is read from the X-Actor header
+func (h *Handler) audit(r *http.Request, action, target string, err error) {
+	actor := r.Header.Get("X-Actor")
+	if actor == "" {
+		actor = "anonymous"
+	}
+	h.orch.Audit(action, target, actor, err)
+}
+
+// decodeData parses the optional JSON data map of a request body
+// An empty body or a JSON null yields an empty map
+// Any other decoding failure is returned so clients learn about bad input
+func (h *Handler) decodeData(r *http.Request) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := h.orch.NewDecoder(r.Body).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return data, nil
+}
+
+// enqueueOptions extracts execution options from the execute request body
+// Supports "deadline" and "startAt" as RFC 3339 timestamps, "tags" as a list of strings,
+// and "priority" as an integer
+// Option keys are left in the data so tasks can still read them
+func enqueueOptions(data map[string]interface{}) (orchestrator.EnqueueOptions, error) {
+	var opts orchestrator.EnqueueOptions
+	if v, ok := data["deadline"]; ok {
+		s, _ := v.(string)
+		deadline, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid deadline %v: must be an RFC 3339 timestamp", v)
+		}
+		opts.Deadline = deadline
+	}
+	if v, ok := data["startAt"]; ok {
+		s, _ := v.(string)
+		startAt, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid startAt %v: must be an RFC 3339 timestamp", v)
+		}
+		opts.StartAt = startAt
+	}
+	if v, ok := data["affinityKey"]; ok {
+		key, ok := v.(string)
+		if !ok {
+			return opts, fmt.Errorf("invalid affinityKey %v: must be a string", v)
+		}
+		opts.AffinityKey = key
+	}
+	if v, ok := data["priority"]; ok {
+		var priority float64
+		switch n := v.(type) {
+		case float64:
+			priority = n
+		case json.Number:
+			priority, _ = n.Float64()
+		default:
+			priority = math.NaN()
+		}
+		if priority != math.Trunc(priority) || math.Abs(priority) > math.MaxInt32 {
+			return opts, fmt.Errorf("invalid priority %v: must be an integer", v)
+		}
+		opts.Priority = int(priority)
+	}
+	if v, ok := data["tags"]; ok {
+		tags, _ := v.([]interface{})
+		for _, t := range tags {
+			s, ok := t.(string)
+			if !ok || s == "" {
+				return opts, fmt.Errorf("invalid tags %v: must be a list of strings", v)
+			}
+			opts.Tags = append(opts.Tags, s)
+		}
+	}
+	return opts, nil
+}
+
 // HandleGetJobState processes requests to get job execution state
-// GET /jobs/{id}/state
+// GET /jobs/{id}/state?fields=status&status={status}
 // Returns current state of job execution
 func (h *Handler) HandleGetJobState(w http.ResponseWriter, r *http.Request) {
 	// Extract execution ID from URL parameters
@@ -100,18 +855,119 @@ func (h *Handler) HandleGetJobState(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Apply the optional projection for jobs with many tasks
+	// fields=status replaces the task list with counts per status
+	// status={status} keeps only the tasks with that status
+	if status := r.URL.Query().Get("status"); status != "" {
+		state.Tasks = filterTasks(state.Tasks, models.TaskStatus(status))
+	}
+	switch fields := r.URL.Query().Get("fields"); fields {
+	case "":
+	case "status":
+		state.TaskCounts = countTasks(state.Tasks)
+		state.Tasks = nil
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported fields projection %q", fields), http.StatusBadRequest)
+		return
+	}
+
 	// Return job state in response
 	// Automatically serialized to JSON
 	json.NewEncoder(w).Encode(state)
 }
 
+// filterTasks keeps the tasks with the given status
+// Tasks that haven't started yet match PENDING
+func filterTasks(tasks []models.TaskState, status models.TaskStatus) []models.TaskState {
+	filtered := []models.TaskState{}
+	for _, task := range tasks {
+		if taskStatus(task) == status {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
+
+// countTasks counts tasks per status
+func countTasks(tasks []models.TaskState) map[models.TaskStatus]int {
+	counts := make(map[models.TaskStatus]int)
+	for _, task := range tasks {
+		counts[taskStatus(task)]++
+	}
+	return counts
+}
+
+// taskStatus returns a task's status, reporting unstarted tasks as PENDING
+func taskStatus(task models.TaskState) models.TaskStatus {
+	if task.Status == "" {
+		return models.TaskStatusPending
+	}
+	return task.Status
+}
+
+// HandleCompareJobs processes requests to compare two job executions
+// GET /jobs/compare?a={executionID}&b={executionID}
+// Returns a structured diff of data, task statuses, durations, and errors
+func (h *Handler) HandleCompareJobs(w http.ResponseWriter, r *http.Request) {
+	// Both execution IDs are required query parameters
+	a := r.URL.Query().Get("a")
+	b := r.URL.Query().Get("b")
+	if a == "" || b == "" {
+		http.Error(w, "Query parameters a and b are required", http.StatusBadRequest)
+		return
+	}
+
+	// Build the diff between the two executions
+	// Returns error if either execution is not found
+	diff, err := h.orch.CompareExecutions(a, b)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(diff)
+}
+
+// HandleListQueue processes requests to inspect the job queue
+// GET /system/queue
+// Returns queued executions in dequeue order with their definition names
+func (h *Handler) HandleListQueue(w http.ResponseWriter, r *http.Request) {
+	queued, err := h.orch.ListQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if queued == nil {
+		queued = []*models.QueuedExecution{}
+	}
+
+	json.NewEncoder(w).Encode(queued)
+}
+
+// HandleGetThroughput processes requests for the processing rate
+// GET /system/throughput
+// Returns the recent rate and per-minute counts of finished executions
+func (h *Handler) HandleGetThroughput(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.orch.GetThroughput())
+}
+
 // HandleGetSystemState processes requests to get overall system state
 // GET /system/state
 // Returns state of all jobs and queue information
+// With consistent=true running and queued jobs are read as one snapshot
 func (h *Handler) HandleGetSystemState(w http.ResponseWriter, r *http.Request) {
 	// Get current state of entire system
 	// Includes active and queued jobs
-	state, err := h.orch.GetSystemState()
+	getState := h.orch.GetSystemState
+	if r.URL.Query().Get("consistent") == "true" {
+		getState = h.orch.GetSystemSnapshot
+	}
+	state, err := getState()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
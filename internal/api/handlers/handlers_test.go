@@ -0,0 +1,820 @@
+// handlers_test.go tests the HTTP handlers against an orchestrator on a temporary BoltDB
+// Requests are served through the handler functions directly, without a router
+// Executions are stored straight into the database to control their start times
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestHandler returns a handler on a fresh database holding the given executions
+func newTestHandler(t *testing.T, executions ...*models.JobExecution) *Handler {
+	t.Helper()
+	return handlerOn(t, openTestDB(t, executions...))
+}
+
+// openTestDB opens a fresh database holding the given executions
+func openTestDB(t *testing.T, executions ...*models.JobExecution) *storage.BoltDB {
+	t.Helper()
+	db, err := storage.NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	for _, je := range executions {
+		if err := db.StoreJobExecution(je); err != nil {
+			t.Fatalf("store execution: %v", err)
+		}
+	}
+	return db
+}
+
+// handlerOn returns a handler on an orchestrator using db
+func handlerOn(t *testing.T, db storage.DB) *Handler {
+	t.Helper()
+	o, err := orchestrator.New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	t.Cleanup(func() { o.Close() })
+	return NewHandler(o)
+}
+
+// listExecutions serves GET /jobs with the query and decodes the listed IDs
+func listExecutions(t *testing.T, h *Handler, query string) (int, []string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.HandleListExecutions(rec, httptest.NewRequest(http.MethodGet, "/jobs?"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var executions []*models.JobExecution
+	if err := json.NewDecoder(rec.Body).Decode(&executions); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	ids := make([]string, 0, len(executions))
+	for _, je := range executions {
+		ids = append(ids, je.ID)
+	}
+	return rec.Code, ids
+}
+
+// TestHandleListExecutions checks the order, status filter and limit of GET /jobs
+// Both the full listing and the time range list newest first
+func TestHandleListExecutions(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var executions []*models.JobExecution
+	for i := 0; i < 4; i++ {
+		status := models.JobStatusCompleted
+		if i%2 == 1 {
+			status = models.JobStatusFailed
+		}
+		executions = append(executions, &models.JobExecution{
+			ID:        fmt.Sprintf("exec-%d", i),
+			Status:    status,
+			StartTime: base.Add(time.Duration(i) * time.Hour),
+			EndTime:   base.Add(time.Duration(i)*time.Hour + time.Minute),
+		})
+	}
+	h := newTestHandler(t, executions...)
+	from, to := base.Format(time.RFC3339), base.Add(24*time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"exec-3", "exec-2", "exec-1", "exec-0"}},
+		{"from=" + from + "&to=" + to, []string{"exec-3", "exec-2", "exec-1", "exec-0"}},
+		{"status=FAILED", []string{"exec-3", "exec-1"}},
+		{"status=FAILED&from=" + from + "&to=" + to, []string{"exec-3", "exec-1"}},
+		{"limit=2", []string{"exec-3", "exec-2"}},
+		{"limit=1&from=" + from + "&to=" + to, []string{"exec-3"}},
+		{"from=" + base.Add(time.Hour).Format(time.RFC3339) + "&to=" + base.Add(3*time.Hour).Format(time.RFC3339), []string{"exec-2", "exec-1"}},
+	}
+	for _, tt := range tests {
+		code, ids := listExecutions(t, h, tt.query)
+		if code != http.StatusOK || !slices.Equal(ids, tt.want) {
+			t.Errorf("GET /jobs?%s = %d %v, want 200 %v", tt.query, code, ids, tt.want)
+		}
+	}
+}
+
+// TestHandleListExecutionsRejects checks invalid query parameters are answered with 400
+func TestHandleListExecutionsRejects(t *testing.T) {
+	h := newTestHandler(t)
+	for _, query := range []string{"status=running", "status=DONE", "limit=0", "limit=501", "limit=ten", "from=yesterday"} {
+		if code, _ := listExecutions(t, h, query); code != http.StatusBadRequest {
+			t.Errorf("GET /jobs?%s = %d, want 400", query, code)
+		}
+	}
+}
+
+// waitForStatus polls an execution until it has the status
+func waitForStatus(t *testing.T, h *Handler, id string, status models.JobStatus) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		state, err := h.orch.GetJobExecutionState(id)
+		if err == nil && state.Status == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for execution %s to be %s", id, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestHandleCancelBatch cancels a running, a delayed, a finished, and an unknown execution
+// Each ID gets its own outcome in request order and its own audit entry
+func TestHandleCancelBatch(t *testing.T) {
+	h := newTestHandler(t, &models.JobExecution{ID: "done", Status: models.JobStatusCompleted, StartTime: time.Now(), EndTime: time.Now()})
+	h.orch.RegisterFunction("wait", func(ctx context.Context, data map[string]interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{
+		ID:    "long",
+		Tasks: []*models.Task{{ID: "wait", FunctionName: "wait"}},
+	}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+	running, err := h.orch.EnqueueJob("long", nil)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	delayed, err := h.orch.EnqueueJobWithOptions("long", nil, orchestrator.EnqueueOptions{StartAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("enqueue delayed: %v", err)
+	}
+	waitForStatus(t, h, running, models.JobStatusRunning)
+
+	ids := []string{running, "done", "missing", delayed}
+	body, _ := json.Marshal(map[string][]string{"executionIds": ids})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/cancel-batch", bytes.NewReader(body))
+	req.Header.Set("X-Actor", "ops")
+	rec := httptest.NewRecorder()
+	h.HandleCancelBatch(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /jobs/cancel-batch = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Results []models.CancelResult `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	want := []struct {
+		outcome models.CancelOutcome
+		status  models.JobStatus
+	}{
+		{models.CancelOutcomeCancelled, models.JobStatusRunning},
+		{models.CancelOutcomeAlreadyTerminal, models.JobStatusCompleted},
+		{models.CancelOutcomeNotFound, ""},
+		{models.CancelOutcomeCancelled, models.JobStatusQueued},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v, want one per ID", resp.Results)
+	}
+	for i, result := range resp.Results {
+		if result.ExecutionID != ids[i] || result.Outcome != want[i].outcome || result.Status != want[i].status {
+			t.Errorf("result %d = %+v, want %s %s %s", i, result, ids[i], want[i].outcome, want[i].status)
+		}
+		if (result.Error == "") != (want[i].outcome == models.CancelOutcomeCancelled) {
+			t.Errorf("result %d error = %q for outcome %s", i, result.Error, result.Outcome)
+		}
+	}
+	waitForStatus(t, h, running, models.JobStatusCancelled)
+	waitForStatus(t, h, delayed, models.JobStatusCancelled)
+
+	// Newest entries come first, so the audit log lists the batch in reverse
+	entries, err := h.orch.ListAuditEntries(0, 10)
+	if err != nil {
+		t.Fatalf("list audit entries: %v", err)
+	}
+	if len(entries) != len(ids) {
+		t.Fatalf("audit entries = %d, want %d", len(entries), len(ids))
+	}
+	for i, entry := range entries {
+		result := resp.Results[len(ids)-1-i]
+		wantResult := "ok"
+		if result.Outcome != models.CancelOutcomeCancelled {
+			wantResult = result.Error
+		}
+		if entry.Action != "cancel-batch" || entry.Target != result.ExecutionID || entry.Actor != "ops" || entry.Result != wantResult {
+			t.Errorf("audit entry %+v, want cancel-batch of %s by ops with result %q", entry, result.ExecutionID, wantResult)
+		}
+	}
+}
+
+// TestHandleCancelByTag cancels by a tag shared by a running and a queued execution
+// Executions with other tags, without tags, or already finished are left alone
+func TestHandleCancelByTag(t *testing.T) {
+	const tag = "customer:acme"
+	h := newTestHandler(t, &models.JobExecution{
+		ID:        "done",
+		Status:    models.JobStatusCompleted,
+		Tags:      []string{tag},
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+	})
+	// Released before the orchestrator shuts down, which waits for running jobs
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	h.orch.RegisterFunction("wait", func(ctx context.Context, data map[string]interface{}) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-release:
+			return nil
+		}
+	})
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{
+		ID:    "long",
+		Tasks: []*models.Task{{ID: "wait", FunctionName: "wait"}},
+	}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+	enqueue := func(tags ...string) string {
+		id, err := h.orch.EnqueueJobWithOptions("long", nil, orchestrator.EnqueueOptions{Tags: tags})
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		return id
+	}
+	running := enqueue(tag, "priority:high")
+	waitForStatus(t, h, running, models.JobStatusRunning)
+	queued, other, untagged := enqueue(tag), enqueue("customer:globex"), enqueue()
+
+	cancel := func(query string) (int, map[string]int) {
+		rec := httptest.NewRecorder()
+		h.HandleCancelByTag(rec, httptest.NewRequest(http.MethodPost, "/jobs/cancel?"+query, nil))
+		var resp map[string]int
+		if rec.Code == http.StatusAccepted {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+		}
+		return rec.Code, resp
+	}
+	if code, resp := cancel("tag=" + tag); code != http.StatusAccepted || resp["cancelled"] != 2 {
+		t.Fatalf("POST /jobs/cancel?tag=%s = %d %v, want 202 with 2 cancelled", tag, code, resp)
+	}
+	waitForStatus(t, h, running, models.JobStatusCancelled)
+	waitForStatus(t, h, queued, models.JobStatusCancelled)
+
+	// The freed worker moves on to the next execution left in the queue
+	waitForStatus(t, h, other, models.JobStatusRunning)
+	for _, id := range []string{untagged, "done"} {
+		state, err := h.orch.GetJobExecutionState(id)
+		if err == nil && state.Status == models.JobStatusCancelled {
+			t.Errorf("execution %s without the tag was cancelled", id)
+		}
+	}
+
+	if code, resp := cancel("tag=" + tag); code != http.StatusAccepted || resp["cancelled"] != 0 {
+		t.Errorf("cancelling the tag again = %d %v, want 202 with 0 cancelled", code, resp)
+	}
+	if code, _ := cancel(""); code != http.StatusBadRequest {
+		t.Errorf("POST /jobs/cancel without a tag = %d, want 400", code)
+	}
+}
+
+// listAuditEntries serves GET /admin/audit with the query and decodes the entries
+func listAuditEntries(t *testing.T, h *Handler, query string) (int, []*models.AuditEntry) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.HandleListAuditEntries(rec, httptest.NewRequest(http.MethodGet, "/admin/audit?"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var entries []*models.AuditEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return rec.Code, entries
+}
+
+// TestHandleCancelJobAudit cancels a running and an unknown execution
+// Both attempts are listed by GET /admin/audit with their actor and result
+func TestHandleCancelJobAudit(t *testing.T) {
+	h := newTestHandler(t)
+	h.orch.RegisterFunction("wait", func(ctx context.Context, data map[string]interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{
+		ID:    "long",
+		Tasks: []*models.Task{{ID: "wait", FunctionName: "wait"}},
+	}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+	running, err := h.orch.EnqueueJob("long", nil)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitForStatus(t, h, running, models.JobStatusRunning)
+
+	cancel := func(id, actor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/cancel", nil)
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		rec := httptest.NewRecorder()
+		h.HandleCancelJob(rec, withURLParam(req, "id", id))
+		return rec.Code
+	}
+	if code := cancel(running, "ops"); code != http.StatusAccepted {
+		t.Fatalf("cancel running = %d, want 202", code)
+	}
+	waitForStatus(t, h, running, models.JobStatusCancelled)
+	if code := cancel("missing", ""); code != http.StatusNotFound {
+		t.Errorf("cancel missing = %d, want 404", code)
+	}
+
+	code, entries := listAuditEntries(t, h, "")
+	if code != http.StatusOK || len(entries) != 2 {
+		t.Fatalf("GET /admin/audit = %d with %d entries, want 200 with 2", code, len(entries))
+	}
+	if e := entries[0]; e.Action != "cancel" || e.Target != "missing" || e.Actor != "anonymous" || !strings.Contains(e.Result, "not found") {
+		t.Errorf("newest entry = %+v, want the failed cancel of missing by anonymous", e)
+	}
+	if e := entries[1]; e.Action != "cancel" || e.Target != running || e.Actor != "ops" || e.Result != "ok" || e.Time.IsZero() {
+		t.Errorf("oldest entry = %+v, want the cancel of %s by ops", e, running)
+	}
+
+	// Pages continue where the previous one ended
+	if code, page := listAuditEntries(t, h, "offset=1&limit=1"); code != http.StatusOK || len(page) != 1 || page[0].Target != running {
+		t.Errorf("second page = %d %+v, want the cancel of %s", code, page, running)
+	}
+	for _, query := range []string{"offset=-1", "limit=0", "limit=501", "limit=many"} {
+		if code, _ := listAuditEntries(t, h, query); code != http.StatusBadRequest {
+			t.Errorf("GET /admin/audit?%s = %d, want 400", query, code)
+		}
+	}
+}
+
+// TestHandleListFinishedExecutions checks GET /jobs/finished lists only finished
+// executions, most recently ended first, and rejects invalid limits
+func TestHandleListFinishedExecutions(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestHandler(t,
+		&models.JobExecution{ID: "completed", Status: models.JobStatusCompleted, EndTime: base.Add(time.Minute)},
+		&models.JobExecution{ID: "paused", Status: models.JobStatusPaused, StartTime: base},
+		&models.JobExecution{ID: "failed", Status: models.JobStatusFailed, EndTime: base.Add(2 * time.Minute)},
+	)
+
+	rec := httptest.NewRecorder()
+	h.HandleListFinishedExecutions(rec, httptest.NewRequest(http.MethodGet, "/jobs/finished?limit=5", nil))
+	var executions []*models.JobExecution
+	if err := json.NewDecoder(rec.Body).Decode(&executions); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var ids []string
+	for _, je := range executions {
+		ids = append(ids, je.ID)
+	}
+	if !slices.Equal(ids, []string{"failed", "completed"}) {
+		t.Errorf("GET /jobs/finished = %v, want [failed completed]", ids)
+	}
+
+	for _, limit := range []string{"0", "501", "ten"} {
+		rec := httptest.NewRecorder()
+		h.HandleListFinishedExecutions(rec, httptest.NewRequest(http.MethodGet, "/jobs/finished?limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /jobs/finished?limit=%s = %d, want 400", limit, rec.Code)
+		}
+	}
+}
+
+// unreadableDB fails reading the executions in broken with errors other than not found
+type unreadableDB struct {
+	storage.DB
+	broken map[string]bool // IDs of the executions that can't be read
+}
+
+// GetJobExecution fails for broken IDs and reads others from the wrapped database
+func (db *unreadableDB) GetJobExecution(id string) (*models.JobExecution, error) {
+	if db.broken[id] {
+		return nil, errors.New("disk read failed")
+	}
+	return db.DB.GetJobExecution(id)
+}
+
+// TestHandleGetJobStateProjection checks the task projections of GET /jobs/{id}/state
+// Tasks can be filtered by status or summarized as counts, unstarted ones count as PENDING
+func TestHandleGetJobStateProjection(t *testing.T) {
+	h := newTestHandler(t, &models.JobExecution{
+		ID:           "exec-1",
+		DefinitionID: "batch",
+		Status:       models.JobStatusFailed,
+		StartTime:    time.Now(),
+		TaskStatuses: map[string]models.TaskStatus{
+			"a": models.TaskStatusCompleted,
+			"b": models.TaskStatusFailed,
+			"c": models.TaskStatusFailed,
+		},
+	})
+	var tasks []*models.Task
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tasks = append(tasks, &models.Task{ID: id, FunctionName: "work"})
+	}
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{ID: "batch", Tasks: tasks}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+
+	get := func(query string) (int, *models.JobExecutionState) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/jobs/exec-1/state?"+query, nil)
+		h.HandleGetJobState(rec, withURLParam(req, "id", "exec-1"))
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		var state models.JobExecutionState
+		if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return rec.Code, &state
+	}
+	ids := func(state *models.JobExecutionState) []string {
+		var ids []string
+		for _, task := range state.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	if _, state := get(""); len(state.Tasks) != 4 || state.TaskCounts != nil {
+		t.Errorf("full state has tasks %v and counts %v, want all 4 tasks only", ids(state), state.TaskCounts)
+	}
+	if _, state := get("status=FAILED"); !slices.Equal(ids(state), []string{"b", "c"}) {
+		t.Errorf("failed tasks = %v, want b and c", ids(state))
+	}
+	if _, state := get("status=PENDING"); !slices.Equal(ids(state), []string{"d"}) {
+		t.Errorf("pending tasks = %v, want d", ids(state))
+	}
+
+	_, state := get("fields=status")
+	want := map[models.TaskStatus]int{models.TaskStatusCompleted: 1, models.TaskStatusFailed: 2, models.TaskStatusPending: 1}
+	if state.Tasks != nil || !maps.Equal(state.TaskCounts, want) {
+		t.Errorf("summary has tasks %v and counts %v, want counts %v only", ids(state), state.TaskCounts, want)
+	}
+	if _, state := get("fields=status&status=FAILED"); !maps.Equal(state.TaskCounts, map[models.TaskStatus]int{models.TaskStatusFailed: 2}) {
+		t.Errorf("summary of failed tasks = %v, want 2 FAILED", state.TaskCounts)
+	}
+	if code, _ := get("fields=everything"); code != http.StatusBadRequest {
+		t.Errorf("unsupported projection = %d, want 400", code)
+	}
+}
+
+// TestHandleCompareJobs checks GET /jobs/compare answers a diff, 404 for unknown
+// executions, and 500 when storage fails
+func TestHandleCompareJobs(t *testing.T) {
+	h := handlerOn(t, &unreadableDB{
+		DB: openTestDB(t,
+			&models.JobExecution{ID: "first", DefinitionID: "etl", Status: models.JobStatusCompleted},
+			&models.JobExecution{ID: "second", DefinitionID: "etl", Status: models.JobStatusFailed, Error: "boom"},
+			&models.JobExecution{ID: "unreadable", DefinitionID: "etl", Status: models.JobStatusCompleted},
+		),
+		broken: map[string]bool{"unreadable": true},
+	})
+
+	for _, tc := range []struct {
+		query string
+		code  int
+	}{
+		{"a=first&b=second", http.StatusOK},
+		{"a=first&b=missing", http.StatusNotFound},
+		{"a=missing&b=second", http.StatusNotFound},
+		{"a=first&b=unreadable", http.StatusInternalServerError},
+		{"a=first", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		h.HandleCompareJobs(rec, httptest.NewRequest(http.MethodGet, "/jobs/compare?"+tc.query, nil))
+		if rec.Code != tc.code {
+			t.Errorf("GET /jobs/compare?%s = %d, want %d", tc.query, rec.Code, tc.code)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+		var diff models.ExecutionDiff
+		if err := json.NewDecoder(rec.Body).Decode(&diff); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if diff.A != "first" || diff.B != "second" || !diff.SameDefinition || diff.StatusB != models.JobStatusFailed || diff.ErrorB != "boom" {
+			t.Errorf("diff = %+v, want first against the failed second", diff)
+		}
+	}
+}
+
+// withURLParam returns the request with a chi URL parameter set, as the router would
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandleExecuteJobBody checks the bodies POST /jobs/{id}/execute accepts
+// Empty bodies run the job without data, malformed JSON is rejected with 400
+func TestHandleExecuteJobBody(t *testing.T) {
+	h := newTestHandler(t)
+	received := make(chan map[string]interface{}, 1)
+	h.orch.RegisterFunction("echo", func(ctx context.Context, data map[string]interface{}) error {
+		received <- data
+		return nil
+	})
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{
+		ID:    "echo",
+		Tasks: []*models.Task{{ID: "echo", FunctionName: "echo"}},
+	}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		data map[string]interface{} // Data the task receives, if the job runs
+	}{
+		{"empty body", "", http.StatusAccepted, map[string]interface{}{}},
+		{"null", "null", http.StatusAccepted, map[string]interface{}{}},
+		{"valid JSON", `{"user": "ada"}`, http.StatusAccepted, map[string]interface{}{"user": "ada"}},
+		{"malformed JSON", `{"user": `, http.StatusBadRequest, nil},
+		{"not an object", `["ada"]`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/jobs/echo/execute", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.HandleExecuteJob(rec, withURLParam(req, "id", "echo"))
+			if rec.Code != tt.code {
+				t.Fatalf("POST with %s = %d, want %d: %s", tt.name, rec.Code, tt.code, rec.Body)
+			}
+			if tt.data == nil {
+				return
+			}
+			select {
+			case data := <-received:
+				if !maps.Equal(data, tt.data) {
+					t.Errorf("task received %v, want %v", data, tt.data)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("job didn't run")
+			}
+		})
+	}
+
+	// Rejected requests never enqueue an execution
+	executions, err := h.orch.ListExecutions("")
+	if err != nil {
+		t.Fatalf("list executions: %v", err)
+	}
+	if len(executions) != 3 {
+		t.Errorf("%d executions, want one per accepted request", len(executions))
+	}
+}
+
+// TestHandleExecuteJobUseNumber enqueues a large integer ID through POST /jobs/{id}/execute
+// With json.Number decoding opted in, the task and the stored execution keep it exact,
+// by default it is decoded as float64 and loses precision
+func TestHandleExecuteJobUseNumber(t *testing.T) {
+	const id = "9007199254740993" // 2^53 + 1, not representable as float64
+	for _, useNumber := range []bool{true, false} {
+		db, err := storage.NewBoltDBWithOptions(filepath.Join(t.TempDir(), "test.db"), storage.Options{UseNumber: useNumber})
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		var opts []orchestrator.Option
+		if useNumber {
+			opts = append(opts, orchestrator.WithUseNumber())
+		}
+		o, err := orchestrator.New(db, 1, opts...)
+		if err != nil {
+			t.Fatalf("new orchestrator: %v", err)
+		}
+		t.Cleanup(func() { o.Close() })
+		h := NewHandler(o)
+		received := make(chan interface{}, 1)
+		h.orch.RegisterFunction("echo", func(ctx context.Context, data map[string]interface{}) error {
+			received <- data["id"]
+			return nil
+		})
+		if err := h.orch.RegisterJobDefinition(&models.JobDefinition{
+			ID:    "echo",
+			Tasks: []*models.Task{{ID: "echo", FunctionName: "echo"}},
+		}); err != nil {
+			t.Fatalf("register definition: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/jobs/echo/execute", strings.NewReader(`{"id": `+id+`}`))
+		rec := httptest.NewRecorder()
+		h.HandleExecuteJob(rec, withURLParam(req, "id", "echo"))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("POST /jobs/echo/execute = %d, want 202: %s", rec.Code, rec.Body)
+		}
+		var resp map[string]string
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		waitForStatus(t, h, resp["executionID"], models.JobStatusCompleted)
+		je, err := db.GetJobExecution(resp["executionID"])
+		if err != nil {
+			t.Fatalf("get execution: %v", err)
+		}
+
+		got := <-received
+		if useNumber {
+			if got != json.Number(id) || je.Data["id"] != json.Number(id) {
+				t.Errorf("with json.Number the task received %#v and storage returned %#v, want %s", got, je.Data["id"], id)
+			}
+			continue
+		}
+		if _, ok := got.(float64); !ok {
+			t.Errorf("by default the task received %#v, want a float64", got)
+		}
+	}
+}
+
+// TestHandleRunAdHocJob runs a job defined inline end to end
+// The definition is validated but never registered
+func TestHandleRunAdHocJob(t *testing.T) {
+	h := newTestHandler(t)
+	h.orch.RegisterOutputFunction("exclaim", func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		word, _ := data["word"].(string)
+		return map[string]interface{}{"word": word + "!"}, nil
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.HandleRunAdHocJob(rec, httptest.NewRequest(http.MethodPost, "/jobs/run-adhoc", strings.NewReader(body)))
+		return rec
+	}
+	rec := post(`{"definition": {"id": "oneoff", "tasks": [
+		{"id": "first", "functionName": "exclaim"},
+		{"id": "second", "functionName": "exclaim"}
+	]}, "data": {"word": "hi"}}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /jobs/run-adhoc = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	waitForStatus(t, h, resp["executionID"], models.JobStatusCompleted)
+
+	executions, err := h.orch.ListExecutions(models.JobStatusCompleted)
+	if err != nil || len(executions) != 1 {
+		t.Fatalf("completed executions = %v, %v, want the ad-hoc one", executions, err)
+	}
+	if word := executions[0].Data["word"]; word != "hi!!" {
+		t.Errorf("ad-hoc job produced %v, want both tasks to run", word)
+	}
+	if definitions, err := h.orch.ListJobDefinitions(); err != nil || len(definitions) != 0 {
+		t.Errorf("registered definitions = %v, %v, want none", definitions, err)
+	}
+
+	for name, body := range map[string]string{
+		"malformed":          `{"definition": `,
+		"missing definition": `{"data": {"word": "hi"}}`,
+		"unknown function":   `{"definition": {"id": "oneoff", "tasks": [{"id": "a", "functionName": "whisper"}]}}`,
+		"dependency cycle": `{"definition": {"id": "oneoff", "strategy": "dag", "tasks": [
+			{"id": "a", "functionName": "exclaim", "dependsOn": ["b"]},
+			{"id": "b", "functionName": "exclaim", "dependsOn": ["a"]}
+		]}}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST with %s = %d, want 400: %s", name, rec.Code, rec.Body)
+		}
+	}
+}
+
+// TestHandleRunTask runs registered functions ad hoc through POST /tasks/{functionName}/run
+// Each run is answered with its outcome and recorded as an execution
+func TestHandleRunTask(t *testing.T) {
+	h := newTestHandler(t)
+	h.orch.RegisterOutputFunction("greet", func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"greeting": fmt.Sprintf("hello %v", data["name"])}, nil
+	})
+	h.orch.RegisterFunction("fail", func(ctx context.Context, data map[string]interface{}) error {
+		return errors.New("boom")
+	})
+
+	run := func(functionName, body string) (int, *models.JobExecution) {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+functionName+"/run", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.HandleRunTask(rec, withURLParam(req, "functionName", functionName))
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		var je models.JobExecution
+		if err := json.NewDecoder(rec.Body).Decode(&je); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return rec.Code, &je
+	}
+
+	code, je := run("greet", `{"name": "ada"}`)
+	if code != http.StatusOK || je.Status != models.JobStatusCompleted || je.Data["greeting"] != "hello ada" {
+		t.Fatalf("run greet = %d %+v, want COMPLETED with a greeting", code, je)
+	}
+	state, err := h.orch.GetJobExecutionState(je.ID)
+	if err != nil || state.Status != models.JobStatusCompleted || len(state.Tasks) != 1 {
+		t.Errorf("recorded run = %+v, %v, want a COMPLETED execution of one task", state, err)
+	}
+
+	if code, je := run("fail", ""); code != http.StatusOK || je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "boom") {
+		t.Errorf("run fail = %d %+v, want FAILED with the function's error", code, je)
+	}
+	if code, _ := run("missing", ""); code != http.StatusNotFound {
+		t.Errorf("run missing = %d, want 404", code)
+	}
+}
+
+// TestHandleGetThroughput runs jobs to completion and reads GET /system/throughput
+// The finishes show up in the history, the rate only counts complete minutes
+func TestHandleGetThroughput(t *testing.T) {
+	h := newTestHandler(t)
+	h.orch.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{ID: "noop", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		id, err := h.orch.EnqueueJob("noop", nil)
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		waitForStatus(t, h, id, models.JobStatusCompleted)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleGetThroughput(rec, httptest.NewRequest(http.MethodGet, "/system/throughput", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /system/throughput = %d, want 200", rec.Code)
+	}
+	var stats models.ThroughputStats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	finished := 0
+	for _, count := range stats.History {
+		finished += count.Finished
+	}
+	if len(stats.History) != 15 || finished != 3 {
+		t.Errorf("history = %+v, want 15 minutes counting 3 finishes", stats.History)
+	}
+	// Unless the finishes straddled a minute, none of them is in a complete minute yet
+	if stats.RatePerMinute > 3.0/5 {
+		t.Errorf("rate = %v per minute, want at most 3 finishes over 5 minutes", stats.RatePerMinute)
+	}
+}
+
+// TestHandleGetResolvedDefinition checks GET /job-definitions/{id}/resolved answers
+// the definition with its defaults filled in, 404 for unknown definitions
+func TestHandleGetResolvedDefinition(t *testing.T) {
+	h := newTestHandler(t)
+	if err := h.orch.RegisterJobDefinition(&models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "a", FunctionName: "f"}}}); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+
+	get := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/job-definitions/"+id+"/resolved", nil)
+		h.HandleGetResolvedDefinition(rec, withURLParam(req, "id", id))
+		return rec
+	}
+	rec := get("report")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET resolved = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var jd models.JobDefinition
+	if err := json.NewDecoder(rec.Body).Decode(&jd); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if jd.Strategy != models.StrategySequential || len(jd.Tasks) != 1 || jd.Tasks[0].RetryBackoff != models.BackoffExponential {
+		t.Errorf("resolved definition = %+v, want the sequential strategy and exponential backoff filled in", jd)
+	}
+	if rec := get("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET resolved of an unknown definition = %d, want 404", rec.Code)
+	}
+}
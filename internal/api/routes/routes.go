@@ -8,6 +8,7 @@ import (
 	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // SetupRoutes configures all API routes for the application
@@ -23,20 +24,165 @@ func SetupRoutes(r chi.Router, orch *orchestrator.Orchestrator) {
 	// Used to create new job templates in the system
 	r.Post("/job-definitions", h.HandleRegisterJobDefinition)
 
+	// List Job Definitions
+	// GET /job-definitions?tag={tag}
+	// Lists all definitions or discovers them by tag
+	r.Get("/job-definitions", h.HandleListJobDefinitions)
+
+	// Delete Job Definition
+	// DELETE /job-definitions/{id}
+	// Removes a definition without queued or running executions
+	r.Delete("/job-definitions/{id}", h.HandleDeleteJobDefinition)
+
+	// Reorder Job Definition Tasks
+	// POST /job-definitions/{id}/reorder
+	// Changes the execution order of a definition's tasks
+	r.Post("/job-definitions/{id}/reorder", h.HandleReorderTasks)
+
+	// Get Failure Reasons
+	// GET /job-definitions/{id}/failures
+	// Groups failure messages of a definition's executions
+	r.Get("/job-definitions/{id}/failures", h.HandleGetFailureReasons)
+
+	// Get Resolved Job Definition
+	// GET /job-definitions/{id}/resolved
+	// Shows a definition as its executions run it
+	r.Get("/job-definitions/{id}/resolved", h.HandleGetResolvedDefinition)
+
+	// Get Task Graph
+	// GET /job-definitions/{id}/graph
+	// Describes task dependencies for rendering
+	r.Get("/job-definitions/{id}/graph", h.HandleGetTaskGraph)
+
 	// Execute Job
 	// POST /jobs/{id}/execute
 	// Triggers execution of a specific job definition
 	r.Post("/jobs/{id}/execute", h.HandleExecuteJob)
 
+	// Run Ad Hoc Job
+	// POST /jobs/run-adhoc
+	// Runs a one-off job whose definition is given inline
+	r.Post("/jobs/run-adhoc", h.HandleRunAdHocJob)
+
+	// Bulk Execute Job
+	// POST /jobs/{id}/bulk-execute
+	// Enqueues many executions as a cancellable background operation
+	r.Post("/jobs/{id}/bulk-execute", h.HandleBulkExecuteJob)
+
+	// Get Operation
+	// GET /operations/{id}
+	// Reports progress of a bulk operation
+	r.Get("/operations/{id}", h.HandleGetOperation)
+
+	// Cancel Operation
+	// POST /operations/{id}/cancel
+	// Stops a running bulk operation
+	r.Post("/operations/{id}/cancel", h.HandleCancelOperation)
+
+	// List Jobs
+	// GET /jobs?status={status}&from={time}&to={time}&limit={n}
+	// Lists executions newest first, optionally by status and start time range
+	r.Get("/jobs", h.HandleListExecutions)
+
+	// List Finished Jobs
+	// GET /jobs/finished?limit={n}
+	// Lists the most recently finished executions
+	r.Get("/jobs/finished", h.HandleListFinishedExecutions)
+
+	// Compare Jobs
+	// GET /jobs/compare?a={id}&b={id}
+	// Diffs two job executions
+	r.Get("/jobs/compare", h.HandleCompareJobs)
+
+	// Cancel Jobs By Tag
+	// POST /jobs/cancel?tag={tag}
+	// Cancels all queued and running executions with a tag
+	r.Post("/jobs/cancel", h.HandleCancelByTag)
+
+	// Cancel Jobs In Batch
+	// POST /jobs/cancel-batch
+	// Cancels listed executions, reporting the outcome of each
+	r.Post("/jobs/cancel-batch", h.HandleCancelBatch)
+
+	// Cancel Job
+	// POST /jobs/{id}/cancel
+	// Stops a running execution
+	r.Post("/jobs/{id}/cancel", h.HandleCancelJob)
+
 	// Get Job State
 	// GET /jobs/{id}/state
 	// Retrieves current state of a job execution
 	r.Get("/jobs/{id}/state", h.HandleGetJobState)
 
+	// Retry Job From Task
+	// POST /jobs/{id}/tasks/{taskId}/retry
+	// Resumes a failed execution from the given task
+	r.Post("/jobs/{id}/tasks/{taskId}/retry", h.HandleRetryTask)
+
+	// Replay Job
+	// POST /jobs/{id}/replay
+	// Starts a new execution of a finished one
+	r.Post("/jobs/{id}/replay", h.HandleReplayJob)
+
+	// Get Job Logs
+	// GET /jobs/{id}/logs
+	// Retrieves the captured task log output of an execution
+	r.Get("/jobs/{id}/logs", h.HandleGetLogs)
+
+	// Get Job Lineage
+	// GET /jobs/{id}/lineage
+	// Traces the chain of replayed executions
+	r.Get("/jobs/{id}/lineage", h.HandleGetLineage)
+
+	// Run Task Ad Hoc
+	// POST /tasks/{functionName}/run
+	// Runs a single registered task function outside of a job
+	r.Post("/tasks/{functionName}/run", h.HandleRunTask)
+
+	// List Audit Entries
+	// GET /admin/audit?offset={n}&limit={n}
+	// Pages through the log of administrative actions
+	r.Get("/admin/audit", h.HandleListAuditEntries)
+
+	// Reconcile State
+	// GET /admin/reconcile?fix={true|false}
+	// Verifies in-memory state against storage, optionally repairing it
+	r.Get("/admin/reconcile", h.HandleReconcile)
+
+	// Requeue Stuck Jobs
+	// POST /admin/requeue-running
+	// Re-enqueues executions stored as RUNNING that nothing executes
+	r.Post("/admin/requeue-running", h.HandleRequeueRunning)
+
+	// Pause Processing
+	// POST /admin/pause?hold={true|false}
+	// Stops dequeuing jobs, optionally holding running jobs between tasks
+	r.Post("/admin/pause", h.HandlePause)
+
+	// Resume Processing
+	// POST /admin/resume
+	// Continues dequeuing jobs and releases held jobs
+	r.Post("/admin/resume", h.HandleResume)
+
 	// Get System State
 	// GET /system/state
 	// Retrieves overall system status
 	r.Get("/system/state", h.HandleGetSystemState)
+
+	// List Queue
+	// GET /system/queue
+	// Lists queued executions with their definition names
+	r.Get("/system/queue", h.HandleListQueue)
+
+	// Get Throughput
+	// GET /system/throughput
+	// Reports how many executions finish per minute
+	r.Get("/system/throughput", h.HandleGetThroughput)
+
+	// Get Metrics
+	// GET /metrics
+	// Exports job, queue, and worker metrics for Prometheus
+	r.Handle("/metrics", promhttp.Handler())
 }
 
 /* API Routes Overview:
@@ -46,6 +192,26 @@ func SetupRoutes(r chi.Router, orch *orchestrator.Orchestrator) {
   - Creates reusable job templates
   - Accepts: JSON job definition
   - Returns: Success confirmation
+  - GET /job-definitions?tag={tag}
+  - Lists all definitions, or those carrying a tag
+  - Query Params: optional tag to filter by
+  - Returns: JSON array of definitions
+  - DELETE /job-definitions/{id}
+  - Removes a definition, keeping its finished executions
+  - Returns: 204 No Content, 404 if unknown, 409 while executions of it are queued or running
+  - POST /job-definitions/{id}/reorder
+  - Reorders tasks of a definition
+  - Accepts: JSON list of task IDs
+  - Returns: Updated job definition
+  - GET /job-definitions/{id}/failures
+  - Aggregates failure reasons across executions
+  - Returns: JSON array of failure messages with counts
+  - GET /job-definitions/{id}/resolved
+  - Shows a definition with inherited settings and defaults applied
+  - Returns: Resolved job definition
+  - GET /job-definitions/{id}/graph
+  - Describes task dependencies of a definition
+  - Returns: Nodes, edges, and the order the tasks run in
 
 2. Job Execution:
   - POST /jobs/{id}/execute
@@ -53,21 +219,105 @@ func SetupRoutes(r chi.Router, orch *orchestrator.Orchestrator) {
   - URL Param: job definition ID
   - Accepts: Optional JSON data
   - Returns: Execution ID
+  - POST /jobs/run-adhoc
+  - Runs a one-off job without registering its definition
+  - Accepts: JSON object with a definition and optional data
+  - Returns: Execution ID
+  - POST /jobs/{id}/tasks/{taskId}/retry
+  - Resumes a failed execution from a task
+  - URL Params: execution ID and task ID
+  - Returns: Execution ID
+  - POST /jobs/{id}/replay
+  - Starts a new execution of a finished one
+  - URL Param: execution ID
+  - Returns: New execution ID
+  - POST /jobs/{id}/bulk-execute
+  - Enqueues one execution per item in the background
+  - Accepts: JSON object with an items array
+  - Returns: Operation ID
+  - GET /operations/{id}
+  - Reports bulk operation progress
+  - POST /operations/{id}/cancel
+  - Cancels a running bulk operation
+  - POST /jobs/cancel?tag={tag}
+  - Cancels all queued and running executions with a tag
+  - Returns: Number of cancelled executions
+  - POST /jobs/cancel-batch
+  - Cancels listed queued and running executions
+  - Accepts: JSON object with an executionIds array, at most 1000
+  - Returns: Outcome per execution: cancelled, already-terminal, not-found, or failed
+  - POST /jobs/{id}/cancel
+  - Cancels a running execution
+  - URL Param: execution ID
+  - Returns: 202 Accepted, 404 if the execution isn't running
 
 3. Job State Monitoring:
+  - GET /jobs?status={status}&from={time}&to={time}&limit={n}
+  - Lists executions newest first, optionally those started in a time range
+  - Query Params: optional status, RFC 3339 from (inclusive) and to (exclusive, default now),
+    limit (1-500, default 50)
+  - Returns: JSON array of executions, 400 for an unknown status or invalid parameters
+  - GET /jobs/finished?limit={n}
+  - Lists finished executions, most recently ended first
+  - Query Params: optional limit, 1 to 500, default 50
+  - Returns: JSON array of executions
   - GET /jobs/{id}/state
   - Checks job execution progress
   - URL Param: execution ID
+  - Query Params: optional fields=status for task counts, status to filter tasks
   - Returns: Current job state
+  - GET /jobs/{id}/logs
+  - Retrieves captured task log output
+  - Returns: Plain text log lines, possibly ending in a truncation marker
+  - GET /jobs/{id}/lineage
+  - Traces replayed executions back to the original
+  - Returns: JSON array of executions, oldest first
+
+4. Job Comparison:
+  - GET /jobs/compare?a={id}&b={id}
+  - Diffs two executions
+  - Query Params: execution IDs a and b
+  - Returns: Differences in data, task statuses, durations, and errors
+
+5. Ad-hoc Task Runs:
+  - POST /tasks/{functionName}/run
+  - Runs one task function once
+  - URL Param: function name
+  - Accepts: Optional JSON data
+  - Returns: Recorded execution with outcome
 
-4. System Monitoring:
+6. System Monitoring:
   - GET /system/state
   - Checks overall system status
+  - Accepts: Optional consistent=true for a single snapshot
   - Returns: Active and queued jobs
+  - GET /system/queue
+  - Lists queued executions in dequeue order
+  - Returns: Execution and definition IDs, definition names, and queue times
+  - GET /system/throughput
+  - Reports the processing rate for capacity planning
+  - Returns: Average finishes per minute and the last 15 minutes of counts
+  - GET /metrics
+  - Exports metrics in the Prometheus text format
+  - Returns: Job counts and durations, queue depth and wait, active workers
 
-Future Route Considerations:
-- GET /job-definitions - List all job definitions
-- DELETE /job-definitions/{id} - Remove job definition
-- POST /jobs/{id}/cancel - Cancel running job
-- GET /jobs - List all job executions
+7. Administration:
+  - GET /admin/audit?offset={n}&limit={n}
+  - Lists administrative actions, newest first
+  - Query Params: optional offset and limit (default 50, max 500)
+  - Returns: Audit entries with action, target, actor, time, and result
+  - GET /admin/reconcile?fix={true|false}
+  - Compares running jobs and the queue with stored executions
+  - Query Params: optional fix to repair discrepancies
+  - Returns: Discrepancies found and whether they were fixed
+  - POST /admin/requeue-running
+  - Re-enqueues executions stuck in RUNNING with nothing executing them
+  - Returns: IDs of the requeued executions
+  - POST /admin/pause?hold={true|false}
+  - Stops taking jobs off the queue
+  - Query Params: optional hold to also stop running jobs before their next task
+  - Returns: No content
+  - POST /admin/resume
+  - Continues processing and releases held jobs
+  - Returns: No content
 */
@@ -0,0 +1,72 @@
+// metrics.go defines the Prometheus metrics of the orchestrator
+// Queue metrics carry a queue label so backed up queues stand out
+// Served on GET /metrics through the default registry
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultQueue is the label value of the orchestrator's single job queue
+const DefaultQueue = "default"
+
+// QueueWait observes how long executions waited in the queue before starting
+// Measured from when the execution was last queued
+var QueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "orchestrator",
+	Name:      "queue_wait_seconds",
+	Help:      "Time executions spent queued before they started running.",
+	Buckets:   []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600},
+}, []string{"queue"})
+
+// JobsEnqueued counts executions created, whether queued right away or delayed
+var JobsEnqueued = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "orchestrator",
+	Name:      "jobs_enqueued_total",
+	Help:      "Number of executions enqueued.",
+})
+
+// JobsFinished counts finished executions by final status
+// Status is COMPLETED, FAILED, or CANCELLED
+var JobsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "orchestrator",
+	Name:      "jobs_finished_total",
+	Help:      "Number of executions that finished, by status.",
+}, []string{"status"})
+
+// JobDuration observes how long finished executions took by final status
+// Measured from when the execution was created, so it includes time spent queued
+var JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "orchestrator",
+	Name:      "job_duration_seconds",
+	Help:      "Time from creating an execution until it finished, by status.",
+	Buckets:   []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200},
+}, []string{"status"})
+
+// ActiveWorkers reports the number of workers running an execution by worker pool
+var ActiveWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "orchestrator",
+	Name:      "active_workers",
+	Help:      "Number of workers currently running an execution, by pool.",
+}, []string{"pool"})
+
+func init() {
+	prometheus.MustRegister(QueueWait, JobsEnqueued, JobsFinished, JobDuration, ActiveWorkers)
+}
+
+// RegisterQueueDepth exports the depth of a queue as a gauge
+// depth is called on every scrape, failures report the depth as zero
+func RegisterQueueDepth(queue string, depth func() (int, error)) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   "orchestrator",
+		Name:        "queue_depth",
+		Help:        "Number of executions waiting in the queue.",
+		ConstLabels: prometheus.Labels{"queue": queue},
+	}, func() float64 {
+		n, err := depth()
+		if err != nil {
+			return 0
+		}
+		return float64(n)
+	}))
+}
@@ -0,0 +1,58 @@
+// metrics_test.go tests the labels of the exported queue metrics
+// Metrics are read back through the default gatherer as they would be scraped
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queueDepths gathers the exported queue depths by queue label
+func queueDepths(t *testing.T) map[string]float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	depths := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "orchestrator_queue_depth" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "queue" {
+					depths[label.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return depths
+}
+
+// TestQueueDepthLabels registers the depths of two queues and a failing one
+func TestQueueDepthLabels(t *testing.T) {
+	for queue, depth := range map[string]func() (int, error){
+		"billing": func() (int, error) { return 7, nil },
+		"reports": func() (int, error) { return 2, nil },
+		"broken":  func() (int, error) { return 5, errors.New("storage unavailable") },
+	} {
+		if err := RegisterQueueDepth(queue, depth); err != nil {
+			t.Fatalf("register %s: %v", queue, err)
+		}
+	}
+
+	depths := queueDepths(t)
+	for queue, want := range map[string]float64{"billing": 7, "reports": 2, "broken": 0} {
+		if got, ok := depths[queue]; !ok || got != want {
+			t.Errorf("depth of queue %s = %v (exported %v), want %v", queue, got, ok, want)
+		}
+	}
+
+	// A queue's depth can only be exported once
+	if err := RegisterQueueDepth("billing", func() (int, error) { return 0, nil }); err == nil {
+		t.Error("registering the billing queue twice succeeded")
+	}
+}
@@ -0,0 +1,64 @@
+// affinity.go routes executions with the same affinity key to the same worker
+// An execution prefers the worker of the last execution with its key if that worker is idle
+// Only a hint for reusing warm caches, executions never wait for their preferred worker
+package orchestrator
+
+import (
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// maxAffinityKeys bounds how many affinity keys are remembered
+// All keys are forgotten at once when the bound is reached
+const maxAffinityKeys = 10000
+
+// affinityTarget is a worker an affinity key can run on
+type affinityTarget struct {
+	pool   string // Label of the worker's pool
+	worker int    // Number of the worker within its pool
+}
+
+// affinityTable remembers the worker of each affinity key
+type affinityTable struct {
+	mu      sync.Mutex
+	workers map[string]affinityTarget // Last worker by affinity key
+	claims  map[affinityTarget]int    // Number of keys whose last worker it is
+}
+
+// takeWorker picks the worker of a pool running an execution
+// Executions with an affinity key prefer the worker their key last ran on,
+// others prefer workers no key last ran on to keep those free for their keys
+func (o *Orchestrator) takeWorker(pool *workerPool, je *models.JobExecution) int {
+	t := &o.affinity
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	claimed := func(worker int) bool {
+		return t.claims[affinityTarget{pool: pool.label, worker: worker}] > 0
+	}
+
+	if je == nil || je.AffinityKey == "" {
+		return pool.take(-1, claimed)
+	}
+
+	preferred := -1
+	previous, known := t.workers[je.AffinityKey]
+	if known && previous.pool == pool.label {
+		preferred = previous.worker
+	}
+	worker := pool.take(preferred, claimed)
+
+	// Remember the worker for the next execution with the key
+	if t.workers == nil || (!known && len(t.workers) >= maxAffinityKeys) {
+		t.workers = make(map[string]affinityTarget)
+		t.claims = make(map[affinityTarget]int)
+		known = false
+	}
+	if known {
+		t.claims[previous]--
+	}
+	target := affinityTarget{pool: pool.label, worker: worker}
+	t.workers[je.AffinityKey] = target
+	t.claims[target]++
+	return worker
+}
@@ -0,0 +1,91 @@
+// affinity_test.go tests routing executions with the same affinity key to one worker
+// Executions with a key return to their worker, other executions keep off it,
+// and a busy preferred worker never makes an execution wait
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// runWithKey runs an execution of definitionID with an affinity key to the end
+// Returns the worker that ran it
+func runWithKey(t *testing.T, o *Orchestrator, definitionID, key string) string {
+	t.Helper()
+	id, err := o.EnqueueJobWithOptions(definitionID, nil, EnqueueOptions{AffinityKey: key})
+	if err != nil {
+		t.Fatalf("enqueue %s: %v", definitionID, err)
+	}
+	je := waitForFinish(t, o, id)
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED", je.Status)
+	}
+	return je.Worker
+}
+
+// TestAffinityKeyReusesWorker runs executions one after the other on a pool of
+// idle workers, so each can have the worker it prefers
+func TestAffinityKeyReusesWorker(t *testing.T) {
+	o := newTestOrchestrator(t, 3)
+	o.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "render",
+		Tasks: []*models.Task{{ID: "frame", FunctionName: "noop"}},
+	})
+
+	warm := runWithKey(t, o, "render", "scene-a")
+	if warm == "" {
+		t.Fatal("execution stored no worker")
+	}
+	for i := 0; i < 3; i++ {
+		if worker := runWithKey(t, o, "render", "scene-a"); worker != warm {
+			t.Errorf("run %d with scene-a on %s, want %s", i, worker, warm)
+		}
+	}
+
+	// Another key and executions without a key stay off the claimed worker
+	other := runWithKey(t, o, "render", "scene-b")
+	if other == warm {
+		t.Errorf("scene-b ran on %s, the worker of scene-a", other)
+	}
+	if worker := runWithKey(t, o, "render", ""); worker == warm || worker == other {
+		t.Errorf("execution without a key ran on claimed worker %s", worker)
+	}
+	if worker := runWithKey(t, o, "render", "scene-a"); worker != warm {
+		t.Errorf("scene-a moved to %s, want %s", worker, warm)
+	}
+}
+
+// TestAffinityBusyWorker takes workers directly while the preferred one is busy
+// The execution runs elsewhere right away and its key follows it
+func TestAffinityBusyWorker(t *testing.T) {
+	o := &Orchestrator{}
+	pool := newWorkerPool("", 2)
+	keyed := &models.JobExecution{AffinityKey: "scene-a"}
+	// take holds a slot of the pool first, as the dispatcher does
+	take := func(je *models.JobExecution) int {
+		pool.slots <- struct{}{}
+		return o.takeWorker(pool, je)
+	}
+
+	first := take(keyed)
+	second := take(keyed)
+	if second == first {
+		t.Fatalf("took busy worker %d twice", first)
+	}
+	pool.put(first)
+	pool.put(second)
+
+	// The key now prefers the worker of its last execution
+	if worker := take(keyed); worker != second {
+		t.Errorf("scene-a took worker %d, want %d", worker, second)
+	}
+	// The worker scene-a left is unclaimed again, so keyless executions get it
+	if worker := take(&models.JobExecution{}); worker != first {
+		t.Errorf("execution without a key took worker %d, want idle worker %d", worker, first)
+	}
+}
@@ -0,0 +1,96 @@
+// alerts.go implements per-definition failure rate alerting
+// Keeps a rolling window of execution outcomes for each definition
+// Emits an AlertTriggered event when the failure rate crosses the threshold
+package orchestrator
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// outcomeWindow holds the most recent execution outcomes of a definition
+// Outcomes are stored in a ring buffer of the configured window size
+type outcomeWindow struct {
+	outcomes []bool // Ring buffer of outcomes, true means failed
+	next     int    // Position of the next write
+	filled   bool   // Whether the ring buffer has wrapped around
+	alerting bool   // Whether the threshold is currently exceeded
+}
+
+// alertTracker maintains outcome windows for all definitions
+// Safe for concurrent use by job goroutines
+type alertTracker struct {
+	mu      sync.Mutex
+	windows map[string]*outcomeWindow
+}
+
+// record adds an outcome to the window of the given definition
+// Returns the current failure rate and whether an alert should be emitted
+// An alert is only reported when the rate crosses the threshold, not while it stays above
+func (t *alertTracker) record(definitionID string, threshold *models.AlertThreshold, failed bool) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.windows == nil {
+		t.windows = make(map[string]*outcomeWindow)
+	}
+
+	// Recreate the window if the configured size changed
+	w, ok := t.windows[definitionID]
+	if !ok || len(w.outcomes) != threshold.WindowSize {
+		w = &outcomeWindow{outcomes: make([]bool, threshold.WindowSize)}
+		t.windows[definitionID] = w
+	}
+
+	w.outcomes[w.next] = failed
+	w.next = (w.next + 1) % len(w.outcomes)
+	if w.next == 0 {
+		w.filled = true
+	}
+
+	// Only evaluate once the window holds enough outcomes
+	if !w.filled {
+		return 0, false
+	}
+
+	failures := 0
+	for _, f := range w.outcomes {
+		if f {
+			failures++
+		}
+	}
+	rate := float64(failures) * 100 / float64(len(w.outcomes))
+
+	exceeded := rate > threshold.FailureRatePercent
+	trigger := exceeded && !w.alerting
+	w.alerting = exceeded
+	return rate, trigger
+}
+
+// recordOutcome tracks the outcome of a finished execution
+// Publishes an AlertTriggered event when the definition's threshold is crossed
+func (o *Orchestrator) recordOutcome(jd *models.JobDefinition, executionID string, failed bool) {
+	if jd.Alert == nil || jd.Alert.WindowSize <= 0 {
+		return
+	}
+
+	rate, trigger := o.alerts.record(jd.ID, jd.Alert, failed)
+	if !trigger {
+		return
+	}
+
+	o.events.Publish(models.Event{
+		Type:         models.EventAlertTriggered,
+		DefinitionID: jd.ID,
+		ExecutionID:  executionID,
+		Message: fmt.Sprintf("failure rate %.1f%% over last %d runs exceeds %.1f%%",
+			rate, jd.Alert.WindowSize, jd.Alert.FailureRatePercent),
+		Details: map[string]interface{}{
+			"failureRatePercent": rate,
+			"windowSize":         jd.Alert.WindowSize,
+			"thresholdPercent":   jd.Alert.FailureRatePercent,
+		},
+	})
+}
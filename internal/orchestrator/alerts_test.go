@@ -0,0 +1,98 @@
+// alerts_test.go tests per-definition failure rate alerting
+// Drives executions above and below the threshold and checks that an alert
+// event is published once per crossing, only after the window filled
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// drainAlerts returns the alert events waiting on a subscription
+func drainAlerts(events <-chan models.Event) []models.Event {
+	var alerts []models.Event
+	for {
+		select {
+		case e := <-events:
+			if e.Type == models.EventAlertTriggered {
+				alerts = append(alerts, e)
+			}
+		default:
+			return alerts
+		}
+	}
+}
+
+// TestAlertTriggeredOnFailureRate runs executions of a definition alerting when
+// more than half of its last four runs failed
+func TestAlertTriggeredOnFailureRate(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("sync", func(ctx context.Context, data map[string]interface{}) error {
+		if data["fail"] == true {
+			return errors.New("upstream down")
+		}
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "sync",
+		Tasks: []*models.Task{{ID: "sync", FunctionName: "sync"}},
+		Alert: &models.AlertThreshold{WindowSize: 4, FailureRatePercent: 50},
+	})
+	events, unsubscribe := o.Events().Subscribe(64)
+	defer unsubscribe()
+
+	steps := []struct {
+		fail   bool
+		alerts int // Alerts expected after the run
+	}{
+		{true, 0},  // Window not filled yet
+		{true, 0},  // Window not filled yet
+		{true, 0},  // Window not filled yet
+		{false, 1}, // 75% over a full window crosses the threshold
+		{true, 0},  // Still above, no repeated alert
+		{false, 0}, // 50% doesn't exceed the threshold
+		{false, 0}, // 25%
+		{true, 0},  // 50%
+		{true, 0},  // 50%
+		{true, 1},  // 75% crosses the threshold again
+	}
+	for i, step := range steps {
+		je := waitForFinish(t, o, enqueue(t, o, "sync", map[string]interface{}{"fail": step.fail}))
+		alerts := drainAlerts(events)
+		if len(alerts) != step.alerts {
+			t.Fatalf("run %d: %d alerts, want %d", i+1, len(alerts), step.alerts)
+		}
+		for _, e := range alerts {
+			if e.DefinitionID != "sync" || e.ExecutionID != je.ID || e.Details["failureRatePercent"] != 75.0 {
+				t.Errorf("run %d: alert %+v, want sync at 75%% raised by %s", i+1, e, je.ID)
+			}
+		}
+	}
+}
+
+// TestAlertTrackerWindows checks that definitions keep separate windows and a
+// resized window starts over
+func TestAlertTrackerWindows(t *testing.T) {
+	var tracker alertTracker
+	threshold := &models.AlertThreshold{WindowSize: 2, FailureRatePercent: 50}
+
+	tracker.record("a", threshold, true)
+	if _, trigger := tracker.record("b", threshold, true); trigger {
+		t.Error("b alerted on its first outcome")
+	}
+	if rate, trigger := tracker.record("a", threshold, true); !trigger || rate != 100 {
+		t.Errorf("a at %.0f%%, trigger %v, want an alert at 100%%", rate, trigger)
+	}
+
+	resized := &models.AlertThreshold{WindowSize: 3, FailureRatePercent: 50}
+	tracker.record("a", resized, true)
+	if _, trigger := tracker.record("a", resized, true); trigger {
+		t.Error("a alerted before its resized window filled")
+	}
+	if rate, trigger := tracker.record("a", resized, true); !trigger || rate != 100 {
+		t.Errorf("a at %.0f%%, trigger %v, want an alert once the resized window filled", rate, trigger)
+	}
+}
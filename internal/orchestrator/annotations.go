@@ -0,0 +1,65 @@
+// annotations.go persists the notes tasks attach to their execution
+// Tasks set them through taskctx.Annotate while they run
+// Changes are saved periodically and with the final state of the execution
+package orchestrator
+
+import (
+	"log"
+	"time"
+)
+
+// annotationSaveInterval is how often changed annotations of a running job are saved
+const annotationSaveInterval = 2 * time.Second
+
+// applyAnnotations copies the run's annotations onto the execution
+// Reports whether they changed since they were last copied
+func (r *jobRun) applyAnnotations() bool {
+	values, changed := r.notes.Snapshot()
+	if !changed {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.Annotations = values
+	return true
+}
+
+// saveAnnotations persists the run's annotations if they changed
+func (r *jobRun) saveAnnotations() {
+	if r.revoked() || !r.applyAnnotations() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.db.UpdateJobExecution(r.je); err != nil {
+		log.Printf("Failed to save annotations of job execution %s: %v", r.je.ID, err)
+	}
+	publishStateChange(r.events, r.je)
+}
+
+// watchAnnotations saves the run's annotations every annotationSaveInterval
+// The returned function stops saving and leaves the latest annotations on the
+// execution, to be persisted with its final state
+func watchAnnotations(run *jobRun) func() {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(annotationSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				run.saveAnnotations()
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+		run.applyAnnotations()
+	}
+}
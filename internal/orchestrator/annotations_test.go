@@ -0,0 +1,54 @@
+// annotations_test.go tests notes tasks attach to their execution while running
+// Annotations set mid-task must show in the execution state before the task ends
+// and stay on the execution once it finished
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// TestAnnotationVisibleMidTask annotates from a task that keeps running
+// The state response shows the latest value while the task still runs
+func TestAnnotationVisibleMidTask(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	progressed, release := make(chan struct{}), make(chan struct{})
+	o.RegisterFunction("import", func(ctx context.Context, data map[string]interface{}) error {
+		taskctx.Annotate(ctx, "progress", "100/1000")
+		taskctx.Annotate(ctx, "progress", "500/1000")
+		close(progressed)
+		<-release
+		taskctx.Annotate(ctx, "progress", "1000/1000")
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "import",
+		Tasks: []*models.Task{{ID: "records", FunctionName: "import"}},
+	})
+
+	id := enqueue(t, o, "import", nil)
+	<-progressed
+	waitFor(t, "annotation in the execution state", func() bool {
+		state, err := o.GetJobExecutionState(id)
+		if err != nil {
+			t.Fatalf("get state: %v", err)
+		}
+		return state.Annotations["progress"] == "500/1000"
+	})
+	if state, _ := o.GetJobExecutionState(id); state.Status != models.JobStatusRunning {
+		t.Fatalf("status = %s while the task runs, want RUNNING", state.Status)
+	}
+
+	close(release)
+	waitForFinish(t, o, id)
+	state, err := o.GetJobExecutionState(id)
+	if err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	if state.Annotations["progress"] != "1000/1000" {
+		t.Errorf("final annotations = %v, want progress 1000/1000", state.Annotations)
+	}
+}
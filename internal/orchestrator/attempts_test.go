@@ -0,0 +1,111 @@
+// attempts_test.go tests recording each attempt of a task separately
+// A task failing twice before succeeding must leave three attempt records,
+// stored with the execution and summed up in the timing of its state
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestTaskAttemptsRecorded runs a task that fails twice and then succeeds
+func TestTaskAttemptsRecorded(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerFlaky(o, "flaky")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "import",
+		Tasks: []*models.Task{{ID: "fetch", FunctionName: "flaky", MaxRetry: 3, RetryBaseDelayMs: 20}},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "import", nil))
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	attempts := je.TaskAttempts["fetch"]
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %+v, want 3", attempts)
+	}
+	for i, attempt := range attempts {
+		wantError := "flaky"
+		if i == 2 {
+			wantError = ""
+		}
+		if attempt.Number != i+1 || attempt.Error != wantError || attempt.StartTime.IsZero() {
+			t.Errorf("attempt %d = %+v, want number %d with error %q", i, attempt, i+1, wantError)
+		}
+		if i > 0 && attempt.StartTime.Before(attempts[i-1].StartTime) {
+			t.Errorf("attempt %d started before the one before it", i+1)
+		}
+	}
+	// Backoff doubles from the base delay before each retry
+	if attempts[0].BackoffMs != 0 || attempts[1].BackoffMs < 20 || attempts[2].BackoffMs < 40 {
+		t.Errorf("backoffs = %d, %d, %d ms, want none, then at least 20 and 40",
+			attempts[0].BackoffMs, attempts[1].BackoffMs, attempts[2].BackoffMs)
+	}
+
+	state, err := o.GetJobExecutionState(je.ID)
+	if err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	if len(state.Tasks) != 1 || len(state.Tasks[0].Attempts) != 3 {
+		t.Errorf("state tasks = %+v, want fetch with 3 attempts", state.Tasks)
+	}
+}
+
+// TestExecutionTiming splits a retried task's time into running and backing off
+// Each attempt runs 30ms, the two retries wait at least 50ms and 100ms
+func TestExecutionTiming(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var calls atomic.Int32
+	o.RegisterFunction("slow", func(ctx context.Context, data map[string]interface{}) error {
+		time.Sleep(30 * time.Millisecond)
+		if calls.Add(1) <= 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	o.RegisterFunction("quick", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "import",
+		Tasks: []*models.Task{
+			{ID: "fetch", FunctionName: "slow", MaxRetry: 3, RetryBaseDelayMs: 50},
+			{ID: "store", FunctionName: "quick", MaxRetry: 3},
+		},
+	})
+
+	start := time.Now()
+	je := waitForFinish(t, o, enqueue(t, o, "import", nil))
+	elapsed := time.Since(start).Milliseconds()
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	state, err := o.GetJobExecutionState(je.ID)
+	if err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	timing := state.Timing
+	if timing == nil {
+		t.Fatal("no timing for an execution with attempts")
+	}
+	if timing.Retries != 2 {
+		t.Errorf("retries = %d, want 2", timing.Retries)
+	}
+	if timing.BackoffMs < 150 || timing.TaskMs < 90 {
+		t.Errorf("backoff %dms and task %dms, want at least 150ms and 90ms", timing.BackoffMs, timing.TaskMs)
+	}
+	if total := timing.BackoffMs + timing.TaskMs; total > elapsed {
+		t.Errorf("backoff and task time add up to %dms, more than the %dms the job took", total, elapsed)
+	}
+	var backoff int64
+	for _, attempt := range je.TaskAttempts["fetch"] {
+		backoff += attempt.BackoffMs
+	}
+	if backoff != timing.BackoffMs || je.TaskAttempts["store"][0].BackoffMs != 0 {
+		t.Errorf("timing backoff %dms, want the %dms of fetch's attempts and none for store", timing.BackoffMs, backoff)
+	}
+}
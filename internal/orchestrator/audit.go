@@ -0,0 +1,34 @@
+// audit.go records administrative actions in the audit log
+// Entries are persisted so actions stay traceable across restarts
+// Failures to write the log never fail the audited action
+package orchestrator
+
+import (
+	"log"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// Audit records an administrative action and its outcome
+// err is the error the action failed with, nil when it succeeded
+func (o *Orchestrator) Audit(action, target, actor string, err error) {
+	entry := &models.AuditEntry{
+		Time:   time.Now(),
+		Action: action,
+		Target: target,
+		Actor:  actor,
+		Result: "ok",
+	}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	if err := o.db.AppendAuditEntry(entry); err != nil {
+		log.Printf("Failed to record audit entry for %s %s: %v", action, target, err)
+	}
+}
+
+// ListAuditEntries returns a page of the audit log, newest entries first
+func (o *Orchestrator) ListAuditEntries(offset, limit int) ([]*models.AuditEntry, error) {
+	return o.db.ListAuditEntries(offset, limit)
+}
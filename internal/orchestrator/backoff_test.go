@@ -0,0 +1,68 @@
+// backoff_test.go tests bounding the worst-case retry backoff of definitions
+// The worst case assumes every task uses all its retries, definitions that could
+// back off longer than WithMaxRetryBackoff allows are rejected at registration
+package orchestrator
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestWorstCaseBackoff sums the backoff of every retry for each strategy and backoff kind
+func TestWorstCaseBackoff(t *testing.T) {
+	tasks := []*models.Task{
+		{ID: "a", FunctionName: "f", MaxRetry: 3},
+		{ID: "b", FunctionName: "f", MaxRetry: 2, RetryBaseDelayMs: 100},
+	}
+	for name, tc := range map[string]struct {
+		jd   *models.JobDefinition
+		want time.Duration
+	}{
+		"sequential": {&models.JobDefinition{Tasks: tasks}, 7300 * time.Millisecond},
+		"parallel":   {&models.JobDefinition{Strategy: models.StrategyParallelAll, Tasks: tasks}, 7 * time.Second},
+		"fixed": {&models.JobDefinition{Tasks: []*models.Task{
+			{ID: "a", FunctionName: "f", MaxRetry: 4, RetryBackoff: models.BackoffFixed, RetryBaseDelayMs: 500},
+		}}, 2 * time.Second},
+		"linear": {&models.JobDefinition{Tasks: []*models.Task{
+			{ID: "a", FunctionName: "f", MaxRetry: 3, RetryBackoff: models.BackoffLinear},
+		}}, 6 * time.Second},
+		"max delay": {&models.JobDefinition{Tasks: []*models.Task{
+			{ID: "a", FunctionName: "f", MaxRetry: 10, RetryMaxDelayMs: 5000},
+		}}, 42 * time.Second},
+		"saturated": {&models.JobDefinition{Tasks: []*models.Task{
+			{ID: "a", FunctionName: "f", MaxRetry: 1000},
+		}}, math.MaxInt64},
+	} {
+		if got := worstCaseBackoff(tc.jd); got != tc.want {
+			t.Errorf("%s: worst case = %s, want %s", name, got, tc.want)
+		}
+	}
+}
+
+// TestMaxRetryBackoffRejects registers a definition whose retries could wait
+// over 17 minutes in total against a bound of one minute
+func TestMaxRetryBackoffRejects(t *testing.T) {
+	long := &models.JobDefinition{ID: "long", Tasks: []*models.Task{{ID: "a", FunctionName: "f", MaxRetry: 10}}}
+	short := &models.JobDefinition{ID: "short", Tasks: []*models.Task{{ID: "a", FunctionName: "f", MaxRetry: 5}}}
+
+	o := newTestOrchestrator(t, 1, WithMaxRetryBackoff(time.Minute))
+	if err := o.RegisterJobDefinition(long); !errors.Is(err, ErrInvalidDefinition) {
+		t.Errorf("register over the bound: %v, want ErrInvalidDefinition", err)
+	}
+	if _, err := o.db.GetJobDefinition("long"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rejected definition was stored: %v", err)
+	}
+	if err := o.RegisterJobDefinition(short); err != nil {
+		t.Errorf("register within the bound: %v", err)
+	}
+
+	// Without a bound any backoff is accepted
+	unbounded := newTestOrchestrator(t, 1)
+	if err := unbounded.RegisterJobDefinition(long); err != nil {
+		t.Errorf("register without a bound: %v", err)
+	}
+}
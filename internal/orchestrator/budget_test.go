@@ -0,0 +1,101 @@
+// budget_test.go tests the retry budget shared by all tasks of a job
+// Flaky tasks retrying on their own would each use their full retry count,
+// the budget caps the retries of the whole job instead
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// registerFlaky registers functions for the task IDs that fail twice before succeeding
+// Returns the attempts of each task
+func registerFlaky(o *Orchestrator, taskIDs ...string) func() map[string]int {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	for _, id := range taskIDs {
+		o.RegisterFunction(id, func(ctx context.Context, data map[string]interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[id]++
+			if attempts[id] <= 2 {
+				return errors.New("flaky")
+			}
+			return nil
+		})
+	}
+	return func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		counts := make(map[string]int, len(attempts))
+		for id, n := range attempts {
+			counts[id] = n
+		}
+		return counts
+	}
+}
+
+// flakyDefinition returns a definition of three flaky tasks with the given budget
+func flakyDefinition(id string, budget int) *models.JobDefinition {
+	jd := &models.JobDefinition{ID: id, RetryBudget: budget}
+	for _, task := range []string{"extract", "transform", "load"} {
+		jd.Tasks = append(jd.Tasks, &models.Task{
+			ID:               task,
+			FunctionName:     id + "-" + task,
+			MaxRetry:         5,
+			RetryBaseDelayMs: 1,
+		})
+	}
+	return jd
+}
+
+// TestRetryBudgetLimitsJob runs three flaky tasks that each need two retries
+// A budget of 2 is used up by the first task, the second fails on its first error
+func TestRetryBudgetLimitsJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	attempts := registerFlaky(o, "limited-extract", "limited-transform", "limited-load")
+	registerDefinition(t, o, flakyDefinition("limited", 2))
+
+	je := waitForFinish(t, o, enqueue(t, o, "limited", nil))
+	if je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "retry budget exhausted") {
+		t.Errorf("status = %s, error = %q, want FAILED with the budget exhausted", je.Status, je.Error)
+	}
+	if je.RetriesUsed != 2 {
+		t.Errorf("retries used = %d, want the budget of 2", je.RetriesUsed)
+	}
+	want := map[string]int{"limited-extract": 3, "limited-transform": 1}
+	counts := attempts()
+	if len(counts) != len(want) {
+		t.Errorf("attempts = %v, want %v", counts, want)
+	}
+	for id, n := range want {
+		if counts[id] != n {
+			t.Errorf("task %s attempted %d times, want %d", id, counts[id], n)
+		}
+	}
+}
+
+// TestRetryBudgetUnlimited runs the same tasks without a budget to completion
+func TestRetryBudgetUnlimited(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	attempts := registerFlaky(o, "free-extract", "free-transform", "free-load")
+	registerDefinition(t, o, flakyDefinition("free", 0))
+
+	je := waitForFinish(t, o, enqueue(t, o, "free", nil))
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	if je.RetriesUsed != 6 {
+		t.Errorf("retries used = %d, want 6", je.RetriesUsed)
+	}
+	for id, n := range attempts() {
+		if n != 3 {
+			t.Errorf("task %s attempted %d times, want 3", id, n)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// cancel.go implements cancelling executions on request
+// Executions are selected by ID, in batches, or by the tags they were enqueued with
+// Used for incident response, e.g. stopping all work of a customer
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// CancelJob cancels a running execution
+// The execution stops at its next task boundary or when its task observes
+// the context, ending CANCELLED along with the interrupted task
+// Returns ErrNotFound if the execution isn't running
+func (o *Orchestrator) CancelJob(executionID string) error {
+	cancel, ok := o.cancels.Load(executionID)
+	if !ok {
+		return fmt.Errorf("running execution %s %w", executionID, ErrNotFound)
+	}
+	cancel.(context.CancelCauseFunc)(fmt.Errorf("%w: cancelled by request", ErrCancelled))
+	return nil
+}
+
+// CancelByTag cancels every queued or running execution carrying the tag
+// Running executions stop at their next task boundary or when their task
+// observes the context
+// Returns the number of executions that were cancelled
+func (o *Orchestrator) CancelByTag(tag string) (int, error) {
+	// Collect first, ForEachJobExecution doesn't allow writes
+	var matching []*models.JobExecution
+	err := o.db.ForEachJobExecution(func(je *models.JobExecution) error {
+		if !je.Status.Finished() && slices.Contains(je.Tags, tag) {
+			matching = append(matching, je)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	cause := fmt.Errorf("%w: cancelled by tag %s", ErrCancelled, tag)
+	count := 0
+	for _, je := range matching {
+		ok, err := o.cancelExecution(je, cause)
+		if err != nil {
+			log.Printf("Failed to cancel job %s: %v", je.ID, err)
+			continue
+		}
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CancelJobs cancels each of the given queued or running executions
+// Returns one result per ID in request order
+// Running executions stop at their next task boundary or when their task observes the context
+func (o *Orchestrator) CancelJobs(executionIDs []string) []models.CancelResult {
+	cause := fmt.Errorf("%w: cancelled by request", ErrCancelled)
+	results := make([]models.CancelResult, 0, len(executionIDs))
+	for _, id := range executionIDs {
+		result := models.CancelResult{ExecutionID: id}
+		je, err := o.db.GetJobExecution(id)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			result.Outcome = models.CancelOutcomeNotFound
+			result.Error = fmt.Sprintf("execution %s not found", id)
+		case err != nil:
+			result.Outcome = models.CancelOutcomeFailed
+			result.Error = err.Error()
+		case je.Status.Finished():
+			result.Outcome = models.CancelOutcomeAlreadyTerminal
+			result.Status = je.Status
+			result.Error = fmt.Sprintf("execution %s is %s", id, je.Status)
+		default:
+			result.Status = je.Status
+			ok, err := o.cancelExecution(je, cause)
+			switch {
+			case err != nil:
+				result.Outcome = models.CancelOutcomeFailed
+				result.Error = err.Error()
+			case !ok:
+				// Stored as running but nothing executes it, see RequeueStuckRunning
+				result.Outcome = models.CancelOutcomeFailed
+				result.Error = fmt.Sprintf("execution %s is %s but not being executed", id, je.Status)
+			default:
+				result.Outcome = models.CancelOutcomeCancelled
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
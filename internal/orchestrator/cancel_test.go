@@ -0,0 +1,47 @@
+// cancel_test.go tests cancelling executions on request
+// A job cancelled while its task waits to be retried must stop right away
+// instead of sitting out the backoff, its task ending CANCELLED
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestCancelDuringBackoff cancels a job whose failed task backs off for a minute
+func TestCancelDuringBackoff(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	attempts := make(chan struct{}, 4)
+	o.RegisterFunction("flaky", func(ctx context.Context, data map[string]interface{}) error {
+		attempts <- struct{}{}
+		return errors.New("flaky")
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "import",
+		Tasks: []*models.Task{{ID: "fetch", FunctionName: "flaky", MaxRetry: 3, RetryBaseDelayMs: 60000}},
+	})
+
+	id := enqueue(t, o, "import", nil)
+	<-attempts
+	// Give the task a moment to enter its backoff after the failed attempt
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := o.CancelJob(id); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	je := waitForFinish(t, o, id)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("job stopped %v after the cancellation, want it to abort the backoff", elapsed)
+	}
+	if je.Status != models.JobStatusCancelled || je.TaskStatuses["fetch"] != models.TaskStatusCancelled {
+		t.Errorf("job %s with task %s, want both CANCELLED", je.Status, je.TaskStatuses["fetch"])
+	}
+	if n := len(attempts); n != 0 {
+		t.Errorf("%d more attempts after the cancellation, want none", n)
+	}
+}
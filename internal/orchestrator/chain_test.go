@@ -0,0 +1,155 @@
+// chain_test.go tests enqueueing chained jobs when an execution completes
+// Covers priority inheritance, priority overrides, failed parents and chain cycles
+// Chained executions are found by the execution that triggered them
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// chainedFrom waits for the execution chained to parentID and returns it once finished
+func chainedFrom(t *testing.T, o *Orchestrator, parentID string) *models.JobExecution {
+	t.Helper()
+	var child *models.JobExecution
+	waitFor(t, "execution chained to "+parentID, func() bool {
+		executions, err := o.ListExecutions("")
+		if err != nil {
+			t.Fatalf("list executions: %v", err)
+		}
+		for _, je := range executions {
+			if je.TriggeredBy == parentID {
+				child = je
+				return true
+			}
+		}
+		return false
+	})
+	return waitForFinish(t, o, child.ID)
+}
+
+// registerChain registers a parent definition chaining to a child definition
+func registerChain(t *testing.T, o *Orchestrator, priority *int) {
+	t.Helper()
+	o.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "child",
+		Tasks: []*models.Task{{ID: "step", FunctionName: "noop"}},
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "parent",
+		Tasks: []*models.Task{{ID: "step", FunctionName: "noop"}},
+		Chain: []*models.ChainedJob{{DefinitionID: "child", Priority: priority}},
+	})
+}
+
+// TestChainedJobInheritsPriority checks a chained job runs at its parent's
+// priority and starts from the parent's data
+func TestChainedJobInheritsPriority(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerChain(t, o, nil)
+
+	id, err := o.EnqueueJobWithOptions("parent", map[string]interface{}{"order": "42"}, EnqueueOptions{Priority: 9})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitForStatus(t, o, id, models.JobStatusCompleted)
+
+	child := chainedFrom(t, o, id)
+	if child.DefinitionID != "child" {
+		t.Errorf("chained definition = %s, want child", child.DefinitionID)
+	}
+	if child.Priority != 9 {
+		t.Errorf("chained priority = %d, want 9", child.Priority)
+	}
+	if child.Data["order"] != "42" {
+		t.Errorf("chained data = %v, want the parent's data", child.Data)
+	}
+}
+
+// TestChainedJobPriorityOverride checks a chain entry's priority wins over the parent's
+func TestChainedJobPriorityOverride(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	priority := 2
+	registerChain(t, o, &priority)
+
+	id, err := o.EnqueueJobWithOptions("parent", nil, EnqueueOptions{Priority: 9})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitForStatus(t, o, id, models.JobStatusCompleted)
+
+	if child := chainedFrom(t, o, id); child.Priority != 2 {
+		t.Errorf("chained priority = %d, want 2", child.Priority)
+	}
+}
+
+// TestFailedJobDoesNotChain checks chained jobs are only enqueued on success
+func TestFailedJobDoesNotChain(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("fail", func(ctx context.Context, data map[string]interface{}) error {
+		return errors.New("boom")
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "child",
+		Tasks: []*models.Task{{ID: "step", FunctionName: "fail"}},
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "parent",
+		Tasks: []*models.Task{{ID: "step", FunctionName: "fail"}},
+		Chain: []*models.ChainedJob{{DefinitionID: "child"}},
+	})
+
+	id := enqueue(t, o, "parent", nil)
+	waitForFinish(t, o, id)
+
+	executions, err := o.ListExecutions("")
+	if err != nil {
+		t.Fatalf("list executions: %v", err)
+	}
+	if len(executions) != 1 {
+		t.Errorf("got %d executions, want only the failed parent", len(executions))
+	}
+}
+
+// chainTo returns a definition chaining to the given definitions
+func chainTo(id string, next ...string) *models.JobDefinition {
+	jd := &models.JobDefinition{ID: id, Tasks: []*models.Task{{ID: "step", FunctionName: "noop"}}}
+	for _, definitionID := range next {
+		jd.Chain = append(jd.Chain, &models.ChainedJob{DefinitionID: definitionID})
+	}
+	return jd
+}
+
+// TestChainCycleRejected checks definitions whose chain leads back to them aren't registered
+func TestChainCycleRejected(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, chainTo("extract", "transform"))
+	registerDefinition(t, o, chainTo("transform", "load", "notify"))
+	registerDefinition(t, o, chainTo("notify"))
+
+	for name, jd := range map[string]*models.JobDefinition{
+		"self":     chainTo("loop", "loop"),
+		"pair":     chainTo("transform", "extract"),
+		"indirect": chainTo("load", "extract"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := o.RegisterJobDefinition(jd); !errors.Is(err, ErrInvalidDefinition) {
+				t.Errorf("register %s = %v, want ErrInvalidDefinition", jd.ID, err)
+			}
+		})
+	}
+
+	// Chains may share definitions as long as they don't loop
+	if err := o.RegisterJobDefinition(chainTo("load", "notify")); err != nil {
+		t.Errorf("register load = %v, want a chain without a cycle accepted", err)
+	}
+	if err := o.RegisterJobDefinition(chainTo("entry", "extract", "notify")); err != nil {
+		t.Errorf("register entry = %v, want a chain without a cycle accepted", err)
+	}
+}
@@ -0,0 +1,83 @@
+// cleanup_test.go tests the cleanup hooks of task functions
+// Hooks run once a task is cancelled mid-run, with a context of their own,
+// and never when the task completes or fails on its own
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// cleanupCall records a call of a cleanup hook
+type cleanupCall struct {
+	data      map[string]interface{} // Input the hook received
+	cancelled bool                   // Whether the hook's context was already done
+}
+
+// recordCleanup registers a cleanup hook for name reporting its calls on the returned channel
+func recordCleanup(o *Orchestrator, name string) <-chan cleanupCall {
+	calls := make(chan cleanupCall, 1)
+	o.RegisterCleanup(name, func(ctx context.Context, data map[string]interface{}) {
+		calls <- cleanupCall{data: data, cancelled: ctx.Err() != nil}
+	})
+	return calls
+}
+
+// TestCleanupRunsOnCancel cancels a running task and checks its hook cleans up
+func TestCleanupRunsOnCancel(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	started := make(chan struct{}, 1)
+	o.RegisterFunction("download", blockingFunction(started, nil, nil))
+	calls := recordCleanup(o, "download")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "fetch",
+		Tasks: []*models.Task{{ID: "download", FunctionName: "download"}},
+	})
+
+	id := enqueue(t, o, "fetch", map[string]interface{}{"path": "/tmp/part"})
+	<-started
+	if err := o.CancelJob(id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCancelled {
+		t.Fatalf("status = %s, want CANCELLED", je.Status)
+	}
+
+	select {
+	case call := <-calls:
+		if call.cancelled {
+			t.Error("cleanup ran with a cancelled context")
+		}
+		if call.data["path"] != "/tmp/part" {
+			t.Errorf("cleanup data = %v, want the task's input", call.data)
+		}
+	default:
+		t.Fatal("cleanup didn't run for the cancelled task")
+	}
+}
+
+// TestCleanupSkippedWithoutCancel runs tasks that complete or fail on their own
+// Neither must invoke the cleanup hook
+func TestCleanupSkippedWithoutCancel(t *testing.T) {
+	for name, err := range map[string]error{"completed": nil, "failed": errors.New("boom")} {
+		t.Run(name, func(t *testing.T) {
+			o := newTestOrchestrator(t, 1)
+			o.RegisterFunction("download", blockingFunction(nil, closedChannel(), err))
+			calls := recordCleanup(o, "download")
+			registerDefinition(t, o, &models.JobDefinition{
+				ID:    "fetch",
+				Tasks: []*models.Task{{ID: "download", FunctionName: "download"}},
+			})
+
+			waitForFinish(t, o, enqueue(t, o, "fetch", nil))
+			select {
+			case <-calls:
+				t.Error("cleanup ran for a task that wasn't cancelled")
+			default:
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// compare.go implements comparison of two job executions
+// Produces a structured diff of data, task outcomes, durations, and errors
+// Helps debug why one run failed while a similar one succeeded
+package orchestrator
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// CompareExecutions builds a diff between two job executions
+// Both executions are loaded from storage
+// Returns an error if either execution cannot be found
+func (o *Orchestrator) CompareExecutions(aID, bID string) (*models.ExecutionDiff, error) {
+	a, err := o.db.GetJobExecution(aID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := o.db.GetJobExecution(bID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.ExecutionDiff{
+		A:              a.ID,
+		B:              b.ID,
+		SameDefinition: a.DefinitionID == b.DefinitionID,
+		StatusA:        a.Status,
+		StatusB:        b.Status,
+		DurationMsA:    executionDuration(a),
+		DurationMsB:    executionDuration(b),
+		ErrorA:         a.Error,
+		ErrorB:         b.Error,
+		Data:           []models.ValueDiff{},
+		Tasks:          []models.TaskDiff{},
+	}
+
+	// Compare input data key by key
+	// Keys are sorted so the diff output is stable
+	for _, key := range unionKeys(a.Data, b.Data) {
+		if !reflect.DeepEqual(a.Data[key], b.Data[key]) {
+			diff.Data = append(diff.Data, models.ValueDiff{Key: key, A: a.Data[key], B: b.Data[key]})
+		}
+	}
+
+	// Compare task outcomes for every task seen in either execution
+	taskIDs := unionKeys(a.TaskStatuses, b.TaskStatuses)
+	for _, id := range taskIDs {
+		td := models.TaskDiff{
+			ID:      id,
+			StatusA: a.TaskStatuses[id],
+			StatusB: b.TaskStatuses[id],
+			ErrorA:  a.TaskErrors[id],
+			ErrorB:  b.TaskErrors[id],
+		}
+		if td.StatusA != td.StatusB || td.ErrorA != td.ErrorB {
+			diff.Tasks = append(diff.Tasks, td)
+		}
+	}
+
+	return diff, nil
+}
+
+// executionDuration returns the run time of a finished execution in milliseconds
+// Unfinished executions report zero
+func executionDuration(je *models.JobExecution) int64 {
+	if je.EndTime.IsZero() {
+		return 0
+	}
+	return je.EndTime.Sub(je.StartTime).Milliseconds()
+}
+
+// unionKeys returns the sorted set of keys present in either map
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		seen[k] = struct{}{}
+	}
+	for k := range b {
+		seen[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+// concurrency.go enforces per-definition concurrency limits
+// Jobs of a definition at its limit are set aside instead of blocking the queue
+// They are queued again, in order, as running jobs of the definition finish
+package orchestrator
+
+import (
+	"log"
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// definitionSlots tracks running and set aside jobs of limited definitions
+// Safe for concurrent use by the queue loop and job goroutines
+// Set aside jobs stay QUEUED in storage, after a crash recoverState queues them again
+type definitionSlots struct {
+	mu       sync.Mutex
+	running  map[string]int      // Running jobs by definition ID
+	deferred map[string][]string // Set aside execution IDs by definition ID, oldest first
+}
+
+// admit reserves a slot of the job's definition
+// Returns the ID of a limited definition whose slot must be released when the job finishes,
+// and false if the definition is at its limit and the job was set aside
+func (o *Orchestrator) admit(executionID string, jd *models.JobDefinition) (string, bool) {
+	if jd == nil || jd.MaxConcurrency <= 0 {
+		return "", true
+	}
+
+	s := &o.slots
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == nil {
+		s.running = make(map[string]int)
+		s.deferred = make(map[string][]string)
+	}
+	if s.running[jd.ID] >= jd.MaxConcurrency {
+		s.deferred[jd.ID] = append(s.deferred[jd.ID], executionID)
+		return "", false
+	}
+	s.running[jd.ID]++
+	return jd.ID, true
+}
+
+// release frees a slot of the definition
+// Queues the oldest set aside job of the definition again, if any
+func (o *Orchestrator) release(definitionID string) {
+	s := &o.slots
+	s.mu.Lock()
+	s.running[definitionID]--
+	var next string
+	if ids := s.deferred[definitionID]; len(ids) > 0 {
+		next = ids[0]
+		s.deferred[definitionID] = ids[1:]
+	}
+	s.mu.Unlock()
+
+	if next == "" {
+		return
+	}
+	if err := o.enqueue(next); err != nil {
+		log.Printf("Failed to requeue deferred job %s: %v", next, err)
+	}
+	o.dispatched.Delete(next)
+}
+
+// requeueDeferred puts all set aside jobs back into the queue
+// Called on shutdown so they are persisted for the next start
+func (o *Orchestrator) requeueDeferred() {
+	s := &o.slots
+	s.mu.Lock()
+	deferred := s.deferred
+	s.deferred = make(map[string][]string)
+	s.mu.Unlock()
+
+	for _, ids := range deferred {
+		for _, id := range ids {
+			if err := o.enqueue(id); err != nil {
+				log.Printf("Failed to requeue deferred job %s: %v", id, err)
+			}
+			o.dispatched.Delete(id)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// data.go handles the flow of data between the tasks of a job
+// Builds task inputs from the job data and merges task outputs back
+// Outputs can be namespaced by task ID to avoid key collisions
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// taskInput builds the data passed to a task function
+// Without an input mapping the job data is passed as is
+// Mapped keys are resolved from dot separated paths into the job data
+func taskInput(task *models.Task, data map[string]interface{}) (map[string]interface{}, error) {
+	if len(task.InputMapping) == 0 {
+		return data, nil
+	}
+
+	// Copy the job data so mapped keys don't leak into it
+	input := make(map[string]interface{}, len(data)+len(task.InputMapping))
+	for k, v := range data {
+		input[k] = v
+	}
+	for key, path := range task.InputMapping {
+		v, ok := lookupPath(data, path)
+		if !ok {
+			return nil, fmt.Errorf("input mapping %s: path %s not found in job data", key, path)
+		}
+		input[key] = v
+	}
+	return input, nil
+}
+
+// lookupPath resolves a dot separated path such as "taskA.result" in nested maps
+func lookupPath(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// checkRequiredOutputs verifies a task produced all of its required outputs
+// Returns an error listing every missing output key
+func checkRequiredOutputs(task *models.Task, output map[string]interface{}) error {
+	var missing []string
+	for _, key := range task.RequiredOutputs {
+		if _, ok := output[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required outputs: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkSuccessCriteria verifies a task's outputs have the values its success criteria expect
+// Lets a function that returned nil still fail, e.g. when it captured an HTTP 500
+// Returns an error describing the first mismatching output
+func checkSuccessCriteria(task *models.Task, output map[string]interface{}) error {
+	keys := make([]string, 0, len(task.SuccessWhen))
+	for key := range task.SuccessWhen {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		want := task.SuccessWhen[key]
+		got, ok := output[key]
+		if !ok {
+			return fmt.Errorf("success criteria not met: output %s missing, want %v", key, want)
+		}
+		if !outputEqual(got, want) {
+			return fmt.Errorf("success criteria not met: output %s is %v, want %v", key, got, want)
+		}
+	}
+	return nil
+}
+
+// outputEqual compares an output value with an expected value from a definition
+// Numbers compare by value, so an int output matches a number decoded from JSON
+func outputEqual(got, want interface{}) bool {
+	if g, ok := toFloat(got); ok {
+		w, ok := toFloat(want)
+		return ok && g == w
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// toFloat converts any numeric value to float64
+// Reports false for values that aren't numbers
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float32, float64:
+		return reflect.ValueOf(n).Float(), true
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(n).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(n).Uint()), true
+	}
+	return 0, false
+}
+
+// mergeOutputs adds a task's outputs to the job data
+// Namespaced outputs are stored as a map under the task ID
+// Otherwise output keys overwrite existing top level keys
+func mergeOutputs(data map[string]interface{}, taskID string, output map[string]interface{}, namespaced bool) map[string]interface{} {
+	if len(output) == 0 {
+		return data
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	if namespaced {
+		data[taskID] = output
+		return data
+	}
+	for k, v := range output {
+		data[k] = v
+	}
+	return data
+}
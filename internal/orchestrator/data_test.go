@@ -0,0 +1,184 @@
+// data_test.go tests passing task outputs through the job data
+// Namespaced outputs of tasks using the same keys are kept apart and can be picked
+// up by later tasks through their input mapping, missing required outputs and outputs
+// failing the success criteria fail a task
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// registerSources registers output functions producing the same "result" key
+func registerSources(o *Orchestrator) {
+	for _, name := range []string{"extract", "enrich"} {
+		o.RegisterOutputFunction(name, func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"result": name}, nil
+		})
+	}
+}
+
+// TestNamespacedOutputsDontCollide runs two tasks producing the same output key
+// Namespaced, both values survive and a third task receives them through its mapping
+func TestNamespacedOutputsDontCollide(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerSources(o)
+	received := make(chan map[string]interface{}, 1)
+	o.RegisterFunction("combine", func(ctx context.Context, data map[string]interface{}) error {
+		received <- data
+		return nil
+	})
+	namespaced := true
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:              "pipeline",
+		OutputNamespace: &namespaced,
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "extract"},
+			{ID: "enrich", FunctionName: "enrich"},
+			{ID: "combine", FunctionName: "combine", InputMapping: map[string]string{
+				"raw": "extract.result", "rich": "enrich.result",
+			}},
+		},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	for _, task := range []string{"extract", "enrich"} {
+		outputs, _ := je.Data[task].(map[string]interface{})
+		if outputs["result"] != task {
+			t.Errorf("data[%s] = %v, want the task's own result", task, je.Data[task])
+		}
+	}
+	if _, ok := je.Data["result"]; ok {
+		t.Errorf("namespaced outputs leaked to the top level: %v", je.Data)
+	}
+	input := <-received
+	if input["raw"] != "extract" || input["rich"] != "enrich" {
+		t.Errorf("combine received raw %v and rich %v, want extract and enrich", input["raw"], input["rich"])
+	}
+}
+
+// TestFlatOutputsOverwrite checks outputs without namespacing merge into the top
+// level, where a later task's output replaces an earlier one's
+func TestFlatOutputsOverwrite(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerSources(o)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pipeline",
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "extract"},
+			{ID: "enrich", FunctionName: "enrich"},
+		},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+	if je.Data["result"] != "enrich" {
+		t.Errorf("data[result] = %v, want the last task's output", je.Data["result"])
+	}
+}
+
+// TestInputMappingMissingPath fails a task whose mapping points at no data
+func TestInputMappingMissingPath(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerSources(o)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pipeline",
+		Tasks: []*models.Task{
+			{ID: "enrich", FunctionName: "enrich", InputMapping: map[string]string{"raw": "extract.result"}},
+		},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+	if je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "path extract.result not found") {
+		t.Errorf("status = %s, error = %q, want FAILED on the missing path", je.Status, je.Error)
+	}
+}
+
+// TestRequiredOutputs fails a task whose function returned without a required output
+// The outputs of a failed task never reach the job data or the tasks after it
+func TestRequiredOutputs(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerSources(o)
+	var ran atomic.Bool
+	o.RegisterFunction("load", func(ctx context.Context, data map[string]interface{}) error {
+		ran.Store(true)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pipeline",
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "extract", RequiredOutputs: []string{"result", "rows", "schema"}},
+			{ID: "load", FunctionName: "load"},
+		},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+	if je.Status != models.JobStatusFailed || je.TaskStatuses["extract"] != models.TaskStatusFailed {
+		t.Fatalf("job %s with extract %s, want both FAILED", je.Status, je.TaskStatuses["extract"])
+	}
+	if !strings.Contains(je.TaskErrors["extract"], "missing required outputs: rows, schema") {
+		t.Errorf("extract error = %q, want the missing outputs listed", je.TaskErrors["extract"])
+	}
+	if _, ok := je.Data["result"]; ok {
+		t.Errorf("outputs of the failed task reached the job data: %v", je.Data)
+	}
+	if ran.Load() {
+		t.Error("task after the failed one ran")
+	}
+
+	// With all required outputs present the job completes
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "complete",
+		Tasks: []*models.Task{{ID: "extract", FunctionName: "extract", RequiredOutputs: []string{"result"}}},
+	})
+	if je := waitForFinish(t, o, enqueue(t, o, "complete", nil)); je.Status != models.JobStatusCompleted {
+		t.Errorf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+}
+
+// TestSuccessWhen runs a function that returns nil but reports HTTP 500 in its outputs
+// Those attempts fail and are retried until the status code matches the criteria
+func TestSuccessWhen(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var calls atomic.Int32
+	o.RegisterOutputFunction("call", func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		if calls.Add(1) <= 2 {
+			return map[string]interface{}{"statusCode": 500}, nil
+		}
+		return map[string]interface{}{"statusCode": 200}, nil
+	})
+
+	// Decoded from JSON the expected code is a float64 while the function returns an int
+	var task models.Task
+	if err := json.Unmarshal([]byte(`{"id": "call", "functionName": "call", "maxRetry": 1, "retryBaseDelayMs": 10, "successWhen": {"statusCode": 200}}`), &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	registerDefinition(t, o, &models.JobDefinition{ID: "api", Tasks: []*models.Task{&task}})
+
+	je := waitForFinish(t, o, enqueue(t, o, "api", nil))
+	if je.Status != models.JobStatusFailed || je.TaskStatuses["call"] != models.TaskStatusFailed {
+		t.Fatalf("job %s with call %s, want both FAILED", je.Status, je.TaskStatuses["call"])
+	}
+	if !strings.Contains(je.TaskErrors["call"], "output statusCode is 500, want 200") {
+		t.Errorf("call error = %q, want the mismatching output named", je.TaskErrors["call"])
+	}
+	if _, ok := je.Data["statusCode"]; ok {
+		t.Errorf("outputs of the failed task reached the job data: %v", je.Data)
+	}
+
+	// A third attempt gets a 200, with enough retries the job completes
+	calls.Store(0)
+	task.MaxRetry = 2
+	registerDefinition(t, o, &models.JobDefinition{ID: "api", Tasks: []*models.Task{&task}})
+	je = waitForFinish(t, o, enqueue(t, o, "api", nil))
+	if je.Status != models.JobStatusCompleted || len(je.TaskAttempts["call"]) != 3 {
+		t.Errorf("job %s after %d attempts, want COMPLETED after 3: %s", je.Status, len(je.TaskAttempts["call"]), je.Error)
+	}
+}
@@ -0,0 +1,72 @@
+// deadline_test.go tests absolute execution deadlines given at enqueue time
+// Executions still queued at their deadline fail without running, others run
+// with the deadline on the context of their tasks
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestDeadlinePassedInQueue holds the only worker until a queued execution's
+// deadline passed, the execution must then fail without running its task
+func TestDeadlinePassedInQueue(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	var ran atomic.Bool
+	o.RegisterFunction("send", func(ctx context.Context, data map[string]interface{}) error {
+		ran.Store(true)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{ID: "busy", Tasks: []*models.Task{{ID: "block", FunctionName: "block"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "send", FunctionName: "send"}}})
+
+	busy := enqueue(t, o, "busy", nil)
+	<-started
+	late, err := o.EnqueueJobWithOptions("report", nil, EnqueueOptions{Deadline: time.Now().Add(50 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	waitForFinish(t, o, busy)
+
+	je := waitForFinish(t, o, late)
+	if je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "deadline") {
+		t.Errorf("status = %s, error = %q, want FAILED by the deadline", je.Status, je.Error)
+	}
+	if ran.Load() {
+		t.Error("task ran after the execution's deadline")
+	}
+}
+
+// TestDeadlineMetBeforeRun runs an execution well before its deadline
+// Its task sees the deadline on its context
+func TestDeadlineMetBeforeRun(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	seen := make(chan time.Time, 1)
+	o.RegisterFunction("send", func(ctx context.Context, data map[string]interface{}) error {
+		deadline, _ := ctx.Deadline()
+		seen <- deadline
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "send", FunctionName: "send"}}})
+
+	deadline := time.Now().Add(time.Minute)
+	id, err := o.EnqueueJobWithOptions("report", nil, EnqueueOptions{Deadline: deadline})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	if got := <-seen; got.IsZero() || got.After(deadline) {
+		t.Errorf("task deadline = %v, want at most %v", got, deadline)
+	}
+}
@@ -0,0 +1,293 @@
+// definition.go implements operations on stored job definitions
+// Supports modifying definitions after registration
+// Running executions keep using the definition they loaded at start
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// validateJobDefinition checks a definition before it is stored
+// Returns an error wrapping ErrInvalidDefinition describing the first problem
+func validateJobDefinition(jd *models.JobDefinition) error {
+	if jd.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidDefinition)
+	}
+	if _, err := taskctx.ParseLevel(jd.LogLevel); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	for _, task := range jd.Tasks {
+		if _, err := taskctx.ParseLevel(task.LogLevel); err != nil {
+			return fmt.Errorf("%w: task %s: %v", ErrInvalidDefinition, task.ID, err)
+		}
+		switch task.RetryBackoff {
+		case "", models.BackoffExponential, models.BackoffLinear, models.BackoffFixed:
+		default:
+			return fmt.Errorf("%w: task %s: unknown retry backoff %q", ErrInvalidDefinition, task.ID, task.RetryBackoff)
+		}
+		if task.RetryBaseDelayMs < 0 || task.RetryMaxDelayMs < 0 {
+			return fmt.Errorf("%w: task %s: retry delays must not be negative", ErrInvalidDefinition, task.ID)
+		}
+		if task.WarnAfterSeconds < 0 {
+			return fmt.Errorf("%w: task %s: warnAfterSeconds must not be negative", ErrInvalidDefinition, task.ID)
+		}
+	}
+	for _, next := range jd.Chain {
+		if next == nil || next.DefinitionID == "" {
+			return fmt.Errorf("%w: chained jobs need a definitionId", ErrInvalidDefinition)
+		}
+	}
+
+	if slices.Contains(jd.Dependencies, "") {
+		return fmt.Errorf("%w: dependency names must not be empty", ErrInvalidDefinition)
+	}
+	if jd.TaskTimeoutPercent < 0 || jd.TaskTimeoutPercent > 100 {
+		return fmt.Errorf("%w: taskTimeoutPercent must be between 0 and 100", ErrInvalidDefinition)
+	}
+
+	switch jd.Strategy {
+	case "", models.StrategySequential, models.StrategyParallelAll:
+	case models.StrategyDAG:
+		// Reject unknown dependencies and cycles up front
+		if _, err := topoOrder(jd.Tasks); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidDefinition, jd.Strategy)
+	}
+
+	switch jd.FailureMode {
+	case "", models.FailureModeFailFast, models.FailureModeCollect, models.FailureModeFirstFailure:
+	default:
+		return fmt.Errorf("%w: unknown failure mode %q", ErrInvalidDefinition, jd.FailureMode)
+	}
+	return nil
+}
+
+// resolveBase composes a definition extending a base into a concrete definition
+// Base tasks come first, own tasks replace base tasks with the same ID and the
+// rest are appended. Settings left unset are taken from the base
+func (o *Orchestrator) resolveBase(jd *models.JobDefinition) error {
+	if jd.BaseDefinition == "" {
+		return nil
+	}
+
+	// Follow the chain of bases to reject definitions extending themselves
+	seen := map[string]bool{jd.ID: true}
+	var base *models.JobDefinition
+	for id := jd.BaseDefinition; id != ""; id = base.BaseDefinition {
+		if seen[id] {
+			return fmt.Errorf("%w: inheritance cycle through base definition %s", ErrInvalidDefinition, id)
+		}
+		seen[id] = true
+
+		var err error
+		if base, err = o.db.GetJobDefinition(id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%w: base definition %s not found", ErrInvalidDefinition, id)
+			}
+			return err
+		}
+	}
+
+	// Stored bases are already resolved, so the direct base holds all inherited tasks
+	base, err := o.db.GetJobDefinition(jd.BaseDefinition)
+	if err != nil {
+		return err
+	}
+	own := make(map[string]*models.Task, len(jd.Tasks))
+	for _, task := range jd.Tasks {
+		own[task.ID] = task
+	}
+	tasks := make([]*models.Task, 0, len(base.Tasks)+len(jd.Tasks))
+	for _, task := range base.Tasks {
+		if override, ok := own[task.ID]; ok {
+			task = override
+			delete(own, task.ID)
+		}
+		tasks = append(tasks, task)
+	}
+	for _, task := range jd.Tasks {
+		if _, ok := own[task.ID]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+	jd.Tasks = tasks
+
+	inheritSettings(jd, base)
+	return nil
+}
+
+// inheritSettings copies the execution settings a definition leaves unset from its base
+// Tags, environments, and the chain describe the definition itself and aren't inherited
+// New settings must be added here to be inherited
+func inheritSettings(jd, base *models.JobDefinition) {
+	inherit(&jd.Alert, base.Alert)
+	inherit(&jd.LogLevel, base.LogLevel)
+	inherit(&jd.Strategy, base.Strategy)
+	inherit(&jd.OutputNamespace, base.OutputNamespace)
+	inherit(&jd.MaxAgeSeconds, base.MaxAgeSeconds)
+	inherit(&jd.RetryBudget, base.RetryBudget)
+	inherit(&jd.StartJitterSeconds, base.StartJitterSeconds)
+	inherit(&jd.TimeoutSeconds, base.TimeoutSeconds)
+	inherit(&jd.TaskTimeoutPercent, base.TaskTimeoutPercent)
+	inherit(&jd.MaxQueueTimeSeconds, base.MaxQueueTimeSeconds)
+	inherit(&jd.MaxConcurrency, base.MaxConcurrency)
+	inherit(&jd.PoolLabel, base.PoolLabel)
+	inherit(&jd.FailureMode, base.FailureMode)
+	if jd.Dependencies == nil {
+		jd.Dependencies = base.Dependencies
+	}
+}
+
+// inherit sets a setting left at its zero value to the base's value
+func inherit[T comparable](setting *T, base T) {
+	var unset T
+	if *setting == unset {
+		*setting = base
+	}
+}
+
+// checkChain rejects a definition whose chain leads back to itself
+// Follows the chains of stored definitions, a cycle would enqueue executions forever
+// Chained definitions that aren't registered yet end the walk
+func (o *Orchestrator) checkChain(jd *models.JobDefinition) error {
+	seen := make(map[string]bool)
+	pending := []*models.JobDefinition{jd}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, next := range current.Chain {
+			if next.DefinitionID == jd.ID {
+				return fmt.Errorf("%w: chain cycle through definition %s", ErrInvalidDefinition, current.ID)
+			}
+			if seen[next.DefinitionID] {
+				continue
+			}
+			seen[next.DefinitionID] = true
+
+			chained, err := o.db.GetJobDefinition(next.DefinitionID)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			pending = append(pending, chained)
+		}
+	}
+	return nil
+}
+
+// GetResolvedJobDefinition returns a definition as its executions run it
+// The stored definition already includes inherited tasks and settings,
+// defaults applied at execution time are filled in on top
+func (o *Orchestrator) GetResolvedJobDefinition(definitionID string) (*models.JobDefinition, error) {
+	jd, err := o.db.GetJobDefinition(definitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if jd.Strategy == "" {
+		jd.Strategy = models.StrategySequential
+	}
+	if jd.FailureMode == "" {
+		jd.FailureMode = models.FailureModeFailFast
+	}
+
+	// Spell out the log level each task logs at, as taskContext picks it
+	level, _ := taskctx.ParseLevel(jd.LogLevel)
+	jd.LogLevel = level.String()
+	for _, task := range jd.Tasks {
+		if task.LogLevel == "" {
+			task.LogLevel = jd.LogLevel
+		}
+		level, _ := taskctx.ParseLevel(task.LogLevel)
+		task.LogLevel = level.String()
+
+		if task.RetryBackoff == "" {
+			task.RetryBackoff = models.BackoffExponential
+		}
+		if task.RetryBaseDelayMs == 0 {
+			task.RetryBaseDelayMs = int(defaultRetryBaseDelay / time.Millisecond)
+		}
+	}
+	return jd, nil
+}
+
+// taskContext derives the context passed to a task function
+// Attaches a logger using the task's level, or the definition's level if unset,
+// and the annotations of the execution
+func taskContext(ctx context.Context, run *jobRun, task *models.Task) context.Context {
+	name := task.LogLevel
+	if name == "" {
+		name = run.jd.LogLevel
+	}
+	level, _ := taskctx.ParseLevel(name)
+	ctx = taskctx.WithAnnotations(ctx, run.notes)
+	return taskctx.WithLogger(ctx, taskctx.NewCapturingLogger(level, fmt.Sprintf("[%s/%s] ", run.je.ID, task.ID), run.logs))
+}
+
+// ListJobDefinitions returns all registered job definitions
+func (o *Orchestrator) ListJobDefinitions() ([]*models.JobDefinition, error) {
+	return o.db.ListJobDefinitions()
+}
+
+// ListJobDefinitionsByTag returns all job definitions carrying the given tag
+func (o *Orchestrator) ListJobDefinitionsByTag(tag string) ([]*models.JobDefinition, error) {
+	return o.db.ListJobDefinitionsByTag(tag)
+}
+
+// DeleteJobDefinition removes a registered job definition
+// Finished executions of the definition are kept for inspection
+// Returns ErrDefinitionInUse while executions of it are queued or running,
+// which could no longer load it, and ErrNotFound if it doesn't exist
+func (o *Orchestrator) DeleteJobDefinition(definitionID string) error {
+	// Keep executions from being created or retried between check and delete
+	o.defMu.Lock()
+	defer o.defMu.Unlock()
+	return o.db.DeleteJobDefinition(definitionID)
+}
+
+// ReorderTasks changes the execution order of a definition's tasks
+// The task IDs must be a permutation of the definition's existing tasks
+// The new order is applied atomically in storage
+func (o *Orchestrator) ReorderTasks(definitionID string, taskIDs []string) (*models.JobDefinition, error) {
+	var updated *models.JobDefinition
+	err := o.db.UpdateJobDefinition(definitionID, func(jd *models.JobDefinition) error {
+		if len(taskIDs) != len(jd.Tasks) {
+			return fmt.Errorf("%w: expected %d task IDs, got %d", ErrInvalidTaskOrder, len(jd.Tasks), len(taskIDs))
+		}
+
+		// Index existing tasks so each ID can be resolved once
+		tasks := make(map[string]*models.Task, len(jd.Tasks))
+		for _, task := range jd.Tasks {
+			tasks[task.ID] = task
+		}
+
+		reordered := make([]*models.Task, 0, len(taskIDs))
+		for _, id := range taskIDs {
+			task, ok := tasks[id]
+			if !ok {
+				return fmt.Errorf("%w: unknown or duplicate task %s", ErrInvalidTaskOrder, id)
+			}
+			delete(tasks, id)
+			reordered = append(reordered, task)
+		}
+
+		jd.Tasks = reordered
+		updated = jd
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,205 @@
+// definition_test.go tests registering, changing, and deleting job definitions
+// Covers deleting a definition while executions of it are being enqueued,
+// reordering the tasks of a definition while an execution of it runs, and
+// resolving a definition to the form its executions run
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestDeleteJobDefinitionRacesEnqueue deletes a definition while it is enqueued concurrently
+// Once the delete succeeds no unfinished execution may exist and no enqueue may succeed
+func TestDeleteJobDefinitionRacesEnqueue(t *testing.T) {
+	o := newTestOrchestrator(t, 4)
+	o.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "short-lived",
+		Tasks: []*models.Task{{ID: "step", FunctionName: "noop"}},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := o.EnqueueJob("short-lived", nil); err != nil && !errors.Is(err, ErrNotFound) {
+					t.Errorf("enqueue: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	var deleted bool
+	for !deleted {
+		err := o.DeleteJobDefinition("short-lived")
+		switch {
+		case err == nil:
+			deleted = true
+		case !errors.Is(err, ErrDefinitionInUse):
+			t.Fatalf("delete: %v", err)
+		}
+	}
+
+	// Nothing unfinished was left when the delete succeeded and nothing was created since
+	executions, err := o.ListExecutions("")
+	if err != nil {
+		t.Fatalf("list executions: %v", err)
+	}
+	for _, je := range executions {
+		if !je.Status.Finished() {
+			t.Errorf("execution %s is %s after its definition was deleted", je.ID, je.Status)
+		}
+	}
+	wg.Wait()
+
+	if _, err := o.EnqueueJob("short-lived", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("enqueue after delete = %v, want ErrNotFound", err)
+	}
+}
+
+// TestReorderTasks reorders a definition while an execution of it runs
+// The running execution keeps the order it started with, later ones use the new order
+func TestReorderTasks(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var mu sync.Mutex
+	var ran []string
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	for _, name := range []string{"extract", "load"} {
+		o.RegisterFunction(name, func(ctx context.Context, data map[string]interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name)
+			return nil
+		})
+	}
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pipeline",
+		Tasks: []*models.Task{
+			{ID: "gate", FunctionName: "block"},
+			{ID: "extract", FunctionName: "extract"},
+			{ID: "load", FunctionName: "load"},
+		},
+	})
+
+	running := enqueue(t, o, "pipeline", nil)
+	<-started
+	jd, err := o.ReorderTasks("pipeline", []string{"gate", "load", "extract"})
+	if err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+	var order []string
+	for _, task := range jd.Tasks {
+		order = append(order, task.ID)
+	}
+	if !slices.Equal(order, []string{"gate", "load", "extract"}) {
+		t.Errorf("reordered tasks = %v, want gate, load, extract", order)
+	}
+	close(release)
+	waitForFinish(t, o, running)
+	waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"extract", "load", "load", "extract"}; !slices.Equal(ran, want) {
+		t.Errorf("tasks ran in order %v, want %v", ran, want)
+	}
+}
+
+// TestReorderTasksRejects checks orders that aren't a permutation of the tasks
+// The stored order must stay unchanged
+func TestReorderTasksRejects(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pipeline",
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "noop"},
+			{ID: "load", FunctionName: "noop"},
+		},
+	})
+
+	for name, taskIDs := range map[string][]string{
+		"incomplete": {"load"},
+		"duplicate":  {"load", "load"},
+		"unknown":    {"load", "transform"},
+		"extra":      {"load", "extract", "transform"},
+	} {
+		if _, err := o.ReorderTasks("pipeline", taskIDs); !errors.Is(err, ErrInvalidTaskOrder) {
+			t.Errorf("%s order %v: %v, want ErrInvalidTaskOrder", name, taskIDs, err)
+		}
+	}
+	if _, err := o.ReorderTasks("missing", []string{"load"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("reorder of an unknown definition: %v, want ErrNotFound", err)
+	}
+
+	jd, err := o.db.GetJobDefinition("pipeline")
+	if err != nil {
+		t.Fatalf("get definition: %v", err)
+	}
+	if jd.Tasks[0].ID != "extract" || jd.Tasks[1].ID != "load" {
+		t.Errorf("rejected reorders changed the tasks to %s, %s", jd.Tasks[0].ID, jd.Tasks[1].ID)
+	}
+}
+
+// TestGetResolvedJobDefinition submits a definition extending a base and compares it
+// with its resolved form, which spells out the inherited and default settings
+func TestGetResolvedJobDefinition(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	for _, submitted := range []string{
+		`{"id": "base", "logLevel": "warn", "tasks": [{"id": "setup", "functionName": "f"}]}`,
+		`{"id": "child", "baseDefinition": "base", "tasks": [
+			{"id": "run", "functionName": "f", "logLevel": "debug", "maxRetry": 2},
+			{"id": "report", "functionName": "f", "retryBackoff": "fixed", "retryBaseDelayMs": 250}
+		]}`,
+	} {
+		var jd models.JobDefinition
+		if err := json.Unmarshal([]byte(submitted), &jd); err != nil {
+			t.Fatalf("decode definition: %v", err)
+		}
+		registerDefinition(t, o, &jd)
+	}
+
+	jd, err := o.GetResolvedJobDefinition("child")
+	if err != nil {
+		t.Fatalf("get resolved definition: %v", err)
+	}
+	if jd.Strategy != models.StrategySequential || jd.FailureMode != models.FailureModeFailFast || jd.LogLevel != "warn" {
+		t.Errorf("resolved strategy %q, failure mode %q and log level %q, want sequential, fail-fast and the inherited warn",
+			jd.Strategy, jd.FailureMode, jd.LogLevel)
+	}
+	want := []models.Task{
+		{ID: "setup", FunctionName: "f", LogLevel: "warn", RetryBackoff: models.BackoffExponential, RetryBaseDelayMs: 1000},
+		{ID: "run", FunctionName: "f", LogLevel: "debug", MaxRetry: 2, RetryBackoff: models.BackoffExponential, RetryBaseDelayMs: 1000},
+		{ID: "report", FunctionName: "f", LogLevel: "warn", RetryBackoff: models.BackoffFixed, RetryBaseDelayMs: 250},
+	}
+	if len(jd.Tasks) != len(want) {
+		t.Fatalf("resolved %d tasks, want %d with the inherited one first", len(jd.Tasks), len(want))
+	}
+	for i, task := range jd.Tasks {
+		w := want[i]
+		if task.ID != w.ID || task.LogLevel != w.LogLevel || task.MaxRetry != w.MaxRetry ||
+			task.RetryBackoff != w.RetryBackoff || task.RetryBaseDelayMs != w.RetryBaseDelayMs {
+			t.Errorf("task %d = %+v, want %+v", i, task, w)
+		}
+	}
+
+	// Resolving fills in a copy, the stored definition keeps what was submitted
+	if child := stored(t, o, "child"); child.Tasks[1].RetryBackoff != "" || child.Tasks[1].RetryBaseDelayMs != 0 {
+		t.Errorf("stored task run = %+v, want its retry settings left unset", child.Tasks[1])
+	}
+	if _, err := o.GetResolvedJobDefinition("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown definition: %v, want ErrNotFound", err)
+	}
+}
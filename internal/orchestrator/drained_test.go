@@ -0,0 +1,61 @@
+// drained_test.go tests the QueueDrained event published once all work finished
+// A batch of executions must produce exactly one event, after its last execution,
+// and the next batch must produce its own
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// drainedEvents counts the QueueDrained events arriving within wait
+func drainedEvents(events <-chan models.Event, wait time.Duration) int {
+	count := 0
+	timeout := time.After(wait)
+	for {
+		select {
+		case e := <-events:
+			if e.Type == models.EventQueueDrained {
+				count++
+			}
+		case <-timeout:
+			return count
+		}
+	}
+}
+
+// TestQueueDrainedOncePerBatch runs two batches of executions to completion
+func TestQueueDrainedOncePerBatch(t *testing.T) {
+	o := newTestOrchestrator(t, 2)
+	events, unsubscribe := o.Events().Subscribe(256)
+	defer unsubscribe()
+	started := make(chan struct{}, 8)
+	release := make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "batch", Tasks: []*models.Task{{ID: "block", FunctionName: "block"}}})
+
+	for batch := 1; batch <= 2; batch++ {
+		var ids []string
+		for i := 0; i < 5; i++ {
+			ids = append(ids, enqueue(t, o, "batch", nil))
+		}
+		<-started
+		if n := drainedEvents(events, 50*time.Millisecond); n != 0 {
+			t.Fatalf("batch %d: %d drained events while executions ran", batch, n)
+		}
+
+		release <- struct{}{}
+		for range ids[1:] {
+			<-started
+			release <- struct{}{}
+		}
+		for _, id := range ids {
+			waitForFinish(t, o, id)
+		}
+		if n := drainedEvents(events, 200*time.Millisecond); n != 1 {
+			t.Errorf("batch %d: %d drained events, want exactly one", batch, n)
+		}
+	}
+}
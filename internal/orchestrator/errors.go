@@ -0,0 +1,57 @@
+// errors.go defines the errors returned by the orchestrator
+// Allows API handlers to map failures to the right response
+// Callers should compare using errors.Is
+package orchestrator
+
+import (
+	"errors"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+)
+
+var (
+	// ErrNotFound is returned when a definition or execution does not exist
+	ErrNotFound = storage.ErrNotFound
+
+	// ErrAlreadyQueued is returned when enqueuing an execution that is already queued
+	ErrAlreadyQueued = storage.ErrAlreadyQueued
+
+	// ErrInvalidDefinition is returned when a job definition fails validation
+	ErrInvalidDefinition = errors.New("invalid job definition")
+
+	// ErrNotRetryable is returned when retrying an execution that hasn't failed
+	ErrNotRetryable = errors.New("execution is not in a retryable state")
+
+	// ErrShutdown is the cancellation cause of jobs interrupted by shutdown
+	ErrShutdown = errors.New("orchestrator shut down")
+
+	// ErrLeaseLost is the cancellation cause of executions another instance took over
+	ErrLeaseLost = errors.New("execution lease lost to another instance")
+
+	// ErrStorageUnavailable is the cancellation cause of jobs whose state couldn't be persisted
+	ErrStorageUnavailable = errors.New("storage unavailable")
+
+	// ErrInvalidTaskOrder is returned when a reorder request is not a permutation of the tasks
+	ErrInvalidTaskOrder = errors.New("invalid task order")
+
+	// ErrTaskTimeout is returned when a task attempt exceeds its timeout
+	ErrTaskTimeout = errors.New("task timed out")
+
+	// ErrJobTimeout is the cancellation cause of executions exceeding their definition's timeout
+	ErrJobTimeout = errors.New("job timed out")
+
+	// ErrInvalidInput is returned when external job input can't be used as job data
+	ErrInvalidInput = errors.New("invalid job input")
+
+	// ErrCancelled is the cancellation cause of executions that were cancelled
+	ErrCancelled = errors.New("execution cancelled")
+
+	// ErrOperationFinished is returned when cancelling an operation that already finished
+	ErrOperationFinished = errors.New("operation already finished")
+
+	// ErrDefinitionInUse is returned when deleting a definition that unfinished executions run
+	ErrDefinitionInUse = storage.ErrDefinitionInUse
+
+	// ErrQueueFull is returned when an execution would wait in the queue too long
+	ErrQueueFull = errors.New("queue wait exceeds the definition's maximum")
+)
@@ -0,0 +1,66 @@
+// events.go implements the in-process event bus of the orchestrator
+// Allows components to observe job processing without coupling to it
+// Delivery is best effort so slow subscribers never block job execution
+package orchestrator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// EventBus fans out published events to all current subscribers
+// Safe for concurrent use by publishers and subscribers
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[int]chan models.Event // Active subscriber channels by subscription ID
+	nextID      int                       // Identifier for the next subscription
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[int]chan models.Event)}
+}
+
+// Subscribe registers a new subscriber with the given channel buffer size
+// Returns the event channel and a function that ends the subscription
+// The channel is closed once the subscription ends
+func (b *EventBus) Subscribe(buffer int) (<-chan models.Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	ch := make(chan models.Event, buffer)
+	b.subscribers[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, id)
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish delivers an event to every subscriber
+// Events are dropped for subscribers whose buffer is full
+// Sets the event time if the publisher left it empty
+func (b *EventBus) Publish(e models.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// failuremode_test.go tests how each failure mode lets a failing task affect its job
+// A sequential pipeline fails in two of its middle tasks, the modes differ in
+// whether the remaining tasks run and which failures the job reports
+package orchestrator
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestFailureModes runs the same pipeline under every failure mode
+func TestFailureModes(t *testing.T) {
+	for _, tc := range []struct {
+		mode    models.FailureMode
+		ran     []string
+		load    models.TaskStatus
+		errors  []string
+		omitted string
+	}{
+		{models.FailureModeFailFast, []string{"extract", "transform"}, "", []string{"transform"}, "validate"},
+		{models.FailureModeCollect, []string{"extract", "transform", "validate", "load"}, models.TaskStatusCompleted, []string{"2 tasks failed", "transform", "validate"}, ""},
+		{models.FailureModeFirstFailure, []string{"extract", "transform", "validate", "load"}, models.TaskStatusCompleted, []string{"transform"}, "validate"},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			o := newTestOrchestrator(t, 1)
+			order := recordOrder(o, map[string]bool{"transform": true, "validate": true}, "extract", "transform", "validate", "load")
+			registerDefinition(t, o, &models.JobDefinition{
+				ID:          "pipeline",
+				FailureMode: tc.mode,
+				Tasks: []*models.Task{
+					{ID: "extract", FunctionName: "extract"},
+					{ID: "transform", FunctionName: "transform"},
+					{ID: "validate", FunctionName: "validate"},
+					{ID: "load", FunctionName: "load"},
+				},
+			})
+
+			je := waitForFinish(t, o, enqueue(t, o, "pipeline", nil))
+			if je.Status != models.JobStatusFailed {
+				t.Fatalf("status = %s, want FAILED", je.Status)
+			}
+			if got := order(); !slices.Equal(got, tc.ran) {
+				t.Errorf("tasks ran in order %v, want %v", got, tc.ran)
+			}
+			if je.TaskStatuses["transform"] != models.TaskStatusFailed || je.TaskStatuses["load"] != tc.load {
+				t.Errorf("transform %q and load %q, want FAILED and %q", je.TaskStatuses["transform"], je.TaskStatuses["load"], tc.load)
+			}
+			for _, want := range tc.errors {
+				if !strings.Contains(je.Error, want) {
+					t.Errorf("error %q doesn't mention %q", je.Error, want)
+				}
+			}
+			if tc.omitted != "" && strings.Contains(je.Error, tc.omitted) {
+				t.Errorf("error %q mentions %q, want only the first failure", je.Error, tc.omitted)
+			}
+		})
+	}
+}
+
+// TestFailureModeRejectsUnknown refuses definitions naming an unknown failure mode
+func TestFailureModeRejectsUnknown(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	err := o.RegisterJobDefinition(&models.JobDefinition{
+		ID:          "pipeline",
+		FailureMode: "ignore",
+		Tasks:       []*models.Task{{ID: "a", FunctionName: "f"}},
+	})
+	if !errors.Is(err, ErrInvalidDefinition) {
+		t.Errorf("register: %v, want ErrInvalidDefinition", err)
+	}
+}
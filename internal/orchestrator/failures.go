@@ -0,0 +1,65 @@
+// failures.go aggregates failure reasons of a definition's executions
+// Identical errors of the same task are grouped and counted
+// Computed from the error fields of stored executions
+package orchestrator
+
+import (
+	"sort"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// GetFailureReasons groups the failures of all stored executions of a definition
+// Task errors are grouped per task, job errors without a task error separately
+// Returns the groups ordered by count, most common first
+func (o *Orchestrator) GetFailureReasons(definitionID string) ([]models.FailureReason, error) {
+	if _, err := o.db.GetJobDefinition(definitionID); err != nil {
+		return nil, err
+	}
+
+	type key struct{ taskID, message string }
+	groups := make(map[key]*models.FailureReason)
+	add := func(taskID, message string, je *models.JobExecution) {
+		k := key{taskID, message}
+		fr, ok := groups[k]
+		if !ok {
+			fr = &models.FailureReason{TaskID: taskID, Error: message}
+			groups[k] = fr
+		}
+		fr.Count++
+		if je.EndTime.After(fr.LastSeen) {
+			fr.LastSeen = je.EndTime
+		}
+	}
+
+	err := o.db.ForEachJobExecution(func(je *models.JobExecution) error {
+		if je.DefinitionID != definitionID {
+			return nil
+		}
+		for taskID, message := range je.TaskErrors {
+			add(taskID, message, je)
+		}
+
+		// The job error repeats the task error when a task failed
+		// Only count it when no task error explains the failure
+		if je.Error != "" && len(je.TaskErrors) == 0 {
+			add("", je.Error, je)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := make([]models.FailureReason, 0, len(groups))
+	for _, fr := range groups {
+		reasons = append(reasons, *fr)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].LastSeen.After(reasons[j].LastSeen)
+	})
+	return reasons, nil
+}
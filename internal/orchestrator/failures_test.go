@@ -0,0 +1,65 @@
+// failures_test.go tests aggregating the failure reasons of a definition
+// Executions are stored directly with their errors to control the grouping
+// Groups are ordered by count, ties by the latest occurrence
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestGetFailureReasons groups task and job errors of one definition's executions
+// Errors of other definitions and completed executions are left out
+func TestGetFailureReasons(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	tasks := []*models.Task{{ID: "load", FunctionName: "load"}}
+	registerDefinition(t, o, &models.JobDefinition{ID: "etl", Tasks: tasks})
+	registerDefinition(t, o, &models.JobDefinition{ID: "other", Tasks: tasks})
+
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, je := range []*models.JobExecution{
+		{DefinitionID: "etl", TaskErrors: map[string]string{"load": "timeout"}, Error: "task load failed: timeout"},
+		{DefinitionID: "etl", TaskErrors: map[string]string{"load": "timeout"}, Error: "task load failed: timeout"},
+		{DefinitionID: "etl", TaskErrors: map[string]string{"load": "disk full"}, Error: "task load failed: disk full"},
+		{DefinitionID: "etl", Error: "deadline passed"},
+		{DefinitionID: "etl", TaskErrors: map[string]string{"load": "timeout"}, Error: "task load failed: timeout"},
+		{DefinitionID: "etl", Error: "deadline passed"},
+		{DefinitionID: "etl", Status: models.JobStatusCompleted},
+		{DefinitionID: "other", TaskErrors: map[string]string{"load": "timeout"}, Error: "task load failed: timeout"},
+	} {
+		je.ID = newID("exec")
+		if je.Status == "" {
+			je.Status = models.JobStatusFailed
+		}
+		je.StartTime = base.Add(time.Duration(i) * time.Hour)
+		je.EndTime = je.StartTime.Add(time.Minute)
+		if err := o.db.StoreJobExecution(je); err != nil {
+			t.Fatalf("store execution: %v", err)
+		}
+	}
+
+	reasons, err := o.GetFailureReasons("etl")
+	if err != nil {
+		t.Fatalf("get failure reasons: %v", err)
+	}
+	want := []models.FailureReason{
+		{TaskID: "load", Error: "timeout", Count: 3, LastSeen: base.Add(4*time.Hour + time.Minute)},
+		{Error: "deadline passed", Count: 2, LastSeen: base.Add(5*time.Hour + time.Minute)},
+		{TaskID: "load", Error: "disk full", Count: 1, LastSeen: base.Add(2*time.Hour + time.Minute)},
+	}
+	if len(reasons) != len(want) {
+		t.Fatalf("reasons = %+v, want %+v", reasons, want)
+	}
+	for i, fr := range reasons {
+		if fr.TaskID != want[i].TaskID || fr.Error != want[i].Error || fr.Count != want[i].Count || !fr.LastSeen.Equal(want[i].LastSeen) {
+			t.Errorf("reason %d = %+v, want %+v", i, fr, want[i])
+		}
+	}
+
+	if _, err := o.GetFailureReasons("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown definition: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,38 @@
+// flags.go lets feature flags switch individual tasks on and off
+// The flag provider is consulted before each task runs
+// Disabled tasks are skipped without editing their definition
+package orchestrator
+
+import (
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// FlagProvider decides whether a task of a definition may run
+// Called before every task, so implementations should answer quickly
+type FlagProvider interface {
+	TaskEnabled(definitionID, taskID string) bool
+}
+
+// FlagProviderFunc adapts an ordinary function to the FlagProvider interface
+type FlagProviderFunc func(definitionID, taskID string) bool
+
+// TaskEnabled calls f to decide whether the task may run
+func (f FlagProviderFunc) TaskEnabled(definitionID, taskID string) bool {
+	return f(definitionID, taskID)
+}
+
+// allEnabled enables every task, used when no flag provider is configured
+type allEnabled struct{}
+
+func (allEnabled) TaskEnabled(string, string) bool { return true }
+
+// SetFlagProvider sets the provider deciding which tasks run
+// Should be called before jobs are enqueued
+func (o *Orchestrator) SetFlagProvider(p FlagProvider) {
+	o.flags = p
+}
+
+// taskEnabled reports whether a task of the running job is switched on
+func (o *Orchestrator) taskEnabled(jd *models.JobDefinition, task *models.Task) bool {
+	return o.flags.TaskEnabled(jd.ID, task.ID)
+}
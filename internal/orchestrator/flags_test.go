@@ -0,0 +1,60 @@
+// flags_test.go tests switching tasks on and off with a feature flag provider
+// A flag is toggled between executions of the same definition, a disabled task
+// must be skipped while the rest of the job runs as usual
+package orchestrator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestFlagSkipsTask toggles the flag of one task off and back on
+func TestFlagSkipsTask(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var enabled atomic.Bool
+	enabled.Store(true)
+	o.SetFlagProvider(FlagProviderFunc(func(definitionID, taskID string) bool {
+		return definitionID != "report" || taskID != "notify" || enabled.Load()
+	}))
+	var notified, sent atomic.Int32
+	o.RegisterFunction("notify", func(ctx context.Context, data map[string]interface{}) error {
+		notified.Add(1)
+		return nil
+	})
+	o.RegisterFunction("send", func(ctx context.Context, data map[string]interface{}) error {
+		sent.Add(1)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "report",
+		Tasks: []*models.Task{
+			{ID: "notify", FunctionName: "notify"},
+			{ID: "send", FunctionName: "send"},
+		},
+	})
+
+	for _, tc := range []struct {
+		enabled bool
+		status  models.TaskStatus
+	}{
+		{true, models.TaskStatusCompleted},
+		{false, models.TaskStatusSkipped},
+		{true, models.TaskStatusCompleted},
+	} {
+		enabled.Store(tc.enabled)
+		je := waitForFinish(t, o, enqueue(t, o, "report", nil))
+		if je.Status != models.JobStatusCompleted {
+			t.Fatalf("flag %v: status = %s, want COMPLETED: %s", tc.enabled, je.Status, je.Error)
+		}
+		if je.TaskStatuses["notify"] != tc.status || je.TaskStatuses["send"] != models.TaskStatusCompleted {
+			t.Errorf("flag %v: notify %s and send %s, want notify %s and send COMPLETED",
+				tc.enabled, je.TaskStatuses["notify"], je.TaskStatuses["send"], tc.status)
+		}
+	}
+	if notified.Load() != 2 || sent.Load() != 3 {
+		t.Errorf("notify ran %d and send %d times, want 2 and 3", notified.Load(), sent.Load())
+	}
+}
@@ -0,0 +1,47 @@
+// graph.go builds the task dependency graph of a definition
+// Edges follow the definition's strategy, so they show how the tasks actually run
+// The order is the one the strategy runs the tasks in
+package orchestrator
+
+import "github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+// GetTaskGraph returns the tasks of a definition with their dependencies
+// DAG definitions use their declared dependencies, sequential definitions
+// chain each task to the one before it and parallel-all tasks have none
+func (o *Orchestrator) GetTaskGraph(definitionID string) (*models.TaskGraph, error) {
+	jd, err := o.db.GetJobDefinition(definitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.TaskGraph{
+		Nodes: []models.GraphNode{},
+		Edges: []models.GraphEdge{},
+		Order: []string{},
+	}
+	for _, task := range jd.Tasks {
+		graph.Nodes = append(graph.Nodes, models.GraphNode{ID: task.ID, Name: task.Name, FunctionName: task.FunctionName})
+	}
+
+	order := jd.Tasks
+	switch jd.Strategy {
+	case models.StrategyDAG:
+		if order, err = topoOrder(jd.Tasks); err != nil {
+			return nil, err
+		}
+		for _, task := range jd.Tasks {
+			for _, dep := range task.DependsOn {
+				graph.Edges = append(graph.Edges, models.GraphEdge{From: dep, To: task.ID})
+			}
+		}
+	case models.StrategyParallelAll:
+	default:
+		for i := 1; i < len(jd.Tasks); i++ {
+			graph.Edges = append(graph.Edges, models.GraphEdge{From: jd.Tasks[i-1].ID, To: jd.Tasks[i].ID})
+		}
+	}
+	for _, task := range order {
+		graph.Order = append(graph.Order, task.ID)
+	}
+	return graph, nil
+}
@@ -0,0 +1,95 @@
+// graph_test.go tests the task dependency graph of definitions
+// A diamond DAG checks nodes, edges, and a valid topological order
+// Sequential and parallel-all definitions check the edges their strategy implies
+package orchestrator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestTaskGraphDiamond builds the graph of a diamond: fetch feeds parse and
+// resize, which both feed publish
+// Tasks are declared out of order so the order has to be computed
+func TestTaskGraphDiamond(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "diamond",
+		Strategy: models.StrategyDAG,
+		Tasks: []*models.Task{
+			{ID: "publish", Name: "Publish", FunctionName: "publish", DependsOn: []string{"parse", "resize"}},
+			{ID: "parse", Name: "Parse", FunctionName: "parse", DependsOn: []string{"fetch"}},
+			{ID: "resize", Name: "Resize", FunctionName: "resize", DependsOn: []string{"fetch"}},
+			{ID: "fetch", Name: "Fetch", FunctionName: "fetch"},
+		},
+	})
+
+	graph, err := o.GetTaskGraph("diamond")
+	if err != nil {
+		t.Fatalf("get graph: %v", err)
+	}
+
+	wantNodes := []models.GraphNode{
+		{ID: "publish", Name: "Publish", FunctionName: "publish"},
+		{ID: "parse", Name: "Parse", FunctionName: "parse"},
+		{ID: "resize", Name: "Resize", FunctionName: "resize"},
+		{ID: "fetch", Name: "Fetch", FunctionName: "fetch"},
+	}
+	if !slices.Equal(graph.Nodes, wantNodes) {
+		t.Errorf("nodes = %v, want %v", graph.Nodes, wantNodes)
+	}
+	wantEdges := []models.GraphEdge{
+		{From: "parse", To: "publish"},
+		{From: "resize", To: "publish"},
+		{From: "fetch", To: "parse"},
+		{From: "fetch", To: "resize"},
+	}
+	if !slices.Equal(graph.Edges, wantEdges) {
+		t.Errorf("edges = %v, want %v", graph.Edges, wantEdges)
+	}
+
+	// Every task comes after the tasks it depends on
+	if len(graph.Order) != len(wantNodes) {
+		t.Fatalf("order = %v, want all %d tasks", graph.Order, len(wantNodes))
+	}
+	for _, edge := range graph.Edges {
+		if slices.Index(graph.Order, edge.From) > slices.Index(graph.Order, edge.To) {
+			t.Errorf("order %v runs %s before %s", graph.Order, edge.To, edge.From)
+		}
+	}
+	if graph.Order[0] != "fetch" || graph.Order[3] != "publish" {
+		t.Errorf("order = %v, want fetch first and publish last", graph.Order)
+	}
+}
+
+// TestTaskGraphStrategies checks the edges implied by the other strategies
+func TestTaskGraphStrategies(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	tasks := []*models.Task{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	registerDefinition(t, o, &models.JobDefinition{ID: "sequential", Tasks: tasks})
+	registerDefinition(t, o, &models.JobDefinition{ID: "parallel", Strategy: models.StrategyParallelAll, Tasks: tasks})
+
+	sequential, err := o.GetTaskGraph("sequential")
+	if err != nil {
+		t.Fatalf("get graph: %v", err)
+	}
+	wantEdges := []models.GraphEdge{{From: "a", To: "b"}, {From: "b", To: "c"}}
+	if !slices.Equal(sequential.Edges, wantEdges) || !slices.Equal(sequential.Order, []string{"a", "b", "c"}) {
+		t.Errorf("sequential graph = %+v, want a chain in definition order", sequential)
+	}
+
+	parallel, err := o.GetTaskGraph("parallel")
+	if err != nil {
+		t.Fatalf("get graph: %v", err)
+	}
+	if len(parallel.Edges) != 0 || !slices.Equal(parallel.Order, []string{"a", "b", "c"}) {
+		t.Errorf("parallel graph = %+v, want no edges", parallel)
+	}
+
+	if _, err := o.GetTaskGraph("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("graph of a missing definition = %v, want ErrNotFound", err)
+	}
+}
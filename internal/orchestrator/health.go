@@ -0,0 +1,142 @@
+// health.go gates executions on the health of the external systems they depend on
+// Definitions list dependencies by name, each probed in the background by a registered health check
+// Executions taken off the queue while a dependency is unhealthy are deferred
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// HealthCheck reports whether an external dependency is usable, nil if it is healthy
+type HealthCheck func(ctx context.Context) error
+
+// healthCheckTimeout bounds how long a single health check may run
+const healthCheckTimeout = 5 * time.Second
+
+// healthProbeInterval is how often each dependency is checked again
+// Probes run more often if the dependency retry delay is shorter
+const healthProbeInterval = 5 * time.Second
+
+// defaultDependencyRetryDelay is how long executions wait after finding a dependency unhealthy
+const defaultDependencyRetryDelay = 10 * time.Second
+
+// WithDependencyRetryDelay sets how long executions are deferred when a dependency is unhealthy
+// They are queued again once the delay passes and checked anew
+func WithDependencyRetryDelay(d time.Duration) Option {
+	return func(o *Orchestrator) {
+		if d > 0 {
+			o.healthDelay = d
+		}
+	}
+}
+
+// RegisterHealthCheck registers the health check of the dependency with the given name
+// Definitions listing the dependency only start while the check passes
+// The check runs once right away and then in the background, never in the queue loop,
+// so a hanging check doesn't hold up executions of other definitions
+func (o *Orchestrator) RegisterHealthCheck(dependency string, check HealthCheck) {
+	o.healthMu.Lock()
+	_, watched := o.healthChecks[dependency]
+	o.healthChecks[dependency] = check
+	o.healthMu.Unlock()
+
+	o.probeDependency(dependency)
+	if !watched {
+		go o.watchDependency(dependency)
+	}
+}
+
+// watchDependency probes a dependency until shutdown
+// Probes at least as often as deferred executions retry, so they find a fresh result
+func (o *Orchestrator) watchDependency(dependency string) {
+	ticker := time.NewTicker(min(healthProbeInterval, o.healthDelay))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.closing:
+			return
+		case <-ticker.C:
+			o.probeDependency(dependency)
+		}
+	}
+}
+
+// probeDependency runs the health check of a dependency and records the result
+// Logs when the dependency becomes unhealthy or recovers
+func (o *Orchestrator) probeDependency(dependency string) {
+	o.healthMu.RLock()
+	check := o.healthChecks[dependency]
+	o.healthMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(o.ctx, healthCheckTimeout)
+	err := check(ctx)
+	cancel()
+
+	o.healthMu.Lock()
+	previous, probed := o.health[dependency]
+	o.health[dependency] = err
+	o.healthMu.Unlock()
+
+	switch {
+	case err != nil && (previous == nil || !probed):
+		log.Printf("Dependency %s is unhealthy: %v", dependency, err)
+	case err == nil && previous != nil:
+		log.Printf("Dependency %s is healthy again", dependency)
+	}
+}
+
+// unhealthyDependency looks up the latest health of the definition's dependencies in order
+// Returns the first unhealthy dependency with the reason, or an empty name
+// Dependencies without a registered check count as unhealthy
+func (o *Orchestrator) unhealthyDependency(jd *models.JobDefinition) (string, error) {
+	o.healthMu.RLock()
+	defer o.healthMu.RUnlock()
+	for _, dependency := range jd.Dependencies {
+		if _, ok := o.healthChecks[dependency]; !ok {
+			return dependency, fmt.Errorf("no health check registered")
+		}
+		if err := o.health[dependency]; err != nil {
+			return dependency, err
+		}
+	}
+	return "", nil
+}
+
+// checkDependencies makes sure the dependencies of a job taken off the queue are healthy
+// Returns false if one isn't, the job was then deferred by the dependency retry delay
+func (o *Orchestrator) checkDependencies(je *models.JobExecution, jd *models.JobDefinition) bool {
+	if jd == nil || len(jd.Dependencies) == 0 {
+		return true
+	}
+	dependency, err := o.unhealthyDependency(jd)
+	if dependency == "" {
+		return true
+	}
+
+	defer o.dispatched.Delete(je.ID)
+	message := fmt.Sprintf("dependency %s is unhealthy: %v", dependency, err)
+	log.Printf("Deferring job %s by %s: %s", je.ID, o.healthDelay, message)
+	if err := o.db.ScheduleJob(je.ID, time.Now().Add(o.healthDelay)); err != nil {
+		log.Printf("Failed to defer job %s, queuing it again: %v", je.ID, err)
+		if err := o.enqueue(je.ID); err != nil {
+			log.Printf("Failed to requeue job %s: %v", je.ID, err)
+		}
+		return false
+	}
+	o.events.Publish(models.Event{
+		Type:         models.EventJobDeferred,
+		DefinitionID: jd.ID,
+		ExecutionID:  je.ID,
+		Message:      message,
+		Details: map[string]interface{}{
+			"dependency": dependency,
+			"retryAt":    time.Now().Add(o.healthDelay),
+		},
+	})
+	return false
+}
@@ -0,0 +1,126 @@
+// health_test.go tests gating executions on the health of their dependencies
+// Covers healthy and unhealthy dependencies and checks that hang
+// Health check results are switched at runtime through atomic flags
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// switchableCheck returns a health check failing while healthy is false
+func switchableCheck(healthy *atomic.Bool) HealthCheck {
+	return func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("unavailable")
+		}
+		return nil
+	}
+}
+
+// registerDependentDefinition registers a single task definition depending on dependency
+func registerDependentDefinition(t *testing.T, o *Orchestrator, id, dependency string) {
+	t.Helper()
+	jd := &models.JobDefinition{ID: id, Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}}
+	if dependency != "" {
+		jd.Dependencies = []string{dependency}
+	}
+	registerDefinition(t, o, jd)
+}
+
+// TestHealthyDependencyRunsJob runs a job whose dependency passes its check
+func TestHealthyDependencyRunsJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	o.RegisterHealthCheck("db", func(ctx context.Context) error { return nil })
+	registerDependentDefinition(t, o, "needs-db", "db")
+
+	id := enqueue(t, o, "needs-db", nil)
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
+
+// TestUnhealthyDependencyDefersJob defers a job while its dependency fails its check
+// The job runs once the dependency is healthy again
+func TestUnhealthyDependencyDefersJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithDependencyRetryDelay(20*time.Millisecond))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	var healthy atomic.Bool
+	o.RegisterHealthCheck("db", switchableCheck(&healthy))
+	registerDependentDefinition(t, o, "needs-db", "db")
+
+	events, unsubscribe := o.Events().Subscribe(16)
+	defer unsubscribe()
+	id := enqueue(t, o, "needs-db", nil)
+
+	deferred := false
+	for !deferred {
+		select {
+		case e := <-events:
+			deferred = e.Type == models.EventJobDeferred && e.ExecutionID == id
+		case <-time.After(testTimeout):
+			t.Fatal("timed out waiting for the job to be deferred")
+		}
+	}
+	if je := execution(t, o, id); je.Status != models.JobStatusQueued {
+		t.Fatalf("status = %s while dependency is unhealthy, want %s", je.Status, models.JobStatusQueued)
+	}
+
+	healthy.Store(true)
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
+
+// TestUnregisteredDependencyDefersJob defers a job whose dependency has no check
+func TestUnregisteredDependencyDefersJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithDependencyRetryDelay(time.Hour))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDependentDefinition(t, o, "needs-cache", "cache")
+
+	id := enqueue(t, o, "needs-cache", nil)
+	waitFor(t, "job to be deferred", func() bool {
+		queued, err := o.db.GetQueuedJobs()
+		return err == nil && len(queued) == 0 && !o.inFlight(id)
+	})
+	if je := execution(t, o, id); je.Status != models.JobStatusQueued {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusQueued)
+	}
+}
+
+// TestHangingHealthCheckDoesNotStallQueue keeps a dependency's check hanging
+// Jobs of definitions without that dependency must still run right away
+func TestHangingHealthCheckDoesNotStallQueue(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithDependencyRetryDelay(20*time.Millisecond))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	var hang atomic.Bool
+	o.RegisterHealthCheck("slow", func(ctx context.Context) error {
+		if hang.Load() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	registerDependentDefinition(t, o, "needs-slow", "slow")
+	registerDependentDefinition(t, o, "independent", "")
+
+	hang.Store(true)
+	o.Pause(false)
+	for range 5 {
+		enqueue(t, o, "needs-slow", nil)
+	}
+	id := enqueue(t, o, "independent", nil)
+	start := time.Now()
+	o.Resume()
+
+	waitForFinish(t, o, id)
+	if elapsed := time.Since(start); elapsed >= healthCheckTimeout {
+		t.Fatalf("independent job took %s behind a hanging health check", elapsed)
+	}
+}
@@ -0,0 +1,135 @@
+// helpers_test.go provides shared helpers for the orchestrator tests
+// Each test runs an orchestrator on its own BoltDB in a temporary directory
+// Helpers poll for asynchronous state changes with a bounded wait
+package orchestrator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// testTimeout bounds how long a test waits for a condition
+const testTimeout = 10 * time.Second
+
+// openTestDB opens a BoltDB in the test's temporary directory
+func openTestDB(t testing.TB) *storage.BoltDB {
+	t.Helper()
+	db, err := storage.NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	return db
+}
+
+// newTestOrchestrator starts an orchestrator on a fresh BoltDB
+// The orchestrator is shut down when the test ends
+func newTestOrchestrator(t testing.TB, maxConcurrent int, opts ...Option) *Orchestrator {
+	t.Helper()
+	return startTestOrchestrator(t, openTestDB(t), maxConcurrent, opts...)
+}
+
+// startTestOrchestrator starts an orchestrator on the given storage
+// The orchestrator is shut down when the test ends
+func startTestOrchestrator(t testing.TB, db storage.DB, maxConcurrent int, opts ...Option) *Orchestrator {
+	t.Helper()
+	o, err := New(db, maxConcurrent, opts...)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		o.Shutdown(ctx)
+	})
+	return o
+}
+
+// registerDefinition registers a definition and fails the test on error
+func registerDefinition(t testing.TB, o *Orchestrator, jd *models.JobDefinition) {
+	t.Helper()
+	if err := o.RegisterJobDefinition(jd); err != nil {
+		t.Fatalf("register definition %s: %v", jd.ID, err)
+	}
+}
+
+// enqueue enqueues an execution and fails the test on error
+func enqueue(t testing.TB, o *Orchestrator, definitionID string, data map[string]interface{}) string {
+	t.Helper()
+	id, err := o.EnqueueJob(definitionID, data)
+	if err != nil {
+		t.Fatalf("enqueue %s: %v", definitionID, err)
+	}
+	return id
+}
+
+// waitFor polls cond until it holds, failing the test after testTimeout
+func waitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// execution reads an execution from storage and fails the test on error
+func execution(t testing.TB, o *Orchestrator, id string) *models.JobExecution {
+	t.Helper()
+	je, err := o.db.GetJobExecution(id)
+	if err != nil {
+		t.Fatalf("get execution %s: %v", id, err)
+	}
+	return je
+}
+
+// waitForStatus waits until the stored execution has the given status
+func waitForStatus(t testing.TB, o *Orchestrator, id string, status models.JobStatus) *models.JobExecution {
+	t.Helper()
+	var je *models.JobExecution
+	waitFor(t, "execution "+id+" to be "+string(status), func() bool {
+		je = execution(t, o, id)
+		return je.Status == status
+	})
+	return je
+}
+
+// waitForFinish waits until the stored execution finished with its end time recorded
+func waitForFinish(t testing.TB, o *Orchestrator, id string) *models.JobExecution {
+	t.Helper()
+	var je *models.JobExecution
+	waitFor(t, "execution "+id+" to finish", func() bool {
+		je = execution(t, o, id)
+		return je.Status.Finished() && !je.EndTime.IsZero()
+	})
+	return je
+}
+
+// blockingFunction returns a task function that signals on started, if not nil,
+// and blocks until release is closed, returning err
+func blockingFunction(started chan<- struct{}, release <-chan struct{}, err error) TaskFunction {
+	return func(ctx context.Context, data map[string]interface{}) error {
+		if started != nil {
+			started <- struct{}{}
+		}
+		select {
+		case <-release:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// closedChannel returns a channel that is already closed
+func closedChannel() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
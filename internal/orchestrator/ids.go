@@ -0,0 +1,36 @@
+// ids.go generates the IDs of executions and operations
+// IDs carry a nanosecond timestamp that never goes backwards within a process,
+// so they stay unique and ordered when the wall clock is adjusted
+package orchestrator
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// idClock hands out strictly increasing nanosecond timestamps
+// Follows the wall clock, and counts up from the last timestamp while the clock lags behind it
+type idClock struct {
+	last atomic.Int64 // Last timestamp handed out
+}
+
+// next returns a timestamp after every previous one, now unless the clock went backwards
+func (c *idClock) next(now time.Time) int64 {
+	for {
+		last := c.last.Load()
+		ts := max(now.UnixNano(), last+1)
+		if c.last.CompareAndSwap(last, ts) {
+			return ts
+		}
+	}
+}
+
+// ids is shared by all orchestrators of the process so their IDs never collide
+var ids idClock
+
+// newID returns a new ID such as "exec-1718000000000000000"
+// The queue orders executions by its own sequence numbers, not by their IDs
+func newID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, ids.next(time.Now()))
+}
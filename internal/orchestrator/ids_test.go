@@ -0,0 +1,79 @@
+// ids_test.go tests that execution and operation IDs never go backwards
+// A simulated wall clock jumps back and stalls while timestamps are handed out
+// Concurrent callers must still get distinct IDs
+package orchestrator
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestIDClockBackwards hands out timestamps while the clock jumps back an hour
+// Timestamps count up from the last one until the clock catches up again
+func TestIDClockBackwards(t *testing.T) {
+	var c idClock
+	start := time.Unix(1718000000, 0)
+	clock := []time.Time{
+		start,
+		start.Add(time.Second),
+		start.Add(-time.Hour), // Clock adjusted backwards
+		start.Add(-time.Hour),
+		start.Add(time.Second), // Still behind the last timestamp
+		start.Add(2 * time.Second),
+	}
+
+	var last int64
+	for i, now := range clock {
+		ts := c.next(now)
+		if ts <= last {
+			t.Fatalf("timestamp %d = %d, not after %d", i, ts, last)
+		}
+		last = ts
+	}
+	// Once caught up, timestamps follow the clock again
+	if last != start.Add(2*time.Second).UnixNano() {
+		t.Errorf("last timestamp = %d, want the clock at %d", last, start.Add(2*time.Second).UnixNano())
+	}
+}
+
+// TestIDsUniqueAndIncreasing takes IDs from many goroutines at once
+// Each goroutine sees increasing IDs and no ID is handed out twice
+func TestIDsUniqueAndIncreasing(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			taken := make([]string, 0, perGoroutine)
+			for i := 0; i < perGoroutine; i++ {
+				id := newID("exec")
+				ts, err := strconv.ParseInt(strings.TrimPrefix(id, "exec-"), 10, 64)
+				if err != nil {
+					t.Errorf("id %q has no timestamp: %v", id, err)
+					return
+				}
+				if ts <= last {
+					t.Errorf("id %q not after exec-%d", id, last)
+				}
+				last = ts
+				taken = append(taken, id)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range taken {
+				if seen[id] {
+					t.Errorf("id %q handed out twice", id)
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,123 @@
+// inherit_test.go tests resolving definitions that extend a base definition
+// Covers composing base and own tasks, inheriting and overriding settings,
+// and rejecting unknown bases and inheritance cycles
+package orchestrator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// stored returns the registered definition with the given ID
+func stored(t *testing.T, o *Orchestrator, id string) *models.JobDefinition {
+	t.Helper()
+	jd, err := o.db.GetJobDefinition(id)
+	if err != nil {
+		t.Fatalf("get definition %s: %v", id, err)
+	}
+	return jd
+}
+
+// TestBaseDefinitionComposesTasks checks the base's tasks come first, own tasks
+// with a base task's ID replace it in place, and the others are appended
+func TestBaseDefinitionComposesTasks(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "base",
+		Tasks: []*models.Task{
+			{ID: "setup", FunctionName: "setup"},
+			{ID: "work", FunctionName: "work"},
+			{ID: "teardown", FunctionName: "teardown"},
+		},
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:             "derived",
+		BaseDefinition: "base",
+		Tasks: []*models.Task{
+			{ID: "notify", FunctionName: "notify"},
+			{ID: "work", FunctionName: "fastWork"},
+		},
+	})
+
+	var tasks []string
+	for _, task := range stored(t, o, "derived").Tasks {
+		tasks = append(tasks, task.ID+":"+task.FunctionName)
+	}
+	want := []string{"setup:setup", "work:fastWork", "teardown:teardown", "notify:notify"}
+	if !slices.Equal(tasks, want) {
+		t.Errorf("derived tasks = %v, want %v", tasks, want)
+	}
+}
+
+// TestBaseDefinitionSettings checks which settings a derived definition inherits
+// Execution settings are inherited unless set, the definition's identity never is
+func TestBaseDefinitionSettings(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	on, off := true, false
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:              "base",
+		Name:            "Base",
+		Tasks:           []*models.Task{{ID: "work", FunctionName: "work"}},
+		LogLevel:        "debug",
+		RetryBudget:     3,
+		TimeoutSeconds:  60,
+		OutputNamespace: &on,
+		FailureMode:     models.FailureModeCollect,
+		Alert:           &models.AlertThreshold{WindowSize: 10, FailureRatePercent: 20},
+		Tags:            []string{"billing"},
+		Environments:    []string{"prod"},
+		Chain:           []*models.ChainedJob{{DefinitionID: "report"}},
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:             "inheriting",
+		BaseDefinition: "base",
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:              "overriding",
+		BaseDefinition:  "base",
+		RetryBudget:     5,
+		OutputNamespace: &off,
+		Tags:            []string{"adhoc"},
+	})
+
+	inheriting := stored(t, o, "inheriting")
+	if inheriting.LogLevel != "debug" || inheriting.RetryBudget != 3 || inheriting.TimeoutSeconds != 60 ||
+		!inheriting.NamespacesOutputs() || inheriting.FailureMode != models.FailureModeCollect ||
+		inheriting.Alert == nil || inheriting.Alert.WindowSize != 10 {
+		t.Errorf("inheriting definition = %+v, want the base's execution settings", inheriting)
+	}
+	if inheriting.Name != "" || inheriting.Tags != nil || inheriting.Environments != nil || inheriting.Chain != nil {
+		t.Errorf("inheriting definition took name %q, tags %v, environments %v, and chain %v from the base",
+			inheriting.Name, inheriting.Tags, inheriting.Environments, inheriting.Chain)
+	}
+
+	overriding := stored(t, o, "overriding")
+	if overriding.RetryBudget != 5 || overriding.NamespacesOutputs() || !slices.Equal(overriding.Tags, []string{"adhoc"}) {
+		t.Errorf("overriding definition has retry budget %d, namespaced outputs %v, and tags %v, want its own",
+			overriding.RetryBudget, overriding.NamespacesOutputs(), overriding.Tags)
+	}
+	if overriding.TimeoutSeconds != 60 {
+		t.Errorf("overriding definition timeout = %d, want the base's 60", overriding.TimeoutSeconds)
+	}
+}
+
+// TestBaseDefinitionRejects checks unknown bases and inheritance cycles
+func TestBaseDefinitionRejects(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	tasks := []*models.Task{{ID: "work", FunctionName: "work"}}
+	registerDefinition(t, o, &models.JobDefinition{ID: "a", Tasks: tasks})
+	registerDefinition(t, o, &models.JobDefinition{ID: "b", BaseDefinition: "a"})
+
+	for name, jd := range map[string]*models.JobDefinition{
+		"unknown base": {ID: "c", BaseDefinition: "missing"},
+		"self":         {ID: "c", BaseDefinition: "c", Tasks: tasks},
+		"cycle":        {ID: "a", BaseDefinition: "b", Tasks: tasks},
+	} {
+		if err := o.RegisterJobDefinition(jd); !errors.Is(err, ErrInvalidDefinition) {
+			t.Errorf("%s: %v, want ErrInvalidDefinition", name, err)
+		}
+	}
+}
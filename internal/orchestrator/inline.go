@@ -0,0 +1,39 @@
+// inline.go runs jobs submitted together with their definition
+// Inline definitions are kept with their execution instead of being registered
+// Useful for one-off workflows that don't warrant a stored definition
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// Inline definitions are identified by this prefix and their own ID
+// Keeps their executions apart from those of registered definitions
+const (
+	inlineDefinitionPrefix = "inline:" // Definition ID prefix of inline executions
+	inlineDefaultID        = "adhoc"   // ID of inline definitions that don't set one
+)
+
+// EnqueueInlineJob queues an execution of a definition given inline
+// The definition is validated like a registered one but only stored with the execution
+// Returns the execution ID for tracking the job
+func (o *Orchestrator) EnqueueInlineJob(jd *models.JobDefinition, data map[string]interface{}, opts EnqueueOptions) (string, error) {
+	if jd.ID == "" {
+		jd.ID = inlineDefaultID
+	}
+	if !strings.HasPrefix(jd.ID, inlineDefinitionPrefix) {
+		jd.ID = inlineDefinitionPrefix + jd.ID
+	}
+	if err := o.prepareDefinition(jd); err != nil {
+		return "", err
+	}
+
+	// Nothing would register the functions of a one-off job later
+	if missing := o.missingFunctions(jd); len(missing) > 0 {
+		return "", fmt.Errorf("%w: task functions not registered: %s", ErrInvalidDefinition, strings.Join(missing, ", "))
+	}
+	return o.enqueueExecution(jd.ID, jd, data, opts)
+}
@@ -0,0 +1,156 @@
+// input.go fetches job data from external URLs
+// Lets clients reference large inputs instead of inlining them
+// Fetches are bounded in size and time and must return JSON
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxInputSize      = 10 << 20         // Largest accepted input document in bytes
+	inputFetchTimeout = 30 * time.Second // Longest time a fetch may take
+	maxInputRedirects = 10               // Redirects followed before a fetch fails
+)
+
+// WithInputHosts restricts FetchInput to URLs on the given hosts
+// Listed hosts may resolve to private addresses, e.g. for inputs served inside
+// the network. By default any host resolving to a public address is allowed
+func WithInputHosts(hosts ...string) Option {
+	return func(o *Orchestrator) {
+		o.inputHosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			o.inputHosts[strings.ToLower(host)] = true
+		}
+	}
+}
+
+// checkInputURL rejects input URLs that aren't http or https or whose host isn't allowed
+// Applied to the URL given and to every redirect
+func (o *Orchestrator) checkInputURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: input URL scheme %q is not http or https", ErrInvalidInput, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: input URL has no host", ErrInvalidInput)
+	}
+	if len(o.inputHosts) > 0 && !o.inputHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("%w: input host %s is not allowed", ErrInvalidInput, u.Hostname())
+	}
+	return nil
+}
+
+// newInputClient returns the client fetching external inputs
+// Hosts not on the allowlist may only resolve to public addresses, checked on the
+// address dialed so a changing DNS answer can't point a fetch inside the network
+// The timeout bounds the whole request, proxies are not used
+func (o *Orchestrator) newInputClient() *http.Client {
+	dialer := &net.Dialer{Timeout: inputFetchTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if o.inputHosts[strings.ToLower(host)] {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !publicAddress(ip) {
+				return nil, fmt.Errorf("%w: input host %s resolves to non-public address %s", ErrInvalidInput, host, ip)
+			}
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+	}
+	return &http.Client{
+		Timeout:   inputFetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxInputRedirects {
+				return errors.New("too many redirects")
+			}
+			return o.checkInputURL(req.URL)
+		},
+	}
+}
+
+// publicAddress reports whether ip is a public unicast address
+// Rejects loopback, private, link-local, and unspecified addresses
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// FetchInput downloads a JSON object from rawURL to use as job data
+// Only fetches http and https URLs on allowed hosts, see WithInputHosts, and
+// rejects non-JSON content types and documents over the size limit
+// Input problems wrap ErrInvalidInput, other errors are fetch failures
+func (o *Orchestrator) FetchInput(ctx context.Context, rawURL string) (map[string]interface{}, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := o.checkInputURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.inputClient.Do(req)
+	if err != nil {
+		// Disallowed hosts and addresses are reported as invalid input
+		if errors.Is(err, ErrInvalidInput) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch input: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch input: %s returned %s", rawURL, resp.Status)
+	}
+
+	// Only JSON documents can become job data
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return nil, fmt.Errorf("%w: content type %q is not application/json", ErrInvalidInput, resp.Header.Get("Content-Type"))
+	}
+
+	// Read one byte past the limit to detect oversize documents
+	// without trusting the Content-Length header
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInputSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if len(body) > maxInputSize {
+		return nil, fmt.Errorf("%w: input exceeds %d bytes", ErrInvalidInput, maxInputSize)
+	}
+
+	var data map[string]interface{}
+	if err := o.NewDecoder(bytes.NewReader(body)).Decode(&data); err != nil {
+		return nil, fmt.Errorf("%w: input is not a JSON object: %v", ErrInvalidInput, err)
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+// input_test.go tests fetching job data from external URLs
+// Serves inputs from an httptest.Server on the loopback address, which only
+// allowlisted hosts may resolve to
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newInputServer serves body with the given content type at every path
+// Requests for /redirect are sent to target instead
+func newInputServer(t *testing.T, contentType, body, target string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestFetchInput fetches a JSON object from an allowlisted host
+func TestFetchInput(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithInputHosts("127.0.0.1"))
+	srv := newInputServer(t, "application/json; charset=utf-8", `{"batch": "42"}`, "")
+
+	data, err := o.FetchInput(context.Background(), srv.URL+"/input.json")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if data["batch"] != "42" {
+		t.Errorf("data = %v, want the served document", data)
+	}
+}
+
+// TestFetchInputRejects checks inputs that must not become job data
+// Every rejection wraps ErrInvalidInput so the API answers 400
+func TestFetchInputRejects(t *testing.T) {
+	allowed := newTestOrchestrator(t, 1, WithInputHosts("127.0.0.1"))
+	open := newTestOrchestrator(t, 1)
+	served := newInputServer(t, "application/json", `{"batch": "42"}`, "")
+	oversize := newInputServer(t, "application/json", `{"pad": "`+strings.Repeat("x", maxInputSize)+`"}`, "")
+	text := newInputServer(t, "text/plain", `{"batch": "42"}`, "")
+	port := (&url.URL{Host: served.Listener.Addr().String()}).Port()
+	redirect := newInputServer(t, "application/json", "", "http://localhost:"+port+"/input.json")
+
+	tests := []struct {
+		name string
+		o    *Orchestrator
+		url  string
+	}{
+		{"oversize document", allowed, oversize.URL},
+		{"wrong content type", allowed, text.URL},
+		{"file scheme", allowed, "file:///etc/passwd"},
+		{"host not allowlisted", allowed, "http://localhost:" + port},
+		{"redirect to host not allowlisted", allowed, redirect.URL + "/redirect"},
+		{"loopback without allowlist", open, served.URL},
+		{"link-local without allowlist", open, "http://169.254.169.254/latest/meta-data"},
+	}
+	for _, tt := range tests {
+		_, err := tt.o.FetchInput(context.Background(), tt.url)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: err = %v, want ErrInvalidInput", tt.name, err)
+		}
+	}
+}
+
+// TestPublicAddress checks which resolved addresses hosts off the allowlist may use
+func TestPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"192.168.0.1":      false,
+		"169.254.169.254":  false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+	}
+	for addr, want := range tests {
+		if got := publicAddress(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddress(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// jitter_test.go tests spreading out the starts of executions with a start jitter
+// Executions of a definition with jitter are delayed by a random share of the window,
+// added to any start time requested at enqueue time
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestStartJitterSpread enqueues a burst of executions of a definition with a 10s jitter
+// Their starts must fall within the window and not bunch up at one time
+func TestStartJitterSpread(t *testing.T) {
+	const window = 10 * time.Second
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:                 "report",
+		StartJitterSeconds: 10,
+		Tasks:              []*models.Task{{ID: "send", FunctionName: "send"}},
+	})
+
+	before := time.Now()
+	var earliest, latest time.Time
+	for i := 0; i < 50; i++ {
+		start := execution(t, o, enqueue(t, o, "report", nil)).ScheduledAt
+		if start.Before(before) || start.After(time.Now().Add(window)) {
+			t.Fatalf("start %v outside the jitter window after %v", start, before)
+		}
+		if earliest.IsZero() || start.Before(earliest) {
+			earliest = start
+		}
+		if start.After(latest) {
+			latest = start
+		}
+	}
+	// 50 uniform picks all within a fifth of the window are practically impossible
+	if spread := latest.Sub(earliest); spread < window/5 {
+		t.Errorf("starts spread over %v, want them spread across the %v window", spread, window)
+	}
+}
+
+// TestStartJitterAfterRequestedStart adds the jitter to a requested start time
+// Definitions without jitter start exactly when requested
+func TestStartJitterAfterRequestedStart(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	tasks := []*models.Task{{ID: "send", FunctionName: "send"}}
+	registerDefinition(t, o, &models.JobDefinition{ID: "jittered", StartJitterSeconds: 5, Tasks: tasks})
+	registerDefinition(t, o, &models.JobDefinition{ID: "exact", Tasks: tasks})
+
+	startAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	for definitionID, latest := range map[string]time.Time{
+		"jittered": startAt.Add(5 * time.Second),
+		"exact":    startAt,
+	} {
+		id, err := o.EnqueueJobWithOptions(definitionID, nil, EnqueueOptions{StartAt: startAt})
+		if err != nil {
+			t.Fatalf("enqueue %s: %v", definitionID, err)
+		}
+		if start := execution(t, o, id).ScheduledAt; start.Before(startAt) || start.After(latest) {
+			t.Errorf("%s starts at %v, want between %v and %v", definitionID, start, startAt, latest)
+		}
+	}
+}
@@ -5,25 +5,103 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"time"
 
+	"github.com/fawad1985/go-job-orchestrator/internal/metrics"
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
 )
 
+// EnqueueOptions holds optional settings for a new job execution
+// The zero value enqueues a job without any extra constraints
+type EnqueueOptions struct {
+	Deadline          time.Time // Absolute time by which the execution must finish, zero for none
+	StartAt           time.Time // Earliest time the execution may start, zero to start right away
+	ParentExecutionID string    // Execution this one replays, empty for new jobs
+	Tags              []string  // Labels such as "customer:acme" to find the execution by
+	AffinityKey       string    // Executions with the same key prefer the same worker, empty for none
+	Priority          int       // Higher priorities are dequeued first, 0 by default
+	TriggeredBy       string    // Execution whose completion chained this one, empty for none
+}
+
 // EnqueueJob adds a new job to the execution queue
 // It creates a new job execution instance and stores it in the database
 // Returns the execution ID for tracking the job
 func (o *Orchestrator) EnqueueJob(definitionID string, data map[string]interface{}) (string, error) {
+	return o.EnqueueJobWithOptions(definitionID, data, EnqueueOptions{})
+}
+
+// EnqueueJobWithOptions adds a new job to the execution queue with extra settings
+// Behaves like EnqueueJob, applying the given options to the execution
+func (o *Orchestrator) EnqueueJobWithOptions(definitionID string, data map[string]interface{}, opts EnqueueOptions) (string, error) {
+	return o.enqueueExecution(definitionID, nil, data, opts)
+}
+
+// enqueueExecution creates and queues an execution of a definition
+// inline is stored with the execution and run instead of a registered definition, nil for none
+func (o *Orchestrator) enqueueExecution(definitionID string, inline *models.JobDefinition, data map[string]interface{}, opts EnqueueOptions) (string, error) {
+	o.defMu.RLock()
+	defer o.defMu.RUnlock()
+
+	// Executions of unregistered definitions could never load them
+	// Checked under defMu so the definition can't be deleted before the execution is stored
+	jd, jdErr := inline, error(nil)
+	if jd == nil {
+		jd, jdErr = o.db.GetJobDefinition(definitionID)
+		if errors.Is(jdErr, ErrNotFound) {
+			return "", jdErr
+		}
+	}
+
+	// Spread out starts of definitions with a start jitter
+	// The random delay is added on top of any requested start time
+	startAt := opts.StartAt
+	if jdErr == nil && jd.StartJitterSeconds > 0 {
+		if startAt.Before(time.Now()) {
+			startAt = time.Now()
+		}
+		startAt = startAt.Add(rand.N(time.Duration(jd.StartJitterSeconds) * time.Second))
+	}
+
+	// Reject executions that would wait too long behind the current queue
+	// Delayed executions are exempt as their wait is intended
+	if jdErr == nil && !startAt.After(time.Now()) {
+		if err := o.checkQueueTime(jd); err != nil {
+			return "", err
+		}
+	}
+
+	// Keep the data as enqueued for replays, tasks add their outputs to a copy
+	// Stored even when empty so replays can tell it from records without an input
+	input := maps.Clone(data)
+	if input == nil {
+		input = make(map[string]interface{})
+	}
+
 	// Create a new job execution instance with unique ID and initial state
-	// Uses timestamp-based ID for uniqueness and temporal tracking
+	// Uses a timestamp-based ID that stays unique if the clock goes backwards
 	execution := &models.JobExecution{
-		ID:           fmt.Sprintf("exec-%d", time.Now().UnixNano()),
-		DefinitionID: definitionID,
-		Status:       models.JobStatusQueued,
-		StartTime:    time.Now(),
-		Data:         data,
+		ID:                newID("exec"),
+		DefinitionID:      definitionID,
+		Status:            models.JobStatusQueued,
+		QueuedAt:          time.Now(),
+		StartTime:         time.Now(),
+		Deadline:          opts.Deadline,
+		ScheduledAt:       startAt,
+		Data:              data,
+		Input:             input,
+		ParentExecutionID: opts.ParentExecutionID,
+		Tags:              opts.Tags,
+		AffinityKey:       opts.AffinityKey,
+		Priority:          opts.Priority,
+		TriggeredBy:       opts.TriggeredBy,
+		Definition:        inline,
 	}
 
 	// Store the job execution in the database
@@ -32,16 +110,26 @@ func (o *Orchestrator) EnqueueJob(definitionID string, data map[string]interface
 		return "", err
 	}
 
+	// Hold delayed jobs back until their start time
+	if startAt.After(time.Now()) {
+		if err := o.db.ScheduleJob(execution.ID, startAt); err != nil {
+			return "", err
+		}
+		metrics.JobsEnqueued.Inc()
+		return execution.ID, nil
+	}
+
 	// Add the job to the execution queue
 	// Once queued, workers can pick it up for execution
-	if err := o.db.EnqueueJob(execution.ID); err != nil {
+	if err := o.enqueue(execution.ID); err != nil {
 		return "", err
 	}
+	metrics.JobsEnqueued.Inc()
 
 	return execution.ID, nil
 }
 
-// ExecuteJob runs a job and all its tasks in sequence
+// ExecuteJob runs a job and all its tasks using the definition's strategy
 // Manages the complete lifecycle of a job execution
 // Handles state transitions, task execution, and error cases
 func (o *Orchestrator) ExecuteJob(ctx context.Context, executionID string) error {
@@ -53,92 +141,132 @@ func (o *Orchestrator) ExecuteJob(ctx context.Context, executionID string) error
 	}
 
 	// Skip if job is already in a terminal state
-	// Prevents re-execution of completed or failed jobs
-	if je.Status == models.JobStatusCompleted || je.Status == models.JobStatusFailed {
+	// Prevents re-execution of completed, failed, or cancelled jobs
+	if je.Status.Finished() {
 		return nil
 	}
 
 	// Get the job definition that specifies what tasks to run
 	// This contains the task sequence and configuration
-	jd, err := o.db.GetJobDefinition(je.DefinitionID)
+	jd, err := o.definitionFor(je)
 	if err != nil {
 		return fmt.Errorf("failed to get job definition: %w", err)
 	}
 
+	// Fail jobs whose deadline passed while they were waiting
+	// They are never started so no task runs after its deadline
+	if !je.Deadline.IsZero() {
+		if time.Now().After(je.Deadline) {
+			return o.failBeforeStart(je, jd, fmt.Errorf("deadline %s passed before execution started", je.Deadline.Format(time.RFC3339)))
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, je.Deadline)
+		defer cancel()
+	}
+
+	// Bound the run by the definition's timeout
+	// The cause names the timeout so the failed task reports it clearly
+	if jd.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		timeout := time.Duration(jd.TimeoutSeconds) * time.Second
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w after %ds", ErrJobTimeout, jd.TimeoutSeconds))
+		defer cancel()
+	}
+
+	// Make the execution cancellable on its own
+	// Used by the reaper to cancel executions past their maximum age
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	o.cancels.Store(executionID, cancel)
+	defer o.cancels.Delete(executionID)
+
+	// The execution may have been cancelled while it was being dequeued
+	if current, err := o.db.GetJobExecution(executionID); err == nil && current.Status.Finished() {
+		return nil
+	}
+
+	// Record how long the execution waited in the queue
+	// Executions stored before QueuedAt existed aren't observed
+	if !je.QueuedAt.IsZero() {
+		metrics.QueueWait.WithLabelValues(metrics.DefaultQueue).Observe(time.Since(je.QueuedAt).Seconds())
+	}
+
 	// Update job status to running and track in memory
 	// This marks the beginning of job execution
 	je.Status = models.JobStatusRunning
-	if err := o.db.UpdateJobExecution(je); err != nil {
-		return fmt.Errorf("failed to update job execution status to running: %w", err)
+	je.Worker = taskctx.Worker(ctx)
+	if err := persist("update job execution status to running", func() error {
+		return o.db.UpdateJobExecution(je)
+	}); err != nil {
+		o.strand(executionID)
+		return err
 	}
+	publishStateChange(o.events, je)
 
 	// Track this job as currently executing
 	// Used for system state monitoring
 	o.ongoingJobs.Store(executionID, struct{}{})
 
+	// Capture task logs, continuing the output of earlier runs
+	logs := taskctx.NewLogBuffer(o.maxLogBytes, je.Logs)
+
 	// Ensure cleanup happens regardless of execution outcome
 	// Updates final state and removes from tracking
 	defer func() {
 		o.ongoingJobs.Delete(executionID)
-		je.EndTime = time.Now()
-		if err := o.db.UpdateJobExecution(je); err != nil {
-			log.Printf("Failed to update job execution after completion: %v", err)
+
+		// The instance that took the execution over owns its state now
+		if errors.Is(context.Cause(ctx), ErrLeaseLost) {
+			log.Printf("Job %s stopped, it is left to the instance that took it over", executionID)
+			return
 		}
+
+		je.Logs = logs.String()
+		if je.Status != models.JobStatusRunning {
+			je.EndTime = time.Now()
+			o.throughput.record(je.EndTime)
+			o.recordOutcome(jd, executionID, je.Status == models.JobStatusFailed)
+			o.publishOutcome(je)
+		}
+		stranded := errors.Is(interruption(ctx), ErrStorageUnavailable)
+		if err := persist("update job execution after completion", func() error {
+			return o.db.UpdateJobExecution(je)
+		}); err != nil {
+			log.Printf("Job %s left for recovery: %v", executionID, err)
+			stranded = true
+		}
+		publishStateChange(o.events, je)
 		if err := o.db.RemoveFromQueue(executionID); err != nil {
 			log.Printf("Failed to remove job %s from queue: %v", executionID, err)
 		}
-	}()
 
-	// Initialize task status tracking if needed
-	// Maps task IDs to their current execution status
-	if je.TaskStatuses == nil {
-		je.TaskStatuses = make(map[string]models.TaskStatus)
-	}
+		// Queue the job again once storage answers, it isn't left until the next start
+		if stranded {
+			o.strand(executionID)
+		}
+	}()
 
-	// Execute each task in the job sequentially
-	// Handles task state management and error cases
-	for _, task := range jd.Tasks {
-		select {
-		case <-ctx.Done():
-			// Handle context cancellation
-			// Updates job and task state to failed
-			je.Status = models.JobStatusFailed
-			je.TaskStatuses[task.ID] = models.TaskStatusFailed
-			return ctx.Err()
-
-		default:
-			// Update task status to running
-			// Tracks progress through the task sequence
-			je.TaskStatuses[task.ID] = models.TaskStatusRunning
-			if err := o.db.UpdateJobExecution(je); err != nil {
-				log.Printf("Failed to update task status to running: %v", err)
-			}
+	// Execute the tasks using the definition's strategy
+	// Task state transitions go through the run so they persist in order
+	run := newJobRun(o.db, o.events, je, jd, logs)
+	run.halt = cancel
+	run.lost = func() bool { return errors.Is(context.Cause(ctx), ErrLeaseLost) }
 
-			// Execute the task with its configured handler
-			// Attempts execution with retry logic
-			if err := o.executeTask(ctx, task, je.Data); err != nil {
-				je.TaskStatuses[task.ID] = models.TaskStatusFailed
-				je.Status = models.JobStatusFailed
-				if updateErr := o.db.UpdateJobExecution(je); updateErr != nil {
-					log.Printf("Failed to update job execution after task failure: %v", updateErr)
-				}
-				return fmt.Errorf("task %s failed: %w", task.ID, err)
-			}
+	// Save notes the tasks attach while they run
+	stopAnnotations := watchAnnotations(run)
+	defer stopAnnotations()
 
-			// Update task status to completed
-			// Marks successful task execution
-			je.TaskStatuses[task.ID] = models.TaskStatusCompleted
-			if err := o.db.UpdateJobExecution(je); err != nil {
-				log.Printf("Failed to update task status to completed: %v", err)
-			}
-		}
+	if err := o.runTasks(ctx, run); err != nil {
+		return err
 	}
 
 	// Update job status to completed after all tasks succeed
 	// Marks successful job completion
 	je.Status = models.JobStatusCompleted
-	if err := o.db.UpdateJobExecution(je); err != nil {
-		return fmt.Errorf("failed to update job execution status to completed: %w", err)
+	if err := persist("update job execution status to completed", func() error {
+		return o.db.UpdateJobExecution(je)
+	}); err != nil {
+		return err
 	}
 
 	// Increment executed jobs count
@@ -146,9 +274,268 @@ func (o *Orchestrator) ExecuteJob(ctx context.Context, executionID string) error
 		log.Printf("Failed to increment executed jobs count: %v", err)
 	}
 
+	o.enqueueChained(je, jd)
+	return nil
+}
+
+// enqueueChained enqueues the definitions chained to a completed execution
+// Each starts from a copy of its final data and inherits its priority unless
+// the chain entry overrides it, so an urgent pipeline stays urgent
+func (o *Orchestrator) enqueueChained(je *models.JobExecution, jd *models.JobDefinition) {
+	for _, next := range jd.Chain {
+		priority := je.Priority
+		if next.Priority != nil {
+			priority = *next.Priority
+		}
+		id, err := o.enqueueExecution(next.DefinitionID, nil, maps.Clone(je.Data), EnqueueOptions{
+			Priority:    priority,
+			TriggeredBy: je.ID,
+		})
+		if err != nil {
+			log.Printf("Failed to enqueue job %s chained to execution %s: %v", next.DefinitionID, je.ID, err)
+			continue
+		}
+		log.Printf("Enqueued execution %s of job %s chained to execution %s", id, next.DefinitionID, je.ID)
+	}
+}
+
+// runTask executes one task of a job run
+// Handles task state management and error cases
+// Returns an error when the job must stop
+func (o *Orchestrator) runTask(ctx context.Context, run *jobRun, task *models.Task) error {
+	// Skip tasks completed by an earlier run of this execution
+	// Happens when resuming after a retry or a restart
+	if run.taskStatus(task.ID) == models.TaskStatusCompleted {
+		return nil
+	}
+
+	// Wait here while running jobs are paused
+	// The current task of the job already finished
+	if err := o.holdWhilePaused(ctx, run); err != nil {
+		return err
+	}
+
+	// Don't start a task once a sibling failed the job
+	// Parallel tasks can be held while another task fails fast
+	if err := run.stopped(); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		// Jobs interrupted by shutdown or lost storage stay RUNNING for recovery
+		if err := interruption(ctx); err != nil {
+			return err
+		}
+		if cancelled(ctx) {
+			run.abort(task.ID, models.JobStatusCancelled, context.Cause(ctx))
+			return context.Cause(ctx)
+		}
+
+		// Handle context cancellation, e.g. a job timeout
+		// Updates job and task state to failed
+		run.abort(task.ID, models.JobStatusFailed, context.Cause(ctx))
+		return context.Cause(ctx)
+
+	default:
+	}
+
+	// Skip tasks switched off by a feature flag
+	// The job carries on with the next task
+	if !o.taskEnabled(run.jd, task) {
+		run.skipTask(task.ID)
+		return nil
+	}
+
+	// Update task status to running
+	// Tracks progress through the task sequence
+	run.startTask(task.ID)
+	if err := interruption(ctx); err != nil {
+		// Don't run a task whose start couldn't be persisted
+		run.resetTask(task.ID)
+		return err
+	}
+
+	// Build the task input and execute the task with its configured handler
+	// Attempts execution with retry logic
+	var output map[string]interface{}
+	input, err := run.input(task)
+	if err == nil {
+		taskCtx := taskContext(ctx, run, task)
+		finished := o.warnWhenSlow(taskCtx, run, task)
+		output, err = o.executeTask(taskCtx, task, input, run)
+		finished()
+	}
+	if err != nil && ctx.Err() != nil {
+		// Let the task release its resources after being cancelled
+		o.runCleanup(task, input)
+	}
+	if cause := interruption(ctx); err != nil && cause != nil {
+		// Reset the interrupted task so it runs again after recovery
+		run.resetTask(task.ID)
+		return cause
+	}
+	if err != nil && cancelled(ctx) {
+		run.abort(task.ID, models.JobStatusCancelled, context.Cause(ctx))
+		return context.Cause(ctx)
+	}
+	if err != nil {
+		run.failTask(task.ID, err)
+		return fmt.Errorf("task %s failed: %w", task.ID, err)
+	}
+
+	// Update task status to completed
+	// Marks successful task execution
+	run.completeTask(task.ID, output)
 	return nil
 }
 
+// RetryFromTask re-runs a failed execution starting at the given task
+// Tasks it doesn't affect keep their results and the accumulated job data is reused
+// The execution is queued again and resumes from the specified task
+func (o *Orchestrator) RetryFromTask(executionID, taskID string) error {
+	o.defMu.RLock()
+	defer o.defMu.RUnlock()
+
+	je, err := o.db.GetJobExecution(executionID)
+	if err != nil {
+		return err
+	}
+	if je.Status != models.JobStatusFailed {
+		return fmt.Errorf("%w: execution %s is %s", ErrNotRetryable, executionID, je.Status)
+	}
+
+	jd, err := o.definitionFor(je)
+	if err != nil {
+		return err
+	}
+
+	// Find the task to resume from
+	start := -1
+	for i, task := range jd.Tasks {
+		if task.ID == taskID {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return fmt.Errorf("task %s %w in job %s", taskID, ErrNotFound, jd.ID)
+	}
+
+	// Reset the task and the tasks that ran on its results so they run again
+	// Under DAG those are its dependents, otherwise everything after it
+	reset := jd.Tasks[start:]
+	if jd.Strategy == models.StrategyDAG {
+		if reset, err = dependentTasks(jd.Tasks, taskID); err != nil {
+			return err
+		}
+	}
+	for _, task := range reset {
+		delete(je.TaskStatuses, task.ID)
+		delete(je.TaskErrors, task.ID)
+		delete(je.TaskTimes, task.ID)
+	}
+	je.Status = models.JobStatusQueued
+	je.QueuedAt = time.Now()
+	je.RetriesUsed = 0
+	je.Error = ""
+	je.EndTime = time.Time{}
+
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		return err
+	}
+	return o.enqueue(je.ID)
+}
+
+// ReplayJobExecution starts a new execution of a finished job
+// Uses the data the original was enqueued with and records it as the parent
+// Returns the ID of the new execution
+func (o *Orchestrator) ReplayJobExecution(executionID string) (string, error) {
+	je, err := o.db.GetJobExecution(executionID)
+	if err != nil {
+		return "", err
+	}
+	if !je.Status.Finished() {
+		return "", fmt.Errorf("%w: execution %s is %s", ErrNotRetryable, executionID, je.Status)
+	}
+
+	// Executions stored before inputs were kept only have their final data
+	input := je.Input
+	if input == nil {
+		input = je.Data
+	}
+	return o.enqueueExecution(je.DefinitionID, je.Definition, maps.Clone(input), EnqueueOptions{
+		ParentExecutionID: je.ID,
+		Tags:              je.Tags,
+		AffinityKey:       je.AffinityKey,
+		Priority:          je.Priority,
+	})
+}
+
+// ListExecutions returns all executions, newest first
+// Filters by status unless status is empty
+func (o *Orchestrator) ListExecutions(status models.JobStatus) ([]*models.JobExecution, error) {
+	return o.db.ListJobExecutions(status)
+}
+
+// ListExecutionsByTime returns the executions started in [from, to), oldest first
+func (o *Orchestrator) ListExecutionsByTime(from, to time.Time) ([]*models.JobExecution, error) {
+	return o.db.ListJobExecutionsByTime(from, to)
+}
+
+// ListFinishedExecutions returns up to limit finished executions, most recently ended first
+// Reads an end time index instead of every stored execution
+func (o *Orchestrator) ListFinishedExecutions(limit int) ([]*models.JobExecution, error) {
+	return o.db.ListFinishedExecutions(limit)
+}
+
+// GetLogs returns the captured task log output of an execution
+// Output beyond the configured limit ends with taskctx.TruncationMarker
+func (o *Orchestrator) GetLogs(executionID string) (string, error) {
+	je, err := o.db.GetJobExecution(executionID)
+	if err != nil {
+		return "", err
+	}
+	return je.Logs, nil
+}
+
+// GetLineage returns the chain of executions leading to an execution
+// Ordered from the original execution to the given one
+func (o *Orchestrator) GetLineage(executionID string) ([]*models.JobExecution, error) {
+	var chain []*models.JobExecution
+	seen := make(map[string]bool)
+	for id := executionID; id != "" && !seen[id]; {
+		je, err := o.db.GetJobExecution(id)
+		if err != nil {
+			return nil, err
+		}
+		seen[id] = true
+		chain = append(chain, je)
+		id = je.ParentExecutionID
+	}
+
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// failBeforeStart marks a job as failed without running any of its tasks
+// Removes it from the queue and records the outcome like a normal failure
+func (o *Orchestrator) failBeforeStart(je *models.JobExecution, jd *models.JobDefinition, reason error) error {
+	je.Status = models.JobStatusFailed
+	je.Error = reason.Error()
+	je.EndTime = time.Now()
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		log.Printf("Failed to update job execution %s: %v", je.ID, err)
+	}
+	publishStateChange(o.events, je)
+	if err := o.db.RemoveFromQueue(je.ID); err != nil {
+		log.Printf("Failed to remove job %s from queue: %v", je.ID, err)
+	}
+	o.recordOutcome(jd, je.ID, true)
+	o.publishOutcome(je)
+	return reason
+}
+
 // GetJobExecutionState retrieves the current state of a job execution
 // Combines job execution state with task states for status reporting
 // Returns a complete snapshot of job and task status
@@ -162,11 +549,15 @@ func (o *Orchestrator) GetJobExecutionState(executionID string) (*models.JobExec
 
 	// Get the corresponding job definition
 	// Used to include task metadata in state
-	jd, err := o.db.GetJobDefinition(je.DefinitionID)
+	jd, err := o.definitionFor(je)
 	if err != nil {
 		return nil, err
 	}
+	return executionState(je, jd), nil
+}
 
+// executionState builds the state of an execution from its definition's tasks
+func executionState(je *models.JobExecution, jd *models.JobDefinition) *models.JobExecutionState {
 	// Create the state response structure
 	// Combines execution state with job definition details
 	state := &models.JobExecutionState{
@@ -174,18 +565,39 @@ func (o *Orchestrator) GetJobExecutionState(executionID string) (*models.JobExec
 		DefinitionID: je.DefinitionID,
 		Status:       je.Status,
 		StartTime:    je.StartTime,
+		Annotations:  je.Annotations,
 	}
 
 	// Build task state list combining definition and execution state
 	// Provides complete task execution progress
 	for _, task := range jd.Tasks {
 		taskState := models.TaskState{
-			ID:     task.ID,
-			Name:   task.Name,
-			Status: je.TaskStatuses[task.ID],
+			ID:       task.ID,
+			Name:     task.Name,
+			Status:   je.TaskStatuses[task.ID],
+			Attempts: je.TaskAttempts[task.ID],
+			Error:    je.TaskErrors[task.ID],
+
+			StartTime: je.TaskTimes[task.ID].StartTime,
+			EndTime:   je.TaskTimes[task.ID].EndTime,
 		}
 		state.Tasks = append(state.Tasks, taskState)
 	}
 
-	return state, nil
+	// Sum up where the time of the attempts went
+	// Executions without recorded attempts have no timing
+	for _, attempts := range je.TaskAttempts {
+		if state.Timing == nil {
+			state.Timing = &models.ExecutionTiming{}
+		}
+		for i, attempt := range attempts {
+			state.Timing.TaskMs += attempt.DurationMs
+			state.Timing.BackoffMs += attempt.BackoffMs
+			if i > 0 {
+				state.Timing.Retries++
+			}
+		}
+	}
+
+	return state
 }
@@ -0,0 +1,107 @@
+// lease.go keeps the leases on executions this instance works on alive
+// Instances sharing storage recover each other's executions once their lease expires
+// Executions whose lease was taken over are stopped without writing their state
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// errLeaseHeld is reported when repairing an execution another instance holds
+var errLeaseHeld = errors.New("execution is leased by another instance")
+
+// renewLeases periodically renews the leases of executions this instance holds
+// Runs at a third of the lease duration so one missed renewal doesn't lose them
+// Stops once jobs were cancelled on shutdown
+func (o *Orchestrator) renewLeases(lease time.Duration) {
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.renewOnce()
+		}
+	}
+}
+
+// renewOnce renews the leases of dispatched, running and stranded executions
+// Running executions whose lease was taken over are cancelled with ErrLeaseLost
+func (o *Orchestrator) renewOnce() {
+	held := make(map[string]bool)
+	for _, m := range []*sync.Map{&o.dispatched, &o.ongoingJobs, &o.stranded} {
+		m.Range(func(key, _ interface{}) bool {
+			held[key.(string)] = true
+			return true
+		})
+	}
+	if len(held) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(held))
+	for id := range held {
+		ids = append(ids, id)
+	}
+	renewed, err := o.db.RenewLeases(ids)
+	if err != nil {
+		log.Printf("Failed to renew execution leases: %v", err)
+		return
+	}
+	for _, id := range renewed {
+		delete(held, id)
+	}
+
+	for id := range held {
+		log.Printf("Lease of job %s was taken over by another instance, stopping it", id)
+		o.stranded.Delete(id)
+		if cancel, ok := o.cancels.Load(id); ok {
+			cancel.(context.CancelCauseFunc)(ErrLeaseLost)
+		}
+	}
+}
+
+// recoverExpiredLeases periodically recovers executions whose lease expired
+// Their instance stopped without handing them back, e.g. it crashed
+// Stops once shutdown starts
+func (o *Orchestrator) recoverExpiredLeases(lease time.Duration) {
+	ticker := time.NewTicker(lease)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.closing:
+			return
+		case <-ticker.C:
+			requeued, err := o.RequeueStuckRunning()
+			if err != nil {
+				log.Printf("Failed to recover running executions with expired leases: %v", err)
+			}
+			if len(requeued) > 0 {
+				log.Printf("Requeued %d running executions with expired leases", len(requeued))
+			}
+			if err := o.requeueUnqueued(); err != nil {
+				log.Printf("Failed to recover queued executions with expired leases: %v", err)
+			}
+		}
+	}
+}
+
+// claimAndEnqueue queues an execution again once it was claimed from its expired lease
+// Returns errLeaseHeld if another instance holds a live lease on it
+func (o *Orchestrator) claimAndEnqueue(id string) error {
+	claimed, err := o.db.ClaimExecution(id)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errLeaseHeld
+	}
+	return o.enqueue(id)
+}
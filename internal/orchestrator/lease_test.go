@@ -0,0 +1,154 @@
+// lease_test.go tests how executions leased to other instances are left alone
+// Leases are simulated on top of BoltDB, which is never shared itself
+// Covers recovery, reconciliation, and jobs whose lease is taken over
+package orchestrator
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// leasedDB simulates storage shared with other instances
+// Executions in others are under a live lease of another instance
+type leasedDB struct {
+	storage.DB
+	lease  time.Duration // Lease duration reported to the orchestrator
+	mu     sync.Mutex
+	others map[string]bool // Executions leased to another instance
+}
+
+// newLeasedDB wraps a fresh BoltDB with the given lease duration
+func newLeasedDB(t testing.TB, lease time.Duration) *leasedDB {
+	return &leasedDB{DB: openTestDB(t), lease: lease, others: make(map[string]bool)}
+}
+
+// setLeased marks an execution as leased to another instance or releases it
+func (d *leasedDB) setLeased(id string, leased bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.others[id] = leased
+}
+
+// LeaseDuration reports the simulated lease duration
+func (d *leasedDB) LeaseDuration() time.Duration {
+	return d.lease
+}
+
+// ClaimExecution succeeds unless another instance holds the execution
+func (d *leasedDB) ClaimExecution(id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.others[id], nil
+}
+
+// RenewLeases returns the IDs no other instance took over
+func (d *leasedDB) RenewLeases(ids []string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var held []string
+	for _, id := range ids {
+		if !d.others[id] {
+			held = append(held, id)
+		}
+	}
+	return held, nil
+}
+
+// LeasedExecutions returns the executions other instances hold
+func (d *leasedDB) LeasedExecutions() (map[string]bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	leased := maps.Clone(d.others)
+	maps.DeleteFunc(leased, func(_ string, held bool) bool { return !held })
+	return leased, nil
+}
+
+// TestRecoveryLeavesLeasedExecutions starts next to another instance running a job
+// Neither startup, requeuing, nor reconciling may touch it until its lease expires
+func TestRecoveryLeavesLeasedExecutions(t *testing.T) {
+	db := newLeasedDB(t, time.Hour)
+	theirs := &models.JobExecution{
+		ID:           "theirs",
+		DefinitionID: "shared",
+		Status:       models.JobStatusRunning,
+		StartTime:    time.Now(),
+		TaskStatuses: map[string]models.TaskStatus{"a": models.TaskStatusRunning},
+	}
+	if err := db.StoreJobExecution(theirs); err != nil {
+		t.Fatalf("store execution: %v", err)
+	}
+	db.setLeased(theirs.ID, true)
+
+	o := startTestOrchestrator(t, db, 1)
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "shared",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}},
+	})
+	if o.inFlight(theirs.ID) {
+		t.Fatal("startup resumed an execution leased to another instance")
+	}
+
+	requeued, err := o.RequeueStuckRunning()
+	if err != nil {
+		t.Fatalf("requeue stuck running: %v", err)
+	}
+	if len(requeued) != 0 {
+		t.Fatalf("requeued %v, want the leased execution left alone", requeued)
+	}
+	report, err := o.Reconcile(true)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(report.Discrepancies) != 0 {
+		t.Fatalf("reconcile reported %+v, want the leased execution left alone", report.Discrepancies)
+	}
+	if je := execution(t, o, theirs.ID); je.Status != models.JobStatusRunning {
+		t.Fatalf("leased execution is %s, want it still RUNNING", je.Status)
+	}
+
+	// The other instance stopped renewing, its execution is recovered now
+	db.setLeased(theirs.ID, false)
+	requeued, err = o.RequeueStuckRunning()
+	if err != nil {
+		t.Fatalf("requeue stuck running: %v", err)
+	}
+	if !slices.Equal(requeued, []string{theirs.ID}) {
+		t.Fatalf("requeued %v, want [%s]", requeued, theirs.ID)
+	}
+	waitForStatus(t, o, theirs.ID, models.JobStatusCompleted)
+}
+
+// TestLostLeaseStopsJob takes over the lease of a running job
+// The job stops without writing its state, which belongs to the new owner
+func TestLostLeaseStopsJob(t *testing.T) {
+	db := newLeasedDB(t, 30*time.Millisecond)
+	o := startTestOrchestrator(t, db, 1)
+	started := make(chan struct{}, 1)
+	o.RegisterFunction("block", blockingFunction(started, make(chan struct{}), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "long",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "block"}},
+	})
+
+	id := enqueue(t, o, "long", nil)
+	<-started
+	before := execution(t, o, id)
+
+	db.setLeased(id, true)
+	waitFor(t, "job to stop after losing its lease", func() bool { return !o.inFlight(id) })
+
+	je := execution(t, o, id)
+	if je.Status != models.JobStatusRunning || je.TaskStatuses["a"] != models.TaskStatusRunning {
+		t.Errorf("execution is %s with task %s, want its RUNNING state left to the new owner", je.Status, je.TaskStatuses["a"])
+	}
+	if !je.EndTime.IsZero() || je.Error != before.Error {
+		t.Errorf("execution was finished with error %q after losing its lease", je.Error)
+	}
+}
@@ -0,0 +1,63 @@
+// loglevel_test.go tests the log levels of the loggers handed to task functions
+// Tasks log at the definition's level unless they set their own, and only
+// messages at or above that level reach the execution's captured logs
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// TestTaskLogLevels runs a definition logging at warn with one task at debug
+func TestTaskLogLevels(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	for _, name := range []string{"quiet", "verbose"} {
+		o.RegisterFunction(name, func(ctx context.Context, data map[string]interface{}) error {
+			log := taskctx.Log(ctx)
+			log.Debugf("%s debug", name)
+			log.Infof("%s info", name)
+			log.Warnf("%s warn", name)
+			return nil
+		})
+	}
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "chatty",
+		LogLevel: "warn",
+		Tasks: []*models.Task{
+			{ID: "quiet", FunctionName: "quiet"},
+			{ID: "verbose", FunctionName: "verbose", LogLevel: "debug"},
+		},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "chatty", nil))
+	for line, want := range map[string]bool{
+		"WARN: quiet warn":     true,
+		"INFO: quiet info":     false,
+		"DEBUG: quiet debug":   false,
+		"DEBUG: verbose debug": true,
+		"INFO: verbose info":   true,
+		"WARN: verbose warn":   true,
+	} {
+		if got := strings.Contains(je.Logs, line); got != want {
+			t.Errorf("logs contain %q = %v, want %v\n%s", line, got, want, je.Logs)
+		}
+	}
+}
+
+// TestInvalidLogLevelRejected checks unknown level names fail registration
+func TestInvalidLogLevelRejected(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	for name, jd := range map[string]*models.JobDefinition{
+		"definition": {ID: "a", LogLevel: "loud", Tasks: []*models.Task{{ID: "t", FunctionName: "f"}}},
+		"task":       {ID: "b", Tasks: []*models.Task{{ID: "t", FunctionName: "f", LogLevel: "trace"}}},
+	} {
+		if err := o.RegisterJobDefinition(jd); !errors.Is(err, ErrInvalidDefinition) {
+			t.Errorf("%s level: %v, want ErrInvalidDefinition", name, err)
+		}
+	}
+}
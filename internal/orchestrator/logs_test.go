@@ -0,0 +1,44 @@
+// logs_test.go tests capturing task logs up to the configured size
+// Output past the cap is dropped and marked as truncated once,
+// output within the cap is captured in full
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// TestLogCaptureTruncated runs a task logging far more than a 200 byte cap
+func TestLogCaptureTruncated(t *testing.T) {
+	const max = 200
+	o := newTestOrchestrator(t, 1, WithMaxLogBytes(max))
+	for name, lines := range map[string]int{"chatty": 100, "quiet": 2} {
+		o.RegisterFunction(name, func(ctx context.Context, data map[string]interface{}) error {
+			for i := 0; i < lines; i++ {
+				taskctx.Log(ctx).Infof("%s line %03d", name, i)
+			}
+			return nil
+		})
+		registerDefinition(t, o, &models.JobDefinition{ID: name, Tasks: []*models.Task{{ID: name, FunctionName: name}}})
+	}
+
+	logs := waitForFinish(t, o, enqueue(t, o, "chatty", nil)).Logs
+	if !strings.HasSuffix(logs, taskctx.TruncationMarker) || strings.Count(logs, taskctx.TruncationMarker) != 1 {
+		t.Errorf("logs don't end in a single truncation marker:\n%s", logs)
+	}
+	if len(logs) > max+len(taskctx.TruncationMarker) {
+		t.Errorf("captured %d bytes, want at most %d plus the marker", len(logs), max)
+	}
+	if !strings.Contains(logs, "chatty line 000") || strings.Contains(logs, "chatty line 099") {
+		t.Errorf("logs kept the wrong lines, want the first ones:\n%s", logs)
+	}
+
+	logs = waitForFinish(t, o, enqueue(t, o, "quiet", nil)).Logs
+	if strings.Contains(logs, taskctx.TruncationMarker) || !strings.Contains(logs, "quiet line 001") {
+		t.Errorf("logs within the cap weren't captured in full:\n%s", logs)
+	}
+}
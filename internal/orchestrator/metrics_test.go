@@ -0,0 +1,57 @@
+// metrics_test.go tests the queue metrics recorded while executions run
+// Metrics are read back through the default gatherer as they would be scraped
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/metrics"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queueWaits gathers the number of observed queue waits by queue label
+func queueWaits(t *testing.T) map[string]uint64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	waits := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "orchestrator_queue_wait_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "queue" {
+					waits[label.GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return waits
+}
+
+// TestQueueWaitLabeled runs executions and checks their waits carry the queue label
+func TestQueueWaitLabeled(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "noop", FunctionName: "noop"}}})
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+
+	before := queueWaits(t)[metrics.DefaultQueue]
+	for i := 0; i < 3; i++ {
+		waitForFinish(t, o, enqueue(t, o, "report", nil))
+	}
+
+	waits := queueWaits(t)
+	if got := waits[metrics.DefaultQueue] - before; got != 3 {
+		t.Errorf("%d waits observed for queue %s, want 3", got, metrics.DefaultQueue)
+	}
+	for queue := range waits {
+		if queue != metrics.DefaultQueue {
+			t.Errorf("waits observed for unknown queue %q", queue)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// migration_test.go tests migrating task input from an older shape before the task runs
+// Old inputs reach the function in the new shape, new inputs pass through unchanged,
+// and a failing migration fails the task without running it
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// splitName migrates inputs carrying a full "name" to separate first and last names
+func splitName(data map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := data["name"].(string)
+	if !ok {
+		return data, nil
+	}
+	first, last, found := strings.Cut(name, " ")
+	if !found {
+		return nil, fmt.Errorf("name %q has no last name", name)
+	}
+	delete(data, "name")
+	data["first"], data["last"] = first, last
+	return data, nil
+}
+
+// TestMigrationUpgradesInput runs executions enqueued with old and new shaped input
+func TestMigrationUpgradesInput(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	received := make(chan map[string]interface{}, 1)
+	o.RegisterFunction("greet", func(ctx context.Context, data map[string]interface{}) error {
+		received <- data
+		return nil
+	})
+	o.RegisterMigration("greet", splitName)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "welcome",
+		Tasks: []*models.Task{{ID: "greet", FunctionName: "greet"}},
+	})
+
+	want := map[string]interface{}{"first": "Ada", "last": "Lovelace"}
+	for name, input := range map[string]map[string]interface{}{
+		"old shape": {"name": "Ada Lovelace"},
+		"new shape": {"first": "Ada", "last": "Lovelace"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			id := enqueue(t, o, "welcome", input)
+			if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+				t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+			}
+			if got := <-received; !reflect.DeepEqual(got, want) {
+				t.Errorf("task received %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestMigrationFailureFailsTask checks a task isn't run with input its migration rejected
+func TestMigrationFailureFailsTask(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var ran atomic.Bool
+	o.RegisterFunction("greet", func(ctx context.Context, data map[string]interface{}) error {
+		ran.Store(true)
+		return nil
+	})
+	o.RegisterMigration("greet", splitName)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "welcome",
+		Tasks: []*models.Task{{ID: "greet", FunctionName: "greet"}},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "welcome", map[string]interface{}{"name": "Ada"}))
+	if je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "no last name") {
+		t.Errorf("status = %s, error = %q, want FAILED by the migration", je.Status, je.Error)
+	}
+	if ran.Load() {
+		t.Error("task ran with input its migration rejected")
+	}
+	if je.TaskStatuses["greet"] != models.TaskStatusFailed {
+		t.Errorf("task status = %s, want FAILED", je.TaskStatuses["greet"])
+	}
+}
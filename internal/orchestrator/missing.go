@@ -0,0 +1,77 @@
+// missing.go handles queued executions whose task functions aren't registered
+// Functions can be unregistered at runtime or be missing after a restart
+// Such executions fail before starting by default, or wait for the functions to return
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// missingCheckInterval is how often held executions check for their functions
+const missingCheckInterval = time.Second
+
+// WithHoldMissingFunctions keeps executions whose task functions aren't registered QUEUED
+// They start once all functions are registered again and fail if that takes longer than timeout
+// By default such executions fail as soon as they are taken off the queue
+func WithHoldMissingFunctions(timeout time.Duration) Option {
+	return func(o *Orchestrator) {
+		o.holdMissing = timeout
+	}
+}
+
+// missingFunctions returns the functions of the definition's tasks that aren't registered
+func (o *Orchestrator) missingFunctions(jd *models.JobDefinition) []string {
+	var missing []string
+	for _, task := range jd.Tasks {
+		if _, ok := o.resolveTaskFunction(task); !ok {
+			missing = append(missing, task.FunctionName)
+		}
+	}
+	return missing
+}
+
+// checkFunctions makes sure the functions of a job taken off the queue are registered
+// Returns false if some are missing, the job was then failed or is held until they return
+func (o *Orchestrator) checkFunctions(je *models.JobExecution, jd *models.JobDefinition) bool {
+	if jd == nil {
+		return true
+	}
+	missing := o.missingFunctions(jd)
+	if len(missing) == 0 {
+		o.missingSince.Delete(je.ID)
+		return true
+	}
+
+	// Fail the job right away, or once it was held for longer than the timeout
+	// The timeout counts from when this process first found functions missing
+	now := time.Now()
+	since, _ := o.missingSince.LoadOrStore(je.ID, now)
+	if o.holdMissing <= 0 || now.Sub(since.(time.Time)) >= o.holdMissing {
+		o.missingSince.Delete(je.ID)
+		reason := fmt.Errorf("task functions not registered: %s", strings.Join(missing, ", "))
+		if o.holdMissing > 0 {
+			reason = fmt.Errorf("task functions not registered within %s: %s", o.holdMissing, strings.Join(missing, ", "))
+		}
+		err := o.failBeforeStart(je, jd, reason)
+		log.Printf("Error executing job %s: %v", je.ID, err)
+		o.dispatched.Delete(je.ID)
+		o.checkDrained()
+		return false
+	}
+
+	// Hold the job by scheduling it to be checked again shortly
+	// The schedule is stored, so held jobs survive a crash
+	defer o.dispatched.Delete(je.ID)
+	if err := o.db.ScheduleJob(je.ID, now.Add(missingCheckInterval)); err != nil {
+		log.Printf("Failed to hold job %s for its task functions, queuing it again: %v", je.ID, err)
+		if err := o.enqueue(je.ID); err != nil {
+			log.Printf("Failed to requeue job %s: %v", je.ID, err)
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// missing_test.go tests executions whose task functions aren't registered
+// Covers failing them right away and holding them until the functions return
+// Functions are unregistered after the definition is registered, as at runtime
+package orchestrator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// registerUnregistered registers a definition whose function is then unregistered
+func registerUnregistered(t *testing.T, o *Orchestrator) {
+	t.Helper()
+	o.RegisterFunction("gone", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "needs-gone",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "gone"}},
+	})
+	o.UnregisterFunction("gone")
+}
+
+// TestMissingFunctionFailsJob fails an execution whose function is gone by default
+func TestMissingFunctionFailsJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	registerUnregistered(t, o)
+
+	je := waitForFinish(t, o, enqueue(t, o, "needs-gone", nil))
+	if je.Status != models.JobStatusFailed {
+		t.Fatalf("status = %s, want FAILED", je.Status)
+	}
+	if !strings.Contains(je.Error, "task functions not registered: gone") {
+		t.Errorf("error = %q, want the missing function named", je.Error)
+	}
+}
+
+// TestMissingFunctionHoldsJob holds an execution until its function is registered again
+// The held execution is scheduled in storage rather than waiting in memory
+func TestMissingFunctionHoldsJob(t *testing.T) {
+	db := openTestDB(t)
+	o := startTestOrchestrator(t, db, 1, WithHoldMissingFunctions(testTimeout))
+	registerUnregistered(t, o)
+
+	id := enqueue(t, o, "needs-gone", nil)
+	waitFor(t, "execution to be held", func() bool {
+		_, held := o.missingSince.Load(id)
+		return held
+	})
+	if je := execution(t, o, id); je.Status != models.JobStatusQueued {
+		t.Fatalf("held status = %s, want QUEUED", je.Status)
+	}
+	unqueued, err := db.ListUnqueuedJobs()
+	if err != nil {
+		t.Fatalf("list unqueued jobs: %v", err)
+	}
+	if len(unqueued) != 0 {
+		t.Fatalf("unqueued = %v, want the held execution scheduled in storage", unqueued)
+	}
+
+	o.RegisterFunction("gone", blockingFunction(nil, closedChannel(), nil))
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED", je.Status)
+	}
+}
+
+// TestMissingFunctionHoldTimesOut fails a held execution once the hold timeout passes
+func TestMissingFunctionHoldTimesOut(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithHoldMissingFunctions(missingCheckInterval/2))
+	registerUnregistered(t, o)
+
+	je := waitForFinish(t, o, enqueue(t, o, "needs-gone", nil))
+	if je.Status != models.JobStatusFailed {
+		t.Fatalf("status = %s, want FAILED", je.Status)
+	}
+	if !strings.Contains(je.Error, "not registered within") {
+		t.Errorf("error = %q, want the hold timeout named", je.Error)
+	}
+}
+
+// TestMissingFunctionHoldSurvivesRestart stops an orchestrator holding an execution
+// The next start on the same storage runs it once the function is registered
+func TestMissingFunctionHoldSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	o, err := New(db, 1, WithHoldMissingFunctions(testTimeout))
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	registerUnregistered(t, o)
+	id := enqueue(t, o, "needs-gone", nil)
+	waitFor(t, "execution to be held", func() bool {
+		_, held := o.missingSince.Load(id)
+		return held
+	})
+	if err := o.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if db, err = storage.NewBoltDB(path); err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	o = startTestOrchestrator(t, db, 1, WithHoldMissingFunctions(testTimeout))
+	o.RegisterFunction("gone", blockingFunction(nil, closedChannel(), nil))
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED", je.Status)
+	}
+}
@@ -0,0 +1,131 @@
+// operations.go implements asynchronous bulk operations
+// Bulk enqueues run in the background and report progress
+// Operations can be cancelled, keeping the jobs enqueued so far
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// operation tracks a running bulk operation
+// The mutex guards state, which is read by progress requests
+type operation struct {
+	mu     sync.Mutex
+	state  models.Operation
+	cancel context.CancelFunc
+}
+
+// snapshot returns a copy of the operation state
+func (op *operation) snapshot() *models.Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	state := op.state
+	state.ExecutionIDs = append([]string(nil), op.state.ExecutionIDs...)
+	state.Errors = append([]string(nil), op.state.Errors...)
+	return &state
+}
+
+// BulkEnqueue starts enqueuing one execution per data item in the background
+// Returns the operation ID used to follow progress or cancel
+// Returns an error wrapping ErrQueueFull if the queue is already too long for the definition
+func (o *Orchestrator) BulkEnqueue(definitionID string, items []map[string]interface{}) (string, error) {
+	// Fail fast on unknown definitions
+	// Otherwise every item would create an execution that can never run
+	jd, err := o.db.GetJobDefinition(definitionID)
+	if err != nil {
+		return "", err
+	}
+
+	// Reject the whole operation if even its first item would wait too long
+	// Items rejected later on are reported as errors of the operation
+	if err := o.checkQueueTime(jd); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(o.ctx)
+	op := &operation{
+		state: models.Operation{
+			ID:           newID("op"),
+			Type:         "bulk-enqueue",
+			DefinitionID: definitionID,
+			Status:       models.OperationStatusRunning,
+			Total:        len(items),
+			StartTime:    time.Now(),
+		},
+		cancel: cancel,
+	}
+	o.operations.Store(op.state.ID, op)
+
+	go o.runBulkEnqueue(ctx, op, items)
+
+	return op.state.ID, nil
+}
+
+// runBulkEnqueue enqueues the items until done or cancelled
+// Progress is recorded after every item
+func (o *Orchestrator) runBulkEnqueue(ctx context.Context, op *operation, items []map[string]interface{}) {
+	defer op.cancel()
+
+	for _, data := range items {
+		// Stop between items once cancelled
+		// Executions enqueued so far are left to run
+		if ctx.Err() != nil {
+			op.mu.Lock()
+			op.state.Status = models.OperationStatusCancelled
+			op.state.EndTime = time.Now()
+			op.mu.Unlock()
+			return
+		}
+
+		executionID, err := o.EnqueueJob(op.state.DefinitionID, data)
+
+		op.mu.Lock()
+		if err != nil {
+			op.state.Failed++
+			op.state.Errors = append(op.state.Errors, err.Error())
+		} else {
+			op.state.Completed++
+			op.state.ExecutionIDs = append(op.state.ExecutionIDs, executionID)
+		}
+		op.mu.Unlock()
+	}
+
+	op.mu.Lock()
+	op.state.Status = models.OperationStatusCompleted
+	op.state.EndTime = time.Now()
+	op.mu.Unlock()
+}
+
+// GetOperation returns the progress of a bulk operation
+func (o *Orchestrator) GetOperation(id string) (*models.Operation, error) {
+	v, ok := o.operations.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("operation %w", ErrNotFound)
+	}
+	return v.(*operation).snapshot(), nil
+}
+
+// CancelOperation stops a running bulk operation
+// Returns ErrOperationFinished if it already completed or was cancelled
+func (o *Orchestrator) CancelOperation(id string) error {
+	v, ok := o.operations.Load(id)
+	if !ok {
+		return fmt.Errorf("operation %w", ErrNotFound)
+	}
+	op := v.(*operation)
+
+	op.mu.Lock()
+	running := op.state.Status == models.OperationStatusRunning
+	op.mu.Unlock()
+	if !running {
+		return ErrOperationFinished
+	}
+
+	op.cancel()
+	return nil
+}
@@ -0,0 +1,136 @@
+// operations_test.go tests bulk enqueue operations and their cancellation
+// A gated storage stub holds the operation at a known item, so a cancellation
+// lands partway and leaves the items enqueued before it in place
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// gatedDB wraps a DB and holds the store of a queued execution until released
+type gatedDB struct {
+	storage.DB
+	mu      sync.Mutex
+	queued  int           // Number of queued executions stored so far
+	holdAt  int           // Store of the queued execution to hold, counting from one
+	held    chan struct{} // Closed once the held store was reached
+	release chan struct{} // Closed to let the held store proceed
+}
+
+// StoreJobExecution stores the execution, holding the configured queued store
+func (g *gatedDB) StoreJobExecution(je *models.JobExecution) error {
+	if je.Status == models.JobStatusQueued {
+		g.mu.Lock()
+		g.queued++
+		hold := g.queued == g.holdAt
+		g.mu.Unlock()
+		if hold {
+			close(g.held)
+			<-g.release
+		}
+	}
+	return g.DB.StoreJobExecution(je)
+}
+
+// waitForOperation polls an operation until it is no longer running
+func waitForOperation(t *testing.T, o *Orchestrator, id string) *models.Operation {
+	t.Helper()
+	var op *models.Operation
+	waitFor(t, "operation "+id+" to finish", func() bool {
+		var err error
+		op, err = o.GetOperation(id)
+		if err != nil {
+			t.Fatalf("get operation: %v", err)
+		}
+		return op.Status != models.OperationStatusRunning
+	})
+	return op
+}
+
+// TestCancelBulkEnqueuePartway cancels an operation while its third item is stored
+// The first three items stay enqueued and the rest are never created
+func TestCancelBulkEnqueuePartway(t *testing.T) {
+	db := &gatedDB{DB: openTestDB(t), holdAt: 3, held: make(chan struct{}), release: make(chan struct{})}
+	o := startTestOrchestrator(t, db, 1)
+	o.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error { return nil })
+	registerDefinition(t, o, &models.JobDefinition{ID: "import", Tasks: []*models.Task{{ID: "noop", FunctionName: "noop"}}})
+
+	items := make([]map[string]interface{}, 10)
+	for i := range items {
+		items[i] = map[string]interface{}{"row": i}
+	}
+	id, err := o.BulkEnqueue("import", items)
+	if err != nil {
+		t.Fatalf("bulk enqueue: %v", err)
+	}
+
+	select {
+	case <-db.held:
+	case <-time.After(testTimeout):
+		t.Fatal("operation never reached its third item")
+	}
+	if err := o.CancelOperation(id); err != nil {
+		t.Fatalf("cancel operation: %v", err)
+	}
+	close(db.release)
+
+	op := waitForOperation(t, o, id)
+	if op.Status != models.OperationStatusCancelled {
+		t.Fatalf("status = %s, want CANCELLED", op.Status)
+	}
+	if op.Total != 10 || op.Completed != 3 || op.Failed != 0 || len(op.ExecutionIDs) != 3 {
+		t.Errorf("total %d, completed %d, failed %d, executions %d, want 10, 3, 0, and 3",
+			op.Total, op.Completed, op.Failed, len(op.ExecutionIDs))
+	}
+	if op.EndTime.IsZero() {
+		t.Error("cancelled operation has no end time")
+	}
+	for _, executionID := range op.ExecutionIDs {
+		if je := waitForFinish(t, o, executionID); je.Status != models.JobStatusCompleted {
+			t.Errorf("execution %s status = %s, want COMPLETED", executionID, je.Status)
+		}
+	}
+	executions, err := o.db.ListJobExecutions("")
+	if err != nil {
+		t.Fatalf("list executions: %v", err)
+	}
+	if len(executions) != 3 {
+		t.Errorf("%d executions stored, want the 3 enqueued before the cancellation", len(executions))
+	}
+
+	if err := o.CancelOperation(id); !errors.Is(err, ErrOperationFinished) {
+		t.Errorf("cancelling again: %v, want ErrOperationFinished", err)
+	}
+}
+
+// TestBulkEnqueueCompletes runs an operation to the end and checks it can't be cancelled
+func TestBulkEnqueueCompletes(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("noop", func(ctx context.Context, data map[string]interface{}) error { return nil })
+	registerDefinition(t, o, &models.JobDefinition{ID: "import", Tasks: []*models.Task{{ID: "noop", FunctionName: "noop"}}})
+
+	id, err := o.BulkEnqueue("import", []map[string]interface{}{{"row": 1}, {"row": 2}})
+	if err != nil {
+		t.Fatalf("bulk enqueue: %v", err)
+	}
+	op := waitForOperation(t, o, id)
+	if op.Status != models.OperationStatusCompleted || op.Completed != 2 || len(op.ExecutionIDs) != 2 {
+		t.Errorf("status %s with %d completed, want COMPLETED with 2", op.Status, op.Completed)
+	}
+	if err := o.CancelOperation(id); !errors.Is(err, ErrOperationFinished) {
+		t.Errorf("cancelling a completed operation: %v, want ErrOperationFinished", err)
+	}
+	if _, err := o.GetOperation("op-missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown operation: %v, want ErrNotFound", err)
+	}
+	if err := o.CancelOperation("op-missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("cancelling an unknown operation: %v, want ErrNotFound", err)
+	}
+}
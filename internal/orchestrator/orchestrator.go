@@ -5,42 +5,152 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/fawad1985/go-job-orchestrator/internal/metrics"
 	"github.com/fawad1985/go-job-orchestrator/internal/storage"
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
 )
 
 // Orchestrator manages the complete job execution system
 // Controls worker pools, maintains job state, and coordinates task execution
 // Provides thread-safe operation for concurrent job processing
 type Orchestrator struct {
-	db            storage.DB              // Persistent storage interface
-	workerPool    chan struct{}           // Limits concurrent job executions
-	ongoingJobs   sync.Map                // Tracks currently executing jobs
-	taskFunctions map[string]TaskFunction // Maps task IDs to their implementations
-	maxConcurrent int                     // Maximum number of concurrent jobs
-	stop          chan struct{}           // Signal to stop processing
-	done          chan struct{}           // Signal that processing has stopped
+	db            storage.DB                    // Persistent storage interface
+	workerPool    *workerPool                   // Limits concurrent job executions
+	pools         map[string]*workerPool        // Labeled worker pools by label
+	affinity      affinityTable                 // Worker each affinity key last ran on
+	ongoingJobs   sync.Map                      // Tracks currently executing jobs
+	dispatched    sync.Map                      // Jobs taken off the queue and not yet finished
+	fnMu          sync.RWMutex                  // Guards the function, cleanup, and migration registries, which may change while jobs run
+	defMu         sync.RWMutex                  // Read-held while creating or retrying executions, write-held while deleting definitions
+	taskFunctions map[string]OutputTaskFunction // Maps task IDs to their implementations
+	functions     map[string]OutputTaskFunction // Maps function names to their implementations
+	cleanups      map[string]CleanupFunc        // Maps function names to their cleanup hooks
+	migrations    map[string]MigrationFunc      // Maps function names to their input migrations
+	taskSlots     chan struct{}                 // Slots DAG tasks of all jobs share, nil for no limit
+	stop          chan struct{}                 // Closed to stop processing
+	stopOnce      sync.Once                     // Guards closing stop and closing
+	wake          chan struct{}                 // Signals the queue loop that jobs were enqueued
+	done          chan struct{}                 // Signal that processing has stopped
+	closing       chan struct{}                 // Closed when shutdown starts, releases held jobs
+	ctx           context.Context               // Base context of all job executions
+	cancel        context.CancelCauseFunc       // Cancels running jobs on forced shutdown
+	jobs          sync.WaitGroup                // Tracks running job goroutines
+	drainPending  atomic.Bool                   // Set once work is enqueued, cleared when drained
+	events        *EventBus                     // Publishes job processing events
+	alerts        alertTracker                  // Rolling outcome windows for alerting
+	throughput    throughputTracker             // Recent finish times for queue wait estimates
+	slots         definitionSlots               // Per-definition concurrency accounting
+	operations    sync.Map                      // Tracks bulk operations by ID
+	cancels       sync.Map                      // Cancel functions of running executions by ID
+	stranded      sync.Map                      // Jobs stopped by unavailable storage, requeued once it answers
+	strandRetry   time.Duration                 // How often stranded jobs are requeued
+	publisher     Publisher                     // Receives outcomes of finished executions
+	flags         FlagProvider                  // Decides which tasks are enabled
+	useNumber     bool                          // Decode numbers in job data as json.Number
+	maxLogBytes   int                           // Maximum captured task log output per execution
+	requeueOnBoot bool                          // Queue executions interrupted by shutdown instead of resuming them
+	maxBackoff    time.Duration                 // Longest allowed worst-case retry backoff of a job, zero for no limit
+	pause         pauseGate                     // Gates queue processing and running jobs while paused
+	holdMissing   time.Duration                 // How long jobs with unregistered functions wait for them, zero to fail them
+	missingSince  sync.Map                      // When each held job was first found missing functions
+	inputHosts    map[string]bool               // Hosts FetchInput may fetch from, nil for any public host
+	inputClient   *http.Client                  // Client fetching external inputs
+	healthMu      sync.RWMutex                  // Guards healthChecks and health
+	healthChecks  map[string]HealthCheck        // Maps dependency names to their health checks
+	health        map[string]error              // Latest health check result by dependency, nil if healthy
+	healthDelay   time.Duration                 // How long jobs are deferred while a dependency is unhealthy
+}
+
+// defaultMaxLogBytes bounds the captured task log output of an execution
+const defaultMaxLogBytes = 64 << 10
+
+// Option configures optional Orchestrator behavior
+type Option func(*Orchestrator)
+
+// WithUseNumber makes NewDecoder decode numbers as json.Number instead of float64
+// Preserves the precision of large integers such as IDs in job data
+// Pair it with storage.Options.UseNumber so stored data decodes the same way
+func WithUseNumber() Option {
+	return func(o *Orchestrator) {
+		o.useNumber = true
+	}
+}
+
+// WithMaxLogBytes sets how much task log output is captured per execution
+// Output beyond the limit is dropped and marked as truncated
+func WithMaxLogBytes(n int) Option {
+	return func(o *Orchestrator) {
+		if n > 0 {
+			o.maxLogBytes = n
+		}
+	}
+}
+
+// WithRequeueInterrupted queues executions interrupted by shutdown again on startup
+// By default they resume right away, outside the worker pool and concurrency limits
+// Cancelled executions stay cancelled either way, only the shutdown's own cancellation is undone
+func WithRequeueInterrupted() Option {
+	return func(o *Orchestrator) {
+		o.requeueOnBoot = true
+	}
+}
+
+// WithMaxRetryBackoff rejects definitions whose retries could back off longer than d
+// The worst case assumes every task uses all its retries
+func WithMaxRetryBackoff(d time.Duration) Option {
+	return func(o *Orchestrator) {
+		o.maxBackoff = d
+	}
 }
 
 // New creates and initializes a new Orchestrator instance
 // Sets up the worker pool and recovers any interrupted jobs
 // Starts the job queue processing loop
-func New(db storage.DB, maxConcurrent int) (*Orchestrator, error) {
+func New(db storage.DB, maxConcurrent int, opts ...Option) (*Orchestrator, error) {
 	// Initialize orchestrator with configuration and channels
 	// Creates worker pool and task function registry
+	ctx, cancel := context.WithCancelCause(context.Background())
 	o := &Orchestrator{
 		db:            db,
-		workerPool:    make(chan struct{}, maxConcurrent),
-		taskFunctions: make(map[string]TaskFunction),
-		maxConcurrent: maxConcurrent,
+		workerPool:    newWorkerPool("", maxConcurrent),
+		taskFunctions: make(map[string]OutputTaskFunction),
+		functions:     make(map[string]OutputTaskFunction),
+		cleanups:      make(map[string]CleanupFunc),
+		migrations:    make(map[string]MigrationFunc),
+		healthChecks:  make(map[string]HealthCheck),
+		health:        make(map[string]error),
+		healthDelay:   defaultDependencyRetryDelay,
+		strandRetry:   defaultStrandRetry,
 		stop:          make(chan struct{}),
+		wake:          make(chan struct{}, 1),
 		done:          make(chan struct{}),
+		closing:       make(chan struct{}),
+		events:        NewEventBus(),
+		publisher:     noopPublisher{},
+		flags:         allEnabled{},
+		maxLogBytes:   defaultMaxLogBytes,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	if maxConcurrent > 0 {
+		o.taskSlots = make(chan struct{}, maxConcurrent)
+	}
+	for _, opt := range opts {
+		opt(o)
 	}
+	o.inputClient = o.newInputClient()
 
 	// Recover state from previous runs
 	// Ensures jobs interrupted by shutdown are properly handled
@@ -52,27 +162,145 @@ func New(db storage.DB, maxConcurrent int) (*Orchestrator, error) {
 	// Begins processing jobs in background
 	go o.processQueue()
 
+	// Start cancelling executions past their maximum age
+	go o.reapStaleExecutions()
+
+	// Start moving delayed jobs to the queue once due
+	go o.promoteScheduledJobs()
+
+	// Start requeuing jobs stopped by unavailable storage once it answers
+	go o.requeueStranded()
+
+	// Keep leases on this instance's executions alive and recover those of
+	// instances that stopped renewing theirs, when storage is shared
+	if lease := o.db.LeaseDuration(); lease > 0 {
+		go o.renewLeases(lease)
+		go o.recoverExpiredLeases(lease)
+	}
+
 	return o, nil
 }
 
+// NewDecoder returns a JSON decoder for job data read from r
+// Numbers decode as json.Number when WithUseNumber is set
+func (o *Orchestrator) NewDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
+
 // recoverState restores any running jobs from the last shutdown
 // Prevents job loss during system restarts
 // Re-queues previously running jobs for execution
 func (o *Orchestrator) recoverState() error {
+	if err := o.recoverRunning(); err != nil {
+		return err
+	}
+	return o.requeueUnqueued()
+}
+
+// recoverRunning resumes or requeues the jobs that were running during last shutdown
+// Nothing runs yet in this instance, so every RUNNING execution not leased by
+// another instance sharing storage was interrupted
+func (o *Orchestrator) recoverRunning() error {
+	// Put interrupted jobs back into the queue when configured
+	if o.requeueOnBoot {
+		requeued, err := o.RequeueStuckRunning()
+		if len(requeued) > 0 {
+			log.Printf("Requeued %d executions interrupted by shutdown", len(requeued))
+		}
+		return err
+	}
+
 	// Get list of jobs that were running during last shutdown
 	// These jobs need to be recovered and restarted
-	runningJobs, err := o.db.GetRunningJobs()
+	running, err := o.db.GetRunningJobs()
 	if err != nil {
 		return err
 	}
+	var runningJobs []string
+	for _, id := range running {
+		claimed, err := o.db.ClaimExecution(id)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			runningJobs = append(runningJobs, id)
+		}
+	}
 
 	// Restart each previously running job
 	// Jobs are tracked and executed in new goroutines
+	if len(runningJobs) > 0 {
+		o.drainPending.Store(true)
+	}
 	for _, jobID := range runningJobs {
 		o.ongoingJobs.Store(jobID, struct{}{})
-		go o.ExecuteJob(context.Background(), jobID)
+		o.jobs.Add(1)
+		go func(id string) {
+			defer o.jobs.Done()
+			if err := o.ExecuteJob(o.ctx, id); err != nil {
+				log.Printf("Error executing recovered job %s: %v", id, err)
+			}
+			o.checkDrained()
+		}(jobID)
+	}
+
+	return nil
+}
+
+// requeueUnqueued puts QUEUED executions missing from the queue back into it
+// They waited in memory when the process stopped without a graceful shutdown, e.g. set
+// aside at a concurrency limit, buffered, or waiting for a pool slot
+// Delayed executions are scheduled for their start time again, the rest keep their queue order
+// Executions another instance sharing storage holds a live lease on are left to it
+func (o *Orchestrator) requeueUnqueued() error {
+	ids, err := o.db.ListUnqueuedJobs()
+	if err != nil || len(ids) == 0 {
+		return err
 	}
 
+	executions := make([]*models.JobExecution, 0, len(ids))
+	for _, id := range ids {
+		if o.inFlight(id) {
+			continue
+		}
+		claimed, err := o.db.ClaimExecution(id)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		je, err := o.db.GetJobExecution(id)
+		if err != nil {
+			return err
+		}
+		if je.Status != models.JobStatusQueued {
+			continue
+		}
+		executions = append(executions, je)
+	}
+	if len(executions) == 0 {
+		return nil
+	}
+	sort.SliceStable(executions, func(i, j int) bool {
+		return executions[i].QueuedAt.Before(executions[j].QueuedAt)
+	})
+
+	for _, je := range executions {
+		if je.ScheduledAt.After(time.Now()) {
+			err = o.db.ScheduleJob(je.ID, je.ScheduledAt)
+		} else {
+			err = o.enqueue(je.ID)
+		}
+		if err != nil && !errors.Is(err, ErrAlreadyQueued) {
+			return err
+		}
+	}
+	log.Printf("Requeued %d queued executions missing from the queue", len(executions))
 	return nil
 }
 
@@ -90,71 +318,364 @@ func (o *Orchestrator) processQueue() {
 			return
 
 		default:
+			// Leave jobs queued while processing is paused
+			if resumed := o.pausedUntil(false); resumed != nil {
+				select {
+				case <-o.stop:
+					return
+				case <-resumed:
+				}
+				continue
+			}
+
 			// Attempt to dequeue next job
-			// If queue is empty, wait before retrying
+			// If queue is empty, wait until a job is enqueued or the next poll
 			jobID, err := o.db.DequeueJob()
 			if err != nil {
-				if err.Error() == "queue is empty" {
-					time.Sleep(time.Second)
+				if errors.Is(err, storage.ErrQueueEmpty) {
+					o.waitForJobs()
 					continue
 				}
 				log.Printf("Error dequeuing job: %v", err)
 				continue
 			}
 
+			// Track the job while it is neither queued nor done
+			// It may wait here for a worker slot for a long time
+			o.dispatched.Store(jobID, struct{}{})
+
+			// Look up the definition the job runs once for routing it
+			// Unreadable jobs are left for ExecuteJob to report
+			je, jd := o.dispatchedDefinition(jobID)
+
+			// Find the labeled pool the job is pinned to, if any
+			// Jobs whose label has no pool are failed without running
+			labeled, err := o.poolFor(je, jd)
+			if err != nil {
+				log.Printf("Error executing job %s: %v", jobID, err)
+				o.dispatched.Delete(jobID)
+				o.checkDrained()
+				continue
+			}
+
+			// Fail or hold the job if functions of its tasks aren't registered
+			if !o.checkFunctions(je, jd) {
+				continue
+			}
+
+			// Defer the job while a dependency of its definition is unhealthy
+			// It is queued again after the dependency retry delay
+			if !o.checkDependencies(je, jd) {
+				continue
+			}
+
+			// Set the job aside if its definition is at its concurrency limit
+			// It is queued again once a job of the definition finishes
+			limited, ok := o.admit(jobID, jd)
+			if !ok {
+				continue
+			}
+
 			// Acquire worker slot from pool
 			// Ensures we don't exceed max concurrent jobs
-			o.workerPool <- struct{}{}
+			// Jobs of labeled pools wait for a slot in their goroutine instead,
+			// so jobs of other pools keep being taken off the queue
+			// A job waiting for a slot when shutdown starts goes back to the queue
+			pool := labeled
+			if pool == nil {
+				pool = o.workerPool
+				if !o.acquireSlot(jobID, pool) {
+					if limited != "" {
+						o.release(limited)
+					}
+					o.dispatched.Delete(jobID)
+					return
+				}
+			}
 
 			// Execute job in new goroutine
 			// Worker slot is released after completion
+			o.jobs.Add(1)
 			go func(id string) {
-				defer func() { <-o.workerPool }() // Release worker
-				if err := o.ExecuteJob(context.Background(), id); err != nil {
+				defer o.jobs.Done()
+				defer o.dispatched.Delete(id)
+				if labeled != nil && !o.acquireSlot(id, labeled) {
+					if limited != "" {
+						o.release(limited)
+					}
+					return
+				}
+				// Pick the worker, preferring the one of the job's affinity key
+				worker := o.takeWorker(pool, je)
+				defer pool.put(worker) // Release worker
+				active := metrics.ActiveWorkers.WithLabelValues(pool.name())
+				active.Inc()
+				defer active.Dec()
+				ctx := taskctx.WithWorker(o.ctx, pool.workerName(worker))
+				if err := o.ExecuteJob(ctx, id); err != nil {
 					log.Printf("Error executing job %s: %v", id, err)
 				}
+				if limited != "" {
+					o.release(limited)
+				}
+				o.checkDrained()
 			}(jobID)
 		}
 	}
 }
 
+// queuePollInterval is how often an empty queue is checked without being woken
+// Catches jobs enqueued directly in storage, e.g. by another process
+const queuePollInterval = time.Second
+
+// waitForJobs blocks while the queue is empty
+// Returns once a job is enqueued, the poll interval passes, or processing stops
+func (o *Orchestrator) waitForJobs() {
+	timer := time.NewTimer(queuePollInterval)
+	defer timer.Stop()
+	select {
+	case <-o.stop:
+	case <-o.wake:
+	case <-timer.C:
+	}
+}
+
+// wakeQueue lets a waiting queue loop know that jobs were enqueued
+// Never blocks, a pending signal already covers any number of new jobs
+func (o *Orchestrator) wakeQueue() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchedDefinition reads a job taken off the queue and the definition it runs
+// Returns nils if either can't be read
+func (o *Orchestrator) dispatchedDefinition(executionID string) (*models.JobExecution, *models.JobDefinition) {
+	je, err := o.db.GetJobExecution(executionID)
+	if err != nil {
+		return nil, nil
+	}
+	jd, err := o.definitionFor(je)
+	if err != nil {
+		return nil, nil
+	}
+	return je, jd
+}
+
+// promoteInterval is how often delayed jobs are checked for being due
+const promoteInterval = time.Second
+
+// promoteScheduledJobs periodically moves due delayed jobs to the queue
+// Stops once the orchestrator is shut down
+func (o *Orchestrator) promoteScheduledJobs() {
+	ticker := time.NewTicker(promoteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case now := <-ticker.C:
+			promoted, err := o.db.PromoteDueJobs(now)
+			if err != nil {
+				log.Printf("Failed to promote scheduled jobs: %v", err)
+				continue
+			}
+			if len(promoted) > 0 {
+				o.drainPending.Store(true)
+				o.wakeQueue()
+			}
+		}
+	}
+}
+
+// enqueue adds an execution to the queue
+// Arms the queue drained notification for the new work and wakes the queue loop
+func (o *Orchestrator) enqueue(executionID string) error {
+	if err := o.db.EnqueueJob(executionID); err != nil {
+		return err
+	}
+	o.drainPending.Store(true)
+	o.wakeQueue()
+	return nil
+}
+
+// checkDrained publishes a QueueDrained event once all work has finished
+// Called whenever a job finishes, the event fires once per batch of work
+func (o *Orchestrator) checkDrained() {
+	if !o.drainPending.Load() || o.activeJobCount() > 0 {
+		return
+	}
+	count, err := o.db.GetQueuedJobCount()
+	if err != nil || count > 0 {
+		return
+	}
+
+	// Only the first job to observe the drained state publishes the event
+	if o.drainPending.CompareAndSwap(true, false) {
+		o.events.Publish(models.Event{
+			Type:    models.EventQueueDrained,
+			Message: "queue is empty and all jobs have finished",
+		})
+	}
+}
+
+// activeJobCount returns the number of jobs currently executing
+func (o *Orchestrator) activeJobCount() int {
+	count := 0
+	o.ongoingJobs.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
 // Close gracefully shuts down the orchestrator
-// Stops queue processing and waits for completion
+// Stops queue processing and waits for running jobs to finish
 // Ensures clean shutdown of database connection
 func (o *Orchestrator) Close() error {
-	// Signal queue processor to stop
-	o.stop <- struct{}{}
+	return o.Shutdown(context.Background())
+}
+
+// CloseWithTimeout shuts down the orchestrator, waiting at most the grace period
+// Jobs still running after the grace period are cancelled and left RUNNING
+// in storage so they are recovered on the next start, storage is closed once
+// they stopped or shutdownUnwind passed
+// Returns an error if the grace period was exceeded
+func (o *Orchestrator) CloseWithTimeout(grace time.Duration) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), grace, fmt.Errorf("grace period of %s exceeded", grace))
+	defer cancel()
+	return o.Shutdown(ctx)
+}
+
+// shutdownUnwind bounds how long Shutdown waits for cancelled jobs to record their state
+// Storage is closed afterwards, so jobs mustn't still be writing to it
+const shutdownUnwind = 5 * time.Second
+
+// Shutdown stops queue processing and waits for running jobs until ctx is done
+// Jobs still running then are cancelled and left RUNNING in storage so they are
+// recovered on the next start, Shutdown waits up to shutdownUnwind for them to
+// stop before closing storage
+// Returns an error if ctx ended the wait
+func (o *Orchestrator) Shutdown(ctx context.Context) error {
+	// Signal queue processor to stop, closing never blocks however busy it is
+	// Jobs held by a pause or waiting for a worker stop too and are recovered on the next start
+	o.stopOnce.Do(func() {
+		close(o.closing)
+		close(o.stop)
+	})
 
-	// Wait for queue processor to finish
-	<-o.done
+	// Wait for queue processor and running jobs in the background
+	// so the wait can be abandoned once ctx is done
+	stopped := make(chan struct{})
+	go func() {
+		<-o.done
+		o.jobs.Wait()
+		close(stopped)
+	}()
+
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		err = fmt.Errorf("running jobs did not finish in time, leaving them for recovery: %w", context.Cause(ctx))
+	}
+
+	// Cancel anything still running, jobs observing it keep their RUNNING state
+	// Give them a moment to write that state before storage is closed
+	o.cancel(ErrShutdown)
+	select {
+	case <-stopped:
+	case <-time.After(shutdownUnwind):
+		log.Printf("Jobs still running %s after being cancelled, closing storage under them", shutdownUnwind)
+	}
+	o.requeueDeferred()
 
 	// Close database connection
-	return o.db.Close()
+	if cerr := o.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Events returns the orchestrator's event bus
+// Subscribers receive events such as alerts as they occur
+func (o *Orchestrator) Events() *EventBus {
+	return o.events
 }
 
 // RegisterJobDefinition adds a new job definition to the system
 // Stores the definition for future execution
 // Enables jobs to be executed using this definition
 func (o *Orchestrator) RegisterJobDefinition(jd *models.JobDefinition) error {
+	if err := o.prepareDefinition(jd); err != nil {
+		return err
+	}
 	return o.db.StoreJobDefinition(jd)
 }
 
+// prepareDefinition resolves and checks a definition before it is used
+// Shared by registered and inline definitions
+func (o *Orchestrator) prepareDefinition(jd *models.JobDefinition) error {
+	if err := o.resolveBase(jd); err != nil {
+		return err
+	}
+	if err := validateJobDefinition(jd); err != nil {
+		return err
+	}
+	if err := o.checkChain(jd); err != nil {
+		return err
+	}
+
+	// Pinned definitions need their pool, otherwise no execution could ever start
+	if _, ok := o.pools[jd.PoolLabel]; jd.PoolLabel != "" && !ok {
+		return fmt.Errorf("%w: no worker pool labeled %s", ErrInvalidDefinition, jd.PoolLabel)
+	}
+
+	// Reject retry settings that could hold a worker for too long
+	if wait := worstCaseBackoff(jd); o.maxBackoff > 0 && wait > o.maxBackoff {
+		return fmt.Errorf("%w: worst-case retry backoff of %s exceeds %s", ErrInvalidDefinition, wait, o.maxBackoff)
+	}
+	return nil
+}
+
+// ListQueue returns the queued executions in dequeue order
+// Entries carry their definition name and queue time for display
+func (o *Orchestrator) ListQueue() ([]*models.QueuedExecution, error) {
+	return o.db.ListQueuedExecutions()
+}
+
 // GetSystemState retrieves the current state of the entire system
 // Provides overview of active and queued jobs
 // Used for monitoring and debugging
 func (o *Orchestrator) GetSystemState() (*models.SystemState, error) {
 	state := &models.SystemState{}
 
-	// Collect state of all actively running jobs
-	// Includes current status and progress
+	// Snapshot the running job IDs before reading any state
+	// Storage reads don't happen inside Range while jobs come and go
+	var running []string
 	o.ongoingJobs.Range(func(key, value interface{}) bool {
-		jobID := key.(string)
-		jobState, err := o.GetJobExecutionState(jobID)
-		if err == nil {
-			state.ActiveJobs = append(state.ActiveJobs, *jobState)
-		}
+		running = append(running, key.(string))
 		return true
 	})
+	sort.Strings(running)
+
+	// Collect state of all actively running jobs
+	// Jobs that finished or vanished since the snapshot are left out
+	for _, jobID := range running {
+		jobState, err := o.GetJobExecutionState(jobID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if jobState.Status.Finished() {
+			continue
+		}
+		state.ActiveJobs = append(state.ActiveJobs, *jobState)
+	}
 
 	// Get list of jobs waiting in queue
 	// Shows pending work
@@ -179,5 +700,43 @@ func (o *Orchestrator) GetSystemState() (*models.SystemState, error) {
 	}
 	state.ExecutedJobs = executedCount
 
+	// Get the number of executions per status from the maintained counters
+	statusCounts, err := o.db.CountExecutionsByStatus()
+	if err != nil {
+		return nil, err
+	}
+	state.StatusCounts = statusCounts
+	state.Paused = o.Paused()
+
+	return state, nil
+}
+
+// GetSystemSnapshot retrieves the system state as of a single point in time
+// Running and queued executions are read from storage in one read transaction,
+// so an execution moving from the queue to running is counted exactly once
+// Executions taken off the queue but not yet started appear in neither list
+func (o *Orchestrator) GetSystemSnapshot() (*models.SystemState, error) {
+	snap, err := o.db.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.SystemState{
+		QueuedJobs:   snap.Queued,
+		QueuedCount:  len(snap.Queued),
+		ExecutedJobs: snap.ExecutedCount,
+		StatusCounts: snap.StatusCounts,
+		Paused:       o.Paused(),
+	}
+	sort.Slice(snap.Running, func(i, j int) bool {
+		return snap.Running[i].ID < snap.Running[j].ID
+	})
+	for _, je := range snap.Running {
+		jd, err := o.definitionFor(je)
+		if err != nil {
+			return nil, err
+		}
+		state.ActiveJobs = append(state.ActiveJobs, *executionState(je, jd))
+	}
 	return state, nil
 }
@@ -0,0 +1,245 @@
+// orchestrator_test.go tests starting and stopping the orchestrator
+// Covers recovering executions left behind by a process that stopped abruptly
+// Each test reopens the same BoltDB to simulate a restart
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestRecoverRequeuesUnqueuedExecutions starts on executions stored QUEUED but
+// missing from the queue, as left by a crash while they waited in memory
+// Due executions must run and delayed ones must be scheduled again
+func TestRecoverRequeuesUnqueuedExecutions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	jd := &models.JobDefinition{ID: "lost", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}}
+	if err := db.StoreJobDefinition(jd); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	due := &models.JobExecution{ID: newID("exec"), DefinitionID: "lost", Status: models.JobStatusQueued, QueuedAt: time.Now()}
+	delayed := &models.JobExecution{ID: newID("exec"), DefinitionID: "lost", Status: models.JobStatusQueued, QueuedAt: time.Now(), ScheduledAt: time.Now().Add(time.Hour)}
+	for _, je := range []*models.JobExecution{due, delayed} {
+		if err := db.StoreJobExecution(je); err != nil {
+			t.Fatalf("store execution: %v", err)
+		}
+	}
+
+	// Functions are registered after startup, hold the recovered jobs until then
+	o := startTestOrchestrator(t, db, 1, WithHoldMissingFunctions(testTimeout))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+
+	if je := waitForFinish(t, o, due.ID); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+	unqueued, err := db.ListUnqueuedJobs()
+	if err != nil {
+		t.Fatalf("list unqueued jobs: %v", err)
+	}
+	if len(unqueued) != 0 {
+		t.Fatalf("unqueued = %v, want delayed execution scheduled again", unqueued)
+	}
+	if je := execution(t, o, delayed.ID); je.Status != models.JobStatusQueued {
+		t.Fatalf("delayed status = %s, want %s", je.Status, models.JobStatusQueued)
+	}
+}
+
+// TestRecoverRequeuesLostBufferedEnqueues drops the buffer of a BufferedQueue
+// without flushing it, as a crash would, and restarts on the same BoltDB
+// The buffered execution must be queued again and run
+func TestRecoverRequeuesLostBufferedEnqueues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	buffered := storage.NewBufferedQueue(db, 100, time.Hour)
+	o, err := New(buffered, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	o.Pause(false)
+	registerDefinition(t, o, &models.JobDefinition{ID: "buffered", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}})
+	id := enqueue(t, o, "buffered", nil)
+
+	// Crash: the underlying DB goes away with the enqueue still buffered
+	if err := db.Close(); err != nil {
+		t.Fatalf("close db: %v", err)
+	}
+	close(o.stop)
+	<-o.done
+
+	db, err = storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	restarted := startTestOrchestrator(t, db, 1, WithHoldMissingFunctions(testTimeout))
+	restarted.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	if je := waitForFinish(t, restarted, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
+
+// closeTrackingDB wraps a DB and counts execution writes made after Close
+type closeTrackingDB struct {
+	storage.DB
+	closed     atomic.Bool
+	lateWrites atomic.Int32
+}
+
+// UpdateJobExecution counts writes arriving after Close
+func (c *closeTrackingDB) UpdateJobExecution(je *models.JobExecution) error {
+	if c.closed.Load() {
+		c.lateWrites.Add(1)
+	}
+	return c.DB.UpdateJobExecution(je)
+}
+
+// Close marks the DB closed before closing it
+func (c *closeTrackingDB) Close() error {
+	c.closed.Store(true)
+	return c.DB.Close()
+}
+
+// slowUnwindFunction returns a task function that signals on started and,
+// once cancelled, takes unwind to return like a task releasing resources
+func slowUnwindFunction(started chan<- struct{}, unwind time.Duration) TaskFunction {
+	return func(ctx context.Context, data map[string]interface{}) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		time.Sleep(unwind)
+		return ctx.Err()
+	}
+}
+
+// TestShutdownWaitsForCancelledJobs shuts down with a context that expires while
+// a job runs, the job takes a moment to return after being cancelled
+// Its state must be written before storage is closed and left RUNNING for recovery
+func TestShutdownWaitsForCancelledJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	bolt, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db := &closeTrackingDB{DB: bolt}
+	o, err := New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	started := make(chan struct{}, 1)
+	o.RegisterFunction("slow", slowUnwindFunction(started, 200*time.Millisecond))
+	registerDefinition(t, o, &models.JobDefinition{ID: "slow", Tasks: []*models.Task{{ID: "a", FunctionName: "slow"}}})
+	id := enqueue(t, o, "slow", nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := o.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("shutdown: err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := db.lateWrites.Load(); n > 0 {
+		t.Fatalf("%d execution writes after storage was closed", n)
+	}
+
+	reopened, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	defer reopened.Close()
+	je, err := reopened.GetJobExecution(id)
+	if err != nil {
+		t.Fatalf("get execution: %v", err)
+	}
+	if je.Status != models.JobStatusRunning || je.TaskStatuses["a"] == models.TaskStatusRunning {
+		t.Fatalf("status = %s with task %q, want RUNNING with the task reset for recovery", je.Status, je.TaskStatuses["a"])
+	}
+}
+
+// TestCloseWithTimeoutLeavesJobsForRecovery closes with a grace period shorter than
+// a running job, the job takes a moment to return after being cancelled
+// Storage must stay open until the job recorded its state
+func TestCloseWithTimeoutLeavesJobsForRecovery(t *testing.T) {
+	bolt := openTestDB(t)
+	db := &closeTrackingDB{DB: bolt}
+	o, err := New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	started := make(chan struct{}, 1)
+	o.RegisterFunction("slow", slowUnwindFunction(started, 100*time.Millisecond))
+	registerDefinition(t, o, &models.JobDefinition{ID: "slow", Tasks: []*models.Task{{ID: "a", FunctionName: "slow"}}})
+	enqueue(t, o, "slow", nil)
+	<-started
+
+	start := time.Now()
+	if err := o.CloseWithTimeout(50 * time.Millisecond); err == nil {
+		t.Fatal("close with timeout: want an error for the exceeded grace period")
+	}
+	if elapsed := time.Since(start); elapsed > shutdownUnwind {
+		t.Fatalf("close with timeout took %s", elapsed)
+	}
+	if n := db.lateWrites.Load(); n > 0 {
+		t.Fatalf("%d execution writes after storage was closed", n)
+	}
+	select {
+	case <-o.done:
+	default:
+		t.Fatal("queue loop still running after close")
+	}
+}
+
+// TestRequeueInterruptedOnRestart cancels one job and interrupts another by shutdown,
+// then restarts on the same BoltDB with WithRequeueInterrupted
+// Only the shutdown's cancellation is undone, the cancelled job stays CANCELLED
+func TestRequeueInterruptedOnRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	o, err := New(db, 2)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	started := make(chan struct{}, 2)
+	o.RegisterFunction("wait", blockingFunction(started, nil, nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "wait", Tasks: []*models.Task{{ID: "a", FunctionName: "wait"}}})
+	cancelled := enqueue(t, o, "wait", nil)
+	interrupted := enqueue(t, o, "wait", nil)
+	<-started
+	<-started
+
+	if err := o.CancelJob(cancelled); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	waitForStatus(t, o, cancelled, models.JobStatusCancelled)
+	if err := o.CloseWithTimeout(10 * time.Millisecond); err == nil {
+		t.Fatal("close with timeout: want an error for the exceeded grace period")
+	}
+
+	db, err = storage.NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	restart := time.Now()
+	restarted := startTestOrchestrator(t, db, 1, WithRequeueInterrupted(), WithHoldMissingFunctions(testTimeout))
+	restarted.RegisterFunction("wait", blockingFunction(nil, closedChannel(), nil))
+	// Resuming would keep the original QueuedAt, requeuing stamps it again
+	if je := waitForFinish(t, restarted, interrupted); je.Status != models.JobStatusCompleted || je.QueuedAt.Before(restart) {
+		t.Errorf("interrupted job %s queued at %v, want it requeued after the restart and COMPLETED", je.Status, je.QueuedAt)
+	}
+	if je := execution(t, restarted, cancelled); je.Status != models.JobStatusCancelled {
+		t.Errorf("cancelled job %s, want it to stay CANCELLED", je.Status)
+	}
+}
@@ -0,0 +1,86 @@
+// pause.go lets operators pause and resume job processing
+// A paused orchestrator takes no jobs off the queue, and optionally holds
+// running jobs in the PAUSED state before their next task until resumed
+package orchestrator
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// pauseGate tracks whether processing is paused
+// Waiters block on the resumed channel, which is closed by resume
+type pauseGate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // Closed on resume, nil while not paused
+	hold    bool          // Running jobs stop at their next task boundary
+}
+
+// Pause stops taking jobs off the queue
+// With holdRunning, running jobs also stop before their next task and wait
+// in the PAUSED state, the task running at the time is allowed to finish
+func (o *Orchestrator) Pause(holdRunning bool) {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+	g.hold = holdRunning
+}
+
+// Resume continues taking jobs off the queue and releases held jobs
+func (o *Orchestrator) Resume() {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+	g.hold = false
+}
+
+// Paused reports whether processing is paused
+func (o *Orchestrator) Paused() bool {
+	o.pause.mu.Lock()
+	defer o.pause.mu.Unlock()
+	return o.pause.resumed != nil
+}
+
+// pausedUntil returns a channel closed on resume, nil while not paused
+// With forJobs set, only pauses that hold running jobs are reported
+func (o *Orchestrator) pausedUntil(forJobs bool) <-chan struct{} {
+	g := &o.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if forJobs && !g.hold {
+		return nil
+	}
+	return g.resumed
+}
+
+// holdWhilePaused keeps a job at a task boundary while running jobs are held
+// The job is PAUSED in storage meanwhile and RUNNING again once resumed or its
+// context ends, which the caller then handles as usual
+// A job a sibling task failed meanwhile keeps its status
+// Returns ErrShutdown if the orchestrator shuts down first, leaving the job for recovery
+func (o *Orchestrator) holdWhilePaused(ctx context.Context, run *jobRun) error {
+	resumed := o.pausedUntil(true)
+	if resumed == nil {
+		return nil
+	}
+
+	run.transition(models.JobStatusRunning, models.JobStatusPaused)
+	defer run.transition(models.JobStatusPaused, models.JobStatusRunning)
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return nil
+	case <-o.closing:
+		return ErrShutdown
+	}
+}
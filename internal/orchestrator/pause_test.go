@@ -0,0 +1,91 @@
+// pause_test.go tests pausing and resuming running jobs
+// Covers jobs held between tasks and siblings failing while a task is held
+// Uses blocking task functions to hold jobs at known points
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestPauseHeldTaskDoesNotRunAfterSiblingFails holds a task by pausing while
+// a concurrently running sibling fails the job fast
+// The held task must not run on resume and the job must finish FAILED
+func TestPauseHeldTaskDoesNotRunAfterSiblingFails(t *testing.T) {
+	o := newTestOrchestrator(t, 2)
+
+	failStarted, releaseFail := make(chan struct{}, 1), make(chan struct{})
+	firstStarted, releaseFirst := make(chan struct{}, 1), make(chan struct{})
+	heldRan := make(chan struct{}, 1)
+	o.RegisterFunction("fail", blockingFunction(failStarted, releaseFail, errors.New("boom")))
+	o.RegisterFunction("first", blockingFunction(firstStarted, releaseFirst, nil))
+	o.RegisterFunction("held", blockingFunction(heldRan, closedChannel(), nil))
+
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "pause-dag",
+		Strategy: models.StrategyDAG,
+		Tasks: []*models.Task{
+			{ID: "fail", FunctionName: "fail"},
+			{ID: "first", FunctionName: "first"},
+			{ID: "held", FunctionName: "held", DependsOn: []string{"first"}},
+		},
+	})
+	id := enqueue(t, o, "pause-dag", nil)
+	<-failStarted
+	<-firstStarted
+
+	// Hold the dependent task at its start, then fail its sibling
+	o.Pause(true)
+	close(releaseFirst)
+	waitForStatus(t, o, id, models.JobStatusPaused)
+	close(releaseFail)
+	waitForStatus(t, o, id, models.JobStatusFailed)
+	o.Resume()
+
+	je := waitForFinish(t, o, id)
+	if je.Status != models.JobStatusFailed {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusFailed)
+	}
+	if status := je.TaskStatuses["held"]; status == models.TaskStatusCompleted || status == models.TaskStatusRunning {
+		t.Errorf("held task status = %s, want it never started", status)
+	}
+	select {
+	case <-heldRan:
+		t.Error("held task ran after its sibling failed the job")
+	default:
+	}
+}
+
+// TestPauseHoldsAndResumesRunningJob pauses a running job between tasks
+// and checks it completes after resuming
+func TestPauseHoldsAndResumesRunningJob(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	o.RegisterFunction("next", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "pause-seq",
+		Tasks: []*models.Task{
+			{ID: "block", FunctionName: "block"},
+			{ID: "next", FunctionName: "next"},
+		},
+	})
+	id := enqueue(t, o, "pause-seq", nil)
+	<-started
+
+	o.Pause(true)
+	close(release)
+	je := waitForStatus(t, o, id, models.JobStatusPaused)
+	if je.TaskStatuses["next"] != "" {
+		t.Fatalf("next task status = %s while paused, want it not started", je.TaskStatuses["next"])
+	}
+
+	o.Resume()
+	je = waitForFinish(t, o, id)
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
@@ -0,0 +1,120 @@
+// persist.go retries writes of execution state through short storage outages
+// A job whose state still can't be written is stopped instead of running on with
+// state that exists only in memory, and queued again once storage answers
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// persistAttempts is how often a state write is tried before storage counts as unavailable
+const persistAttempts = 5
+
+// persistBackoff is the wait after the first failed write, doubling after each further failure
+// With persistAttempts it rides out outages of about one and a half seconds
+const persistBackoff = 100 * time.Millisecond
+
+// persist runs a state write, retrying failures with backoff
+// what describes the write in log messages, e.g. "update task status to running"
+// Returns an error wrapping ErrStorageUnavailable once all attempts failed
+func persist(what string, write func() error) error {
+	delay := persistBackoff
+	var err error
+	for attempt := 1; attempt <= persistAttempts; attempt++ {
+		if err = write(); err == nil {
+			return nil
+		}
+		if attempt < persistAttempts {
+			log.Printf("Failed to %s, retrying in %s: %v", what, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("%w: failed to %s after %d attempts: %v", ErrStorageUnavailable, what, persistAttempts, err)
+}
+
+// interruption returns why a job must stop and be left for recovery, nil if it needn't
+// Covers forced shutdown, state that couldn't be persisted and leases taken over
+func interruption(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	switch {
+	case errors.Is(cause, ErrShutdown):
+		return ErrShutdown
+	case errors.Is(cause, ErrLeaseLost):
+		return ErrLeaseLost
+	case errors.Is(cause, ErrStorageUnavailable):
+		return cause
+	}
+	return nil
+}
+
+// interrupted reports whether a task error means the job is left for recovery
+// Such errors take precedence over task failures so the job isn't failed
+func interrupted(err error) bool {
+	return errors.Is(err, ErrShutdown) || errors.Is(err, ErrStorageUnavailable) || errors.Is(err, ErrLeaseLost)
+}
+
+// defaultStrandRetry is how often jobs stopped by unavailable storage are requeued
+const defaultStrandRetry = 5 * time.Second
+
+// strand records a job stopped because its state couldn't be persisted
+// It is queued again by requeueStranded once storage answers
+func (o *Orchestrator) strand(executionID string) {
+	o.stranded.Store(executionID, struct{}{})
+}
+
+// requeueStranded periodically queues stranded jobs again until shutdown
+// Jobs still stranded at shutdown are left to the recovery on the next start
+func (o *Orchestrator) requeueStranded() {
+	ticker := time.NewTicker(o.strandRetry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.closing:
+			return
+		case <-ticker.C:
+			o.stranded.Range(func(key, _ interface{}) bool {
+				id := key.(string)
+				if err := o.requeueStrandedJob(id); err != nil {
+					log.Printf("Failed to requeue job %s stopped by unavailable storage: %v", id, err)
+					return false
+				}
+				o.stranded.Delete(id)
+				return true
+			})
+		}
+	}
+}
+
+// requeueStrandedJob queues a stranded job again from the state storage has of it
+// Jobs that finished or are executing again meanwhile are left alone
+func (o *Orchestrator) requeueStrandedJob(id string) error {
+	if o.inFlight(id) {
+		return nil
+	}
+	je, err := o.db.GetJobExecution(id)
+	if err != nil {
+		return err
+	}
+	switch je.Status {
+	case models.JobStatusRunning, models.JobStatusPaused:
+		err = o.requeueExecution(je)
+	case models.JobStatusQueued:
+		// Stopped before its start was persisted
+		err = o.enqueue(id)
+		if errors.Is(err, ErrAlreadyQueued) {
+			err = nil
+		}
+	}
+	if err == nil {
+		log.Printf("Requeued job %s stopped by unavailable storage", id)
+	}
+	return err
+}
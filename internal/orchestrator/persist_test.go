@@ -0,0 +1,193 @@
+// persist_test.go tests running jobs through storage outages
+// A flaky storage stub fails writes for a number of attempts or until it is restored
+// Covers riding out short outages and requeuing jobs stopped by longer ones
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// errFlaky is returned by flakyDB while it fails
+var errFlaky = errors.New("storage unavailable")
+
+// flakyDB wraps a DB and fails execution writes and reads on demand
+// Reads fail only while the storage is down, so a job can still be looked up
+// between failing writes of a short outage
+type flakyDB struct {
+	storage.DB
+	mu       sync.Mutex
+	failures int  // Number of upcoming writes to fail
+	down     bool // Fail all writes and execution reads until restored
+}
+
+// failNext makes the next n execution writes fail
+func (f *flakyDB) failNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = n
+}
+
+// setDown fails all execution writes and reads while down is set
+func (f *flakyDB) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+// failWrite reports whether the next write fails, consuming a counted failure
+func (f *flakyDB) failWrite() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return true
+	}
+	if f.failures > 0 {
+		f.failures--
+		return true
+	}
+	return false
+}
+
+// isDown reports whether the storage is down
+func (f *flakyDB) isDown() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.down
+}
+
+// UpdateJobExecution fails while writes fail
+func (f *flakyDB) UpdateJobExecution(je *models.JobExecution) error {
+	if f.failWrite() {
+		return errFlaky
+	}
+	return f.DB.UpdateJobExecution(je)
+}
+
+// UpdateTaskStatus fails while writes fail
+func (f *flakyDB) UpdateTaskStatus(executionID, taskID string, status models.TaskStatus) error {
+	if f.failWrite() {
+		return errFlaky
+	}
+	return f.DB.UpdateTaskStatus(executionID, taskID, status)
+}
+
+// GetJobExecution fails while the storage is down
+func (f *flakyDB) GetJobExecution(id string) (*models.JobExecution, error) {
+	if f.isDown() {
+		return nil, errFlaky
+	}
+	return f.DB.GetJobExecution(id)
+}
+
+// newFlakyOrchestrator starts an orchestrator on a flaky BoltDB
+// Stranded jobs are retried quickly so tests don't wait for the default interval
+func newFlakyOrchestrator(t *testing.T) (*Orchestrator, *flakyDB) {
+	t.Helper()
+	db := &flakyDB{DB: openTestDB(t)}
+	o := startTestOrchestrator(t, db, 2, func(o *Orchestrator) { o.strandRetry = 20 * time.Millisecond })
+	return o, db
+}
+
+// TestPersistRidesOutShortOutage fails a few writes of a running job
+// The job must complete as the writes succeed within the retry budget
+func TestPersistRidesOutShortOutage(t *testing.T) {
+	o, db := newFlakyOrchestrator(t)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "flaky", Tasks: []*models.Task{{ID: "a", FunctionName: "block"}}})
+
+	id := enqueue(t, o, "flaky", nil)
+	<-started
+	db.failNext(persistAttempts - 1)
+	close(release)
+
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
+
+// TestPersistRequeuesJobAfterOutage keeps storage down past the retry budget
+// The job must stop, stay unfinished, and run again once storage is restored
+// without restarting the orchestrator
+func TestPersistRequeuesJobAfterOutage(t *testing.T) {
+	o, db := newFlakyOrchestrator(t)
+	var runsMu sync.Mutex
+	runs := 0
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	o.RegisterFunction("block", func(ctx context.Context, data map[string]interface{}) error {
+		runsMu.Lock()
+		runs++
+		runsMu.Unlock()
+		return blockingFunction(started, release, nil)(ctx, data)
+	})
+	registerDefinition(t, o, &models.JobDefinition{ID: "outage", Tasks: []*models.Task{{ID: "a", FunctionName: "block"}}})
+
+	id := enqueue(t, o, "outage", nil)
+	<-started
+	db.setDown(true)
+	close(release)
+
+	// The job stops once its retries are used up and is recorded as stranded
+	waitFor(t, "job to be stranded", func() bool {
+		_, ok := o.stranded.Load(id)
+		return ok && !o.inFlight(id)
+	})
+	db.setDown(false)
+
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+	runsMu.Lock()
+	defer runsMu.Unlock()
+	if runs != 2 {
+		t.Fatalf("task ran %d times, want 2, the stopped run and the requeued one", runs)
+	}
+}
+
+// TestPersistReleasesRunDuringBackoff checks that a write waiting to be retried
+// doesn't block other tasks of the same run from reading or changing its state
+func TestPersistReleasesRunDuringBackoff(t *testing.T) {
+	db := &flakyDB{DB: openTestDB(t)}
+	je := &models.JobExecution{ID: newID("exec"), Status: models.JobStatusRunning}
+	if err := db.StoreJobExecution(je); err != nil {
+		t.Fatalf("store execution: %v", err)
+	}
+	jd := &models.JobDefinition{Tasks: []*models.Task{{ID: "a"}, {ID: "b"}}}
+	run := newJobRun(db, NewEventBus(), je, jd, nil)
+
+	db.failNext(2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run.completeTask("a", map[string]interface{}{"out": 1})
+	}()
+
+	// The first retry waits persistBackoff, the run must be usable meanwhile
+	time.Sleep(persistBackoff / 4)
+	checked := make(chan struct{})
+	go func() {
+		defer close(checked)
+		run.startTask("b")
+	}()
+	select {
+	case <-checked:
+	case <-time.After(persistBackoff / 2):
+		t.Fatal("run was locked while a write waited to be retried")
+	}
+	<-done
+
+	stored, err := db.GetJobExecution(je.ID)
+	if err != nil {
+		t.Fatalf("get execution: %v", err)
+	}
+	if stored.TaskStatuses["a"] != models.TaskStatusCompleted || stored.TaskStatuses["b"] != models.TaskStatusRunning {
+		t.Fatalf("stored task statuses = %v, want a completed and b running", stored.TaskStatuses)
+	}
+}
@@ -0,0 +1,122 @@
+// pool.go routes executions to labeled worker pools
+// Definitions with a pool label only run on the pool configured under that label
+// Each pool has its own size, so a busy pool never holds up the others
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// defaultPoolName names the pool of definitions without a pool label in worker names
+const defaultPoolName = "default"
+
+// workerPool limits how many jobs run at once and numbers the workers running them
+// Numbers let executions with the same affinity key prefer the same worker
+type workerPool struct {
+	label string        // Pool label, empty for the default pool
+	slots chan struct{} // Holds a token per busy worker
+	mu    sync.Mutex
+	busy  []bool // Busy workers by number
+}
+
+// newWorkerPool creates a pool of size workers
+func newWorkerPool(label string, size int) *workerPool {
+	return &workerPool{label: label, slots: make(chan struct{}, size), busy: make([]bool, size)}
+}
+
+// take marks an idle worker busy and returns its number
+// Must be called holding a slot, which guarantees an idle worker
+// Takes the preferred worker if it is idle, otherwise the lowest numbered idle
+// worker not avoided, falling back to any idle worker
+func (p *workerPool) take(preferred int, avoid func(worker int) bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if preferred >= 0 && preferred < len(p.busy) && !p.busy[preferred] {
+		p.busy[preferred] = true
+		return preferred
+	}
+	fallback := -1
+	for i, busy := range p.busy {
+		if busy {
+			continue
+		}
+		if !avoid(i) {
+			p.busy[i] = true
+			return i
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	if fallback >= 0 {
+		p.busy[fallback] = true
+	}
+	return fallback
+}
+
+// put marks a worker idle and frees its slot
+func (p *workerPool) put(worker int) {
+	p.mu.Lock()
+	if worker >= 0 {
+		p.busy[worker] = false
+	}
+	p.mu.Unlock()
+	<-p.slots
+}
+
+// name returns the pool label, or defaultPoolName for the default pool
+func (p *workerPool) name() string {
+	if p.label == "" {
+		return defaultPoolName
+	}
+	return p.label
+}
+
+// workerName identifies a worker of the pool, e.g. "gpu/0" or "default/3"
+func (p *workerPool) workerName(worker int) string {
+	return fmt.Sprintf("%s/%d", p.name(), worker)
+}
+
+// WithPool adds a worker pool of size slots running definitions labeled with label
+// Definitions without a label keep running on the default pool sized in New
+func WithPool(label string, size int) Option {
+	return func(o *Orchestrator) {
+		if o.pools == nil {
+			o.pools = make(map[string]*workerPool)
+		}
+		o.pools[label] = newWorkerPool(label, size)
+	}
+}
+
+// poolFor returns the labeled worker pool an execution must run on
+// Returns nil for definitions without a label, which use the default pool,
+// and fails the execution if its label has no configured pool
+func (o *Orchestrator) poolFor(je *models.JobExecution, jd *models.JobDefinition) (*workerPool, error) {
+	if jd == nil || jd.PoolLabel == "" {
+		return nil, nil
+	}
+
+	pool, ok := o.pools[jd.PoolLabel]
+	if !ok {
+		return nil, o.failBeforeStart(je, jd, fmt.Errorf("no worker pool labeled %s", jd.PoolLabel))
+	}
+	return pool, nil
+}
+
+// acquireSlot waits for a free slot of a pool
+// On shutdown the execution is queued again instead, returns false in that case
+func (o *Orchestrator) acquireSlot(executionID string, pool *workerPool) bool {
+	select {
+	case pool.slots <- struct{}{}:
+		return true
+	case <-o.closing:
+		if err := o.enqueue(executionID); err != nil {
+			log.Printf("Failed to requeue job %s waiting for a worker: %v", executionID, err)
+		}
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+// pool_test.go tests routing executions of labeled definitions to their worker pool
+// Labeled jobs run only on the pool of their label, and a busy pool holds up
+// neither the default pool nor other labeled pools
+package orchestrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestPoolLabelRoutesExecutions runs labeled and unlabeled jobs and checks their workers
+func TestPoolLabelRoutesExecutions(t *testing.T) {
+	o := newTestOrchestrator(t, 2, WithPool("gpu", 2))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "train", PoolLabel: "gpu", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}})
+
+	for definitionID, pool := range map[string]string{"train": "gpu/", "report": "default/"} {
+		for i := 0; i < 4; i++ {
+			je := waitForFinish(t, o, enqueue(t, o, definitionID, nil))
+			if je.Status != models.JobStatusCompleted {
+				t.Fatalf("%s: status = %s, want COMPLETED", definitionID, je.Status)
+			}
+			if !strings.HasPrefix(je.Worker, pool) {
+				t.Errorf("%s ran on worker %q, want one of pool %s", definitionID, je.Worker, strings.TrimSuffix(pool, "/"))
+			}
+		}
+	}
+}
+
+// TestPoolsRunIndependently fills the single worker of the gpu pool
+// A second gpu job must wait for it while unlabeled jobs keep running
+func TestPoolsRunIndependently(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithPool("gpu", 1))
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	o.RegisterFunction("train", blockingFunction(started, release, nil))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "train", PoolLabel: "gpu", Tasks: []*models.Task{{ID: "a", FunctionName: "train"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}})
+
+	first := enqueue(t, o, "train", nil)
+	<-started
+	second := enqueue(t, o, "train", nil)
+
+	// The default pool is free, unlabeled jobs run past the waiting gpu job
+	for i := 0; i < 3; i++ {
+		if je := waitForFinish(t, o, enqueue(t, o, "report", nil)); je.Status != models.JobStatusCompleted || !strings.HasPrefix(je.Worker, "default/") {
+			t.Fatalf("report %s on worker %q, want COMPLETED on the default pool", je.Status, je.Worker)
+		}
+	}
+	select {
+	case <-started:
+		t.Fatal("a second gpu job started while the only gpu worker was busy")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	for _, id := range []string{first, second} {
+		if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted || je.Worker != "gpu/0" {
+			t.Errorf("train %s on worker %q, want COMPLETED on gpu/0", je.Status, je.Worker)
+		}
+	}
+}
+
+// TestPoolLabelRejectsUnknown refuses definitions pinned to a pool that isn't configured
+func TestPoolLabelRejectsUnknown(t *testing.T) {
+	o := newTestOrchestrator(t, 1, WithPool("gpu", 1))
+	err := o.RegisterJobDefinition(&models.JobDefinition{ID: "train", PoolLabel: "tpu", Tasks: []*models.Task{{ID: "a", FunctionName: "f"}}})
+	if !errors.Is(err, ErrInvalidDefinition) {
+		t.Errorf("register: %v, want ErrInvalidDefinition", err)
+	}
+}
@@ -0,0 +1,57 @@
+// provider_test.go tests merging the task functions of several providers
+// Distinct functions are combined into one map, a name contributed twice
+// or a failing provider stops the merge
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// provider returns a provider of functions producing their own name as output
+func provider(names ...string) FunctionProvider {
+	return FunctionProviderFunc(func() (map[string]OutputTaskFunction, error) {
+		functions := make(map[string]OutputTaskFunction, len(names))
+		for _, name := range names {
+			functions[name] = func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"ran": name}, nil
+			}
+		}
+		return functions, nil
+	})
+}
+
+// TestMergeFunctionProviders merges two providers with distinct functions
+func TestMergeFunctionProviders(t *testing.T) {
+	merged, err := MergeFunctionProviders(provider("resize", "crop"), provider("notify"))
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if names := slices.Sorted(maps.Keys(merged)); !slices.Equal(names, []string{"crop", "notify", "resize"}) {
+		t.Fatalf("merged functions = %v, want crop, notify, and resize", names)
+	}
+	for name, fn := range merged {
+		if output, err := fn(context.Background(), nil); err != nil || output["ran"] != name {
+			t.Errorf("function %s = %v, %v, want its own implementation", name, output, err)
+		}
+	}
+}
+
+// TestMergeFunctionProvidersRejects checks overlapping names and failing providers
+func TestMergeFunctionProvidersRejects(t *testing.T) {
+	if _, err := MergeFunctionProviders(provider("resize", "crop"), provider("crop")); err == nil || !strings.Contains(err.Error(), "crop") {
+		t.Errorf("merge with crop provided twice = %v, want an error naming crop", err)
+	}
+
+	unavailable := errors.New("registry unavailable")
+	failing := FunctionProviderFunc(func() (map[string]OutputTaskFunction, error) {
+		return nil, unavailable
+	})
+	if _, err := MergeFunctionProviders(provider("resize"), failing); !errors.Is(err, unavailable) {
+		t.Errorf("merge with a failing provider = %v, want its error", err)
+	}
+}
@@ -0,0 +1,67 @@
+// publisher.go publishes execution outcomes to external systems
+// Publishers are pluggable, e.g. a message broker, and default to a no-op
+// Delivery is at least once: failed publishes are retried with backoff
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/metrics"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// outcomePublishAttempts bounds the attempts to publish one outcome
+const outcomePublishAttempts = 5
+
+// Publisher delivers execution outcomes to an external system
+// Publish may be called more than once for the same outcome
+type Publisher interface {
+	Publish(ctx context.Context, outcome models.ExecutionOutcome) error
+}
+
+// noopPublisher discards outcomes, used when no publisher is configured
+type noopPublisher struct{}
+
+func (noopPublisher) Publish(context.Context, models.ExecutionOutcome) error { return nil }
+
+// SetPublisher sets the publisher that receives execution outcomes
+// Should be called before jobs are enqueued
+func (o *Orchestrator) SetPublisher(p Publisher) {
+	o.publisher = p
+}
+
+// publishOutcome publishes the outcome of a finished execution in the background
+// Retries with exponential backoff so a broker outage doesn't lose the outcome,
+// which may then be delivered more than once
+func (o *Orchestrator) publishOutcome(je *models.JobExecution) {
+	// Every finished execution passes through here, so count it as well
+	metrics.JobsFinished.WithLabelValues(string(je.Status)).Inc()
+	metrics.JobDuration.WithLabelValues(string(je.Status)).Observe(je.EndTime.Sub(je.StartTime).Seconds())
+
+	outcome := models.ExecutionOutcome{
+		ExecutionID:  je.ID,
+		DefinitionID: je.DefinitionID,
+		Status:       je.Status,
+		Error:        je.Error,
+		StartTime:    je.StartTime,
+		EndTime:      je.EndTime,
+	}
+
+	go func() {
+		var err error
+		for attempt := 0; attempt < outcomePublishAttempts; attempt++ {
+			if err = o.publisher.Publish(o.ctx, outcome); err == nil {
+				return
+			}
+			select {
+			case <-o.ctx.Done():
+				log.Printf("Publishing outcome of %s interrupted by shutdown: %v", outcome.ExecutionID, err)
+				return
+			case <-time.After(time.Duration(1<<attempt) * time.Second):
+			}
+		}
+		log.Printf("Failed to publish outcome of %s after %d attempts: %v", outcome.ExecutionID, outcomePublishAttempts, err)
+	}()
+}
@@ -0,0 +1,106 @@
+// publisher_test.go tests publishing execution outcomes
+// An in-memory publisher captures the outcomes and can fail its first publishes
+// to check outcomes are retried rather than lost
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// memoryPublisher captures published outcomes, failing the first failures publishes
+type memoryPublisher struct {
+	mu       sync.Mutex
+	failures int                       // Number of upcoming publishes to fail
+	attempts int                       // Publishes attempted, including failed ones
+	outcomes []models.ExecutionOutcome // Outcomes published successfully
+}
+
+// Publish records the outcome unless the publish is set to fail
+func (p *memoryPublisher) Publish(ctx context.Context, outcome models.ExecutionOutcome) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.outcomes = append(p.outcomes, outcome)
+	return nil
+}
+
+// published returns the outcome published for the execution, if any
+func (p *memoryPublisher) published(id string) (models.ExecutionOutcome, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, outcome := range p.outcomes {
+		if outcome.ExecutionID == id {
+			return outcome, true
+		}
+	}
+	return models.ExecutionOutcome{}, false
+}
+
+// waitForOutcome waits until the outcome of the execution was published
+func waitForOutcome(t *testing.T, p *memoryPublisher, id string) models.ExecutionOutcome {
+	t.Helper()
+	var outcome models.ExecutionOutcome
+	waitFor(t, "outcome of "+id, func() bool {
+		var ok bool
+		outcome, ok = p.published(id)
+		return ok
+	})
+	return outcome
+}
+
+// TestPublishOutcomes runs a completing and a failing execution
+// Each outcome is published once with the execution's final status
+func TestPublishOutcomes(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	publisher := &memoryPublisher{}
+	o.SetPublisher(publisher)
+	o.RegisterFunction("ok", blockingFunction(nil, closedChannel(), nil))
+	o.RegisterFunction("fail", blockingFunction(nil, closedChannel(), errors.New("boom")))
+	registerDefinition(t, o, &models.JobDefinition{ID: "good", Tasks: []*models.Task{{ID: "ok", FunctionName: "ok"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "bad", Tasks: []*models.Task{{ID: "fail", FunctionName: "fail"}}})
+
+	good, bad := enqueue(t, o, "good", nil), enqueue(t, o, "bad", nil)
+	if outcome := waitForOutcome(t, publisher, good); outcome.Status != models.JobStatusCompleted ||
+		outcome.DefinitionID != "good" || outcome.Error != "" || outcome.EndTime.IsZero() {
+		t.Errorf("outcome of good = %+v, want COMPLETED without an error", outcome)
+	}
+	je := waitForFinish(t, o, bad)
+	if outcome := waitForOutcome(t, publisher, bad); outcome.Status != models.JobStatusFailed || outcome.Error != je.Error {
+		t.Errorf("outcome of bad = %+v, want FAILED with error %q", outcome, je.Error)
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if len(publisher.outcomes) != 2 {
+		t.Errorf("published %d outcomes, want one per execution", len(publisher.outcomes))
+	}
+}
+
+// TestPublishOutcomeRetried fails the first publish of an outcome
+// The outcome must still arrive through a retry
+func TestPublishOutcomeRetried(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	publisher := &memoryPublisher{failures: 1}
+	o.SetPublisher(publisher)
+	o.RegisterFunction("ok", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "good", Tasks: []*models.Task{{ID: "ok", FunctionName: "ok"}}})
+
+	id := enqueue(t, o, "good", nil)
+	if outcome := waitForOutcome(t, publisher, id); outcome.Status != models.JobStatusCompleted {
+		t.Errorf("outcome = %+v, want COMPLETED", outcome)
+	}
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if publisher.attempts != 2 {
+		t.Errorf("%d publish attempts, want the failed one and its retry", publisher.attempts)
+	}
+}
@@ -0,0 +1,106 @@
+// reaper.go cancels executions that exceeded their definition's maximum age
+// Enforces a hard SLA on queued and running executions
+// Runs in the background for the lifetime of the orchestrator
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// reapInterval is how often stale executions are looked for
+const reapInterval = 10 * time.Second
+
+// reapStaleExecutions periodically cancels executions past their maximum age
+// Stops once the orchestrator is shut down
+func (o *Orchestrator) reapStaleExecutions() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.reapOnce(time.Now())
+		}
+	}
+}
+
+// reapOnce cancels every queued or running execution older than its maximum age
+func (o *Orchestrator) reapOnce(now time.Time) {
+	queued, err := o.db.GetQueuedJobs()
+	if err != nil {
+		log.Printf("Reaper failed to list queued jobs: %v", err)
+		return
+	}
+	running, err := o.db.GetRunningJobs()
+	if err != nil {
+		log.Printf("Reaper failed to list running jobs: %v", err)
+		return
+	}
+
+	for _, id := range append(queued, running...) {
+		je, err := o.db.GetJobExecution(id)
+		if err != nil {
+			continue
+		}
+		jd, err := o.definitionFor(je)
+		if err != nil || jd.MaxAgeSeconds <= 0 {
+			continue
+		}
+
+		// Executions stored before QueuedAt existed fall back to their start time
+		queuedAt := je.QueuedAt
+		if queuedAt.IsZero() {
+			queuedAt = je.StartTime
+		}
+		maxAge := time.Duration(jd.MaxAgeSeconds) * time.Second
+		if now.Sub(queuedAt) <= maxAge {
+			continue
+		}
+
+		cause := fmt.Errorf("%w: exceeded maximum age of %s", ErrCancelled, maxAge)
+		if _, err := o.cancelExecution(je, cause); err != nil {
+			log.Printf("Reaper failed to cancel job %s: %v", id, err)
+		}
+	}
+}
+
+// cancelExecution cancels a queued or running execution with the given cause
+// Running executions stop at their next task boundary or when their task
+// observes the context, queued ones are removed from the queue directly
+// Reports whether the execution was queued or running and got cancelled
+func (o *Orchestrator) cancelExecution(je *models.JobExecution, cause error) (bool, error) {
+	if cancel, ok := o.cancels.Load(je.ID); ok {
+		cancel.(context.CancelCauseFunc)(cause)
+		return true, nil
+	}
+	if je.Status != models.JobStatusQueued {
+		return false, nil
+	}
+
+	if err := o.db.RemoveFromQueue(je.ID); err != nil {
+		return false, err
+	}
+	je.Status = models.JobStatusCancelled
+	je.Error = cause.Error()
+	je.EndTime = time.Now()
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		return false, err
+	}
+	o.missingSince.Delete(je.ID)
+	publishStateChange(o.events, je)
+	o.publishOutcome(je)
+	return true, nil
+}
+
+// cancelled reports whether ctx was cancelled through cancelExecution
+func cancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrCancelled)
+}
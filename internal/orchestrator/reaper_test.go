@@ -0,0 +1,64 @@
+// reaper_test.go tests cancelling executions past their definition's maximum age
+// Queued executions are held behind a blocked worker while the reaper runs,
+// only the one queued longer than the maximum age may be cancelled
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestReaperCancelsStaleQueued ages one of two queued executions past the limit
+func TestReaperCancelsStaleQueued(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	var runs atomic.Int32
+	o.RegisterFunction("send", func(ctx context.Context, data map[string]interface{}) error {
+		runs.Add(1)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{ID: "busy", Tasks: []*models.Task{{ID: "block", FunctionName: "block"}}})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:            "report",
+		MaxAgeSeconds: 60,
+		Tasks:         []*models.Task{{ID: "send", FunctionName: "send"}},
+	})
+
+	busy := enqueue(t, o, "busy", nil)
+	<-started
+	stale, fresh := enqueue(t, o, "report", nil), enqueue(t, o, "report", nil)
+
+	// Backdate the stale execution as if it waited two minutes
+	je := execution(t, o, stale)
+	je.QueuedAt = time.Now().Add(-2 * time.Minute)
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		t.Fatalf("backdate execution: %v", err)
+	}
+	o.reapOnce(time.Now())
+
+	je = execution(t, o, stale)
+	if je.Status != models.JobStatusCancelled || !strings.Contains(je.Error, "exceeded maximum age") {
+		t.Errorf("stale execution status = %s, error = %q, want CANCELLED for its age", je.Status, je.Error)
+	}
+	if je := execution(t, o, fresh); je.Status != models.JobStatusQueued {
+		t.Errorf("fresh execution status = %s, want it left QUEUED", je.Status)
+	}
+	if je := execution(t, o, busy); je.Status != models.JobStatusRunning {
+		t.Errorf("execution without a maximum age status = %s, want it left RUNNING", je.Status)
+	}
+
+	close(release)
+	waitForFinish(t, o, busy)
+	if je := waitForFinish(t, o, fresh); je.Status != models.JobStatusCompleted {
+		t.Errorf("fresh execution status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("report task ran %d times, want only for the fresh execution", n)
+	}
+}
@@ -0,0 +1,202 @@
+// reconcile.go compares in-memory state with persisted state
+// Reports jobs that are lost, stuck, or tracked twice after a recovery
+// Optionally repairs each discrepancy it finds
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// Reconcile checks running jobs and the queue against stored executions
+// With fix set, repairs each discrepancy and records whether that worked
+// Best effort: jobs changing state during the check may be reported once
+func (o *Orchestrator) Reconcile(fix bool) (*models.ReconcileReport, error) {
+	report := &models.ReconcileReport{Discrepancies: []models.Discrepancy{}, Fix: fix}
+
+	// Load persisted state first, so jobs moving on during the check
+	// show up as tracked or queued rather than as lost
+	// Ad-hoc runs never go through the queue and are left out
+	statuses := make(map[string]models.JobStatus)
+	err := o.db.ForEachJobExecution(func(je *models.JobExecution) error {
+		// Delayed jobs wait outside the queue until they are due
+		if je.Status == models.JobStatusQueued && je.ScheduledAt.After(time.Now()) {
+			return nil
+		}
+		if !strings.HasPrefix(je.DefinitionID, adHocDefinitionPrefix) {
+			statuses[je.ID] = je.Status
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	queued, err := o.db.GetQueuedJobs()
+	if err != nil {
+		return nil, err
+	}
+	leased, err := o.db.LeasedExecutions()
+	if err != nil {
+		return nil, err
+	}
+	inQueue := make(map[string]bool, len(queued))
+	for _, id := range queued {
+		inQueue[id] = true
+	}
+
+	add := func(kind models.DiscrepancyKind, id, detail string, repair func() error) {
+		d := models.Discrepancy{Kind: kind, ExecutionID: id, Detail: detail}
+		if fix {
+			if err := repair(); err != nil {
+				d.FixError = err.Error()
+			} else {
+				d.Fixed = true
+			}
+		}
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	// Stored executions nobody is working on
+	// Executions under a live lease are being worked on by an instance sharing storage
+	for id, status := range statuses {
+		if o.inFlight(id) || leased[id] {
+			continue
+		}
+		switch {
+		case status == models.JobStatusRunning || status == models.JobStatusPaused:
+			add(models.DiscrepancyRunningNotTracked, id, fmt.Sprintf("execution is %s in storage but not executing", status),
+				func() error { return o.requeueOrphan(id) })
+		case status == models.JobStatusQueued && !inQueue[id]:
+			add(models.DiscrepancyQueuedNotInQueue, id, "execution is QUEUED in storage but missing from the queue",
+				func() error { return o.claimAndEnqueue(id) })
+		}
+	}
+
+	// Executing jobs whose stored execution isn't running
+	o.ongoingJobs.Range(func(key, value interface{}) bool {
+		id := key.(string)
+		status := statuses[id]
+		if status == models.JobStatusQueued {
+			// Probably started after storage was read, check again
+			if je, err := o.db.GetJobExecution(id); err == nil {
+				status = je.Status
+			}
+		}
+		if status != models.JobStatusRunning && status != models.JobStatusPaused {
+			add(models.DiscrepancyTrackedNotRunning, id, fmt.Sprintf("execution is executing but stored as %q", status),
+				func() error { o.ongoingJobs.Delete(id); return nil })
+		}
+		return true
+	})
+
+	// Queue entries that will never run
+	for _, id := range queued {
+		status, ok := statuses[id]
+		if ok && !status.Finished() {
+			continue
+		}
+		detail := fmt.Sprintf("queued execution is %s", status)
+		if !ok {
+			detail = "queued execution does not exist"
+		}
+		add(models.DiscrepancyQueuedFinished, id, detail, func() error { return o.db.RemoveFromQueue(id) })
+	}
+
+	return report, nil
+}
+
+// RequeueStuckRunning re-enqueues executions stored as RUNNING that nothing executes
+// Happens when a process died without the graceful shutdown recovery picking them up
+// Tasks that were running are reset, completed tasks are not run again
+// Ad-hoc runs are left alone, they never go through the queue and aren't tracked while running
+// So are executions another instance sharing storage holds a live lease on
+// Returns the IDs of the requeued executions
+func (o *Orchestrator) RequeueStuckRunning() ([]string, error) {
+	running, err := o.db.GetRunningJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	requeued := []string{}
+	for _, id := range running {
+		if o.inFlight(id) {
+			continue
+		}
+
+		// Re-read right before requeuing, the job may have finished meanwhile
+		je, err := o.db.GetJobExecution(id)
+		if err != nil {
+			return requeued, err
+		}
+		if (je.Status != models.JobStatusRunning && je.Status != models.JobStatusPaused) || o.inFlight(id) {
+			continue
+		}
+		if strings.HasPrefix(je.DefinitionID, adHocDefinitionPrefix) {
+			continue
+		}
+		claimed, err := o.db.ClaimExecution(id)
+		if err != nil {
+			return requeued, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := o.requeueExecution(je); err != nil {
+			return requeued, err
+		}
+		requeued = append(requeued, id)
+	}
+	return requeued, nil
+}
+
+// requeueOrphan queues an execution stored RUNNING that nothing executes as QUEUED again
+// Stored as QUEUED it isn't reported again while it waits for a worker
+// Returns errLeaseHeld if another instance holds a live lease on it
+func (o *Orchestrator) requeueOrphan(id string) error {
+	claimed, err := o.db.ClaimExecution(id)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errLeaseHeld
+	}
+
+	// Re-read after claiming, the job may have finished or started meanwhile
+	je, err := o.db.GetJobExecution(id)
+	if err != nil {
+		return err
+	}
+	if (je.Status != models.JobStatusRunning && je.Status != models.JobStatusPaused) || o.inFlight(id) {
+		return nil
+	}
+	return o.requeueExecution(je)
+}
+
+// requeueExecution queues an interrupted execution again
+// Tasks that were running are reset, completed tasks are not run again
+func (o *Orchestrator) requeueExecution(je *models.JobExecution) error {
+	for taskID, status := range je.TaskStatuses {
+		if status == models.TaskStatusRunning {
+			delete(je.TaskStatuses, taskID)
+		}
+	}
+	je.Status = models.JobStatusQueued
+	je.QueuedAt = time.Now()
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		return err
+	}
+	return o.enqueue(je.ID)
+}
+
+// inFlight reports whether a job was taken off the queue and is being handled
+func (o *Orchestrator) inFlight(id string) bool {
+	if _, ok := o.dispatched.Load(id); ok {
+		return true
+	}
+	_, ok := o.ongoingJobs.Load(id)
+	return ok
+}
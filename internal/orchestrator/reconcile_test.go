@@ -0,0 +1,158 @@
+// reconcile_test.go tests comparing in-memory state with persisted state
+// Covers reporting and repairing injected inconsistencies and requeuing executions
+// left RUNNING without anything executing them, ad-hoc runs must never be requeued
+package orchestrator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestRequeueStuckRunningSkipsAdHocRuns requeues while an ad-hoc run is in progress
+// The run is stored RUNNING but must not be queued and executed a second time
+func TestRequeueStuckRunningSkipsAdHocRuns(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("adhoc", blockingFunction(started, release, nil))
+
+	done := make(chan *models.JobExecution, 1)
+	go func() {
+		je, err := o.RunTask(context.Background(), "adhoc", nil)
+		if err != nil {
+			t.Errorf("run task: %v", err)
+		}
+		done <- je
+	}()
+	<-started
+
+	requeued, err := o.RequeueStuckRunning()
+	if err != nil {
+		t.Fatalf("requeue stuck running: %v", err)
+	}
+	if len(requeued) != 0 {
+		t.Fatalf("requeued %v, want ad-hoc run left alone", requeued)
+	}
+	queued, err := o.db.GetQueuedJobs()
+	if err != nil {
+		t.Fatalf("get queued jobs: %v", err)
+	}
+
+	close(release)
+	je := <-done
+	if slices.Contains(queued, je.ID) {
+		t.Fatalf("ad-hoc execution %s was queued", je.ID)
+	}
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", je.Status, models.JobStatusCompleted)
+	}
+}
+
+// TestRequeueStuckRunningRequeuesOrphans requeues an execution stored RUNNING
+// that nothing executes, resetting its running task
+func TestRequeueStuckRunningRequeuesOrphans(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "orphan",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}},
+	})
+
+	je := &models.JobExecution{
+		ID:           newID("exec"),
+		DefinitionID: "orphan",
+		Status:       models.JobStatusRunning,
+		StartTime:    time.Now(),
+		TaskStatuses: map[string]models.TaskStatus{"a": models.TaskStatusRunning},
+	}
+	if err := o.db.StoreJobExecution(je); err != nil {
+		t.Fatalf("store execution: %v", err)
+	}
+
+	requeued, err := o.RequeueStuckRunning()
+	if err != nil {
+		t.Fatalf("requeue stuck running: %v", err)
+	}
+	if !slices.Equal(requeued, []string{je.ID}) {
+		t.Fatalf("requeued %v, want [%s]", requeued, je.ID)
+	}
+	if got := waitForFinish(t, o, je.ID); got.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want %s", got.Status, models.JobStatusCompleted)
+	}
+}
+
+// TestReconcileReportsAndFixes injects one inconsistency of each kind while paused
+// A report without fix changes nothing, fixing repairs all and leaves nothing to report
+func TestReconcileReportsAndFixes(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.Pause(false)
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "report", Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}}})
+
+	for id, status := range map[string]models.JobStatus{
+		"orphan": models.JobStatusRunning,
+		"lost":   models.JobStatusQueued,
+		"done":   models.JobStatusCompleted,
+	} {
+		je := &models.JobExecution{ID: id, DefinitionID: "report", Status: status, StartTime: time.Now()}
+		if err := o.db.StoreJobExecution(je); err != nil {
+			t.Fatalf("store execution %s: %v", id, err)
+		}
+	}
+	if err := o.db.EnqueueJob("done"); err != nil {
+		t.Fatalf("enqueue finished execution: %v", err)
+	}
+	o.ongoingJobs.Store("ghost", struct{}{})
+
+	want := map[string]models.DiscrepancyKind{
+		"orphan": models.DiscrepancyRunningNotTracked,
+		"lost":   models.DiscrepancyQueuedNotInQueue,
+		"done":   models.DiscrepancyQueuedFinished,
+		"ghost":  models.DiscrepancyTrackedNotRunning,
+	}
+	check := func(fix bool) {
+		t.Helper()
+		report, err := o.Reconcile(fix)
+		if err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+		if len(report.Discrepancies) != len(want) {
+			t.Fatalf("discrepancies = %+v, want one for each of %v", report.Discrepancies, want)
+		}
+		for _, d := range report.Discrepancies {
+			if d.Kind != want[d.ExecutionID] || d.Fixed != fix || d.FixError != "" {
+				t.Errorf("discrepancy %+v, want %s fixed %v", d, want[d.ExecutionID], fix)
+			}
+		}
+	}
+	check(false)
+	check(false)
+	check(true)
+
+	report, err := o.Reconcile(false)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(report.Discrepancies) != 0 {
+		t.Errorf("discrepancies after fixing = %+v, want none", report.Discrepancies)
+	}
+	queued, err := o.db.GetQueuedJobs()
+	if err != nil {
+		t.Fatalf("get queued jobs: %v", err)
+	}
+	slices.Sort(queued)
+	if !slices.Equal(queued, []string{"lost", "orphan"}) {
+		t.Errorf("queue = %v, want lost and orphan only", queued)
+	}
+
+	o.Resume()
+	for _, id := range []string{"lost", "orphan"} {
+		if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+			t.Errorf("execution %s = %s, want COMPLETED", id, je.Status)
+		}
+	}
+}
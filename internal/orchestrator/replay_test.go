@@ -0,0 +1,88 @@
+// replay_test.go tests replaying finished executions
+// Covers replaying from the input an execution was enqueued with rather than its
+// final data, and the lineage recorded between the original and its replay
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestReplayUsesOriginalInput replays an execution whose task added outputs to its data
+// The replay must see the original input without the outputs and reference its parent
+func TestReplayUsesOriginalInput(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+
+	var mu sync.Mutex
+	var seen []map[string]interface{}
+	o.RegisterOutputFunction("enrich", func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		mu.Lock()
+		seen = append(seen, data)
+		mu.Unlock()
+		return map[string]interface{}{"total": 42.0}, nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "enrich",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "enrich"}},
+	})
+
+	original := waitForFinish(t, o, enqueue(t, o, "enrich", map[string]interface{}{"order": "7"}))
+	if original.Data["total"] != 42.0 {
+		t.Fatalf("original data = %v, want the task's output merged", original.Data)
+	}
+
+	id, err := o.ReplayJobExecution(original.ID)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	replay := waitForFinish(t, o, id)
+	if replay.ParentExecutionID != original.ID {
+		t.Errorf("parent = %q, want %q", replay.ParentExecutionID, original.ID)
+	}
+	if len(replay.Input) != 1 || replay.Input["order"] != "7" {
+		t.Errorf("replay input = %v, want the original input", replay.Input)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 {
+		t.Fatalf("task ran %d times, want 2", len(seen))
+	}
+	if _, ok := seen[1]["total"]; ok {
+		t.Errorf("replayed task saw %v, want the input without the original's outputs", seen[1])
+	}
+}
+
+// TestReplayWithoutStoredInput replays an execution stored before inputs were kept
+// Its final data is the only data left, so the replay starts from it
+func TestReplayWithoutStoredInput(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "legacy",
+		Tasks: []*models.Task{{ID: "a", FunctionName: "noop"}},
+	})
+	legacy := &models.JobExecution{
+		ID:           newID("exec"),
+		DefinitionID: "legacy",
+		Status:       models.JobStatusCompleted,
+		StartTime:    time.Now(),
+		EndTime:      time.Now(),
+		Data:         map[string]interface{}{"order": "7"},
+	}
+	if err := o.db.StoreJobExecution(legacy); err != nil {
+		t.Fatalf("store execution: %v", err)
+	}
+
+	id, err := o.ReplayJobExecution(legacy.ID)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replay := waitForFinish(t, o, id); replay.Data["order"] != "7" {
+		t.Errorf("replay data = %v, want the legacy execution's data", replay.Data)
+	}
+}
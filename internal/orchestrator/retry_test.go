@@ -0,0 +1,144 @@
+// retry_test.go tests retrying a failed execution from one of its tasks
+// Sequential jobs rerun the task and those after it, DAG jobs only the task
+// and its dependents, leaving the results of unaffected tasks in place
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// runCounter counts the runs of each task, failing the tasks named in failOnce on their first run
+type runCounter struct {
+	mu       sync.Mutex      // Guards runs
+	runs     map[string]int  // Runs by task ID
+	failOnce map[string]bool // Tasks failing on their first run
+}
+
+// register registers a function for each task ID, counting its runs
+func (c *runCounter) register(o *Orchestrator, taskIDs ...string) {
+	c.runs = make(map[string]int)
+	for _, id := range taskIDs {
+		o.RegisterFunction(id, func(ctx context.Context, data map[string]interface{}) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.runs[id]++
+			if c.failOnce[id] && c.runs[id] == 1 {
+				return errors.New("flaky")
+			}
+			return nil
+		})
+	}
+}
+
+// counts returns a copy of the run counts
+func (c *runCounter) counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int, len(c.runs))
+	for id, n := range c.runs {
+		counts[id] = n
+	}
+	return counts
+}
+
+// retryFrom retries execution id from taskID and waits for it to complete
+func retryFrom(t *testing.T, o *Orchestrator, id, taskID string) {
+	t.Helper()
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusFailed {
+		t.Fatalf("status = %s, want FAILED before the retry", je.Status)
+	}
+	if err := o.RetryFromTask(id, taskID); err != nil {
+		t.Fatalf("retry from %s: %v", taskID, err)
+	}
+	if je := waitForStatus(t, o, id, models.JobStatusCompleted); je.TaskStatuses[taskID] != models.TaskStatusCompleted {
+		t.Fatalf("task %s = %s after the retry, want COMPLETED", taskID, je.TaskStatuses[taskID])
+	}
+}
+
+// TestRetryFromTaskSequential reruns the failed task and the tasks after it
+func TestRetryFromTaskSequential(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	counter := &runCounter{failOnce: map[string]bool{"transform": true}}
+	counter.register(o, "extract", "transform", "load")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "etl",
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "extract"},
+			{ID: "transform", FunctionName: "transform"},
+			{ID: "load", FunctionName: "load"},
+		},
+	})
+
+	retryFrom(t, o, enqueue(t, o, "etl", nil), "transform")
+	want := map[string]int{"extract": 1, "transform": 2, "load": 1}
+	for id, n := range counter.counts() {
+		if n != want[id] {
+			t.Errorf("task %s ran %d times, want %d", id, n, want[id])
+		}
+	}
+}
+
+// TestRetryFromTaskDAG reruns the failed task and its dependents only
+// A task listed after it but not depending on it keeps its result
+func TestRetryFromTaskDAG(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	counter := &runCounter{failOnce: map[string]bool{"transform": true}}
+	counter.register(o, "extract", "transform", "audit", "load", "report")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:          "etl",
+		Strategy:    models.StrategyDAG,
+		FailureMode: models.FailureModeCollect,
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "extract"},
+			{ID: "transform", FunctionName: "transform", DependsOn: []string{"extract"}},
+			{ID: "audit", FunctionName: "audit", DependsOn: []string{"extract"}},
+			{ID: "load", FunctionName: "load", DependsOn: []string{"transform"}},
+			{ID: "report", FunctionName: "report", DependsOn: []string{"load", "audit"}},
+		},
+	})
+
+	retryFrom(t, o, enqueue(t, o, "etl", nil), "transform")
+	want := map[string]int{"extract": 1, "transform": 2, "audit": 1, "load": 1, "report": 1}
+	if counts := counter.counts(); len(counts) != len(want) {
+		t.Errorf("tasks run = %v, want %v", counts, want)
+	}
+	for id, n := range counter.counts() {
+		if n != want[id] {
+			t.Errorf("task %s ran %d times, want %d", id, n, want[id])
+		}
+	}
+}
+
+// TestRetryFromTaskRejects checks executions that aren't failed and unknown tasks
+func TestRetryFromTaskRejects(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	counter := &runCounter{}
+	counter.register(o, "extract")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "etl",
+		Tasks: []*models.Task{{ID: "extract", FunctionName: "extract"}},
+	})
+	completed := enqueue(t, o, "etl", nil)
+	waitForFinish(t, o, completed)
+	if err := o.RetryFromTask(completed, "extract"); !errors.Is(err, ErrNotRetryable) {
+		t.Errorf("retry of a completed execution = %v, want ErrNotRetryable", err)
+	}
+
+	o.RegisterFunction("fail", func(ctx context.Context, data map[string]interface{}) error {
+		return errors.New("boom")
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "broken",
+		Tasks: []*models.Task{{ID: "fail", FunctionName: "fail"}},
+	})
+	failed := enqueue(t, o, "broken", nil)
+	waitForFinish(t, o, failed)
+	if err := o.RetryFromTask(failed, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("retry from an unknown task = %v, want ErrNotFound", err)
+	}
+}
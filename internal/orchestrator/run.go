@@ -0,0 +1,262 @@
+// run.go tracks the mutable state of a single job execution
+// Serializes task state transitions and their persistence
+// Lets concurrently finishing tasks update the execution without losing writes
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// jobRun owns the execution record while its tasks run
+// Every read and write of je goes through its mutex, and each change
+// is persisted while holding it so storage sees updates in order
+// Retries after a failed write persist the whole state, see persist
+type jobRun struct {
+	mu     sync.Mutex
+	db     storage.DB
+	events *EventBus // Announces persisted task state changes
+	je     *models.JobExecution
+	jd     *models.JobDefinition
+	logs   *taskctx.LogBuffer   // Captures the log output of the tasks
+	notes  *taskctx.Annotations // Notes the tasks attach to the execution
+	halt   func(cause error)    // Stops the job once its state can't be persisted, may be nil
+	lost   func() bool          // Reports whether another instance took the execution over, may be nil
+}
+
+// newJobRun wraps an execution for running its tasks
+func newJobRun(db storage.DB, events *EventBus, je *models.JobExecution, jd *models.JobDefinition, logs *taskctx.LogBuffer) *jobRun {
+	if je.TaskStatuses == nil {
+		je.TaskStatuses = make(map[string]models.TaskStatus)
+	}
+	notes := taskctx.NewAnnotations(je.Annotations)
+	return &jobRun{db: db, events: events, je: je, jd: jd, logs: logs, notes: notes}
+}
+
+// taskStatus returns the current status of a task
+func (r *jobRun) taskStatus(taskID string) models.TaskStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.je.TaskStatuses[taskID]
+}
+
+// input builds a task's input from a copy of the job data
+// The copy keeps the task isolated from outputs merged while it runs
+func (r *jobRun) input(task *models.Task) (map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return taskInput(task, maps.Clone(r.je.Data))
+}
+
+// startTask marks a task as running
+// Its start time is persisted with the next full update of the execution
+func (r *jobRun) startTask(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.TaskStatuses[taskID] = models.TaskStatusRunning
+	if r.je.TaskTimes == nil {
+		r.je.TaskTimes = make(map[string]models.TaskTiming)
+	}
+	r.je.TaskTimes[taskID] = models.TaskTiming{StartTime: time.Now()}
+	r.persist("update task status to running", func() error {
+		return r.db.UpdateTaskStatus(r.je.ID, taskID, models.TaskStatusRunning)
+	})
+	publishStateChange(r.events, r.je)
+}
+
+// completeTask marks a task as completed and merges its outputs
+// Only the task status is written unless the task produced outputs or was retried
+func (r *jobRun) completeTask(taskID string, output map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.Data = mergeOutputs(r.je.Data, taskID, output, r.jd.NamespacesOutputs())
+	r.je.TaskStatuses[taskID] = models.TaskStatusCompleted
+	r.finishTask(taskID)
+
+	r.persist("update task status to completed", func() error {
+		if len(output) > 0 || len(r.je.TaskAttempts[taskID]) > 1 {
+			return r.db.UpdateJobExecution(r.je)
+		}
+		return r.db.UpdateTaskStatus(r.je.ID, taskID, models.TaskStatusCompleted)
+	})
+	publishStateChange(r.events, r.je)
+}
+
+// skipTask marks a task as skipped without running it
+func (r *jobRun) skipTask(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.TaskStatuses[taskID] = models.TaskStatusSkipped
+	r.persist("update task status to skipped", func() error {
+		return r.db.UpdateTaskStatus(r.je.ID, taskID, models.TaskStatusSkipped)
+	})
+	publishStateChange(r.events, r.je)
+}
+
+// failTask records a task failure and fails the job
+// Under a continuing failure mode the job keeps running and fails once all tasks ran
+func (r *jobRun) failTask(taskID string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.je.TaskErrors == nil {
+		r.je.TaskErrors = make(map[string]string)
+	}
+	r.je.TaskStatuses[taskID] = models.TaskStatusFailed
+	r.je.TaskErrors[taskID] = err.Error()
+	r.finishTask(taskID)
+	if !r.jd.FailureMode.Continues() {
+		r.je.Status = models.JobStatusFailed
+		r.je.Error = fmt.Sprintf("task %s failed: %v", taskID, err)
+	}
+	r.persist("update job execution after task failure", func() error {
+		return r.db.UpdateJobExecution(r.je)
+	})
+	publishStateChange(r.events, r.je)
+}
+
+// failJob fails the job after its tasks ran under a continuing failure mode
+// The final state is persisted when the execution finishes
+func (r *jobRun) failJob(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.Status = models.JobStatusFailed
+	r.je.Error = err.Error()
+}
+
+// transition changes the job status from one status to another
+// Leaves the status alone if it changed meanwhile, e.g. a sibling task failed the job
+// Returns whether the status was changed
+func (r *jobRun) transition(from, to models.JobStatus) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.je.Status != from {
+		return false
+	}
+	r.je.Status = to
+	r.persist(fmt.Sprintf("update job execution status to %s", to), func() error {
+		return r.db.UpdateJobExecution(r.je)
+	})
+	publishStateChange(r.events, r.je)
+	return true
+}
+
+// stopped returns an error if the job no longer runs, nil while it does
+// Tasks check it before starting so none starts after the job failed
+func (r *jobRun) stopped() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.je.Status {
+	case models.JobStatusRunning, models.JobStatusPaused:
+		return nil
+	default:
+		return fmt.Errorf("job is %s: %s", r.je.Status, r.je.Error)
+	}
+}
+
+// abort ends the job with the given status while a task was pending
+// The final state is persisted when the execution finishes
+func (r *jobRun) abort(taskID string, status models.JobStatus, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.je.TaskStatuses[taskID] = models.TaskStatusFailed
+	if status == models.JobStatusCancelled {
+		r.je.TaskStatuses[taskID] = models.TaskStatusCancelled
+	}
+	r.finishTask(taskID)
+	r.je.Status = status
+	r.je.Error = err.Error()
+}
+
+// persist writes the run's state, retrying through short storage outages
+// Halts the job if the write keeps failing, so it doesn't go on with unpersisted state
+// Must be called holding r.mu, which is released while waiting between attempts so
+// other tasks of the run aren't held up. Retries write the whole current state,
+// which includes this change and supersedes any write made meanwhile
+func (r *jobRun) persist(what string, write func() error) {
+	if r.revoked() {
+		return
+	}
+	if write() == nil {
+		return
+	}
+
+	r.mu.Unlock()
+	err := persist(what, func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.db.UpdateJobExecution(r.je)
+	})
+	r.mu.Lock()
+	if err == nil {
+		return
+	}
+	log.Printf("Stopping job %s for recovery: %v", r.je.ID, err)
+	if r.halt != nil {
+		r.halt(err)
+	}
+}
+
+// revoked reports whether another instance took the execution over
+// Its state then belongs to that instance and isn't written anymore
+func (r *jobRun) revoked() bool {
+	return r.lost != nil && r.lost()
+}
+
+// finishTask records the end time of a started task
+// Must be called holding r.mu
+func (r *jobRun) finishTask(taskID string) {
+	timing, ok := r.je.TaskTimes[taskID]
+	if !ok {
+		return
+	}
+	timing.EndTime = time.Now()
+	r.je.TaskTimes[taskID] = timing
+}
+
+// recordAttempt appends a finished attempt to the task's attempt history
+// backoff is how long the task waited before the attempt
+// Persisted with the next full update of the execution
+func (r *jobRun) recordAttempt(taskID string, start time.Time, backoff time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.je.TaskAttempts == nil {
+		r.je.TaskAttempts = make(map[string][]models.Attempt)
+	}
+	attempt := models.Attempt{
+		Number:     len(r.je.TaskAttempts[taskID]) + 1,
+		StartTime:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		BackoffMs:  backoff.Milliseconds(),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	r.je.TaskAttempts[taskID] = append(r.je.TaskAttempts[taskID], attempt)
+}
+
+// takeRetry consumes one retry from the job's retry budget
+// Returns false once the budget is exhausted
+func (r *jobRun) takeRetry() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jd.RetryBudget > 0 && r.je.RetriesUsed >= r.jd.RetryBudget {
+		return false
+	}
+	r.je.RetriesUsed++
+	return true
+}
+
+// resetTask clears a task's status so it runs again after recovery
+func (r *jobRun) resetTask(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.je.TaskStatuses, taskID)
+	delete(r.je.TaskTimes, taskID)
+}
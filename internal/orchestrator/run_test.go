@@ -0,0 +1,107 @@
+// run_test.go tests that concurrent task state transitions of a run aren't lost
+// Many parallel tasks finish at the same time, some with outputs and some without
+// Run with -race to also check the run's state is only touched under its lock
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// parallelTasks is the number of tasks finishing at once in these tests
+const parallelTasks = 64
+
+// TestConcurrentTaskCompletionsArePersisted completes every task of a run at once
+// Tasks with outputs write the whole execution, the others only their status,
+// and no write may clobber another
+func TestConcurrentTaskCompletionsArePersisted(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	jd := &models.JobDefinition{ID: "wide", Strategy: models.StrategyParallelAll}
+	je := &models.JobExecution{ID: "exec", DefinitionID: jd.ID, Status: models.JobStatusRunning, Data: map[string]interface{}{}}
+	for i := 0; i < parallelTasks; i++ {
+		jd.Tasks = append(jd.Tasks, &models.Task{ID: fmt.Sprintf("task-%02d", i)})
+	}
+	if err := db.StoreJobExecution(je); err != nil {
+		t.Fatalf("store execution: %v", err)
+	}
+	run := newJobRun(db, NewEventBus(), je, jd, taskctx.NewLogBuffer(defaultMaxLogBytes, ""))
+
+	for _, task := range jd.Tasks {
+		run.startTask(task.ID)
+	}
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, task := range jd.Tasks {
+		wg.Add(1)
+		go func(i int, taskID string) {
+			defer wg.Done()
+			<-start
+			var output map[string]interface{}
+			if i%2 == 0 {
+				output = map[string]interface{}{taskID: i}
+			}
+			run.completeTask(taskID, output)
+		}(i, task.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	stored, err := db.GetJobExecution(je.ID)
+	if err != nil {
+		t.Fatalf("get execution: %v", err)
+	}
+	for i, task := range jd.Tasks {
+		if status := stored.TaskStatuses[task.ID]; status != models.TaskStatusCompleted {
+			t.Errorf("task %s = %q, want COMPLETED", task.ID, status)
+		}
+		if _, ok := stored.Data[task.ID]; ok != (i%2 == 0) {
+			t.Errorf("output of task %s stored = %v, want %v", task.ID, ok, i%2 == 0)
+		}
+	}
+}
+
+// TestParallelJobCompletesAllTasks runs a job whose tasks all finish together
+// The stored execution must show every task completed and every output
+func TestParallelJobCompletesAllTasks(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	var emitted atomic.Int32
+	started.Add(parallelTasks)
+	o.RegisterOutputFunction("emit", func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		started.Done()
+		<-release
+		return map[string]interface{}{fmt.Sprintf("output-%02d", emitted.Add(1)): true}, nil
+	})
+	jd := &models.JobDefinition{ID: "wide", Strategy: models.StrategyParallelAll}
+	for i := 0; i < parallelTasks; i++ {
+		jd.Tasks = append(jd.Tasks, &models.Task{ID: fmt.Sprintf("task-%02d", i), FunctionName: "emit"})
+	}
+	registerDefinition(t, o, jd)
+
+	id := enqueue(t, o, "wide", nil)
+	started.Wait()
+	close(release)
+
+	je := waitForFinish(t, o, id)
+	if je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED", je.Status)
+	}
+	for i, task := range jd.Tasks {
+		if je.TaskStatuses[task.ID] != models.TaskStatusCompleted {
+			t.Errorf("task %s = %q, want COMPLETED", task.ID, je.TaskStatuses[task.ID])
+		}
+		if key := fmt.Sprintf("output-%02d", i+1); je.Data[key] != true {
+			t.Errorf("output %s missing from %v", key, je.Data)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// slow.go warns about tasks that run longer than expected
+// The warning is published as an event and logged by the task, which keeps running
+// Helps spotting degrading dependencies before tasks start timing out
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
+)
+
+// warnWhenSlow publishes a TASK_SLOW event once the task runs longer than its warnAfterSeconds
+// The time covers all attempts of the task including retry backoff
+// Returns a function to call when the task finished, which cancels a pending warning
+func (o *Orchestrator) warnWhenSlow(ctx context.Context, run *jobRun, task *models.Task) func() {
+	if task.WarnAfterSeconds <= 0 {
+		return func() {}
+	}
+
+	threshold := time.Duration(task.WarnAfterSeconds) * time.Second
+	timer := time.AfterFunc(threshold, func() {
+		message := fmt.Sprintf("task %s still running after %s", task.ID, threshold)
+		taskctx.Log(ctx).Warnf("%s", message)
+		o.events.Publish(models.Event{
+			Type:         models.EventTaskSlow,
+			DefinitionID: run.jd.ID,
+			ExecutionID:  run.je.ID,
+			Message:      message,
+			Details: map[string]interface{}{
+				"taskId":           task.ID,
+				"warnAfterSeconds": task.WarnAfterSeconds,
+			},
+		})
+	})
+	return func() { timer.Stop() }
+}
@@ -0,0 +1,67 @@
+// slow_test.go tests warnings about tasks running longer than their threshold
+// A task held past its warnAfterSeconds must publish TASK_SLOW and still complete,
+// while tasks finishing in time publish nothing
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestSlowTaskWarnsAndCompletes runs a quick task and then one held past its threshold
+func TestSlowTaskWarnsAndCompletes(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	release := make(chan struct{})
+	o.RegisterFunction("quick", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	o.RegisterFunction("slow", blockingFunction(nil, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "export",
+		Tasks: []*models.Task{
+			{ID: "prepare", FunctionName: "quick", WarnAfterSeconds: 1},
+			{ID: "upload", FunctionName: "slow", WarnAfterSeconds: 1},
+		},
+	})
+
+	events, unsubscribe := o.Events().Subscribe(16)
+	defer unsubscribe()
+	id := enqueue(t, o, "export", nil)
+
+	var warning models.Event
+	for warning.Type != models.EventTaskSlow {
+		select {
+		case e := <-events:
+			if e.ExecutionID == id {
+				warning = e
+			}
+		case <-time.After(testTimeout):
+			t.Fatal("timed out waiting for the slow task warning")
+		}
+	}
+	if warning.Details["taskId"] != "upload" || warning.DefinitionID != "export" {
+		t.Errorf("warning = %+v, want one for task upload of export", warning)
+	}
+	if je := execution(t, o, id); je.Status != models.JobStatusRunning || je.TaskStatuses["upload"] != models.TaskStatusRunning {
+		t.Fatalf("status = %s with upload %s after the warning, want both RUNNING", je.Status, je.TaskStatuses["upload"])
+	}
+
+	close(release)
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED", je.Status)
+	}
+	// The warning doesn't repeat and the quick task never warned
+	for {
+		select {
+		case e := <-events:
+			if e.Type == models.EventTaskSlow {
+				t.Errorf("unexpected warning %+v", e)
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// state_test.go tests the system state snapshot
+// Snapshots are taken while jobs keep finishing and must stay consistent,
+// listing only unfinished jobs, each at most once, and counting them per status
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestSystemStateWhileJobsComplete reads the system state in a loop while a batch of
+// short jobs runs to completion
+func TestSystemStateWhileJobsComplete(t *testing.T) {
+	const jobs, workers = 40, 4
+	o := newTestOrchestrator(t, workers)
+	o.RegisterFunction("blink", func(ctx context.Context, data map[string]interface{}) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{ID: "short", Tasks: []*models.Task{{ID: "blink", FunctionName: "blink"}}})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				state, err := o.GetSystemState()
+				if err != nil {
+					t.Errorf("system state: %v", err)
+					return
+				}
+				if len(state.ActiveJobs) > workers {
+					t.Errorf("%d active jobs, want at most %d", len(state.ActiveJobs), workers)
+				}
+				seen := make(map[string]bool)
+				for _, job := range state.ActiveJobs {
+					if job.Status.Finished() {
+						t.Errorf("finished job %s listed as active with status %s", job.ID, job.Status)
+					}
+					if seen[job.ID] {
+						t.Errorf("job %s listed twice", job.ID)
+					}
+					seen[job.ID] = true
+				}
+			}
+		}()
+	}
+
+	var ids []string
+	for i := 0; i < jobs; i++ {
+		ids = append(ids, enqueue(t, o, "short", nil))
+	}
+	for _, id := range ids {
+		waitForFinish(t, o, id)
+	}
+	close(done)
+	wg.Wait()
+
+	// Once everything finished the snapshot is empty and counts every job
+	// The count of executed jobs is updated after an execution is stored as finished
+	var state *models.SystemState
+	waitFor(t, "all jobs to be counted", func() bool {
+		var err error
+		state, err = o.GetSystemState()
+		if err != nil {
+			t.Fatalf("system state: %v", err)
+		}
+		return state.ExecutedJobs == jobs
+	})
+	if len(state.ActiveJobs) != 0 || state.QueuedCount != 0 {
+		t.Errorf("%d active and %d queued jobs after all finished, want none", len(state.ActiveJobs), state.QueuedCount)
+	}
+}
+
+// TestSystemStateStatusCounts reads the per-status counts as jobs run, fail, and wait
+func TestSystemStateStatusCounts(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	o.RegisterFunction("fail", blockingFunction(nil, closedChannel(), errors.New("boom")))
+	registerDefinition(t, o, &models.JobDefinition{ID: "block", Tasks: []*models.Task{{ID: "a", FunctionName: "block"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "fail", Tasks: []*models.Task{{ID: "a", FunctionName: "fail"}}})
+
+	counts := func() map[models.JobStatus]int {
+		t.Helper()
+		state, err := o.GetSystemState()
+		if err != nil {
+			t.Fatalf("system state: %v", err)
+		}
+		return state.StatusCounts
+	}
+
+	// The only worker runs the blocking job while the failing one waits
+	running := enqueue(t, o, "block", nil)
+	<-started
+	waitForStatus(t, o, running, models.JobStatusRunning)
+	failing := enqueue(t, o, "fail", nil)
+	want := map[models.JobStatus]int{models.JobStatusRunning: 1, models.JobStatusQueued: 1}
+	if got := counts(); !maps.Equal(got, want) {
+		t.Errorf("while running: counts = %v, want %v", got, want)
+	}
+
+	close(release)
+	waitForFinish(t, o, running)
+	waitForFinish(t, o, failing)
+	want = map[models.JobStatus]int{models.JobStatusCompleted: 1, models.JobStatusFailed: 1}
+	if got := counts(); !maps.Equal(got, want) {
+		t.Errorf("after finishing: counts = %v, want %v", got, want)
+	}
+}
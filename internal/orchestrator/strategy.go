@@ -0,0 +1,282 @@
+// strategy.go implements the task execution order strategies
+// A definition runs its tasks in order, all at once, or by dependencies
+// ExecuteJob dispatches on the definition's strategy
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// runTasks runs the tasks of a job using the definition's strategy
+// Returns the error that stopped the job, nil once all tasks completed
+func (o *Orchestrator) runTasks(ctx context.Context, run *jobRun) error {
+	var err error
+	switch run.jd.Strategy {
+	case models.StrategyParallelAll:
+		err = o.runParallel(ctx, run, run.jd.Tasks)
+	case models.StrategyDAG:
+		var order []*models.Task
+		if order, err = topoOrder(run.jd.Tasks); err == nil {
+			err = o.runDAG(ctx, run, order)
+		}
+	default:
+		err = o.runSequential(ctx, run, run.jd.Tasks)
+	}
+
+	// Under a continuing failure mode task failures leave the job running
+	// Fail it now that all tasks had their turn
+	if err != nil && ctx.Err() == nil && !interrupted(err) && run.jd.FailureMode.Continues() {
+		run.failJob(err)
+	}
+	return err
+}
+
+// runSequential runs tasks one after another in the given order
+// Stops at the first task that fails unless the failure mode continues
+func (o *Orchestrator) runSequential(ctx context.Context, run *jobRun, tasks []*models.Task) error {
+	var failures []error
+	for _, task := range tasks {
+		err := o.runTask(ctx, run, task)
+		if err == nil {
+			continue
+		}
+
+		// Cancellation, shutdown, and lost storage always stop the job
+		if ctx.Err() != nil || interrupted(err) || !run.jd.FailureMode.Continues() {
+			return err
+		}
+		failures = append(failures, err)
+	}
+	return reportFailures(run.jd.FailureMode, failures)
+}
+
+// runDAG starts each task once all tasks it depends on completed
+// Independent tasks run concurrently, limited by the task slots DAG tasks of all
+// jobs share. tasks must be in topological order, ready tasks start in that order
+func (o *Orchestrator) runDAG(ctx context.Context, run *jobRun, tasks []*models.Task) error {
+	type result struct {
+		task *models.Task
+		err  error
+	}
+	results := make(chan result)
+	started := make(map[string]bool, len(tasks))
+	completed := make(map[string]bool, len(tasks))
+	blocked := make(map[string]bool) // Failed tasks and the tasks depending on them
+	running := 0
+	reserved := false // A slot was taken for the next ready task
+
+	var failures []error
+	var stopErr error // Stops starting further tasks, running ones are waited for
+	for {
+		// Start ready tasks while task slots are free
+		// Dependencies come first in tasks, so blocking spreads in one pass
+		var waiting *models.Task // Ready task waiting for a slot
+		for _, task := range tasks {
+			if stopErr != nil {
+				break
+			}
+			if started[task.ID] || blocked[task.ID] {
+				continue
+			}
+			if slices.ContainsFunc(task.DependsOn, func(dep string) bool { return blocked[dep] }) {
+				blocked[task.ID] = true
+				continue
+			}
+			if !dependenciesPlaced(task, completed) {
+				continue
+			}
+			if !reserved && !o.tryAcquireTaskSlot() {
+				waiting = task
+				break
+			}
+			reserved = false
+			started[task.ID] = true
+			running++
+			go func(task *models.Task) {
+				defer o.releaseTaskSlot()
+				results <- result{task, o.runTask(ctx, run, task)}
+			}(task)
+		}
+		if running == 0 && waiting == nil {
+			break
+		}
+
+		// Wait for a task to finish or, if one is waiting, for a slot
+		// Nil channels leave out the cases that don't apply
+		var slots chan struct{}
+		var done <-chan struct{}
+		if waiting != nil {
+			slots, done = o.taskSlots, ctx.Done()
+		}
+		select {
+		case slots <- struct{}{}:
+			reserved = true
+			continue
+		case <-done:
+			// Let the waiting task record the cancellation as a started task would
+			stopErr = o.runTask(ctx, run, waiting)
+			continue
+		case r := <-results:
+			running--
+			switch {
+			case r.err == nil:
+				completed[r.task.ID] = true
+			case interrupted(r.err):
+				// Shutdown and lost storage take precedence so interrupted jobs are recovered
+				stopErr = r.err
+			case ctx.Err() != nil || !run.jd.FailureMode.Continues():
+				if stopErr == nil {
+					stopErr = r.err
+				}
+			default:
+				blocked[r.task.ID] = true
+				failures = append(failures, r.err)
+			}
+		}
+	}
+	if reserved {
+		o.releaseTaskSlot()
+	}
+
+	if stopErr != nil {
+		return stopErr
+	}
+	return reportFailures(run.jd.FailureMode, failures)
+}
+
+// tryAcquireTaskSlot takes a DAG task slot without waiting
+// Always succeeds when the orchestrator has no concurrency limit
+func (o *Orchestrator) tryAcquireTaskSlot() bool {
+	if o.taskSlots == nil {
+		return true
+	}
+	select {
+	case o.taskSlots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseTaskSlot frees a slot taken by tryAcquireTaskSlot
+func (o *Orchestrator) releaseTaskSlot() {
+	if o.taskSlots != nil {
+		<-o.taskSlots
+	}
+}
+
+// reportFailures combines the task failures of a job into its error
+// Reports only the first failure unless the mode collects all of them
+func reportFailures(mode models.FailureMode, failures []error) error {
+	switch {
+	case len(failures) == 0:
+		return nil
+	case len(failures) == 1 || mode != models.FailureModeCollect:
+		return failures[0]
+	default:
+		return fmt.Errorf("%d tasks failed: %w", len(failures), errors.Join(failures...))
+	}
+}
+
+// runParallel starts all tasks at once and waits for them to finish
+// A failing task fails the job but doesn't stop the others
+// Shutdown and lost storage take precedence so interrupted jobs are recovered
+func (o *Orchestrator) runParallel(ctx context.Context, run *jobRun, tasks []*models.Task) error {
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task *models.Task) {
+			defer wg.Done()
+			errs[i] = o.runTask(ctx, run, task)
+		}(i, task)
+	}
+	wg.Wait()
+
+	var failures []error
+	for _, err := range errs {
+		if interrupted(err) {
+			return err
+		}
+		if err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return reportFailures(run.jd.FailureMode, failures)
+}
+
+// topoOrder sorts tasks so each runs after the tasks it depends on
+// Independent tasks keep their definition order
+// Returns an error wrapping ErrInvalidDefinition on unknown dependencies or cycles
+func topoOrder(tasks []*models.Task) ([]*models.Task, error) {
+	known := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.ID] = true
+	}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if !known[dep] {
+				return nil, fmt.Errorf("%w: task %s depends on unknown task %s", ErrInvalidDefinition, task.ID, dep)
+			}
+		}
+	}
+
+	// Repeatedly take the first task whose dependencies are all placed
+	// Quadratic, but definitions hold few enough tasks for it not to matter
+	order := make([]*models.Task, 0, len(tasks))
+	placed := make(map[string]bool, len(tasks))
+	for len(order) < len(tasks) {
+		progressed := false
+		for _, task := range tasks {
+			if placed[task.ID] || !dependenciesPlaced(task, placed) {
+				continue
+			}
+			placed[task.ID] = true
+			order = append(order, task)
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("%w: task dependencies contain a cycle", ErrInvalidDefinition)
+		}
+	}
+	return order, nil
+}
+
+// dependentTasks returns the task with taskID and every task depending on it
+// directly or through other tasks, in topological order
+func dependentTasks(tasks []*models.Task, taskID string) ([]*models.Task, error) {
+	order, err := topoOrder(tasks)
+	if err != nil {
+		return nil, err
+	}
+	affected := map[string]bool{taskID: true}
+	var dependents []*models.Task
+	for _, task := range order {
+		for _, dep := range task.DependsOn {
+			if affected[dep] {
+				affected[task.ID] = true
+				break
+			}
+		}
+		if affected[task.ID] {
+			dependents = append(dependents, task)
+		}
+	}
+	return dependents, nil
+}
+
+// dependenciesPlaced reports whether all dependencies of a task are placed
+func dependenciesPlaced(task *models.Task, placed map[string]bool) bool {
+	for _, dep := range task.DependsOn {
+		if !placed[dep] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+// strategy_test.go tests the task execution order strategies
+// Covers the run order of each strategy and the task slots DAG tasks of
+// concurrently running jobs share, using blocking functions to count running tasks
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestDAGTasksShareSlotsAcrossJobs runs two DAG jobs of independent tasks
+// Only as many tasks as the concurrent job limit may run at once across both jobs
+func TestDAGTasksShareSlotsAcrossJobs(t *testing.T) {
+	o := newTestOrchestrator(t, 2)
+
+	started, release := make(chan struct{}, 8), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "wide",
+		Strategy: models.StrategyDAG,
+		Tasks: []*models.Task{
+			{ID: "a", FunctionName: "block"},
+			{ID: "b", FunctionName: "block"},
+			{ID: "c", FunctionName: "block"},
+		},
+	})
+	first := enqueue(t, o, "wide", nil)
+	second := enqueue(t, o, "wide", nil)
+	waitForStatus(t, o, first, models.JobStatusRunning)
+	waitForStatus(t, o, second, models.JobStatusRunning)
+
+	// Both jobs run, but their tasks only get the two slots
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Fatal("a third task started while two held every slot")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	for _, id := range []string{first, second} {
+		if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+			t.Errorf("execution %s = %s, want COMPLETED", id, je.Status)
+		}
+	}
+}
+
+// TestDAGCancelWhileWaitingForSlot cancels a job whose ready task waits for a slot
+// The job must finish CANCELLED without the waiting task ever running
+func TestDAGCancelWhileWaitingForSlot(t *testing.T) {
+	o := newTestOrchestrator(t, 2)
+
+	started, release := make(chan struct{}, 8), make(chan struct{})
+	defer close(release)
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "wide",
+		Strategy: models.StrategyDAG,
+		Tasks: []*models.Task{
+			{ID: "a", FunctionName: "block"},
+			{ID: "b", FunctionName: "block"},
+		},
+	})
+	holder := enqueue(t, o, "wide", nil)
+	<-started
+	<-started
+	waiter := enqueue(t, o, "wide", nil)
+	waitForStatus(t, o, waiter, models.JobStatusRunning)
+
+	if err := o.CancelJob(waiter); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if je := waitForFinish(t, o, waiter); je.Status != models.JobStatusCancelled {
+		t.Errorf("waiting execution = %s, want CANCELLED", je.Status)
+	}
+	select {
+	case <-started:
+		t.Error("a task of the cancelled job ran")
+	default:
+	}
+	if je := execution(t, o, holder); je.Status != models.JobStatusRunning {
+		t.Errorf("holding execution = %s, want RUNNING", je.Status)
+	}
+}
+
+// recordOrder registers functions for the task IDs recording the order they ran in
+// Tasks named in failing return an error after being recorded
+func recordOrder(o *Orchestrator, failing map[string]bool, taskIDs ...string) func() []string {
+	var mu sync.Mutex
+	var order []string
+	for _, id := range taskIDs {
+		o.RegisterFunction(id, func(ctx context.Context, data map[string]interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, id)
+			if failing[id] {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(order)
+	}
+}
+
+// TestStrategySequential runs tasks in definition order, stopping at the first failure
+func TestStrategySequential(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	order := recordOrder(o, map[string]bool{"broken": true}, "load", "extract", "broken", "report")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "ordered",
+		Strategy: models.StrategySequential,
+		Tasks: []*models.Task{
+			{ID: "load", FunctionName: "load"},
+			{ID: "extract", FunctionName: "extract"},
+		},
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "stopping",
+		Tasks: []*models.Task{
+			{ID: "broken", FunctionName: "broken"},
+			{ID: "report", FunctionName: "report"},
+		},
+	})
+
+	if je := waitForFinish(t, o, enqueue(t, o, "ordered", nil)); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	if je := waitForFinish(t, o, enqueue(t, o, "stopping", nil)); je.Status != models.JobStatusFailed {
+		t.Fatalf("status = %s, want FAILED", je.Status)
+	}
+	if got, want := order(), []string{"load", "extract", "broken"}; !slices.Equal(got, want) {
+		t.Errorf("tasks ran in order %v, want %v", got, want)
+	}
+}
+
+// TestStrategyParallelAll starts all tasks at once
+// A failing task fails the job while the others still complete
+func TestStrategyParallelAll(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	started, release := make(chan struct{}, 3), make(chan struct{})
+	o.RegisterFunction("block", blockingFunction(started, release, nil))
+	o.RegisterFunction("fail", blockingFunction(started, release, errors.New("boom")))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "fanout",
+		Strategy: models.StrategyParallelAll,
+		Tasks: []*models.Task{
+			{ID: "a", FunctionName: "block"},
+			{ID: "b", FunctionName: "fail"},
+			{ID: "c", FunctionName: "block"},
+		},
+	})
+
+	id := enqueue(t, o, "fanout", nil)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(testTimeout):
+			t.Fatalf("%d of 3 tasks started before any finished", i)
+		}
+	}
+	close(release)
+
+	je := waitForFinish(t, o, id)
+	if je.Status != models.JobStatusFailed {
+		t.Errorf("status = %s, want FAILED by task b", je.Status)
+	}
+	for task, want := range map[string]models.TaskStatus{"a": models.TaskStatusCompleted, "b": models.TaskStatusFailed, "c": models.TaskStatusCompleted} {
+		if je.TaskStatuses[task] != want {
+			t.Errorf("task %s = %s, want %s", task, je.TaskStatuses[task], want)
+		}
+	}
+}
+
+// TestStrategyDAG runs each task after its dependencies, whatever the definition order
+func TestStrategyDAG(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	order := recordOrder(o, nil, "report", "load", "extract")
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:       "graph",
+		Strategy: models.StrategyDAG,
+		Tasks: []*models.Task{
+			{ID: "report", FunctionName: "report", DependsOn: []string{"load"}},
+			{ID: "load", FunctionName: "load", DependsOn: []string{"extract"}},
+			{ID: "extract", FunctionName: "extract"},
+		},
+	})
+
+	if je := waitForFinish(t, o, enqueue(t, o, "graph", nil)); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+	if got, want := order(), []string{"extract", "load", "report"}; !slices.Equal(got, want) {
+		t.Errorf("tasks ran in order %v, want %v", got, want)
+	}
+}
+
+// TestStrategyRejects checks unknown strategies and DAGs that can't be ordered
+func TestStrategyRejects(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	for name, jd := range map[string]*models.JobDefinition{
+		"unknown strategy": {ID: "a", Strategy: "random", Tasks: []*models.Task{{ID: "t", FunctionName: "f"}}},
+		"unknown dependency": {ID: "b", Strategy: models.StrategyDAG, Tasks: []*models.Task{
+			{ID: "t", FunctionName: "f", DependsOn: []string{"missing"}},
+		}},
+		"cycle": {ID: "c", Strategy: models.StrategyDAG, Tasks: []*models.Task{
+			{ID: "t", FunctionName: "f", DependsOn: []string{"u"}},
+			{ID: "u", FunctionName: "f", DependsOn: []string{"t"}},
+		}},
+	} {
+		if err := o.RegisterJobDefinition(jd); !errors.Is(err, ErrInvalidDefinition) {
+			t.Errorf("%s: %v, want ErrInvalidDefinition", name, err)
+		}
+	}
+}
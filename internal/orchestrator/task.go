@@ -5,7 +5,12 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
@@ -16,49 +21,463 @@ import (
 // Returns an error if the task fails to execute
 type TaskFunction func(ctx context.Context, data map[string]interface{}) error
 
+// OutputTaskFunction defines a task that produces outputs for later tasks
+// Returned outputs are merged into the job data after the task succeeds
+type OutputTaskFunction func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error)
+
+// withOutput adapts a TaskFunction to the OutputTaskFunction signature
+// The adapted function never produces outputs
+func (fn TaskFunction) withOutput() OutputTaskFunction {
+	return func(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error) {
+		return nil, fn(ctx, data)
+	}
+}
+
+// FunctionProvider supplies a named set of task functions
+// Lets embedding applications contribute their own task registries
+// Providers are combined at startup with MergeFunctionProviders
+type FunctionProvider interface {
+	TaskFunctions() (map[string]OutputTaskFunction, error)
+}
+
+// FunctionProviderFunc adapts an ordinary function to the FunctionProvider interface
+// Useful for loaders that don't need their own type
+type FunctionProviderFunc func() (map[string]OutputTaskFunction, error)
+
+// TaskFunctions calls f to obtain the provided task functions
+func (f FunctionProviderFunc) TaskFunctions() (map[string]OutputTaskFunction, error) {
+	return f()
+}
+
+// MergeFunctionProviders combines the functions of several providers into one map
+// Function names must be unique across providers
+// Returns an error if a provider fails or a name is contributed twice
+func MergeFunctionProviders(providers ...FunctionProvider) (map[string]OutputTaskFunction, error) {
+	merged := make(map[string]OutputTaskFunction)
+	for _, provider := range providers {
+		functions, err := provider.TaskFunctions()
+		if err != nil {
+			return nil, err
+		}
+		for name, fn := range functions {
+			if _, exists := merged[name]; exists {
+				return nil, fmt.Errorf("task function %s provided more than once", name)
+			}
+			merged[name] = fn
+		}
+	}
+	return merged, nil
+}
+
 // RegisterTaskFunction associates a function with a task ID
 // Allows the orchestrator to look up and execute task implementations
 // Must be called before a task can be executed
 func (o *Orchestrator) RegisterTaskFunction(taskID string, fn TaskFunction) {
-	o.taskFunctions[taskID] = fn
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	o.taskFunctions[taskID] = fn.withOutput()
+}
+
+// RegisterFunction associates a function with its function name
+// Tasks without a task ID registration are resolved by their FunctionName
+// Also makes the function available for ad-hoc runs
+func (o *Orchestrator) RegisterFunction(name string, fn TaskFunction) {
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	o.functions[name] = fn.withOutput()
+}
+
+// RegisterOutputFunction associates an output producing function with its function name
+// Outputs of the function are made available to the tasks that follow it
+func (o *Orchestrator) RegisterOutputFunction(name string, fn OutputTaskFunction) {
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	o.functions[name] = fn
+}
+
+// UnregisterFunction removes the function registered under a function name
+// Queued executions using it are failed or held, see WithHoldMissingFunctions
+func (o *Orchestrator) UnregisterFunction(name string) {
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	delete(o.functions, name)
+}
+
+// CleanupFunc releases resources of a task whose context was cancelled
+// Receives the task's input and a fresh context bounded by cleanupTimeout
+type CleanupFunc func(ctx context.Context, data map[string]interface{})
+
+// cleanupTimeout bounds how long a cleanup hook may run
+const cleanupTimeout = 30 * time.Second
+
+// RegisterCleanup registers a cleanup hook for the function with the given name
+// The hook runs when a task using the function is cancelled mid-run,
+// e.g. to close connections or delete temp files, never after normal completion
+func (o *Orchestrator) RegisterCleanup(functionName string, cleanup CleanupFunc) {
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	o.cleanups[functionName] = cleanup
+}
+
+// runCleanup invokes the cleanup hook of a cancelled task, if any
+// Uses a fresh context so the hook isn't cancelled along with the task
+func (o *Orchestrator) runCleanup(task *models.Task, data map[string]interface{}) {
+	o.fnMu.RLock()
+	cleanup, ok := o.cleanups[task.FunctionName]
+	o.fnMu.RUnlock()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	cleanup(ctx, data)
+}
+
+// MigrationFunc upgrades task input data to the shape its function expects
+// Receives a copy of the input and returns the data passed to the function
+// Runs for every input, so it must return data already in the new shape unchanged
+type MigrationFunc func(data map[string]interface{}) (map[string]interface{}, error)
+
+// RegisterMigration registers an input data migration for the function with the given name
+// Lets executions enqueued before a change of the input shape run with the new function
+func (o *Orchestrator) RegisterMigration(functionName string, migrate MigrationFunc) {
+	o.fnMu.Lock()
+	defer o.fnMu.Unlock()
+	o.migrations[functionName] = migrate
+}
+
+// migrateInput applies the migration of a task's function to its input, if any
+func (o *Orchestrator) migrateInput(task *models.Task, data map[string]interface{}) (map[string]interface{}, error) {
+	o.fnMu.RLock()
+	migrate, ok := o.migrations[task.FunctionName]
+	o.fnMu.RUnlock()
+	if !ok {
+		return data, nil
+	}
+	migrated, err := migrate(maps.Clone(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate input of task %s: %w", task.ID, err)
+	}
+	return migrated, nil
+}
+
+// resolveTaskFunction looks up the implementation of a task
+// Functions registered for the task ID take precedence over the function name
+func (o *Orchestrator) resolveTaskFunction(task *models.Task) (OutputTaskFunction, bool) {
+	o.fnMu.RLock()
+	defer o.fnMu.RUnlock()
+	if fn, ok := o.taskFunctions[task.ID]; ok {
+		return fn, true
+	}
+	fn, ok := o.functions[task.FunctionName]
+	return fn, ok
+}
+
+// Defaults applied to ad-hoc task runs
+// Ad-hoc runs are meant for testing so they fail fast
+const (
+	adHocDefinitionPrefix = "adhoc:"        // Definition ID prefix of ad-hoc executions
+	adHocMaxRetry         = 0               // Retries for an ad-hoc run
+	adHocTimeout          = 5 * time.Minute // Upper bound on an ad-hoc run
+)
+
+// RunTask executes a single registered function once, outside of any job definition
+// The run is recorded as a lightweight execution so it can be inspected later
+// Returns the finished execution, whose status reflects the outcome of the run
+func (o *Orchestrator) RunTask(ctx context.Context, functionName string, data map[string]interface{}) (*models.JobExecution, error) {
+	o.fnMu.RLock()
+	_, ok := o.functions[functionName]
+	o.fnMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("function %s %w", functionName, ErrNotFound)
+	}
+
+	task := adHocTask(functionName)
+	je := &models.JobExecution{
+		ID:           newID("exec"),
+		DefinitionID: adHocDefinitionPrefix + functionName,
+		Status:       models.JobStatusRunning,
+		StartTime:    time.Now(),
+		Data:         data,
+		TaskStatuses: map[string]models.TaskStatus{task.ID: models.TaskStatusRunning},
+	}
+	if err := o.db.StoreJobExecution(je); err != nil {
+		return nil, err
+	}
+
+	// Run the function with the ad-hoc defaults
+	ctx, cancel := context.WithTimeout(ctx, adHocTimeout)
+	defer cancel()
+	output, err := o.executeTask(ctx, task, data, nil)
+
+	// Record the outcome of the run
+	je.EndTime = time.Now()
+	je.TaskTimes = map[string]models.TaskTiming{task.ID: {StartTime: je.StartTime, EndTime: je.EndTime}}
+	if err != nil {
+		je.Status = models.JobStatusFailed
+		je.TaskStatuses[task.ID] = models.TaskStatusFailed
+		je.TaskErrors = map[string]string{task.ID: err.Error()}
+		je.Error = err.Error()
+	} else {
+		je.Status = models.JobStatusCompleted
+		je.TaskStatuses[task.ID] = models.TaskStatusCompleted
+		je.Data = mergeOutputs(je.Data, task.ID, output, false)
+	}
+	if err := o.db.UpdateJobExecution(je); err != nil {
+		log.Printf("Failed to update ad-hoc execution %s: %v", je.ID, err)
+	}
+
+	return je, nil
+}
+
+// adHocTask builds the single task run by an ad-hoc execution
+func adHocTask(functionName string) *models.Task {
+	return &models.Task{
+		ID:           functionName,
+		Name:         functionName,
+		MaxRetry:     adHocMaxRetry,
+		FunctionName: functionName,
+	}
+}
+
+// definitionFor returns the job definition an execution runs
+// Ad-hoc executions have no stored definition so one is synthesized,
+// inline executions carry their own
+func (o *Orchestrator) definitionFor(je *models.JobExecution) (*models.JobDefinition, error) {
+	if je.Definition != nil {
+		return je.Definition, nil
+	}
+	if functionName, ok := strings.CutPrefix(je.DefinitionID, adHocDefinitionPrefix); ok {
+		return &models.JobDefinition{
+			ID:    je.DefinitionID,
+			Name:  "Ad-hoc run of " + functionName,
+			Tasks: []*models.Task{adHocTask(functionName)},
+		}, nil
+	}
+	return o.db.GetJobDefinition(je.DefinitionID)
 }
 
 // executeTask runs a single task with retry logic
 // Handles task execution, retries, and error reporting
 // Implements exponential backoff between retry attempts
-func (o *Orchestrator) executeTask(ctx context.Context, task *models.Task, data map[string]interface{}) error {
+// Returns the outputs produced by the successful attempt
+// run, when set, records each attempt and grants retries from the job's retry budget
+func (o *Orchestrator) executeTask(ctx context.Context, task *models.Task, data map[string]interface{}, run *jobRun) (map[string]interface{}, error) {
 	// Look up the task implementation
 	// Ensures the task has been properly registered
-	fn, ok := o.taskFunctions[task.ID]
+	fn, ok := o.resolveTaskFunction(task)
 	if !ok {
-		return fmt.Errorf("no function registered for task ID: %s", task.ID)
+		return nil, fmt.Errorf("no function registered for task ID: %s", task.ID)
+	}
+
+	// Upgrade data of executions enqueued with an older input shape
+	data, err := o.migrateInput(task, data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Execute the task with configured number of retries
 	// Uses exponential backoff between attempts
+	timeouts := 0
+	var waited time.Duration // Backoff before the current attempt
 	for retries := 0; retries <= task.MaxRetry; retries++ {
 		// Attempt to execute the task
 		// Pass context and data to task implementation
-		err := fn(ctx, data)
+		start := time.Now()
+		output, err := runAttempt(ctx, fn, data, attemptTimeout(ctx, task, run))
+		if err == nil {
+			err = checkRequiredOutputs(task, output)
+		}
+		if err == nil {
+			err = checkSuccessCriteria(task, output)
+		}
+		if run != nil {
+			run.recordAttempt(task.ID, start, waited, err)
+		}
 
 		// If successful, return immediately
 		// No need for further retry attempts
 		if err == nil {
-			return nil
+			return output, nil
+		}
+
+		// Stop retrying once the job itself was cancelled or timed out
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("task %s stopped: %w", task.ID, err)
+		}
+
+		// Count timeouts in a row separately from other errors
+		// A task that keeps timing out won't use up the full retry budget
+		if errors.Is(err, ErrTaskTimeout) {
+			timeouts++
+		} else {
+			timeouts = 0
+		}
+		if task.MaxConsecutiveTimeouts > 0 && timeouts >= task.MaxConsecutiveTimeouts {
+			return nil, fmt.Errorf("task %s timed out %d consecutive times: %w", task.ID, timeouts, err)
 		}
 
 		// If we've exhausted all retries, return final error
 		// Includes retry count in error message
 		if retries == task.MaxRetry {
-			return fmt.Errorf("task %s failed after %d retries: %v", task.ID, task.MaxRetry, err)
+			return nil, fmt.Errorf("task %s failed after %d retries: %v", task.ID, task.MaxRetry, err)
 		}
 
-		// Exponential backoff between retries
-		// Wait time doubles after each failure: 1s, 2s, 4s, 8s, etc.
-		time.Sleep(time.Duration(1<<retries) * time.Second)
+		// Stop early once the job has used up its retry budget
+		if run != nil && !run.takeRetry() {
+			return nil, fmt.Errorf("task %s failed after %d retries, job retry budget exhausted: %v", task.ID, retries, err)
+		}
+
+		// Back off between retries as the task configures
+		// By default the wait doubles after each failure: 1s, 2s, 4s, 8s, etc.
+		waitStart := time.Now()
+		if err := backoff(ctx, backoffDelay(task, retries)); err != nil {
+			return nil, fmt.Errorf("task %s stopped during retry backoff: %w", task.ID, err)
+		}
+		waited = time.Since(waitStart)
 	}
 
 	// This should never be reached due to return in retry loop
 	// Included for completeness and to satisfy compiler
-	return fmt.Errorf("task %s failed after %d retries", task.ID, task.MaxRetry)
+	return nil, fmt.Errorf("task %s failed after %d retries", task.ID, task.MaxRetry)
+}
+
+// maxBackoffShift caps the doubling of the backoff delay
+// Keeps the delay from overflowing for very large retry counts
+const maxBackoffShift = 32
+
+// defaultRetryBaseDelay is the first wait of tasks that don't set retryBaseDelayMs
+const defaultRetryBaseDelay = time.Second
+
+// backoffDelay returns the wait of a task after the given zero-based failed attempt
+// Grows from the base delay as the task's backoff selects, clamped to its max delay
+func backoffDelay(task *models.Task, retry int) time.Duration {
+	base := defaultRetryBaseDelay
+	if task.RetryBaseDelayMs > 0 {
+		base = time.Duration(task.RetryBaseDelayMs) * time.Millisecond
+	}
+
+	var delay time.Duration
+	switch task.RetryBackoff {
+	case models.BackoffFixed:
+		delay = base
+	case models.BackoffLinear:
+		delay = mulCapped(base, int64(retry)+1)
+	default:
+		delay = mulCapped(base, 1<<min(retry, maxBackoffShift))
+	}
+
+	if task.RetryMaxDelayMs > 0 {
+		delay = min(delay, time.Duration(task.RetryMaxDelayMs)*time.Millisecond)
+	}
+	return delay
+}
+
+// worstCaseBackoff returns the longest a job can spend waiting between retries
+// Tasks of parallel jobs back off at the same time, others one after another
+// Doesn't account for the retry budget, which can only shorten the wait
+func worstCaseBackoff(jd *models.JobDefinition) time.Duration {
+	var total time.Duration
+	for _, task := range jd.Tasks {
+		var wait time.Duration
+		for retry := 0; retry < task.MaxRetry; retry++ {
+			wait = addCapped(wait, backoffDelay(task, retry))
+		}
+		if jd.Strategy == models.StrategyParallelAll {
+			total = max(total, wait)
+		} else {
+			total = addCapped(total, wait)
+		}
+	}
+	return total
+}
+
+// addCapped adds two durations, saturating instead of overflowing
+func addCapped(a, b time.Duration) time.Duration {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// mulCapped multiplies a duration by a positive factor, saturating instead of overflowing
+func mulCapped(d time.Duration, n int64) time.Duration {
+	if d > math.MaxInt64/time.Duration(n) {
+		return math.MaxInt64
+	}
+	return d * time.Duration(n)
+}
+
+// backoff waits for the delay before the next attempt of a task
+// Returns the cause of ctx as soon as it is cancelled, so a cancelled job
+// doesn't sit out the rest of the wait
+func backoff(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// attemptTimeout returns how long the next attempt of a task may run, zero for no limit
+// Tasks without their own timeout get the definition's share of the time the
+// execution has left, so tasks late in a long job get less than early ones
+func attemptTimeout(ctx context.Context, task *models.Task, run *jobRun) time.Duration {
+	if task.TimeoutSeconds > 0 {
+		return time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	if run == nil || run.jd.TaskTimeoutPercent <= 0 {
+		return 0
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(deadline)
+	return max(remaining/100*time.Duration(run.jd.TaskTimeoutPercent), time.Millisecond)
+}
+
+// runAttempt runs one attempt of a task, enforcing its timeout and the job's
+// The function runs in its own goroutine so tasks ignoring the context
+// can't hold the job past the timeout, their result is then discarded
+func runAttempt(ctx context.Context, fn OutputTaskFunction, data map[string]interface{}, timeout time.Duration) (map[string]interface{}, error) {
+	_, bounded := ctx.Deadline()
+	if timeout <= 0 && !bounded {
+		return fn(ctx, data)
+	}
+
+	var attemptCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		attemptCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	type result struct {
+		output map[string]interface{}
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := fn(attemptCtx, data)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-attemptCtx.Done():
+		// Only the attempt's own deadline counts as a timeout
+		// Cancellation of the job itself is reported as is
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		return nil, fmt.Errorf("%w after %v", ErrTaskTimeout, timeout.Round(time.Millisecond))
+	}
 }
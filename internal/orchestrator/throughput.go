@@ -0,0 +1,156 @@
+// throughput.go estimates how fast the orchestrator works off its queue
+// Keeps the finish times of the most recent executions
+// Used to predict the queue wait of newly enqueued executions and report throughput
+package orchestrator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// throughputWindow is the number of recent finishes the estimate is based on
+const throughputWindow = 100
+
+// throughputActivity bounds how far back from the latest finish the estimate looks
+// Counting back from the latest finish keeps idle time since then out of the rate
+const throughputActivity = 5 * time.Minute
+
+// Per-minute throughput reporting
+// The rate averages the complete minutes of the history
+const (
+	throughputHistory = 15 // Minutes of per-minute counts kept
+	throughputRate    = 5  // Complete minutes the reported rate averages
+)
+
+// throughputTracker records finish times in a ring buffer
+// Safe for concurrent use by job goroutines
+type throughputTracker struct {
+	mu       sync.Mutex
+	finishes [throughputWindow]time.Time // Ring buffer of finish times
+	next     int                         // Position of the next write
+	count    int                         // Number of recorded finishes, up to the window size
+
+	minutes [throughputHistory]models.MinuteCount // Ring of per-minute counts indexed by minute
+}
+
+// minuteSlot returns the ring position of the minute containing at
+func minuteSlot(at time.Time) (time.Time, int) {
+	minute := at.Truncate(time.Minute)
+	return minute, int(minute.Unix()/60) % throughputHistory
+}
+
+// record adds the finish time of an execution
+func (t *throughputTracker) record(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finishes[t.next] = at
+	t.next = (t.next + 1) % throughputWindow
+	if t.count < throughputWindow {
+		t.count++
+	}
+
+	// Reuse the slot once its minute has left the history
+	minute, slot := minuteSlot(at)
+	if !t.minutes[slot].Minute.Equal(minute) {
+		t.minutes[slot] = models.MinuteCount{Minute: minute}
+	}
+	t.minutes[slot].Finished++
+}
+
+// stats returns per-minute finish counts up to the minute containing now
+func (t *throughputTracker) stats(now time.Time) *models.ThroughputStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, _ := minuteSlot(now)
+	stats := &models.ThroughputStats{History: make([]models.MinuteCount, 0, throughputHistory)}
+	total := 0
+	for i := throughputHistory - 1; i >= 0; i-- {
+		minute, slot := minuteSlot(current.Add(-time.Duration(i) * time.Minute))
+		count := models.MinuteCount{Minute: minute}
+		if t.minutes[slot].Minute.Equal(minute) {
+			count.Finished = t.minutes[slot].Finished
+		}
+		stats.History = append(stats.History, count)
+		if i > 0 && i <= throughputRate {
+			total += count.Finished
+		}
+	}
+	stats.RatePerMinute = float64(total) / throughputRate
+	return stats
+}
+
+// GetThroughput returns how many executions finished per minute recently
+func (o *Orchestrator) GetThroughput() *models.ThroughputStats {
+	return o.throughput.stats(time.Now())
+}
+
+// estimateWait predicts how long a job enqueued behind depth others waits
+// Jobs finding one of the free workers don't wait, the others wait for the jobs ahead of
+// them at the rate of recent finishes, measured up to the latest finish so idle periods
+// don't count as work. Reports false while too few finishes are recent enough to measure
+func (t *throughputTracker) estimateWait(depth, free int) (time.Duration, bool) {
+	if depth < free {
+		return 0, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count < 2 {
+		return 0, false
+	}
+
+	// Walk back from the latest finish while finishes are recent
+	latest := t.finishes[(t.next+throughputWindow-1)%throughputWindow]
+	earliest, finishes := latest, 1
+	for i := 2; i <= t.count; i++ {
+		at := t.finishes[(t.next+throughputWindow-i)%throughputWindow]
+		if latest.Sub(at) > throughputActivity {
+			break
+		}
+		earliest = at
+		finishes++
+	}
+	if finishes < 2 {
+		return 0, false
+	}
+
+	perJob := latest.Sub(earliest) / time.Duration(finishes-1)
+	if perJob < 0 {
+		perJob = 0
+	}
+	return perJob * time.Duration(depth-free+1), true
+}
+
+// free returns the number of idle workers of the pool
+func (p *workerPool) free() int {
+	return cap(p.slots) - len(p.slots)
+}
+
+// checkQueueTime rejects an execution that is expected to wait in the queue
+// longer than allowed by the definition's MaxQueueTimeSeconds
+// Returns an error wrapping ErrQueueFull if it would
+func (o *Orchestrator) checkQueueTime(jd *models.JobDefinition) error {
+	if jd.MaxQueueTimeSeconds <= 0 {
+		return nil
+	}
+	depth, err := o.db.GetQueuedJobCount()
+	if err != nil {
+		return err
+	}
+	pool := o.workerPool
+	if labeled, ok := o.pools[jd.PoolLabel]; ok {
+		pool = labeled
+	}
+	wait, ok := o.throughput.estimateWait(depth, pool.free())
+	if !ok {
+		return nil
+	}
+	if limit := time.Duration(jd.MaxQueueTimeSeconds) * time.Second; wait > limit {
+		return fmt.Errorf("%w: estimated wait of %s exceeds %s", ErrQueueFull, wait.Round(time.Second), limit)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// throughput_test.go tests the queue wait estimate behind maxQueueTimeSeconds
+// and the per-minute throughput reported for capacity planning
+// Finish times are mostly recorded directly so the tests don't depend on the clock
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// recordFinishes records count finishes spaced by gap, the last one at latest
+func recordFinishes(t *throughputTracker, count int, gap time.Duration, latest time.Time) {
+	for i := count - 1; i >= 0; i-- {
+		t.record(latest.Add(-time.Duration(i) * gap))
+	}
+}
+
+// TestEstimateWaitIgnoresIdleTime measures the rate over recent finishes only
+// An hour without work since the latest finish must not inflate the estimate
+func TestEstimateWaitIgnoresIdleTime(t *testing.T) {
+	var tracker throughputTracker
+	recordFinishes(&tracker, 10, time.Second, time.Now().Add(-time.Hour))
+
+	wait, ok := tracker.estimateWait(1, 0)
+	if !ok {
+		t.Fatal("no estimate after 10 finishes")
+	}
+	if want := 2 * time.Second; wait != want {
+		t.Fatalf("wait = %s, want %s", wait, want)
+	}
+}
+
+// TestEstimateWaitSkipsOldFinishes leaves finishes long before the latest out of the rate
+func TestEstimateWaitSkipsOldFinishes(t *testing.T) {
+	var tracker throughputTracker
+	now := time.Now()
+	recordFinishes(&tracker, 5, time.Minute, now.Add(-2*time.Hour))
+	recordFinishes(&tracker, 5, time.Second, now)
+
+	wait, ok := tracker.estimateWait(0, 0)
+	if !ok {
+		t.Fatal("no estimate after 10 finishes")
+	}
+	if want := time.Second; wait != want {
+		t.Fatalf("wait = %s, want %s", wait, want)
+	}
+}
+
+// TestEstimateWaitWithFreeWorkers expects no wait while a worker is free for the job
+func TestEstimateWaitWithFreeWorkers(t *testing.T) {
+	var tracker throughputTracker
+	recordFinishes(&tracker, 10, time.Minute, time.Now())
+
+	if wait, ok := tracker.estimateWait(2, 3); !ok || wait != 0 {
+		t.Fatalf("wait = %s, %v with free workers, want 0, true", wait, ok)
+	}
+	if wait, ok := tracker.estimateWait(3, 3); !ok || wait != time.Minute {
+		t.Fatalf("wait = %s, %v with all workers taken, want %s, true", wait, ok, time.Minute)
+	}
+}
+
+// TestEstimateWaitNeedsTwoFinishes reports no estimate before a rate can be measured
+func TestEstimateWaitNeedsTwoFinishes(t *testing.T) {
+	var tracker throughputTracker
+	if _, ok := tracker.estimateWait(5, 0); ok {
+		t.Fatal("estimate without finishes")
+	}
+	tracker.record(time.Now())
+	if _, ok := tracker.estimateWait(5, 0); ok {
+		t.Fatal("estimate after a single finish")
+	}
+}
+
+// TestCheckQueueTime rejects executions only while the queue is expected to take too long
+func TestCheckQueueTime(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	o.Pause(false)
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:                  "bounded",
+		MaxQueueTimeSeconds: 5,
+		Tasks:               []*models.Task{{ID: "a", FunctionName: "noop"}},
+	})
+
+	// Idle workers take the job right away however slow recent jobs were
+	recordFinishes(&o.throughput, 10, time.Minute, time.Now().Add(-time.Hour))
+	enqueue(t, o, "bounded", nil)
+
+	// One job ahead and a minute per job is too long
+	_, err := o.EnqueueJob("bounded", nil)
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("enqueue behind a slow queue: err = %v, want %v", err, ErrQueueFull)
+	}
+	if _, err := o.BulkEnqueue("bounded", []map[string]interface{}{{}}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("bulk enqueue behind a slow queue: err = %v, want %v", err, ErrQueueFull)
+	}
+}
+
+// TestThroughputStats records finishes in chosen minutes around a fixed now
+// The rate averages the 5 complete minutes before the current one, minutes
+// that left the history must not show up in the slot they share with a recent one
+func TestThroughputStats(t *testing.T) {
+	var tracker throughputTracker
+	now := time.Date(2024, 6, 1, 12, 0, 30, 0, time.UTC)
+	for minutesAgo, count := range map[int]int{0: 3, 1: 10, 3: 5, 6: 7, 20: 4} {
+		recordFinishes(&tracker, count, time.Second, now.Add(-time.Duration(minutesAgo)*time.Minute))
+	}
+
+	stats := tracker.stats(now)
+	if stats.RatePerMinute != 3 {
+		t.Errorf("rate = %v per minute, want 3", stats.RatePerMinute)
+	}
+	if len(stats.History) != throughputHistory {
+		t.Fatalf("history has %d minutes, want %d", len(stats.History), throughputHistory)
+	}
+	want := map[int]int{0: 3, 1: 10, 3: 5, 6: 7}
+	for i, count := range stats.History {
+		minutesAgo := throughputHistory - 1 - i
+		if wantMinute := now.Truncate(time.Minute).Add(-time.Duration(minutesAgo) * time.Minute); !count.Minute.Equal(wantMinute) {
+			t.Errorf("history[%d] minute = %v, want %v", i, count.Minute, wantMinute)
+		}
+		if count.Finished != want[minutesAgo] {
+			t.Errorf("%d minutes ago: %d finished, want %d", minutesAgo, count.Finished, want[minutesAgo])
+		}
+	}
+}
+
+// TestThroughputCountsCompletions runs jobs to completion and failure
+// Every finish counts, the rate includes them once their minute is complete
+func TestThroughputCountsCompletions(t *testing.T) {
+	o := newTestOrchestrator(t, 2)
+	o.RegisterFunction("ok", blockingFunction(nil, closedChannel(), nil))
+	o.RegisterFunction("fail", blockingFunction(nil, closedChannel(), errors.New("boom")))
+	registerDefinition(t, o, &models.JobDefinition{ID: "ok", Tasks: []*models.Task{{ID: "a", FunctionName: "ok"}}})
+	registerDefinition(t, o, &models.JobDefinition{ID: "fail", Tasks: []*models.Task{{ID: "a", FunctionName: "fail"}}})
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		ids = append(ids, enqueue(t, o, "ok", nil))
+	}
+	ids = append(ids, enqueue(t, o, "fail", nil))
+	for _, id := range ids {
+		waitForFinish(t, o, id)
+	}
+
+	finished := 0
+	for _, count := range o.GetThroughput().History {
+		finished += count.Finished
+	}
+	if finished != 5 {
+		t.Errorf("history counts %d finished, want 5", finished)
+	}
+	// A minute later the finishes fall into complete minutes, however they straddled one
+	if rate := o.throughput.stats(time.Now().Add(time.Minute)).RatePerMinute; rate != 1 {
+		t.Errorf("rate = %v per minute, want 5 finishes over 5 minutes", rate)
+	}
+}
@@ -0,0 +1,133 @@
+// timeout_test.go tests timeouts of task attempts
+// Tasks without their own timeout get the definition's share of the time left,
+// and tasks that keep timing out stop retrying after their consecutive limit
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestAttemptTimeout checks the timeout of an attempt against the time left
+func TestAttemptTimeout(t *testing.T) {
+	run := &jobRun{jd: &models.JobDefinition{TaskTimeoutPercent: 50}}
+	within := func(got, want time.Duration) bool {
+		return got <= want && got > want-time.Second
+	}
+
+	long, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	short, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+	if got := attemptTimeout(long, &models.Task{}, run); !within(got, 10*time.Second) {
+		t.Errorf("timeout with 20s left = %v, want about 10s", got)
+	}
+	if got := attemptTimeout(short, &models.Task{}, run); !within(got, 2*time.Second) {
+		t.Errorf("timeout with 4s left = %v, want about 2s", got)
+	}
+
+	// A task's own timeout wins, and without a deadline or share there is no limit
+	if got := attemptTimeout(short, &models.Task{TimeoutSeconds: 30}, run); got != 30*time.Second {
+		t.Errorf("timeout of a task with its own = %v, want 30s", got)
+	}
+	if got := attemptTimeout(context.Background(), &models.Task{}, run); got != 0 {
+		t.Errorf("timeout without a deadline = %v, want none", got)
+	}
+	if got := attemptTimeout(long, &models.Task{}, &jobRun{jd: &models.JobDefinition{}}); got != 0 {
+		t.Errorf("timeout without a share = %v, want none", got)
+	}
+
+	// An exhausted budget still leaves a minimal timeout
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if got := attemptTimeout(expired, &models.Task{}, run); got != time.Millisecond {
+		t.Errorf("timeout with no time left = %v, want 1ms", got)
+	}
+}
+
+// TestTaskTimeoutsShrink runs a pipeline whose tasks each use up part of the job's time
+// Every task must get a smaller timeout than the one before it
+func TestTaskTimeoutsShrink(t *testing.T) {
+	const step = 200 * time.Millisecond
+	o := newTestOrchestrator(t, 1)
+	var mu sync.Mutex
+	var budgets []time.Duration
+	o.RegisterFunction("stage", func(ctx context.Context, data map[string]interface{}) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Error("task runs without a deadline")
+		}
+		mu.Lock()
+		budgets = append(budgets, time.Until(deadline))
+		mu.Unlock()
+		time.Sleep(step)
+		return nil
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:                 "pipeline",
+		TimeoutSeconds:     10,
+		TaskTimeoutPercent: 50,
+		Tasks: []*models.Task{
+			{ID: "extract", FunctionName: "stage"},
+			{ID: "transform", FunctionName: "stage"},
+			{ID: "load", FunctionName: "stage"},
+		},
+	})
+
+	id := enqueue(t, o, "pipeline", nil)
+	if je := waitForFinish(t, o, id); je.Status != models.JobStatusCompleted {
+		t.Fatalf("status = %s, want COMPLETED: %s", je.Status, je.Error)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(budgets) != 3 {
+		t.Fatalf("budgets = %v, want one per task", budgets)
+	}
+	if budgets[0] > 5*time.Second {
+		t.Errorf("first task budget = %v, want at most half of the job's 10s", budgets[0])
+	}
+	// Each task used step, so the next gets at least half of it less
+	for i := 1; i < len(budgets); i++ {
+		if budgets[i] > budgets[i-1]-step/2 {
+			t.Errorf("task %d budget = %v after %v, want at least %v less", i, budgets[i], budgets[i-1], step/2)
+		}
+	}
+}
+
+// TestConsecutiveTimeoutsStopRetries runs a task that always times out
+// It must give up after its consecutive timeout limit, long before its retries run out
+func TestConsecutiveTimeoutsStopRetries(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	var attempts atomic.Int32
+	o.RegisterFunction("hang", func(ctx context.Context, data map[string]interface{}) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	registerDefinition(t, o, &models.JobDefinition{
+		ID: "stuck",
+		Tasks: []*models.Task{{
+			ID:                     "hang",
+			FunctionName:           "hang",
+			MaxRetry:               5,
+			RetryBaseDelayMs:       1,
+			TimeoutSeconds:         1,
+			MaxConsecutiveTimeouts: 2,
+		}},
+	})
+
+	je := waitForFinish(t, o, enqueue(t, o, "stuck", nil))
+	if je.Status != models.JobStatusFailed || !strings.Contains(je.Error, "timed out 2 consecutive times") {
+		t.Errorf("status = %s, error = %q, want FAILED after 2 timeouts", je.Status, je.Error)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("%d attempts, want 2 of the 6 allowed by retries", n)
+	}
+}
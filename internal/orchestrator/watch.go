@@ -0,0 +1,91 @@
+// watch.go streams the state of an execution to in-process callers
+// State changes are announced on the event bus and re-read from storage
+// Lets embedding programs follow a job without polling over HTTP
+package orchestrator
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// Watch tuning
+// Events may be dropped for a busy watcher, the poll interval bounds the delay that causes
+const (
+	watchBuffer       = 64              // Buffer of the event subscription and the update channel
+	watchPollInterval = 1 * time.Second // How often the state is re-read without events
+)
+
+// publishStateChange announces that an execution or one of its tasks changed state
+// Must be called after the change was persisted so watchers read the new state
+func publishStateChange(events *EventBus, je *models.JobExecution) {
+	events.Publish(models.Event{
+		Type:         models.EventExecutionStateChanged,
+		DefinitionID: je.DefinitionID,
+		ExecutionID:  je.ID,
+		Details:      map[string]interface{}{"status": je.Status},
+	})
+}
+
+// EnqueueAndWatch queues a new execution and streams its state
+// The channel receives the state whenever it changes and is closed once the
+// execution finished or the orchestrator shuts down. Callers must drain it
+func (o *Orchestrator) EnqueueAndWatch(definitionID string, data map[string]interface{}) (string, <-chan models.JobExecutionState, error) {
+	// Subscribe before enqueuing so no change of the new execution is missed
+	events, unsubscribe := o.events.Subscribe(watchBuffer)
+	executionID, err := o.EnqueueJob(definitionID, data)
+	if err != nil {
+		unsubscribe()
+		return "", nil, err
+	}
+
+	updates := make(chan models.JobExecutionState, watchBuffer)
+	go o.watch(executionID, events, unsubscribe, updates)
+	return executionID, updates, nil
+}
+
+// watch sends the state of an execution to updates until it finished
+// Reads the state on every event of the execution and periodically in between
+func (o *Orchestrator) watch(executionID string, events <-chan models.Event, unsubscribe func(), updates chan<- models.JobExecutionState) {
+	defer close(updates)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		// Only send states that differ from the previous one
+		state, err := o.GetJobExecutionState(executionID)
+		if err != nil {
+			return
+		}
+		if current, _ := json.Marshal(state); string(current) != string(last) {
+			last = current
+			select {
+			case updates <- *state:
+			case <-o.ctx.Done():
+				return
+			}
+		}
+		if state.Status.Finished() {
+			return
+		}
+
+		// Wait for the next change of this execution
+	wait:
+		for {
+			select {
+			case e := <-events:
+				if e.Type == models.EventExecutionStateChanged && e.ExecutionID == executionID {
+					break wait
+				}
+			case <-ticker.C:
+				break wait
+			case <-o.ctx.Done():
+				return
+			}
+		}
+	}
+}
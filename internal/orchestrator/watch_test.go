@@ -0,0 +1,121 @@
+// watch_test.go tests streaming execution state to in-process callers
+// Updates are consumed from the channel EnqueueAndWatch returns until it is closed,
+// which happens once the execution finished or the orchestrator shut down
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// nextUpdate returns the next state from updates, false once the channel is closed
+func nextUpdate(t *testing.T, updates <-chan models.JobExecutionState) (models.JobExecutionState, bool) {
+	t.Helper()
+	select {
+	case state, ok := <-updates:
+		return state, ok
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a state update")
+		return models.JobExecutionState{}, false
+	}
+}
+
+// TestEnqueueAndWatch follows a short two task job from start to finish
+// The first task blocks until its running state was received
+func TestEnqueueAndWatch(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	release := make(chan struct{})
+	o.RegisterFunction("wait", blockingFunction(nil, release, nil))
+	o.RegisterFunction("noop", blockingFunction(nil, closedChannel(), nil))
+	registerDefinition(t, o, &models.JobDefinition{
+		ID:    "short",
+		Tasks: []*models.Task{{ID: "first", FunctionName: "wait"}, {ID: "second", FunctionName: "noop"}},
+	})
+
+	id, updates, err := o.EnqueueAndWatch("short", nil)
+	if err != nil {
+		t.Fatalf("enqueue and watch: %v", err)
+	}
+	var states []models.JobExecutionState
+	for {
+		state, ok := nextUpdate(t, updates)
+		if !ok {
+			break
+		}
+		if state.ID != id {
+			t.Fatalf("update for %s, want %s", state.ID, id)
+		}
+		if n := len(states); n > 0 && reflect.DeepEqual(states[n-1], state) {
+			t.Errorf("update %d repeats the state before it", n)
+		}
+		states = append(states, state)
+
+		// The first task runs until the watcher saw it running
+		if state.Status == models.JobStatusRunning && len(state.Tasks) > 0 && state.Tasks[0].Status == models.TaskStatusRunning {
+			select {
+			case <-release:
+			default:
+				close(release)
+			}
+		}
+	}
+
+	select {
+	case <-release:
+	default:
+		t.Fatalf("never saw the first task running, states = %+v", states)
+	}
+	last := states[len(states)-1]
+	if last.Status != models.JobStatusCompleted {
+		t.Fatalf("last state %s, want COMPLETED", last.Status)
+	}
+	for _, task := range last.Tasks {
+		if task.Status != models.TaskStatusCompleted {
+			t.Errorf("task %s ended %s, want COMPLETED", task.ID, task.Status)
+		}
+	}
+	for i, state := range states[:len(states)-1] {
+		if state.Status.Finished() {
+			t.Errorf("update %d is already %s, want only the last one finished", i, state.Status)
+		}
+	}
+}
+
+// TestEnqueueAndWatchUnknownDefinition returns the enqueue error without a channel
+func TestEnqueueAndWatchUnknownDefinition(t *testing.T) {
+	o := newTestOrchestrator(t, 1)
+	if _, updates, err := o.EnqueueAndWatch("missing", nil); !errors.Is(err, ErrNotFound) || updates != nil {
+		t.Errorf("enqueue and watch = %v, %v, want ErrNotFound without updates", updates, err)
+	}
+}
+
+// TestEnqueueAndWatchShutdown closes the channel of a job still running at shutdown
+func TestEnqueueAndWatchShutdown(t *testing.T) {
+	o, err := New(openTestDB(t), 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	started := make(chan struct{}, 1)
+	o.RegisterFunction("wait", blockingFunction(started, nil, nil))
+	registerDefinition(t, o, &models.JobDefinition{ID: "long", Tasks: []*models.Task{{ID: "a", FunctionName: "wait"}}})
+
+	_, updates, err := o.EnqueueAndWatch("long", nil)
+	if err != nil {
+		t.Fatalf("enqueue and watch: %v", err)
+	}
+	<-started
+	o.CloseWithTimeout(10 * time.Millisecond)
+	for {
+		state, ok := nextUpdate(t, updates)
+		if !ok {
+			break
+		}
+		if state.Status.Finished() {
+			t.Errorf("state %s, want the interrupted job left unfinished", state.Status)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// nats.go publishes execution outcomes to a NATS server
+// Each outcome is sent as JSON to a subject suffixed with its status
+// Lets consumers subscribe to e.g. only failed executions
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes outcomes to NATS subjects
+// Outcomes go to "<subject>.<STATUS>", e.g. "orchestrator.outcomes.FAILED"
+type NATSPublisher struct {
+	conn    *nats.Conn // Connection to the NATS server
+	subject string     // Subject prefix of published outcomes
+}
+
+// NewNATSPublisher connects to the NATS server at url
+// The connection reconnects on its own after server restarts
+func NewNATSPublisher(url, subject string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to NATS, %v", err)
+	}
+	return &NATSPublisher{conn: conn, subject: subject}, nil
+}
+
+// Publish sends an outcome and waits for the server to receive it
+// The flush round trip turns a lost connection into an error the caller retries
+func (p *NATSPublisher) Publish(ctx context.Context, outcome models.ExecutionOutcome) error {
+	buf, err := json.Marshal(outcome)
+	if err != nil {
+		return err
+	}
+	if err := p.conn.Publish(p.subject+"."+string(outcome.Status), buf); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close drains pending messages and closes the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,238 @@
+// archive.go implements exporting and importing the full BoltDB state
+// The archive is a stream of JSON records, one per line, after a versioned header
+// Used for backups and for migrating state into a fresh store
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+
+	"go.etcd.io/bbolt"
+)
+
+// Archive format identifiers written to the header record
+const (
+	archiveFormat  = "go-job-orchestrator"
+	archiveVersion = 1
+)
+
+// ErrStoreNotEmpty is returned by Import when the store already holds data
+var ErrStoreNotEmpty = errors.New("store is not empty")
+
+// ErrInvalidArchive is returned by Import when the archive can't be read
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Archive record types, the header always comes first
+const (
+	recordHeader     = "header"
+	recordDefinition = "definition"
+	recordExecution  = "execution"
+	recordQueued     = "queued"
+	recordScheduled  = "scheduled"
+	recordAudit      = "audit"
+	recordStats      = "stats"
+)
+
+// archiveRecord is a single line of an archive
+// Only the fields of the record's type are set
+type archiveRecord struct {
+	Type         string          `json:"type"`
+	Format       string          `json:"format,omitempty"`       // Header: archive format name
+	Version      int             `json:"version,omitempty"`      // Header: archive format version
+	Value        json.RawMessage `json:"value,omitempty"`        // Definition, execution, or audit entry
+	ExecutionID  string          `json:"executionId,omitempty"`  // Queued or scheduled execution
+	At           *time.Time      `json:"at,omitempty"`           // Start time of a scheduled execution
+	ExecutedJobs uint64          `json:"executedJobs,omitempty"` // Stats: executed jobs counter
+}
+
+// Export writes the complete state to w as an archive
+// Reads everything in one transaction, so the archive is a consistent snapshot
+// Partial task status updates are folded into their executions
+func (b *BoltDB) Export(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		if err := enc.Encode(archiveRecord{Type: recordHeader, Format: archiveFormat, Version: archiveVersion}); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(jobDefinitionsBucket)).ForEach(func(_, v []byte) error {
+			return enc.Encode(archiveRecord{Type: recordDefinition, Value: v})
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(_, v []byte) error {
+			// Archives hold plain JSON even when the store compresses executions
+			v, err := executionJSON(v)
+			if err != nil {
+				return err
+			}
+
+			// Keep numbers as they are so data survives the round trip exactly
+			var je models.JobExecution
+			dec := json.NewDecoder(bytes.NewReader(v))
+			dec.UseNumber()
+			if err := dec.Decode(&je); err != nil {
+				return err
+			}
+			mergeTaskStatuses(tx, &je)
+			buf, err := json.Marshal(&je)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(archiveRecord{Type: recordExecution, Value: buf})
+		}); err != nil {
+			return err
+		}
+
+		// Queue entries in enqueue order, the importing store assigns new keys
+		if err := tx.Bucket([]byte(queueBucket)).ForEach(func(k, _ []byte) error {
+			return enc.Encode(archiveRecord{Type: recordQueued, ExecutionID: queuedJobID(k)})
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(scheduledBucket)).ForEach(func(k, _ []byte) error {
+			at := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+			return enc.Encode(archiveRecord{Type: recordScheduled, ExecutionID: string(k[8:]), At: &at})
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(auditBucket)).ForEach(func(_, v []byte) error {
+			return enc.Encode(archiveRecord{Type: recordAudit, Value: v})
+		}); err != nil {
+			return err
+		}
+
+		var executed uint64
+		if v := tx.Bucket([]byte(statsBucket)).Get([]byte(executedJobsCountKey)); v != nil {
+			executed = binary.BigEndian.Uint64(v)
+		}
+		return enc.Encode(archiveRecord{Type: recordStats, ExecutedJobs: executed})
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Import restores an archive written by Export into this store
+// The store must not hold any definitions or executions yet
+// Restores everything in one transaction, a failed import leaves the store empty
+func (b *BoltDB) Import(r io.Reader) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{jobDefinitionsBucket, jobExecutionsBucket, queueBucket, scheduledBucket} {
+			if k, _ := tx.Bucket([]byte(name)).Cursor().First(); k != nil {
+				return fmt.Errorf("%w: %s bucket holds data", ErrStoreNotEmpty, name)
+			}
+		}
+
+		dec := json.NewDecoder(bufio.NewReader(r))
+		var header archiveRecord
+		if err := dec.Decode(&header); err != nil {
+			return fmt.Errorf("%w: reading header: %v", ErrInvalidArchive, err)
+		}
+		if header.Type != recordHeader || header.Format != archiveFormat {
+			return fmt.Errorf("%w: missing header", ErrInvalidArchive)
+		}
+		if header.Version != archiveVersion {
+			return fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, header.Version)
+		}
+
+		for {
+			var rec archiveRecord
+			if err := dec.Decode(&rec); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			if err := importRecord(tx, &rec); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// importRecord writes a single archive record
+// Must be called within a read-write transaction
+func importRecord(tx *bbolt.Tx, rec *archiveRecord) error {
+	switch rec.Type {
+	case recordDefinition:
+		var jd models.JobDefinition
+		if err := json.Unmarshal(rec.Value, &jd); err != nil {
+			return fmt.Errorf("%w: definition: %v", ErrInvalidArchive, err)
+		}
+		return putJobDefinition(tx, &jd)
+
+	case recordExecution:
+		var je struct {
+			ID        string           `json:"id"`
+			StartTime time.Time        `json:"startTime"`
+			EndTime   time.Time        `json:"endTime"`
+			Status    models.JobStatus `json:"status"`
+		}
+		if err := json.Unmarshal(rec.Value, &je); err != nil || je.ID == "" {
+			return fmt.Errorf("%w: execution without ID", ErrInvalidArchive)
+		}
+		if err := countStatusChange(tx, "", je.Status); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte(executionTimesBucket)).Put(executionTimeKey(je.StartTime, je.ID), []byte{}); err != nil {
+			return err
+		}
+		if err := indexFinished(tx, je.ID, je.Status, je.EndTime); err != nil {
+			return err
+		}
+		if err := indexStoredUnfinished(tx, je.ID, rec.Value); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(jobExecutionsBucket)).Put([]byte(je.ID), rec.Value)
+
+	case recordQueued:
+		_, err := queuePut(tx, rec.ExecutionID)
+		return err
+
+	case recordScheduled:
+		if rec.At == nil {
+			return fmt.Errorf("%w: scheduled execution %s without start time", ErrInvalidArchive, rec.ExecutionID)
+		}
+		return tx.Bucket([]byte(scheduledBucket)).Put(scheduledKey(*rec.At, rec.ExecutionID), []byte{})
+
+	case recordAudit:
+		// Keep the entry's ID and advance the sequence past it
+		var entry models.AuditEntry
+		if err := json.Unmarshal(rec.Value, &entry); err != nil {
+			return fmt.Errorf("%w: audit entry: %v", ErrInvalidArchive, err)
+		}
+		bucket := tx.Bucket([]byte(auditBucket))
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, entry.ID)
+		if err := bucket.Put(key, rec.Value); err != nil {
+			return err
+		}
+		if entry.ID > bucket.Sequence() {
+			return bucket.SetSequence(entry.ID)
+		}
+		return nil
+
+	case recordStats:
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, rec.ExecutedJobs)
+		return tx.Bucket([]byte(statsBucket)).Put([]byte(executedJobsCountKey), buf)
+
+	default:
+		return fmt.Errorf("%w: unknown record type %q", ErrInvalidArchive, rec.Type)
+	}
+}
@@ -0,0 +1,161 @@
+// archive_test.go tests exporting a store and importing it into a fresh one
+// A populated store is round-tripped and compared record by record
+// Covers the queue order, delayed jobs, indexes, and rejected imports
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// populate fills a store with definitions, executions in each state, queued and
+// delayed jobs, audit entries, and the executed jobs counter
+func populate(t *testing.T, db *BoltDB, now time.Time) {
+	t.Helper()
+	for _, jd := range []*models.JobDefinition{
+		{ID: "report", Name: "Report", Tags: []string{"nightly"}, Tasks: []*models.Task{{ID: "build", FunctionName: "build"}}},
+		{ID: "cleanup", Name: "Cleanup", Tasks: []*models.Task{{ID: "sweep", FunctionName: "sweep"}}},
+	} {
+		if err := db.StoreJobDefinition(jd); err != nil {
+			t.Fatalf("store definition %s: %v", jd.ID, err)
+		}
+	}
+
+	for _, je := range []*models.JobExecution{
+		{ID: "done", DefinitionID: "cleanup", Status: models.JobStatusCompleted, StartTime: now.Add(-time.Hour), EndTime: now.Add(-time.Minute)},
+		{ID: "running", DefinitionID: "report", Status: models.JobStatusRunning, StartTime: now.Add(-time.Minute), Data: map[string]interface{}{"day": "monday"}},
+		{ID: "queued-low", DefinitionID: "report", Status: models.JobStatusQueued, StartTime: now, Priority: 1},
+		{ID: "queued-high", DefinitionID: "cleanup", Status: models.JobStatusQueued, StartTime: now, Priority: 9},
+		{ID: "delayed", DefinitionID: "report", Status: models.JobStatusQueued, StartTime: now, ScheduledAt: now.Add(time.Hour)},
+	} {
+		storeExecution(t, db, je)
+	}
+	if err := db.UpdateTaskStatus("running", "build", models.TaskStatusRunning); err != nil {
+		t.Fatalf("update task status: %v", err)
+	}
+	for _, id := range []string{"queued-low", "queued-high"} {
+		if err := db.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+	if err := db.ScheduleJob("delayed", now.Add(time.Hour)); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	for _, action := range []string{"cancel", "retry"} {
+		if err := db.AppendAuditEntry(&models.AuditEntry{Time: now, Action: action, Target: "done", Actor: "ops", Result: "ok"}); err != nil {
+			t.Fatalf("append audit entry: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := db.IncrementExecutedJobsCount(); err != nil {
+			t.Fatalf("increment executed jobs: %v", err)
+		}
+	}
+}
+
+// roundTrip exports src and imports the archive into a fresh store
+func roundTrip(t *testing.T, src *BoltDB) *BoltDB {
+	t.Helper()
+	var archive bytes.Buffer
+	if err := src.Export(&archive); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dst := openTestBoltDB(t, Options{})
+	if err := dst.Import(&archive); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	return dst
+}
+
+// TestExportImportRoundTrip restores a populated store and compares every record
+func TestExportImportRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	src := openTestBoltDB(t, Options{})
+	populate(t, src, now)
+	dst := roundTrip(t, src)
+
+	// Definitions and executions, including the partial task status update
+	wantDefs, _ := src.ListJobDefinitions()
+	gotDefs, err := dst.ListJobDefinitions()
+	if err != nil || !reflect.DeepEqual(gotDefs, wantDefs) {
+		t.Errorf("definitions = %v, %v, want %v", gotDefs, err, wantDefs)
+	}
+	for _, id := range []string{"done", "running", "queued-low", "queued-high", "delayed"} {
+		want, _ := src.GetJobExecution(id)
+		got, err := dst.GetJobExecution(id)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("execution %s = %+v, %v, want %+v", id, got, err, want)
+		}
+	}
+	if je, _ := dst.GetJobExecution("running"); je.TaskStatuses["build"] != models.TaskStatusRunning {
+		t.Errorf("task statuses of running = %v, want build RUNNING", je.TaskStatuses)
+	}
+
+	// Queue order, delayed jobs, audit log, and counters
+	if got := drainQueue(t, dst); !slices.Equal(got, []string{"queued-high", "queued-low"}) {
+		t.Errorf("dequeue order = %v, want [queued-high queued-low]", got)
+	}
+	if promoted, err := dst.PromoteDueJobs(now.Add(2 * time.Hour)); err != nil || !slices.Equal(promoted, []string{"delayed"}) {
+		t.Errorf("promoted = %v, %v, want [delayed]", promoted, err)
+	}
+	wantAudit, _ := src.ListAuditEntries(0, 10)
+	gotAudit, err := dst.ListAuditEntries(0, 10)
+	if err != nil || !reflect.DeepEqual(gotAudit, wantAudit) {
+		t.Errorf("audit entries = %v, %v, want %v", gotAudit, err, wantAudit)
+	}
+	if count, err := dst.GetExecutedJobsCount(); err != nil || count != 3 {
+		t.Errorf("executed jobs = %d, %v, want 3", count, err)
+	}
+
+	// Indexes are rebuilt along with the records
+	if tagged, err := dst.ListJobDefinitionsByTag("nightly"); err != nil || len(tagged) != 1 || tagged[0].ID != "report" {
+		t.Errorf("definitions tagged nightly = %v, %v, want [report]", tagged, err)
+	}
+	wantCounts, _ := src.CountExecutionsByStatus()
+	if counts, err := dst.CountExecutionsByStatus(); err != nil || !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("status counts = %v, %v, want %v", counts, err, wantCounts)
+	}
+	if finished, err := dst.ListFinishedExecutions(10); err != nil || len(finished) != 1 || finished[0].ID != "done" {
+		t.Errorf("finished executions = %v, %v, want [done]", finished, err)
+	}
+	if err := dst.DeleteJobDefinition("report"); !errors.Is(err, ErrDefinitionInUse) {
+		t.Errorf("delete definition with unfinished executions = %v, want ErrDefinitionInUse", err)
+	}
+}
+
+// TestImportRejectsNonEmptyStore refuses to import over existing data
+func TestImportRejectsNonEmptyStore(t *testing.T) {
+	src := openTestBoltDB(t, Options{})
+	populate(t, src, time.Now())
+	var archive bytes.Buffer
+	if err := src.Export(&archive); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := src.Import(&archive); !errors.Is(err, ErrStoreNotEmpty) {
+		t.Errorf("import into a populated store = %v, want ErrStoreNotEmpty", err)
+	}
+}
+
+// TestImportRejectsInvalidArchive refuses input that isn't an archive of this version
+func TestImportRejectsInvalidArchive(t *testing.T) {
+	for name, archive := range map[string]string{
+		"empty":     "",
+		"no header": `{"type":"definition","value":{"id":"report"}}` + "\n",
+		"version":   `{"type":"header","format":"go-job-orchestrator","version":99}` + "\n",
+		"garbage":   `{"type":"header","format":"go-job-orchestrator","version":1}` + "\nnot json\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := openTestBoltDB(t, Options{})
+			if err := db.Import(strings.NewReader(archive)); !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("import = %v, want ErrInvalidArchive", err)
+			}
+		})
+	}
+}
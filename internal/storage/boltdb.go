@@ -4,9 +4,12 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/fawad1985/go-job-orchestrator/pkg/models"
@@ -20,26 +23,89 @@ const (
 	jobDefinitionsBucket = "job_definitions"
 	jobExecutionsBucket  = "job_executions"
 	queueBucket          = "queue"
+	queueIndexBucket     = "queue_index"
 	statsBucket          = "stats"
+	definitionTagsBucket = "definition_tags"
+	taskStatusesBucket   = "task_statuses"
+	auditBucket          = "audit"
+	scheduledBucket      = "scheduled"
+	executionTimesBucket = "execution_times"
+	statusCountsBucket   = "status_counts"
+	finishedTimesBucket  = "finished_times"
+	unfinishedBucket     = "unfinished_executions"
 )
 
+// ErrNotFound is returned when a requested record does not exist
+// Wrapped with the record type, e.g. "job definition not found"
+var ErrNotFound = errors.New("not found")
+
+// ErrQueueEmpty is returned by DequeueJob when no job is waiting
+var ErrQueueEmpty = errors.New("queue is empty")
+
+// ErrAlreadyQueued is returned by EnqueueJob when the execution is already queued
+var ErrAlreadyQueued = errors.New("execution is already queued")
+
+// Options configures optional BoltDB behavior
+// The zero value gives the default behavior
+type Options struct {
+	// AllowDuplicateEnqueue makes enqueuing an already queued execution
+	// a silent no-op instead of returning ErrAlreadyQueued
+	AllowDuplicateEnqueue bool
+
+	// UseNumber decodes numbers in execution data as json.Number
+	// instead of float64, preserving the precision of large integers
+	UseNumber bool
+
+	// Discipline selects the dequeue order, FIFO by default
+	Discipline QueueDiscipline
+
+	// CompressExecutions gzips execution records before storing them
+	// Records are read back either way, so it can be turned on or off for an existing database
+	CompressExecutions bool
+
+	// InstanceID names this process in the execution leases of shared storage
+	// Must differ between processes sharing it, defaults to the host name
+	InstanceID string
+}
+
 // DB interface defines all storage operations
 // Abstracts storage implementation details from the rest of the system
 // Enables potential future support for different storage backends
 type DB interface {
 	StoreJobDefinition(jd *models.JobDefinition) error
 	GetJobDefinition(id string) (*models.JobDefinition, error)
+	ListJobDefinitions() ([]*models.JobDefinition, error)
+	ListJobDefinitionsByTag(tag string) ([]*models.JobDefinition, error)
+	UpdateJobDefinition(id string, update func(jd *models.JobDefinition) error) error
+	DeleteJobDefinition(id string) error
 	GetRunningJobs() ([]string, error)
+	ListUnqueuedJobs() ([]string, error)
 	StoreJobExecution(je *models.JobExecution) error
 	GetJobExecution(id string) (*models.JobExecution, error)
+	ForEachJobExecution(fn func(je *models.JobExecution) error) error
+	ListJobExecutions(status models.JobStatus) ([]*models.JobExecution, error)
+	ListJobExecutionsByTime(from, to time.Time) ([]*models.JobExecution, error)
 	UpdateJobExecution(je *models.JobExecution) error
+	UpdateTaskStatus(executionID, taskID string, status models.TaskStatus) error
 	GetQueuedJobs() ([]string, error)
+	ListQueuedExecutions() ([]*models.QueuedExecution, error)
 	EnqueueJob(jobID string) error
 	DequeueJob() (string, error)
 	GetQueuedJobCount() (int, error)
 	RemoveFromQueue(jobID string) error
+	ScheduleJob(jobID string, at time.Time) error
+	PromoteDueJobs(now time.Time) ([]string, error)
 	IncrementExecutedJobsCount() error
 	GetExecutedJobsCount() (int, error)
+	CountExecutionsByStatus() (map[models.JobStatus]int, error)
+	ListFinishedExecutions(limit int) ([]*models.JobExecution, error)
+	Snapshot() (*Snapshot, error)
+	AppendAuditEntry(entry *models.AuditEntry) error
+	ListAuditEntries(offset, limit int) ([]*models.AuditEntry, error)
+	LeaseDuration() time.Duration
+	ClaimExecution(id string) (bool, error)
+	RenewLeases(ids []string) ([]string, error)
+	LeasedExecutions() (map[string]bool, error)
 	Close() error
 }
 
@@ -47,13 +113,20 @@ type DB interface {
 // Provides persistent, transactional storage
 // Handles all database operations
 type BoltDB struct {
-	db *bbolt.DB // Underlying BoltDB instance
+	db   *bbolt.DB // Underlying BoltDB instance
+	opts Options   // Optional behavior
 }
 
 // NewBoltDB creates and initializes a new BoltDB instance
 // Creates required buckets if they don't exist
 // Returns initialized database connection
 func NewBoltDB(path string) (*BoltDB, error) {
+	return NewBoltDBWithOptions(path, Options{})
+}
+
+// NewBoltDBWithOptions creates a BoltDB instance with optional behavior
+// Behaves like NewBoltDB otherwise
+func NewBoltDBWithOptions(path string, opts Options) (*BoltDB, error) {
 	// Open BoltDB with a 1-second timeout
 	// Creates the database file if it doesn't exist
 	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
@@ -64,13 +137,57 @@ func NewBoltDB(path string) (*BoltDB, error) {
 	// Create required buckets in a single transaction
 	// Ensures database is properly initialized
 	err = db.Update(func(tx *bbolt.Tx) error {
-		buckets := []string{jobDefinitionsBucket, jobExecutionsBucket, queueBucket, statsBucket}
+		// Databases without a queue index hold queue keys of the old layout
+		// Databases without the queue layout marker hold queue keys without priorities
+		// Databases without a time index hold executions that aren't indexed yet
+		// Databases without status counters hold executions that aren't counted yet
+		// Databases without a finished index hold finished executions that aren't indexed yet
+		// Databases without an unfinished index hold unfinished executions that aren't indexed yet
+		migrate := tx.Bucket([]byte(queueIndexBucket)) == nil
+		backfill := tx.Bucket([]byte(executionTimesBucket)) == nil
+		count := tx.Bucket([]byte(statusCountsBucket)) == nil
+		finished := tx.Bucket([]byte(finishedTimesBucket)) == nil
+		unfinished := tx.Bucket([]byte(unfinishedBucket)) == nil
+
+		buckets := []string{jobDefinitionsBucket, jobExecutionsBucket, queueBucket, queueIndexBucket, statsBucket, definitionTagsBucket, taskStatusesBucket, auditBucket, scheduledBucket, executionTimesBucket, statusCountsBucket, finishedTimesBucket, unfinishedBucket}
 		for _, bucket := range buckets {
 			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
 			if err != nil {
 				return fmt.Errorf("could not create %s bucket: %v", bucket, err)
 			}
 		}
+		stats := tx.Bucket([]byte(statsBucket))
+		if stats.Get([]byte(queueLayoutKey)) == nil {
+			// Plain job ID keys are re-keyed to the current layout in one step
+			if migrate {
+				if err := migrateQueue(tx); err != nil {
+					return err
+				}
+			} else if err := migrateQueuePriorities(tx); err != nil {
+				return err
+			}
+			if err := stats.Put([]byte(queueLayoutKey), []byte{1}); err != nil {
+				return err
+			}
+		}
+		if backfill {
+			if err := backfillExecutionTimes(tx); err != nil {
+				return err
+			}
+		}
+		if count {
+			if err := backfillStatusCounts(tx); err != nil {
+				return err
+			}
+		}
+		if finished {
+			if err := backfillFinishedTimes(tx); err != nil {
+				return err
+			}
+		}
+		if unfinished {
+			return backfillUnfinished(tx)
+		}
 		return nil
 	})
 
@@ -78,7 +195,7 @@ func NewBoltDB(path string) (*BoltDB, error) {
 		return nil, fmt.Errorf("could not set up buckets, %v", err)
 	}
 
-	return &BoltDB{db: db}, nil
+	return &BoltDB{db: db, opts: opts}, nil
 }
 
 // StoreJobDefinition saves a job definition to the database
@@ -86,13 +203,49 @@ func NewBoltDB(path string) (*BoltDB, error) {
 // Operates in a single transaction
 func (b *BoltDB) StoreJobDefinition(jd *models.JobDefinition) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(jobDefinitionsBucket))
-		buf, err := json.Marshal(jd)
-		if err != nil {
+		return putJobDefinition(tx, jd)
+	})
+}
+
+// putJobDefinition writes a job definition and keeps the tag index in sync
+// Must be called within a read-write transaction
+func putJobDefinition(tx *bbolt.Tx, jd *models.JobDefinition) error {
+	bucket := tx.Bucket([]byte(jobDefinitionsBucket))
+	tags := tx.Bucket([]byte(definitionTagsBucket))
+
+	// Drop index entries of the previously stored version
+	if v := bucket.Get([]byte(jd.ID)); v != nil {
+		var old models.JobDefinition
+		if err := json.Unmarshal(v, &old); err != nil {
 			return err
 		}
-		return bucket.Put([]byte(jd.ID), buf)
-	})
+		for _, tag := range old.Tags {
+			if err := tags.Delete(tagIndexKey(tag, old.ID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	buf, err := json.Marshal(jd)
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put([]byte(jd.ID), buf); err != nil {
+		return err
+	}
+
+	for _, tag := range jd.Tags {
+		if err := tags.Put(tagIndexKey(tag, jd.ID), []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagIndexKey builds the tag index key for a definition
+// Keys are prefixed by tag so all definitions of a tag are adjacent
+func tagIndexKey(tag, definitionID string) []byte {
+	return []byte(tag + "\x00" + definitionID)
 }
 
 // GetJobDefinition retrieves a job definition by ID
@@ -104,7 +257,7 @@ func (b *BoltDB) GetJobDefinition(id string) (*models.JobDefinition, error) {
 		bucket := tx.Bucket([]byte(jobDefinitionsBucket))
 		v := bucket.Get([]byte(id))
 		if v == nil {
-			return fmt.Errorf("job definition not found")
+			return fmt.Errorf("job definition %w", ErrNotFound)
 		}
 		return json.Unmarshal(v, &jd)
 	})
@@ -114,20 +267,111 @@ func (b *BoltDB) GetJobDefinition(id string) (*models.JobDefinition, error) {
 	return &jd, nil
 }
 
+// DeleteJobDefinition removes a job definition and its tag index entries
+// Finished executions of the definition are kept
+// Returns ErrNotFound if the definition doesn't exist and ErrDefinitionInUse
+// while unfinished executions run it, checked in the same transaction
+func (b *BoltDB) DeleteJobDefinition(id string) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobDefinitionsBucket))
+		v := bucket.Get([]byte(id))
+		if v == nil {
+			return fmt.Errorf("job definition %w", ErrNotFound)
+		}
+		if err := checkUnused(tx, id); err != nil {
+			return err
+		}
+		var jd models.JobDefinition
+		if err := json.Unmarshal(v, &jd); err != nil {
+			return err
+		}
+		tags := tx.Bucket([]byte(definitionTagsBucket))
+		for _, tag := range jd.Tags {
+			if err := tags.Delete(tagIndexKey(tag, id)); err != nil {
+				return err
+			}
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// UpdateJobDefinition modifies a stored job definition atomically
+// Reads, updates, and writes the definition in a single transaction
+// The update is discarded if the update function returns an error
+func (b *BoltDB) UpdateJobDefinition(id string, update func(jd *models.JobDefinition) error) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobDefinitionsBucket))
+		v := bucket.Get([]byte(id))
+		if v == nil {
+			return fmt.Errorf("job definition %w", ErrNotFound)
+		}
+		var jd models.JobDefinition
+		if err := json.Unmarshal(v, &jd); err != nil {
+			return err
+		}
+		if err := update(&jd); err != nil {
+			return err
+		}
+		return putJobDefinition(tx, &jd)
+	})
+}
+
+// ListJobDefinitions returns all registered job definitions
+// Returns definitions ordered by ID
+func (b *BoltDB) ListJobDefinitions() ([]*models.JobDefinition, error) {
+	definitions := []*models.JobDefinition{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(jobDefinitionsBucket)).ForEach(func(k, v []byte) error {
+			var jd models.JobDefinition
+			if err := json.Unmarshal(v, &jd); err != nil {
+				return err
+			}
+			definitions = append(definitions, &jd)
+			return nil
+		})
+	})
+	return definitions, err
+}
+
+// ListJobDefinitionsByTag returns all job definitions carrying a tag
+// Uses the tag index so only matching definitions are read
+// Returns definitions ordered by ID
+func (b *BoltDB) ListJobDefinitionsByTag(tag string) ([]*models.JobDefinition, error) {
+	definitions := []*models.JobDefinition{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobDefinitionsBucket))
+		prefix := []byte(tag + "\x00")
+		c := tx.Bucket([]byte(definitionTagsBucket)).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			v := bucket.Get(k[len(prefix):])
+			if v == nil {
+				continue
+			}
+			var jd models.JobDefinition
+			if err := json.Unmarshal(v, &jd); err != nil {
+				return err
+			}
+			definitions = append(definitions, &jd)
+		}
+		return nil
+	})
+	return definitions, err
+}
+
 // GetRunningJobs returns IDs of all currently running jobs
-// Scans job executions bucket for jobs in RUNNING state
+// Scans job executions bucket for jobs in RUNNING or PAUSED state
 // Used for state recovery after system restart
 func (b *BoltDB) GetRunningJobs() ([]string, error) {
 	var runningJobs []string
 	err := b.db.View(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(jobExecutionsBucket))
 		return bucket.ForEach(func(k, v []byte) error {
-			var je models.JobExecution
-			if err := json.Unmarshal(v, &je); err != nil {
+			status, err := storedStatus(v)
+			if err != nil {
 				return err
 			}
-			if je.Status == models.JobStatusRunning {
-				runningJobs = append(runningJobs, je.ID)
+			if status == models.JobStatusRunning || status == models.JobStatusPaused {
+				runningJobs = append(runningJobs, string(k))
 			}
 			return nil
 		})
@@ -135,20 +379,113 @@ func (b *BoltDB) GetRunningJobs() ([]string, error) {
 	return runningJobs, err
 }
 
+// ListUnqueuedJobs returns IDs of QUEUED executions that are neither queued nor scheduled
+// Such executions were waiting in memory, e.g. set aside at a concurrency limit,
+// when the process stopped, used for state recovery after system restart
+func (b *BoltDB) ListUnqueuedJobs() ([]string, error) {
+	var unqueued []string
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		scheduled := make(map[string]bool)
+		err := tx.Bucket([]byte(scheduledBucket)).ForEach(func(k, _ []byte) error {
+			scheduled[string(k[8:])] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		index := tx.Bucket([]byte(queueIndexBucket))
+		return tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(k, v []byte) error {
+			status, err := storedStatus(v)
+			if err != nil {
+				return err
+			}
+			if status == models.JobStatusQueued && index.Get(k) == nil && !scheduled[string(k)] {
+				unqueued = append(unqueued, string(k))
+			}
+			return nil
+		})
+	})
+	return unqueued, err
+}
+
 // StoreJobExecution saves a job execution instance
 // Handles both new executions and updates
 // Uses JSON serialization
+// Supersedes any partial task status updates of the execution
 func (b *BoltDB) StoreJobExecution(je *models.JobExecution) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(jobExecutionsBucket))
-		buf, err := json.Marshal(je)
+		buf, err := b.encodeExecution(je)
 		if err != nil {
 			return err
 		}
-		return bucket.Put([]byte(je.ID), buf)
+
+		// Move the execution to the counter of its new status
+		var previous models.JobStatus
+		if existing := bucket.Get([]byte(je.ID)); existing != nil {
+			if previous, err = storedStatus(existing); err != nil {
+				return err
+			}
+		}
+		if err := countStatusChange(tx, previous, je.Status); err != nil {
+			return err
+		}
+
+		if err := bucket.Put([]byte(je.ID), buf); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte(executionTimesBucket)).Put(executionTimeKey(je.StartTime, je.ID), []byte{}); err != nil {
+			return err
+		}
+		if err := indexFinished(tx, je.ID, je.Status, je.EndTime); err != nil {
+			return err
+		}
+		if err := indexUnfinished(tx, je.DefinitionID, je.ID, je.Status, je.Definition != nil); err != nil {
+			return err
+		}
+
+		// The full record is authoritative, drop partial updates it includes
+		statuses := tx.Bucket([]byte(taskStatusesBucket))
+		prefix := taskStatusPrefix(je.ID)
+		c := statuses.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			if err := statuses.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
+// UpdateTaskStatus records the status of a single task of an execution
+// Writes only the changed status instead of the whole execution record
+// Partial updates are merged into the execution when it is read
+func (b *BoltDB) UpdateTaskStatus(executionID, taskID string, status models.TaskStatus) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		key := append(taskStatusPrefix(executionID), taskID...)
+		return tx.Bucket([]byte(taskStatusesBucket)).Put(key, []byte(status))
+	})
+}
+
+// taskStatusPrefix returns the key prefix of an execution's partial task statuses
+func taskStatusPrefix(executionID string) []byte {
+	return []byte(executionID + "\x00")
+}
+
+// mergeTaskStatuses applies partial task status updates to an execution
+// Must be called within a transaction
+func mergeTaskStatuses(tx *bbolt.Tx, je *models.JobExecution) {
+	prefix := taskStatusPrefix(je.ID)
+	c := tx.Bucket([]byte(taskStatusesBucket)).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if je.TaskStatuses == nil {
+			je.TaskStatuses = make(map[string]models.TaskStatus)
+		}
+		je.TaskStatuses[string(k[len(prefix):])] = models.TaskStatus(v)
+	}
+}
+
 // GetJobExecution retrieves job execution details by ID
 // Deserializes stored JSON into JobExecution struct
 // Returns error if execution not found
@@ -158,9 +495,13 @@ func (b *BoltDB) GetJobExecution(id string) (*models.JobExecution, error) {
 		bucket := tx.Bucket([]byte(jobExecutionsBucket))
 		v := bucket.Get([]byte(id))
 		if v == nil {
-			return fmt.Errorf("job execution not found")
+			return fmt.Errorf("job execution %w", ErrNotFound)
 		}
-		return json.Unmarshal(v, &je)
+		if err := b.decodeExecution(v, &je); err != nil {
+			return err
+		}
+		mergeTaskStatuses(tx, &je)
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -168,6 +509,116 @@ func (b *BoltDB) GetJobExecution(id string) (*models.JobExecution, error) {
 	return &je, nil
 }
 
+// decodeExecution parses a stored execution, compressed or not
+// Numbers in its data are decoded as configured by Options.UseNumber
+func (b *BoltDB) decodeExecution(v []byte, je *models.JobExecution) error {
+	v, err := executionJSON(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(v))
+	if b.opts.UseNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(je)
+}
+
+// ForEachJobExecution calls fn for every stored job execution
+// Executions include their partial task status updates
+// fn runs inside a read transaction and must not write to the DB
+func (b *BoltDB) ForEachJobExecution(fn func(je *models.JobExecution) error) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobExecutionsBucket))
+		return bucket.ForEach(func(k, v []byte) error {
+			var je models.JobExecution
+			if err := b.decodeExecution(v, &je); err != nil {
+				return err
+			}
+			mergeTaskStatuses(tx, &je)
+			return fn(&je)
+		})
+	})
+}
+
+// ListJobExecutions returns all executions, newest first by start time
+// Only executions with the given status are returned unless status is empty
+func (b *BoltDB) ListJobExecutions(status models.JobStatus) ([]*models.JobExecution, error) {
+	executions := []*models.JobExecution{}
+	err := b.ForEachJobExecution(func(je *models.JobExecution) error {
+		if status == "" || je.Status == status {
+			executions = append(executions, je)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(executions, func(i, j int) bool {
+		return executions[i].StartTime.After(executions[j].StartTime)
+	})
+	return executions, nil
+}
+
+// executionTimeKey builds the time index key of an execution
+// Keys sort by start time so a time range is a contiguous key range
+func executionTimeKey(start time.Time, executionID string) []byte {
+	key := make([]byte, 8, 8+len(executionID))
+	binary.BigEndian.PutUint64(key, uint64(start.UnixNano()))
+	return append(key, executionID...)
+}
+
+// backfillExecutionTimes indexes the start times of all stored executions
+// Run once for databases created before the time index existed
+func backfillExecutionTimes(tx *bbolt.Tx) error {
+	index := tx.Bucket([]byte(executionTimesBucket))
+	return tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(k, v []byte) error {
+		v, err := executionJSON(v)
+		if err != nil {
+			return err
+		}
+		var je struct {
+			StartTime time.Time `json:"startTime"`
+		}
+		if err := json.Unmarshal(v, &je); err != nil {
+			return err
+		}
+		return index.Put(executionTimeKey(je.StartTime, string(k)), []byte{})
+	})
+}
+
+// ListJobExecutionsByTime returns executions started in [from, to), oldest first
+// Scans only the matching range of the start time index
+func (b *BoltDB) ListJobExecutionsByTime(from, to time.Time) ([]*models.JobExecution, error) {
+	executions := []*models.JobExecution{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobExecutionsBucket))
+		end := uint64(to.UnixNano())
+		c := tx.Bucket([]byte(executionTimesBucket)).Cursor()
+		for k, _ := c.Seek(executionTimeKey(from, "")); k != nil && binary.BigEndian.Uint64(k[:8]) < end; k, _ = c.Next() {
+			v := bucket.Get(k[8:])
+			if v == nil {
+				continue
+			}
+			var je models.JobExecution
+			if err := b.decodeExecution(v, &je); err != nil {
+				return err
+			}
+
+			// Skip index entries left behind by a changed start time
+			if uint64(je.StartTime.UnixNano()) != binary.BigEndian.Uint64(k[:8]) {
+				continue
+			}
+			mergeTaskStatuses(tx, &je)
+			executions = append(executions, &je)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
 // UpdateJobExecution updates an existing job execution
 // Wraps StoreJobExecution as BoltDB uses same operation for create/update
 func (b *BoltDB) UpdateJobExecution(je *models.JobExecution) error {
@@ -176,22 +627,72 @@ func (b *BoltDB) UpdateJobExecution(je *models.JobExecution) error {
 
 // GetQueuedJobs returns list of all jobs in the queue
 // Used for system state reporting
-// Returns job IDs in queue order
+// Returns job IDs in strict dequeue order, which follows the priority and enqueue
+// sequence of the queue keys and not the format of the job IDs
 func (b *BoltDB) GetQueuedJobs() ([]string, error) {
 	var queuedJobs []string
 	err := b.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(queueBucket))
-		if bucket == nil {
-			return nil
-		}
-		return bucket.ForEach(func(k, v []byte) error {
-			queuedJobs = append(queuedJobs, string(k))
-			return nil
-		})
+		var err error
+		queuedJobs, err = queuedJobIDs(tx, b.opts.Discipline)
+		return err
 	})
 	return queuedJobs, err
 }
 
+// ListQueuedExecutions returns the queue in dequeue order with execution details
+// Reads the queue, executions, and definitions in a single read transaction
+// Each definition is decoded once no matter how many of its executions are queued
+func (b *BoltDB) ListQueuedExecutions() ([]*models.QueuedExecution, error) {
+	var queued []*models.QueuedExecution
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		executions := tx.Bucket([]byte(jobExecutionsBucket))
+		definitions := tx.Bucket([]byte(jobDefinitionsBucket))
+		names := make(map[string]string)
+
+		ids, err := queuedJobIDs(tx, b.opts.Discipline)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			entry := &models.QueuedExecution{ExecutionID: id}
+			queued = append(queued, entry)
+
+			v := executions.Get([]byte(id))
+			if v == nil {
+				continue
+			}
+			var je models.JobExecution
+			if err := b.decodeExecution(v, &je); err != nil {
+				return err
+			}
+			entry.DefinitionID = je.DefinitionID
+			entry.QueuedAt = je.QueuedAt
+			entry.Priority = je.Priority
+			entry.Tags = je.Tags
+
+			// Inline executions carry their definition
+			if je.Definition != nil {
+				entry.DefinitionName = je.Definition.Name
+				continue
+			}
+			name, ok := names[je.DefinitionID]
+			if !ok {
+				if v := definitions.Get([]byte(je.DefinitionID)); v != nil {
+					var jd models.JobDefinition
+					if err := json.Unmarshal(v, &jd); err != nil {
+						return err
+					}
+					name = jd.Name
+				}
+				names[je.DefinitionID] = name
+			}
+			entry.DefinitionName = name
+		}
+		return nil
+	})
+	return queued, err
+}
+
 // Close closes the database connection
 // Should be called when shutting down the system
 func (b *BoltDB) Close() error {
@@ -199,39 +700,97 @@ func (b *BoltDB) Close() error {
 }
 
 // EnqueueJob adds a job to the execution queue
-// Uses job ID as key in queue bucket
-// Simple implementation with no priority ordering
+// Keys carry the priority of the stored execution and an enqueue sequence number,
+// so higher priorities dequeue first and equal priorities keep enqueue order
+// Returns ErrAlreadyQueued if the job is queued already, unless allowed by Options
 func (b *BoltDB) EnqueueJob(jobID string) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(queueBucket))
-		if bucket == nil {
-			return fmt.Errorf("queue bucket not found")
+		added, err := queuePut(tx, jobID)
+		if err != nil {
+			return err
 		}
-		return bucket.Put([]byte(jobID), []byte{})
+		if !added && !b.opts.AllowDuplicateEnqueue {
+			return fmt.Errorf("%w: %s", ErrAlreadyQueued, jobID)
+		}
+		return nil
+	})
+}
+
+// EnqueueJobs adds several jobs to the execution queue in one transaction
+// Used by the buffered queue to flush batches efficiently
+// Jobs that are already queued are skipped so one duplicate can't fail the batch
+func (b *BoltDB) EnqueueJobs(jobIDs []string) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		for _, jobID := range jobIDs {
+			if _, err := queuePut(tx, jobID); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
 // DequeueJob removes and returns the next job from the queue
-// Uses FIFO ordering based on bucket iteration
+// Takes the oldest or newest job of the highest priority depending on the queue discipline
 // Returns error if queue is empty
 func (b *BoltDB) DequeueJob() (string, error) {
 	var jobID string
 	err := b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(queueBucket))
-		if bucket == nil {
-			return fmt.Errorf("queue bucket not found")
-		}
-		cursor := bucket.Cursor()
-		k, _ := cursor.First()
-		if k == nil {
-			return fmt.Errorf("queue is empty")
+		jobID = queueNext(tx, b.opts.Discipline)
+		if jobID == "" {
+			return ErrQueueEmpty
 		}
-		jobID = string(k)
-		return bucket.Delete(k)
+		return queueDelete(tx, jobID)
 	})
 	return jobID, err
 }
 
+// ScheduleJob holds a job back until the given time
+// PromoteDueJobs moves it to the queue once that time has passed
+func (b *BoltDB) ScheduleJob(jobID string, at time.Time) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(scheduledBucket)).Put(scheduledKey(at, jobID), []byte{})
+	})
+}
+
+// scheduledKey builds the key of a scheduled job
+// Keys sort by start time, so due jobs are always at the front
+func scheduledKey(at time.Time, jobID string) []byte {
+	key := make([]byte, 8, 8+len(jobID))
+	binary.BigEndian.PutUint64(key, uint64(at.UnixNano()))
+	return append(key, jobID...)
+}
+
+// PromoteDueJobs moves all jobs scheduled at or before now to the queue
+// Runs in one transaction so a job is never lost or in both places
+// Returns the IDs of the promoted jobs
+func (b *BoltDB) PromoteDueJobs(now time.Time) ([]string, error) {
+	var promoted []string
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		scheduled := tx.Bucket([]byte(scheduledBucket))
+
+		// Collect first, deleting while iterating would skip keys
+		var due [][]byte
+		c := scheduled.Cursor()
+		for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k[:8]) <= uint64(now.UnixNano()); k, _ = c.Next() {
+			due = append(due, k)
+		}
+
+		for _, k := range due {
+			jobID := string(k[8:])
+			if _, err := queuePut(tx, jobID); err != nil {
+				return err
+			}
+			if err := scheduled.Delete(k); err != nil {
+				return err
+			}
+			promoted = append(promoted, jobID)
+		}
+		return nil
+	})
+	return promoted, err
+}
+
 // GetQueuedJobCount returns the number of jobs in queue
 // Uses BoltDB bucket stats for efficient counting
 func (b *BoltDB) GetQueuedJobCount() (int, error) {
@@ -249,13 +808,10 @@ func (b *BoltDB) GetQueuedJobCount() (int, error) {
 
 // RemoveFromQueue removes a specific job from the queue
 // Used when job execution completes or fails
+// Removing a job that isn't queued is not an error
 func (b *BoltDB) RemoveFromQueue(jobID string) error {
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(queueBucket))
-		if bucket == nil {
-			return fmt.Errorf("queue bucket not found")
-		}
-		return bucket.Delete([]byte(jobID))
+		return queueDelete(tx, jobID)
 	})
 }
 
@@ -301,3 +857,46 @@ func (b *BoltDB) GetExecutedJobsCount() (int, error) {
 	})
 	return int(count), err
 }
+
+// AppendAuditEntry adds an entry to the audit log
+// Assigns the entry the next sequence ID, which is also its key
+func (b *BoltDB) AppendAuditEntry(entry *models.AuditEntry) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(auditBucket))
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+
+		buf, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, id)
+		return bucket.Put(key, buf)
+	})
+}
+
+// ListAuditEntries returns a page of the audit log, newest entries first
+// Skips offset entries and returns at most limit
+func (b *BoltDB) ListAuditEntries(offset, limit int) ([]*models.AuditEntry, error) {
+	entries := []*models.AuditEntry{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(auditBucket)).Cursor()
+		for k, v := c.Last(); k != nil && len(entries) < limit; k, v = c.Prev() {
+			if offset > 0 {
+				offset--
+				continue
+			}
+			var entry models.AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	return entries, err
+}
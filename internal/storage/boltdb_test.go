@@ -0,0 +1,509 @@
+// boltdb_test.go tests the BoltDB storage implementation
+// Each test works on its own database in a temporary directory
+// Covers the queue, the indexes kept next to definitions and executions, and partial task status updates
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// openTestBoltDB opens a BoltDB in the test's temporary directory
+// The database is closed when the test ends
+func openTestBoltDB(t testing.TB, opts Options) *BoltDB {
+	t.Helper()
+	db, err := NewBoltDBWithOptions(filepath.Join(t.TempDir(), "test.db"), opts)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// storeExecution stores an execution and fails the test on error
+func storeExecution(t testing.TB, db DB, je *models.JobExecution) {
+	t.Helper()
+	if err := db.StoreJobExecution(je); err != nil {
+		t.Fatalf("store execution %s: %v", je.ID, err)
+	}
+}
+
+// TestListUnqueuedJobs lists QUEUED executions that are neither queued nor scheduled
+func TestListUnqueuedJobs(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	for _, je := range []*models.JobExecution{
+		{ID: "queued", Status: models.JobStatusQueued},
+		{ID: "scheduled", Status: models.JobStatusQueued},
+		{ID: "lost", Status: models.JobStatusQueued},
+		{ID: "running", Status: models.JobStatusRunning},
+		{ID: "done", Status: models.JobStatusCompleted},
+	} {
+		storeExecution(t, db, je)
+	}
+	if err := db.EnqueueJob("queued"); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := db.ScheduleJob("scheduled", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	unqueued, err := db.ListUnqueuedJobs()
+	if err != nil {
+		t.Fatalf("list unqueued jobs: %v", err)
+	}
+	if !slices.Equal(unqueued, []string{"lost"}) {
+		t.Fatalf("unqueued = %v, want [lost]", unqueued)
+	}
+}
+
+// TestDeleteJobDefinitionInUse refuses to delete definitions unfinished executions run
+// Finished and inline executions don't hold on to the definition
+func TestDeleteJobDefinitionInUse(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	if err := db.StoreJobDefinition(&models.JobDefinition{ID: "report"}); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	running := &models.JobExecution{ID: "running", DefinitionID: "report", Status: models.JobStatusRunning}
+	storeExecution(t, db, running)
+	storeExecution(t, db, &models.JobExecution{ID: "done", DefinitionID: "report", Status: models.JobStatusCompleted})
+	storeExecution(t, db, &models.JobExecution{
+		ID:           "inline",
+		DefinitionID: "report",
+		Status:       models.JobStatusQueued,
+		Definition:   &models.JobDefinition{ID: "report"},
+	})
+
+	if err := db.DeleteJobDefinition("report"); !errors.Is(err, ErrDefinitionInUse) {
+		t.Fatalf("delete with running execution = %v, want ErrDefinitionInUse", err)
+	}
+
+	running.Status = models.JobStatusFailed
+	storeExecution(t, db, running)
+	if err := db.DeleteJobDefinition("report"); err != nil {
+		t.Fatalf("delete after executions finished: %v", err)
+	}
+	if err := db.DeleteJobDefinition("report"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("delete of deleted definition = %v, want ErrNotFound", err)
+	}
+}
+
+// TestListJobDefinitionsByTag filters definitions through the tag index
+// The index follows tag changes and deletions, and tags only match whole
+func TestListJobDefinitionsByTag(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	for _, jd := range []*models.JobDefinition{
+		{ID: "ingest", Tags: []string{"etl", "nightly"}},
+		{ID: "invoice", Tags: []string{"billing"}},
+		{ID: "export", Tags: []string{"etl"}},
+		{ID: "untagged"},
+	} {
+		if err := db.StoreJobDefinition(jd); err != nil {
+			t.Fatalf("store definition %s: %v", jd.ID, err)
+		}
+	}
+	tagged := func(tag string) []string {
+		t.Helper()
+		definitions, err := db.ListJobDefinitionsByTag(tag)
+		if err != nil {
+			t.Fatalf("list tag %s: %v", tag, err)
+		}
+		ids := make([]string, 0, len(definitions))
+		for _, jd := range definitions {
+			ids = append(ids, jd.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	for tag, want := range map[string][]string{
+		"etl":     {"export", "ingest"},
+		"billing": {"invoice"},
+		"et":      {},
+		"unknown": {},
+	} {
+		if got := tagged(tag); !slices.Equal(got, want) {
+			t.Errorf("tag %s = %v, want %v", tag, got, want)
+		}
+	}
+
+	// Replacing the tags moves the definition in the index
+	if err := db.StoreJobDefinition(&models.JobDefinition{ID: "ingest", Tags: []string{"billing"}}); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	if err := db.DeleteJobDefinition("export"); err != nil {
+		t.Fatalf("delete definition: %v", err)
+	}
+	if got := tagged("etl"); len(got) != 0 {
+		t.Errorf("tag etl = %v after retagging and deleting its definitions", got)
+	}
+	if got := tagged("billing"); !slices.Equal(got, []string{"ingest", "invoice"}) {
+		t.Errorf("tag billing = %v, want ingest and invoice", got)
+	}
+}
+
+// TestUnfinishedIndexBackfill indexes executions stored before the index existed
+func TestUnfinishedIndexBackfill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.StoreJobDefinition(&models.JobDefinition{ID: "report"}); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	storeExecution(t, db, &models.JobExecution{ID: "queued", DefinitionID: "report", Status: models.JobStatusQueued})
+
+	// Drop the index as a database from before it existed wouldn't have it
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte(unfinishedBucket))
+	})
+	if err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	db.Close()
+
+	db = openTestBoltDBAt(t, path)
+	if err := db.DeleteJobDefinition("report"); !errors.Is(err, ErrDefinitionInUse) {
+		t.Fatalf("delete after reopening = %v, want ErrDefinitionInUse", err)
+	}
+}
+
+// openTestBoltDBAt opens the BoltDB at path
+// The database is closed when the test ends
+func openTestBoltDBAt(t testing.TB, path string) *BoltDB {
+	t.Helper()
+	db, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// wideExecution returns a running execution with n tasks and some job data
+// Mirrors the records partial task status updates are meant for
+func wideExecution(id string, n int) *models.JobExecution {
+	je := &models.JobExecution{
+		ID:           id,
+		DefinitionID: "wide",
+		Status:       models.JobStatusRunning,
+		TaskStatuses: make(map[string]models.TaskStatus, n),
+		Data:         make(map[string]interface{}, n),
+	}
+	for i := 0; i < n; i++ {
+		task := fmt.Sprintf("task-%03d", i)
+		je.TaskStatuses[task] = models.TaskStatusPending
+		je.Data[task] = strings.Repeat("x", 64)
+	}
+	return je
+}
+
+// TestPartialTaskStatusUpdates checks partial updates show in every read of the
+// execution and that a later full update supersedes them
+func TestPartialTaskStatusUpdates(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	je := wideExecution("exec", 3)
+	storeExecution(t, db, je)
+
+	if err := db.UpdateTaskStatus("exec", "task-000", models.TaskStatusCompleted); err != nil {
+		t.Fatalf("update task status: %v", err)
+	}
+	if err := db.UpdateTaskStatus("exec", "task-001", models.TaskStatusRunning); err != nil {
+		t.Fatalf("update task status: %v", err)
+	}
+	got, err := db.GetJobExecution("exec")
+	if err != nil {
+		t.Fatalf("get execution: %v", err)
+	}
+	want := map[string]models.TaskStatus{
+		"task-000": models.TaskStatusCompleted,
+		"task-001": models.TaskStatusRunning,
+		"task-002": models.TaskStatusPending,
+	}
+	if !maps.Equal(got.TaskStatuses, want) {
+		t.Errorf("task statuses = %v, want %v", got.TaskStatuses, want)
+	}
+	listed, err := db.ListJobExecutions(models.JobStatusRunning)
+	if err != nil {
+		t.Fatalf("list executions: %v", err)
+	}
+	if len(listed) != 1 || !maps.Equal(listed[0].TaskStatuses, want) {
+		t.Errorf("listed task statuses = %v, want %v", listed, want)
+	}
+
+	// A full write carries the state its writer holds, partial updates before it are dropped
+	je.TaskStatuses["task-001"] = models.TaskStatusFailed
+	je.Status = models.JobStatusFailed
+	storeExecution(t, db, je)
+	got, err = db.GetJobExecution("exec")
+	if err != nil {
+		t.Fatalf("get execution: %v", err)
+	}
+	if got.TaskStatuses["task-000"] != models.TaskStatusPending || got.TaskStatuses["task-001"] != models.TaskStatusFailed {
+		t.Errorf("task statuses after full update = %v, want the full record's", got.TaskStatuses)
+	}
+}
+
+// benchmarkTasks is the number of tasks of the execution the update benchmarks write
+const benchmarkTasks = 200
+
+// BenchmarkFullTaskUpdate writes the whole execution for each task status change
+func BenchmarkFullTaskUpdate(b *testing.B) {
+	db := openTestBoltDB(b, Options{})
+	je := wideExecution("exec", benchmarkTasks)
+	storeExecution(b, db, je)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		je.TaskStatuses[fmt.Sprintf("task-%03d", i%benchmarkTasks)] = models.TaskStatusCompleted
+		if err := db.UpdateJobExecution(je); err != nil {
+			b.Fatalf("update execution: %v", err)
+		}
+	}
+}
+
+// BenchmarkPartialTaskUpdate writes only the changed status for each task status change
+func BenchmarkPartialTaskUpdate(b *testing.B) {
+	db := openTestBoltDB(b, Options{})
+	storeExecution(b, db, wideExecution("exec", benchmarkTasks))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.UpdateTaskStatus("exec", fmt.Sprintf("task-%03d", i%benchmarkTasks), models.TaskStatusCompleted); err != nil {
+			b.Fatalf("update task status: %v", err)
+		}
+	}
+}
+
+// TestEnqueueJobTwice rejects queuing an execution that is already queued
+// The queue keeps a single entry and removing it is idempotent
+func TestEnqueueJobTwice(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	storeExecution(t, db, &models.JobExecution{ID: "exec", Status: models.JobStatusQueued})
+
+	if err := db.EnqueueJob("exec"); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := db.EnqueueJob("exec"); !errors.Is(err, ErrAlreadyQueued) {
+		t.Fatalf("second enqueue = %v, want ErrAlreadyQueued", err)
+	}
+	if count, err := db.GetQueuedJobCount(); err != nil || count != 1 {
+		t.Fatalf("queued count = %d, %v, want 1", count, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := db.RemoveFromQueue("exec"); err != nil {
+			t.Fatalf("remove #%d: %v", i+1, err)
+		}
+	}
+	if _, err := db.DequeueJob(); !errors.Is(err, ErrQueueEmpty) {
+		t.Fatalf("dequeue after removal = %v, want ErrQueueEmpty", err)
+	}
+
+	// Once off the queue the execution may be queued again
+	if err := db.EnqueueJob("exec"); err != nil {
+		t.Fatalf("enqueue after removal: %v", err)
+	}
+}
+
+// TestEnqueueJobTwiceAllowed makes a duplicate enqueue a no-op when allowed
+func TestEnqueueJobTwiceAllowed(t *testing.T) {
+	db := openTestBoltDB(t, Options{AllowDuplicateEnqueue: true})
+	storeExecution(t, db, &models.JobExecution{ID: "exec", Status: models.JobStatusQueued})
+
+	for i := 0; i < 2; i++ {
+		if err := db.EnqueueJob("exec"); err != nil {
+			t.Fatalf("enqueue #%d: %v", i+1, err)
+		}
+	}
+	if count, err := db.GetQueuedJobCount(); err != nil || count != 1 {
+		t.Fatalf("queued count = %d, %v, want 1", count, err)
+	}
+}
+
+// enqueueAll stores and enqueues executions with the given IDs and priorities in order
+func enqueueAll(t testing.TB, db DB, ids []string, priorities map[string]int) {
+	t.Helper()
+	for _, id := range ids {
+		storeExecution(t, db, &models.JobExecution{ID: id, Status: models.JobStatusQueued, Priority: priorities[id]})
+		if err := db.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+}
+
+// drainQueue dequeues every job and returns them in dequeue order
+func drainQueue(t testing.TB, db DB) []string {
+	t.Helper()
+	var order []string
+	for {
+		id, err := db.DequeueJob()
+		if errors.Is(err, ErrQueueEmpty) {
+			return order
+		}
+		if err != nil {
+			t.Fatalf("dequeue: %v", err)
+		}
+		order = append(order, id)
+	}
+}
+
+// TestDequeueOrder checks each discipline's order, priorities always going first
+func TestDequeueOrder(t *testing.T) {
+	priorities := map[string]int{"urgent": 5}
+	for _, tc := range []struct {
+		name       string
+		discipline QueueDiscipline
+		want       []string
+	}{
+		{"fifo", QueueFIFO, []string{"urgent", "first", "second", "third"}},
+		{"lifo", QueueLIFO, []string{"urgent", "third", "second", "first"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openTestBoltDB(t, Options{Discipline: tc.discipline})
+			enqueueAll(t, db, []string{"first", "second", "urgent", "third"}, priorities)
+			if got := drainQueue(t, db); !slices.Equal(got, tc.want) {
+				t.Errorf("dequeue order = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestQueueListingMatchesDequeueOrder checks both queue listings return jobs
+// in exactly the order they are dequeued, under either discipline
+func TestQueueListingMatchesDequeueOrder(t *testing.T) {
+	ids := []string{"b-first", "a-second", "urgent", "c-third", "low"}
+	priorities := map[string]int{"urgent": 3, "low": -1}
+	for _, tc := range []struct {
+		name       string
+		discipline QueueDiscipline
+	}{
+		{"fifo", QueueFIFO},
+		{"lifo", QueueLIFO},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openTestBoltDB(t, Options{Discipline: tc.discipline})
+			enqueueAll(t, db, ids, priorities)
+
+			listed, err := db.GetQueuedJobs()
+			if err != nil {
+				t.Fatalf("get queued jobs: %v", err)
+			}
+			executions, err := db.ListQueuedExecutions()
+			if err != nil {
+				t.Fatalf("list queued executions: %v", err)
+			}
+			var detailed []string
+			for _, qe := range executions {
+				detailed = append(detailed, qe.ExecutionID)
+			}
+
+			dequeued := drainQueue(t, db)
+			if !slices.Equal(listed, dequeued) {
+				t.Errorf("GetQueuedJobs = %v, dequeue order %v", listed, dequeued)
+			}
+			if !slices.Equal(detailed, dequeued) {
+				t.Errorf("ListQueuedExecutions = %v, dequeue order %v", detailed, dequeued)
+			}
+		})
+	}
+}
+
+// TestListQueuedExecutionsDetails checks queue entries are joined with their execution
+// and definition, inline definitions supply their own name and missing executions
+// are listed without details
+func TestListQueuedExecutionsDetails(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	for _, jd := range []*models.JobDefinition{{ID: "report", Name: "Daily report"}, {ID: "sync"}} {
+		if err := db.StoreJobDefinition(jd); err != nil {
+			t.Fatalf("store definition %s: %v", jd.ID, err)
+		}
+	}
+	queuedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, je := range []*models.JobExecution{
+		{ID: "first", DefinitionID: "report", Tags: []string{"customer:acme"}},
+		{ID: "second", DefinitionID: "report"},
+		{ID: "unnamed", DefinitionID: "sync"},
+		{ID: "inline", DefinitionID: "adhoc-1", Definition: &models.JobDefinition{ID: "adhoc-1", Name: "Ad hoc"}},
+	} {
+		je.Status = models.JobStatusQueued
+		je.QueuedAt = queuedAt
+		storeExecution(t, db, je)
+	}
+	for _, id := range []string{"first", "second", "unnamed", "inline", "missing"} {
+		if err := db.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+
+	listed, err := db.ListQueuedExecutions()
+	if err != nil {
+		t.Fatalf("list queued executions: %v", err)
+	}
+	want := []models.QueuedExecution{
+		{ExecutionID: "first", DefinitionID: "report", DefinitionName: "Daily report", QueuedAt: queuedAt, Tags: []string{"customer:acme"}},
+		{ExecutionID: "second", DefinitionID: "report", DefinitionName: "Daily report", QueuedAt: queuedAt},
+		{ExecutionID: "unnamed", DefinitionID: "sync", QueuedAt: queuedAt},
+		{ExecutionID: "inline", DefinitionID: "adhoc-1", DefinitionName: "Ad hoc", QueuedAt: queuedAt},
+		{ExecutionID: "missing"},
+	}
+	if len(listed) != len(want) {
+		t.Fatalf("listed %d entries, want %d", len(listed), len(want))
+	}
+	for i, qe := range listed {
+		w := want[i]
+		if qe.ExecutionID != w.ExecutionID || qe.DefinitionID != w.DefinitionID || qe.DefinitionName != w.DefinitionName ||
+			!qe.QueuedAt.Equal(w.QueuedAt) || !slices.Equal(qe.Tags, w.Tags) {
+			t.Errorf("entry %d = %+v, want %+v", i, *qe, w)
+		}
+	}
+}
+
+// TestListJobExecutionsByTime checks only executions started within [from, to) are listed,
+// oldest first, and an execution whose start time changed is listed under the new one only
+func TestListJobExecutionsByTime(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 5; i >= 0; i-- {
+		storeExecution(t, db, &models.JobExecution{
+			ID:        fmt.Sprintf("exec-%d", i),
+			Status:    models.JobStatusCompleted,
+			StartTime: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	// Moved from hour 5 into the window
+	storeExecution(t, db, &models.JobExecution{ID: "exec-5", Status: models.JobStatusCompleted, StartTime: base.Add(90 * time.Minute)})
+
+	listed := func(from, to time.Time) []string {
+		t.Helper()
+		executions, err := db.ListJobExecutionsByTime(from, to)
+		if err != nil {
+			t.Fatalf("list executions by time: %v", err)
+		}
+		ids := []string{}
+		for _, je := range executions {
+			ids = append(ids, je.ID)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		from, to time.Time
+		want     []string
+	}{
+		{base.Add(time.Hour), base.Add(4 * time.Hour), []string{"exec-1", "exec-5", "exec-2", "exec-3"}},
+		{base.Add(time.Hour + time.Nanosecond), base.Add(3 * time.Hour), []string{"exec-5", "exec-2"}},
+		{base.Add(4 * time.Hour), base.Add(24 * time.Hour), []string{"exec-4"}},
+		{base.Add(-time.Hour), base, []string{}},
+	} {
+		if got := listed(tc.from, tc.to); !slices.Equal(got, tc.want) {
+			t.Errorf("executions in [%v, %v) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// buffered_queue.go implements a write-behind in-memory queue in front of a DB
+// Buffers enqueues in memory and flushes them to the underlying store in batches
+// Trades a small durability window for higher enqueue throughput
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// batchEnqueuer is implemented by stores that can enqueue many jobs at once
+// BoltDB implements it to flush a whole batch in one transaction
+type batchEnqueuer interface {
+	EnqueueJobs(jobIDs []string) error
+}
+
+// BufferedQueue wraps a DB and buffers queue writes in memory
+// All non-queue operations are passed straight through to the wrapped DB
+//
+// Durability: enqueued job IDs live only in memory until the next flush.
+// Buffered entries are flushed when the batch size is reached, every flush
+// interval, and on Close, so a graceful shutdown loses nothing. A crash can
+// lose up to one flush interval of enqueues; the affected executions remain
+// stored with QUEUED status and are listed by ListUnqueuedJobs, so the
+// orchestrator queues them again when it starts.
+type BufferedQueue struct {
+	DB // Underlying storage
+
+	mu            sync.Mutex
+	pending       []string      // Buffered job IDs in enqueue order
+	batchSize     int           // Number of buffered entries that triggers a flush
+	flushInterval time.Duration // Maximum time entries stay buffered
+	stop          chan struct{} // Signal to stop the flush loop
+	done          chan struct{} // Signal that the flush loop has stopped
+	closeOnce     sync.Once
+}
+
+// NewBufferedQueue creates a buffered queue in front of the given DB
+// Starts a background loop flushing buffered entries every flush interval
+func NewBufferedQueue(db DB, batchSize int, flushInterval time.Duration) *BufferedQueue {
+	q := &BufferedQueue{
+		DB:            db,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go q.flushLoop()
+	return q
+}
+
+// flushLoop periodically writes buffered entries to the underlying DB
+// Failed flushes keep their entries buffered for the next attempt
+func (q *BufferedQueue) flushLoop() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			if err := q.Flush(); err != nil {
+				log.Printf("Failed to flush buffered queue: %v", err)
+			}
+		}
+	}
+}
+
+// Flush writes all buffered entries to the underlying DB
+// Entries are only removed from the buffer once they were written
+func (q *BufferedQueue) Flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.flushLocked()
+}
+
+// flushLocked writes buffered entries while holding the lock
+// Holding the lock keeps dequeue order consistent during the flush
+func (q *BufferedQueue) flushLocked() error {
+	if len(q.pending) == 0 {
+		return nil
+	}
+
+	if be, ok := q.DB.(batchEnqueuer); ok {
+		if err := be.EnqueueJobs(q.pending); err != nil {
+			return err
+		}
+		q.pending = q.pending[:0]
+		return nil
+	}
+
+	// Fall back to one write per entry for stores without batch support
+	// Entries that are already queued are dropped from the buffer
+	for len(q.pending) > 0 {
+		if err := q.DB.EnqueueJob(q.pending[0]); err != nil && !errors.Is(err, ErrAlreadyQueued) {
+			return err
+		}
+		q.pending = q.pending[1:]
+	}
+	return nil
+}
+
+// EnqueueJob buffers a job for a later batched write
+// Flushes immediately once the batch size is reached
+// Duplicates are rejected while buffered and skipped when flushed
+func (q *BufferedQueue) EnqueueJob(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if slices.Contains(q.pending, jobID) {
+		return fmt.Errorf("%w: %s", ErrAlreadyQueued, jobID)
+	}
+
+	q.pending = append(q.pending, jobID)
+	if len(q.pending) >= q.batchSize {
+		return q.flushLocked()
+	}
+	return nil
+}
+
+// DequeueJob returns the next job, preferring the underlying DB
+// Flushed entries are always older than buffered ones, preserving FIFO order
+func (q *BufferedQueue) DequeueJob() (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobID, err := q.DB.DequeueJob()
+	if !errors.Is(err, ErrQueueEmpty) {
+		return jobID, err
+	}
+	if len(q.pending) == 0 {
+		return "", ErrQueueEmpty
+	}
+	jobID = q.pending[0]
+	q.pending = q.pending[1:]
+	return jobID, nil
+}
+
+// GetQueuedJobs returns persisted jobs followed by buffered jobs
+// Matches the order DequeueJob hands them out in
+func (q *BufferedQueue) GetQueuedJobs() ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs, err := q.DB.GetQueuedJobs()
+	if err != nil {
+		return nil, err
+	}
+	return append(jobs, q.pending...), nil
+}
+
+// ListUnqueuedJobs flushes buffered jobs and lists QUEUED executions missing from the queue
+// Flushing keeps buffered jobs from being reported as missing
+func (q *BufferedQueue) ListUnqueuedJobs() ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.flushLocked(); err != nil {
+		return nil, err
+	}
+	return q.DB.ListUnqueuedJobs()
+}
+
+// ListQueuedExecutions flushes buffered jobs and lists the persisted queue
+// Flushing lets the underlying DB enrich all entries in one read
+func (q *BufferedQueue) ListQueuedExecutions() ([]*models.QueuedExecution, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.flushLocked(); err != nil {
+		return nil, err
+	}
+	return q.DB.ListQueuedExecutions()
+}
+
+// Snapshot flushes buffered jobs and reads a snapshot of the underlying DB
+// Flushing puts buffered jobs in the queue the snapshot is read from
+func (q *BufferedQueue) Snapshot() (*Snapshot, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.flushLocked(); err != nil {
+		return nil, err
+	}
+	return q.DB.Snapshot()
+}
+
+// GetQueuedJobCount returns the number of persisted and buffered jobs
+func (q *BufferedQueue) GetQueuedJobCount() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count, err := q.DB.GetQueuedJobCount()
+	if err != nil {
+		return 0, err
+	}
+	return count + len(q.pending), nil
+}
+
+// RemoveFromQueue removes a job from the buffer and the underlying DB
+func (q *BufferedQueue) RemoveFromQueue(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, id := range q.pending {
+		if id == jobID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	return q.DB.RemoveFromQueue(jobID)
+}
+
+// Close flushes all buffered entries and closes the underlying DB
+// Buffered entries are never dropped on a graceful shutdown
+func (q *BufferedQueue) Close() error {
+	var err error
+	q.closeOnce.Do(func() {
+		close(q.stop)
+		<-q.done
+		if ferr := q.Flush(); ferr != nil {
+			err = ferr
+		}
+		if cerr := q.DB.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
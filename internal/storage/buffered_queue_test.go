@@ -0,0 +1,106 @@
+// buffered_queue_test.go tests the write-behind queue in front of BoltDB
+// Covers flushing on Close and at the batch size, and benchmarks enqueue
+// throughput with and without buffering
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestBufferedQueueFlushesOnClose closes a queue with buffered entries
+// Reopening the database must find them queued in their enqueue order
+func TestBufferedQueueFlushesOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	q := NewBufferedQueue(db, 100, time.Hour)
+	ids := []string{"first", "second", "third"}
+	for _, id := range ids {
+		if err := q.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+	if queued, err := db.GetQueuedJobs(); err != nil || len(queued) != 0 {
+		t.Fatalf("persisted before close = %v, %v, want nothing flushed yet", queued, err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	db = openTestBoltDBAt(t, path)
+	queued, err := db.GetQueuedJobs()
+	if err != nil {
+		t.Fatalf("get queued jobs: %v", err)
+	}
+	if !slices.Equal(queued, ids) {
+		t.Fatalf("queued after reopening = %v, want %v", queued, ids)
+	}
+}
+
+// TestBufferedQueueFlushesAtBatchSize checks a full batch is written right away
+// and dequeued before entries buffered after it
+func TestBufferedQueueFlushesAtBatchSize(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	q := NewBufferedQueue(db, 2, time.Hour)
+	defer q.Close()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := q.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+	if count, err := db.GetQueuedJobCount(); err != nil || count != 2 {
+		t.Fatalf("persisted = %d, %v, want the first batch of 2", count, err)
+	}
+	if err := q.EnqueueJob("c"); !errors.Is(err, ErrAlreadyQueued) {
+		t.Fatalf("duplicate buffered enqueue = %v, want ErrAlreadyQueued", err)
+	}
+
+	var order []string
+	for {
+		id, err := q.DequeueJob()
+		if errors.Is(err, ErrQueueEmpty) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("dequeue: %v", err)
+		}
+		order = append(order, id)
+	}
+	if !slices.Equal(order, []string{"a", "b", "c"}) {
+		t.Fatalf("dequeue order = %v, want [a b c]", order)
+	}
+}
+
+// BenchmarkBoltEnqueue enqueues with one BoltDB transaction per job
+func BenchmarkBoltEnqueue(b *testing.B) {
+	db := openTestBoltDB(b, Options{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.EnqueueJob(fmt.Sprintf("exec-%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkBufferedQueueEnqueue enqueues through a buffer flushed in batches of 100
+func BenchmarkBufferedQueueEnqueue(b *testing.B) {
+	q := NewBufferedQueue(openTestBoltDB(b, Options{}), 100, time.Second)
+	defer q.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := q.EnqueueJob(fmt.Sprintf("exec-%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := q.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
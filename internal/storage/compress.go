@@ -0,0 +1,49 @@
+// compress.go implements optional gzip compression of stored execution records
+// Compressed records start with the gzip magic bytes, which JSON never does,
+// so records are read back the same way whether or not compression is enabled
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// encodeExecution serializes an execution for storage
+// Compressed with gzip when Options.CompressExecutions is set
+func (b *BoltDB) encodeExecution(je *models.JobExecution) ([]byte, error) {
+	buf, err := json.Marshal(je)
+	if err != nil || !b.opts.CompressExecutions {
+		return buf, err
+	}
+
+	var out bytes.Buffer
+	zw := gzip.NewWriter(&out)
+	if _, err := zw.Write(buf); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// executionJSON returns the JSON of a stored execution record
+// Decompresses compressed records and returns others unchanged
+func executionJSON(v []byte) ([]byte, error) {
+	if !bytes.HasPrefix(v, gzipMagic) {
+		return v, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(v))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,102 @@
+// compress_test.go tests gzip compression of stored execution records
+// A large execution is round-tripped through the compressed codec and its
+// stored size compared with the plain record, mixed stores must read both kinds
+package storage
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// storedRecord returns the raw record of an execution as written to BoltDB
+func storedRecord(t *testing.T, db *BoltDB, id string) []byte {
+	t.Helper()
+	var v []byte
+	err := db.db.View(func(tx *bbolt.Tx) error {
+		v = bytes.Clone(tx.Bucket([]byte(jobExecutionsBucket)).Get([]byte(id)))
+		return nil
+	})
+	if err != nil || v == nil {
+		t.Fatalf("read record %s: %v", id, err)
+	}
+	return v
+}
+
+// TestCompressedExecutionRoundTrip stores a large execution with and without compression
+// Both read back the same, and the compressed record is a fraction of the plain one
+func TestCompressedExecutionRoundTrip(t *testing.T) {
+	plain := openTestBoltDB(t, Options{})
+	compressed := openTestBoltDB(t, Options{CompressExecutions: true})
+	storeExecution(t, plain, wideExecution("big", 500))
+	storeExecution(t, compressed, wideExecution("big", 500))
+
+	want, err := plain.GetJobExecution("big")
+	if err != nil {
+		t.Fatalf("get plain execution: %v", err)
+	}
+	got, err := compressed.GetJobExecution("big")
+	if err != nil {
+		t.Fatalf("get compressed execution: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compressed execution = %+v, want %+v", got, want)
+	}
+
+	plainRecord, compressedRecord := storedRecord(t, plain, "big"), storedRecord(t, compressed, "big")
+	if !bytes.HasPrefix(compressedRecord, gzipMagic) || bytes.HasPrefix(plainRecord, gzipMagic) {
+		t.Fatal("only the record of the compressing store should be gzipped")
+	}
+	if len(compressedRecord)*4 > len(plainRecord) {
+		t.Errorf("compressed record is %d bytes, want under a quarter of the plain %d", len(compressedRecord), len(plainRecord))
+	}
+}
+
+// TestCompressionReadsMixedRecords turns compression on for a store holding plain records
+// Old and new records are read back alike, whichever way they were written
+func TestCompressionReadsMixedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	old, err := NewBoltDBWithOptions(path, Options{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	storeExecution(t, old, &models.JobExecution{ID: "old", DefinitionID: "report", Status: models.JobStatusCompleted})
+	old.Close()
+
+	db, err := NewBoltDBWithOptions(path, Options{CompressExecutions: true})
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	storeExecution(t, db, &models.JobExecution{ID: "new", DefinitionID: "report", Status: models.JobStatusQueued})
+
+	read := make(map[string]models.JobStatus)
+	err = db.ForEachJobExecution(func(je *models.JobExecution) error {
+		read[je.ID] = je.Status
+		return nil
+	})
+	want := map[string]models.JobStatus{"old": models.JobStatusCompleted, "new": models.JobStatusQueued}
+	if err != nil || !reflect.DeepEqual(read, want) {
+		t.Errorf("executions = %v, %v, want %v", read, err, want)
+	}
+
+	// Updating the plain record rewrites it compressed
+	je, err := db.GetJobExecution("old")
+	if err != nil {
+		t.Fatalf("get old execution: %v", err)
+	}
+	je.Error = "rerun"
+	if err := db.UpdateJobExecution(je); err != nil {
+		t.Fatalf("update old execution: %v", err)
+	}
+	if !bytes.HasPrefix(storedRecord(t, db, "old"), gzipMagic) {
+		t.Error("updated record was not compressed")
+	}
+	if got, err := db.GetJobExecution("old"); err != nil || got.Error != "rerun" {
+		t.Errorf("updated execution = %+v, %v, want error rerun", got, err)
+	}
+}
@@ -0,0 +1,74 @@
+// finished.go maintains an index of finished executions ordered by end time
+// Executions are indexed whenever they are stored with a terminal status
+// Lets dashboards read the most recently finished jobs without scanning every execution
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// indexFinished adds an execution stored with a terminal status to the finished index
+// Entries left behind when an execution runs again are skipped on read
+// Must be called within a read-write transaction
+func indexFinished(tx *bbolt.Tx, executionID string, status models.JobStatus, end time.Time) error {
+	if !status.Finished() {
+		return nil
+	}
+	return tx.Bucket([]byte(finishedTimesBucket)).Put(executionTimeKey(end, executionID), []byte{})
+}
+
+// backfillFinishedTimes indexes the end times of all stored finished executions
+// Run once for databases created before the finished index existed
+func backfillFinishedTimes(tx *bbolt.Tx) error {
+	return tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(k, v []byte) error {
+		v, err := executionJSON(v)
+		if err != nil {
+			return err
+		}
+		var je struct {
+			Status  models.JobStatus `json:"status"`
+			EndTime time.Time        `json:"endTime"`
+		}
+		if err := json.Unmarshal(v, &je); err != nil {
+			return err
+		}
+		return indexFinished(tx, string(k), je.Status, je.EndTime)
+	})
+}
+
+// ListFinishedExecutions returns up to limit finished executions, most recently ended first
+// Walks the finished index backwards, reading only the executions returned
+func (b *BoltDB) ListFinishedExecutions(limit int) ([]*models.JobExecution, error) {
+	executions := []*models.JobExecution{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobExecutionsBucket))
+		c := tx.Bucket([]byte(finishedTimesBucket)).Cursor()
+		for k, _ := c.Last(); k != nil && len(executions) < limit; k, _ = c.Prev() {
+			v := bucket.Get(k[8:])
+			if v == nil {
+				continue
+			}
+			var je models.JobExecution
+			if err := b.decodeExecution(v, &je); err != nil {
+				return err
+			}
+
+			// Skip entries of executions that ran again since
+			if !je.Status.Finished() || uint64(je.EndTime.UnixNano()) != binary.BigEndian.Uint64(k[:8]) {
+				continue
+			}
+			mergeTaskStatuses(tx, &je)
+			executions = append(executions, &je)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
@@ -0,0 +1,67 @@
+// finished_test.go tests listing finished executions from the end time index
+// Executions are stored out of end time order alongside unfinished ones
+// Covers the order, the limit, and executions that run again after finishing
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// finishedIDs lists up to limit finished executions and returns their IDs
+func finishedIDs(t *testing.T, db DB, limit int) []string {
+	t.Helper()
+	executions, err := db.ListFinishedExecutions(limit)
+	if err != nil {
+		t.Fatalf("list finished executions: %v", err)
+	}
+	ids := make([]string, 0, len(executions))
+	for _, je := range executions {
+		if !je.Status.Finished() {
+			t.Errorf("listed %s with status %s", je.ID, je.Status)
+		}
+		ids = append(ids, je.ID)
+	}
+	return ids
+}
+
+// TestListFinishedExecutions lists only terminal executions, most recently ended first
+func TestListFinishedExecutions(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, je := range []*models.JobExecution{
+		{ID: "failed", Status: models.JobStatusFailed, EndTime: base.Add(3 * time.Minute)},
+		{ID: "running", Status: models.JobStatusRunning, StartTime: base},
+		{ID: "completed", Status: models.JobStatusCompleted, EndTime: base.Add(time.Minute)},
+		{ID: "queued", Status: models.JobStatusQueued, StartTime: base},
+		{ID: "cancelled", Status: models.JobStatusCancelled, EndTime: base.Add(2 * time.Minute)},
+	} {
+		storeExecution(t, db, je)
+	}
+
+	if got := finishedIDs(t, db, 10); !slices.Equal(got, []string{"failed", "cancelled", "completed"}) {
+		t.Errorf("finished = %v, want [failed cancelled completed]", got)
+	}
+	if got := finishedIDs(t, db, 2); !slices.Equal(got, []string{"failed", "cancelled"}) {
+		t.Errorf("finished with limit 2 = %v, want [failed cancelled]", got)
+	}
+
+	// A retried execution leaves the list while it runs and returns at its new end time
+	retried := &models.JobExecution{ID: "completed", Status: models.JobStatusRunning, StartTime: base.Add(4 * time.Minute)}
+	if err := db.UpdateJobExecution(retried); err != nil {
+		t.Fatalf("update execution: %v", err)
+	}
+	if got := finishedIDs(t, db, 10); !slices.Equal(got, []string{"failed", "cancelled"}) {
+		t.Errorf("finished while completed runs again = %v, want [failed cancelled]", got)
+	}
+	retried.Status, retried.EndTime = models.JobStatusCompleted, base.Add(5*time.Minute)
+	if err := db.UpdateJobExecution(retried); err != nil {
+		t.Fatalf("update execution: %v", err)
+	}
+	if got := finishedIDs(t, db, 10); !slices.Equal(got, []string{"completed", "failed", "cancelled"}) {
+		t.Errorf("finished after the rerun = %v, want [completed failed cancelled]", got)
+	}
+}
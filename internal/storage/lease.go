@@ -0,0 +1,30 @@
+// lease.go defines how executions are leased to the instance working on them
+// Instances sharing storage only recover executions whose lease expired
+// BoltDB is opened by a single process, so its leases are always its own
+package storage
+
+import "time"
+
+// defaultLeaseDuration is how long a lease lasts unless renewed
+// Instances renew the leases of their executions well within it
+const defaultLeaseDuration = 30 * time.Second
+
+// LeaseDuration returns zero, BoltDB is never shared between processes
+func (b *BoltDB) LeaseDuration() time.Duration {
+	return 0
+}
+
+// ClaimExecution always succeeds, no other process can hold the execution
+func (b *BoltDB) ClaimExecution(id string) (bool, error) {
+	return true, nil
+}
+
+// RenewLeases returns all IDs, no other process can have taken them over
+func (b *BoltDB) RenewLeases(ids []string) ([]string, error) {
+	return ids, nil
+}
+
+// LeasedExecutions returns no IDs, leases of earlier processes are all expired
+func (b *BoltDB) LeasedExecutions() (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
@@ -0,0 +1,266 @@
+// mirror.go implements a write mirror that replicates mutations to a secondary DB
+// Writes go to the primary first and are forwarded to the secondary asynchronously
+// Used to keep a disaster recovery copy without slowing down the primary path
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// ErrMirrorBacklog is reported when a mutation is dropped because the
+// replication backlog is full, the secondary then misses that write
+var ErrMirrorBacklog = errors.New("mirror backlog full")
+
+// MirrorDB wraps a primary DB and mirrors its mutations to a secondary DB
+// Reads are served by the primary only
+//
+// Replication is asynchronous and in order. Failures and dropped writes are
+// reported through the error callback and never fail the primary call.
+type MirrorDB struct {
+	DB // Primary storage
+
+	secondary DB                         // Receives mirrored writes
+	onError   func(op string, err error) // Reports replication failures
+	ops       chan mirrorOp              // Pending mutations in write order
+	done      chan struct{}              // Signal that the replication loop has stopped
+	mu        sync.RWMutex               // Guards sending on ops against closing it
+	closed    bool                       // Set once Close stopped accepting mutations
+	closeOnce sync.Once
+}
+
+// mirrorOp is a mutation waiting to be applied to the secondary
+type mirrorOp struct {
+	name  string
+	apply func(db DB) error
+}
+
+// NewMirrorDB creates a mirror of primary writes onto secondary
+// backlog bounds the number of mutations waiting for replication
+// onError may be nil, in which case replication failures are ignored
+func NewMirrorDB(primary, secondary DB, backlog int, onError func(op string, err error)) *MirrorDB {
+	if onError == nil {
+		onError = func(string, error) {}
+	}
+	m := &MirrorDB{
+		DB:        primary,
+		secondary: secondary,
+		onError:   onError,
+		ops:       make(chan mirrorOp, backlog),
+		done:      make(chan struct{}),
+	}
+	go m.replicate()
+	return m
+}
+
+// replicate applies mirrored mutations to the secondary until closed
+func (m *MirrorDB) replicate() {
+	defer close(m.done)
+	for op := range m.ops {
+		if err := op.apply(m.secondary); err != nil {
+			m.onError(op.name, err)
+		}
+	}
+}
+
+// mirror queues a mutation for the secondary without blocking
+// Mutations that don't fit in the backlog are dropped and reported
+func (m *MirrorDB) mirror(name string, apply func(db DB) error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	select {
+	case m.ops <- mirrorOp{name: name, apply: apply}:
+	default:
+		m.onError(name, ErrMirrorBacklog)
+	}
+}
+
+// clone deep copies a record so later changes by the caller
+// don't leak into the copy waiting for replication
+// Numbers are kept as json.Number so they replicate without precision loss
+func clone[T any](v *T) (*T, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var c T
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// StoreJobDefinition stores a definition and mirrors it
+func (m *MirrorDB) StoreJobDefinition(jd *models.JobDefinition) error {
+	if err := m.DB.StoreJobDefinition(jd); err != nil {
+		return err
+	}
+	c, err := clone(jd)
+	if err != nil {
+		m.onError("StoreJobDefinition", err)
+		return nil
+	}
+	m.mirror("StoreJobDefinition", func(db DB) error { return db.StoreJobDefinition(c) })
+	return nil
+}
+
+// UpdateJobDefinition updates a definition and mirrors the result
+// The secondary stores the updated definition read back from the primary
+func (m *MirrorDB) UpdateJobDefinition(id string, update func(jd *models.JobDefinition) error) error {
+	if err := m.DB.UpdateJobDefinition(id, update); err != nil {
+		return err
+	}
+	jd, err := m.DB.GetJobDefinition(id)
+	if err != nil {
+		m.onError("UpdateJobDefinition", err)
+		return nil
+	}
+	m.mirror("UpdateJobDefinition", func(db DB) error { return db.StoreJobDefinition(jd) })
+	return nil
+}
+
+// DeleteJobDefinition deletes a definition and mirrors the deletion
+func (m *MirrorDB) DeleteJobDefinition(id string) error {
+	if err := m.DB.DeleteJobDefinition(id); err != nil {
+		return err
+	}
+	m.mirror("DeleteJobDefinition", func(db DB) error { return db.DeleteJobDefinition(id) })
+	return nil
+}
+
+// StoreJobExecution stores an execution and mirrors it
+func (m *MirrorDB) StoreJobExecution(je *models.JobExecution) error {
+	if err := m.DB.StoreJobExecution(je); err != nil {
+		return err
+	}
+	c, err := clone(je)
+	if err != nil {
+		m.onError("StoreJobExecution", err)
+		return nil
+	}
+	m.mirror("StoreJobExecution", func(db DB) error { return db.StoreJobExecution(c) })
+	return nil
+}
+
+// UpdateJobExecution updates an execution and mirrors it
+func (m *MirrorDB) UpdateJobExecution(je *models.JobExecution) error {
+	if err := m.DB.UpdateJobExecution(je); err != nil {
+		return err
+	}
+	c, err := clone(je)
+	if err != nil {
+		m.onError("UpdateJobExecution", err)
+		return nil
+	}
+	m.mirror("UpdateJobExecution", func(db DB) error { return db.UpdateJobExecution(c) })
+	return nil
+}
+
+// UpdateTaskStatus updates a task status and mirrors it
+func (m *MirrorDB) UpdateTaskStatus(executionID, taskID string, status models.TaskStatus) error {
+	if err := m.DB.UpdateTaskStatus(executionID, taskID, status); err != nil {
+		return err
+	}
+	m.mirror("UpdateTaskStatus", func(db DB) error { return db.UpdateTaskStatus(executionID, taskID, status) })
+	return nil
+}
+
+// EnqueueJob enqueues a job and mirrors the queue entry
+func (m *MirrorDB) EnqueueJob(jobID string) error {
+	if err := m.DB.EnqueueJob(jobID); err != nil {
+		return err
+	}
+	m.mirror("EnqueueJob", func(db DB) error { return db.EnqueueJob(jobID) })
+	return nil
+}
+
+// DequeueJob dequeues from the primary and removes the same entry on the secondary
+// The secondary's own queue order is never consulted
+func (m *MirrorDB) DequeueJob() (string, error) {
+	jobID, err := m.DB.DequeueJob()
+	if err != nil {
+		return "", err
+	}
+	m.mirror("DequeueJob", func(db DB) error { return db.RemoveFromQueue(jobID) })
+	return jobID, nil
+}
+
+// RemoveFromQueue removes a queue entry and mirrors the removal
+func (m *MirrorDB) RemoveFromQueue(jobID string) error {
+	if err := m.DB.RemoveFromQueue(jobID); err != nil {
+		return err
+	}
+	m.mirror("RemoveFromQueue", func(db DB) error { return db.RemoveFromQueue(jobID) })
+	return nil
+}
+
+// ScheduleJob schedules a job and mirrors the schedule entry
+func (m *MirrorDB) ScheduleJob(jobID string, at time.Time) error {
+	if err := m.DB.ScheduleJob(jobID, at); err != nil {
+		return err
+	}
+	m.mirror("ScheduleJob", func(db DB) error { return db.ScheduleJob(jobID, at) })
+	return nil
+}
+
+// PromoteDueJobs promotes due jobs and promotes the same jobs on the secondary
+func (m *MirrorDB) PromoteDueJobs(now time.Time) ([]string, error) {
+	promoted, err := m.DB.PromoteDueJobs(now)
+	if err != nil {
+		return nil, err
+	}
+	if len(promoted) > 0 {
+		m.mirror("PromoteDueJobs", func(db DB) error {
+			_, err := db.PromoteDueJobs(now)
+			return err
+		})
+	}
+	return promoted, nil
+}
+
+// IncrementExecutedJobsCount increments the counter and mirrors the increment
+func (m *MirrorDB) IncrementExecutedJobsCount() error {
+	if err := m.DB.IncrementExecutedJobsCount(); err != nil {
+		return err
+	}
+	m.mirror("IncrementExecutedJobsCount", func(db DB) error { return db.IncrementExecutedJobsCount() })
+	return nil
+}
+
+// AppendAuditEntry appends an audit entry and mirrors it
+// The secondary assigns its own sequence ID
+func (m *MirrorDB) AppendAuditEntry(entry *models.AuditEntry) error {
+	if err := m.DB.AppendAuditEntry(entry); err != nil {
+		return err
+	}
+	c := *entry
+	m.mirror("AppendAuditEntry", func(db DB) error { return db.AppendAuditEntry(&c) })
+	return nil
+}
+
+// Close waits for pending mutations to replicate, then closes both stores
+func (m *MirrorDB) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		m.mu.Lock()
+		m.closed = true
+		close(m.ops)
+		m.mu.Unlock()
+		<-m.done
+		if serr := m.secondary.Close(); serr != nil {
+			m.onError("Close", serr)
+		}
+		err = m.DB.Close()
+	})
+	return err
+}
@@ -0,0 +1,161 @@
+// mirror_test.go tests mirroring the writes of a primary DB onto a secondary
+// Mutations reach the secondary in order, while failing or slow secondaries
+// are only reported and never fail or undo the primary write
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// failingDB fails storing executions, or blocks doing so until release is closed
+type failingDB struct {
+	DB
+	err     error         // Returned by StoreJobExecution if set
+	started chan struct{} // Signalled as StoreJobExecution is called, if set
+	release chan struct{} // Closed to let StoreJobExecution continue, if set
+}
+
+// StoreJobExecution fails or blocks as configured, otherwise stores the execution
+func (db *failingDB) StoreJobExecution(je *models.JobExecution) error {
+	if db.started != nil {
+		db.started <- struct{}{}
+	}
+	if db.release != nil {
+		<-db.release
+	}
+	if db.err != nil {
+		return db.err
+	}
+	return db.DB.StoreJobExecution(je)
+}
+
+// mirrorError is a replication failure reported by a MirrorDB
+type mirrorError struct {
+	op  string
+	err error
+}
+
+// reportErrors returns an error callback sending to the returned channel
+func reportErrors() (func(op string, err error), <-chan mirrorError) {
+	errs := make(chan mirrorError, 16)
+	return func(op string, err error) { errs <- mirrorError{op, err} }, errs
+}
+
+// TestMirrorReplicatesMutations writes through a mirror and reopens the secondary
+// after Close to find every mutation applied in order
+func TestMirrorReplicatesMutations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secondary.db")
+	secondary, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open secondary: %v", err)
+	}
+	onError, errs := reportErrors()
+	m := NewMirrorDB(openTestBoltDB(t, Options{}), secondary, 16, onError)
+
+	if err := m.StoreJobDefinition(&models.JobDefinition{ID: "etl"}); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	je := &models.JobExecution{ID: "run", DefinitionID: "etl", Status: models.JobStatusQueued,
+		TaskStatuses: map[string]models.TaskStatus{}}
+	storeExecution(t, m, je)
+	if err := m.EnqueueJob("run"); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := m.UpdateTaskStatus("run", "extract", models.TaskStatusCompleted); err != nil {
+		t.Fatalf("update task status: %v", err)
+	}
+	// Changes after the write must not leak into the mirrored copy
+	je.Status = models.JobStatusFailed
+	if err := m.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	select {
+	case e := <-errs:
+		t.Fatalf("replication of %s failed: %v", e.op, e.err)
+	default:
+	}
+
+	mirrored := openTestBoltDBAt(t, path)
+	if _, err := mirrored.GetJobDefinition("etl"); err != nil {
+		t.Errorf("mirrored definition: %v", err)
+	}
+	got, err := mirrored.GetJobExecution("run")
+	if err != nil {
+		t.Fatalf("mirrored execution: %v", err)
+	}
+	if got.Status != models.JobStatusQueued || got.TaskStatuses["extract"] != models.TaskStatusCompleted {
+		t.Errorf("mirrored execution is %s with tasks %v, want QUEUED with extract COMPLETED", got.Status, got.TaskStatuses)
+	}
+	if queued, err := mirrored.GetQueuedJobs(); err != nil || !slices.Equal(queued, []string{"run"}) {
+		t.Errorf("mirrored queue = %v, %v, want [run]", queued, err)
+	}
+}
+
+// TestMirrorSecondaryFailure keeps the primary write when the secondary fails
+// The failure is reported and later mutations still replicate
+func TestMirrorSecondaryFailure(t *testing.T) {
+	primary, err := NewBoltDB(filepath.Join(t.TempDir(), "primary.db"))
+	if err != nil {
+		t.Fatalf("open primary: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "secondary.db")
+	secondary, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open secondary: %v", err)
+	}
+	unavailable := errors.New("secondary unavailable")
+	onError, errs := reportErrors()
+	m := NewMirrorDB(primary, &failingDB{DB: secondary, err: unavailable}, 16, onError)
+
+	storeExecution(t, m, &models.JobExecution{ID: "run", Status: models.JobStatusQueued})
+	if err := m.StoreJobDefinition(&models.JobDefinition{ID: "etl"}); err != nil {
+		t.Fatalf("store definition: %v", err)
+	}
+	if e := <-errs; e.op != "StoreJobExecution" || !errors.Is(e.err, unavailable) {
+		t.Errorf("reported %s: %v, want StoreJobExecution: %v", e.op, e.err, unavailable)
+	}
+	if _, err := m.GetJobExecution("run"); err != nil {
+		t.Errorf("primary lost the execution: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	mirrored := openTestBoltDBAt(t, path)
+	if _, err := mirrored.GetJobDefinition("etl"); err != nil {
+		t.Errorf("definition written after the failure wasn't mirrored: %v", err)
+	}
+	if _, err := mirrored.GetJobExecution("run"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("failed write reached the secondary: %v", err)
+	}
+}
+
+// TestMirrorBacklogFull drops mutations that don't fit in the backlog of a slow
+// secondary, reporting ErrMirrorBacklog while the primary writes succeed
+func TestMirrorBacklogFull(t *testing.T) {
+	primary := openTestBoltDB(t, Options{})
+	slow := &failingDB{DB: openTestBoltDB(t, Options{}), started: make(chan struct{}, 1), release: make(chan struct{})}
+	onError, errs := reportErrors()
+	m := NewMirrorDB(primary, slow, 1, onError)
+	defer close(slow.release)
+
+	// The first write occupies the replication loop, the second fills the backlog
+	storeExecution(t, m, &models.JobExecution{ID: "first"})
+	<-slow.started
+	storeExecution(t, m, &models.JobExecution{ID: "second"})
+	storeExecution(t, m, &models.JobExecution{ID: "dropped"})
+
+	if e := <-errs; e.op != "StoreJobExecution" || !errors.Is(e.err, ErrMirrorBacklog) {
+		t.Errorf("reported %s: %v, want StoreJobExecution: %v", e.op, e.err, ErrMirrorBacklog)
+	}
+	for _, id := range []string{"first", "second", "dropped"} {
+		if _, err := primary.GetJobExecution(id); err != nil {
+			t.Errorf("primary lost execution %s: %v", id, err)
+		}
+	}
+}
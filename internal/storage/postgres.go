@@ -0,0 +1,785 @@
+// postgres.go implements persistent storage using PostgreSQL
+// Lets several orchestrator instances share definitions, executions, and the queue
+// Dequeuing locks the claimed queue row so no two workers take the same job
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/lib/pq" // Also registers the "postgres" driver
+)
+
+// postgresSchema creates the tables used by PostgresDB if they don't exist
+// Records are kept as JSONB, columns that are filtered or sorted on are copied out
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS job_definitions (
+	id   TEXT PRIMARY KEY,
+	data JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS job_definitions_tags ON job_definitions USING GIN ((data->'tags'));
+
+CREATE TABLE IF NOT EXISTS job_executions (
+	id         TEXT PRIMARY KEY,
+	status     TEXT NOT NULL,
+	priority   INTEGER NOT NULL DEFAULT 0,
+	start_time TIMESTAMPTZ NOT NULL,
+	end_time   TIMESTAMPTZ NOT NULL,
+	data       JSONB NOT NULL
+);
+ALTER TABLE job_executions ADD COLUMN IF NOT EXISTS owner TEXT;
+ALTER TABLE job_executions ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS job_executions_status ON job_executions (status);
+CREATE INDEX IF NOT EXISTS job_executions_start_time ON job_executions (start_time);
+CREATE INDEX IF NOT EXISTS job_executions_end_time ON job_executions (end_time);
+CREATE INDEX IF NOT EXISTS job_executions_unfinished ON job_executions ((data->>'definitionId'))
+	WHERE status NOT IN ('COMPLETED', 'FAILED', 'CANCELLED') AND data->'definition' IS NULL;
+
+CREATE TABLE IF NOT EXISTS queue (
+	seq          BIGSERIAL PRIMARY KEY,
+	execution_id TEXT NOT NULL UNIQUE,
+	priority     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS queue_order ON queue (priority DESC, seq);
+
+CREATE TABLE IF NOT EXISTS scheduled (
+	start_at     TIMESTAMPTZ NOT NULL,
+	execution_id TEXT NOT NULL,
+	PRIMARY KEY (start_at, execution_id)
+);
+
+CREATE TABLE IF NOT EXISTS stats (
+	name  TEXT PRIMARY KEY,
+	value BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit (
+	id   BIGSERIAL PRIMARY KEY,
+	data JSONB NOT NULL
+);
+`
+
+// PostgresDB implements the DB interface using PostgreSQL
+// Safe to share between processes, each statement runs in its own transaction
+// unless noted otherwise
+type PostgresDB struct {
+	db    *sql.DB       // Connection pool
+	opts  Options       // Optional behavior, CompressExecutions is left to PostgreSQL's own compression
+	lease time.Duration // How long leases taken by this instance last unless renewed
+}
+
+// NewPostgresDB connects to PostgreSQL and creates the schema if needed
+// dsn is a lib/pq connection string or URL
+func NewPostgresDB(dsn string) (*PostgresDB, error) {
+	return NewPostgresDBWithOptions(dsn, Options{})
+}
+
+// NewPostgresDBWithOptions creates a PostgresDB instance with optional behavior
+// Behaves like NewPostgresDB otherwise
+func NewPostgresDBWithOptions(dsn string, opts Options) (*PostgresDB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not open db, %v", err)
+	}
+	if _, err := db.Exec(postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not set up tables, %v", err)
+	}
+	if opts.InstanceID == "" {
+		opts.InstanceID = defaultInstanceID()
+	}
+	return &PostgresDB{db: db, opts: opts, lease: defaultLeaseDuration}, nil
+}
+
+// defaultInstanceID names this process after its host
+// Falls back to the process ID if the host name is unknown
+func defaultInstanceID() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return fmt.Sprintf("pid-%d", os.Getpid())
+}
+
+// queueOrder is the ORDER BY clause listing the queue in dequeue order
+func (p *PostgresDB) queueOrder() string {
+	if p.opts.Discipline == QueueLIFO {
+		return "priority DESC, seq DESC"
+	}
+	return "priority DESC, seq"
+}
+
+// sqlQuerier is implemented by both the connection pool and transactions
+type sqlQuerier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing if it returns nil
+// opts may be nil for a default read-write transaction
+func (p *PostgresDB) inTx(opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.BeginTx(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// StoreJobDefinition saves or replaces a job definition
+func (p *PostgresDB) StoreJobDefinition(jd *models.JobDefinition) error {
+	return putPostgresDefinition(p.db, jd)
+}
+
+// putPostgresDefinition upserts a definition through a connection or transaction
+func putPostgresDefinition(exec sqlQuerier, jd *models.JobDefinition) error {
+	buf, err := json.Marshal(jd)
+	if err != nil {
+		return err
+	}
+	_, err = exec.Exec(`INSERT INTO job_definitions (id, data) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data`, jd.ID, buf)
+	return err
+}
+
+// GetJobDefinition retrieves a job definition by ID
+// Returns ErrNotFound if the definition doesn't exist
+func (p *PostgresDB) GetJobDefinition(id string) (*models.JobDefinition, error) {
+	var buf []byte
+	err := p.db.QueryRow(`SELECT data FROM job_definitions WHERE id = $1`, id).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("job definition %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jd models.JobDefinition
+	if err := json.Unmarshal(buf, &jd); err != nil {
+		return nil, err
+	}
+	return &jd, nil
+}
+
+// ListJobDefinitions returns all registered job definitions ordered by ID
+func (p *PostgresDB) ListJobDefinitions() ([]*models.JobDefinition, error) {
+	return p.queryDefinitions(`SELECT data FROM job_definitions ORDER BY id`)
+}
+
+// ListJobDefinitionsByTag returns all job definitions carrying a tag ordered by ID
+// Served by the GIN index on the definition's tags
+func (p *PostgresDB) ListJobDefinitionsByTag(tag string) ([]*models.JobDefinition, error) {
+	return p.queryDefinitions(`SELECT data FROM job_definitions WHERE data->'tags' ? $1 ORDER BY id`, tag)
+}
+
+// queryDefinitions decodes the definitions selected by a query
+func (p *PostgresDB) queryDefinitions(query string, args ...any) ([]*models.JobDefinition, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := []*models.JobDefinition{}
+	for rows.Next() {
+		var buf []byte
+		if err := rows.Scan(&buf); err != nil {
+			return nil, err
+		}
+		var jd models.JobDefinition
+		if err := json.Unmarshal(buf, &jd); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, &jd)
+	}
+	return definitions, rows.Err()
+}
+
+// UpdateJobDefinition modifies a stored job definition atomically
+// The row is locked from read to write so concurrent updates don't overwrite each other
+// The update is discarded if the update function returns an error
+func (p *PostgresDB) UpdateJobDefinition(id string, update func(jd *models.JobDefinition) error) error {
+	return p.inTx(nil, func(tx *sql.Tx) error {
+		var buf []byte
+		err := tx.QueryRow(`SELECT data FROM job_definitions WHERE id = $1 FOR UPDATE`, id).Scan(&buf)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("job definition %w", ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		var jd models.JobDefinition
+		if err := json.Unmarshal(buf, &jd); err != nil {
+			return err
+		}
+		if err := update(&jd); err != nil {
+			return err
+		}
+		return putPostgresDefinition(tx, &jd)
+	})
+}
+
+// DeleteJobDefinition removes a job definition
+// Finished executions of the definition are kept
+// Returns ErrNotFound if the definition doesn't exist and ErrDefinitionInUse
+// while unfinished executions run it, checked in the deleting statement
+func (p *PostgresDB) DeleteJobDefinition(id string) error {
+	res, err := p.db.Exec(`DELETE FROM job_definitions WHERE id = $1
+		AND NOT EXISTS (SELECT 1 FROM job_executions e WHERE e.data->>'definitionId' = $1
+			AND e.status NOT IN ($2, $3, $4) AND e.data->'definition' IS NULL)`,
+		id, models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		return nil
+	}
+
+	// Nothing was deleted, tell a missing definition from one in use
+	var exists bool
+	if err := p.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM job_definitions WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("job definition %w", ErrNotFound)
+	}
+	return fmt.Errorf("%w: unfinished executions of %s", ErrDefinitionInUse, id)
+}
+
+// GetRunningJobs returns IDs of all executions in RUNNING or PAUSED state
+// Used for state recovery after system restart
+func (p *PostgresDB) GetRunningJobs() ([]string, error) {
+	return queryPostgresIDs(p.db, `SELECT id FROM job_executions WHERE status IN ($1, $2) ORDER BY id`,
+		models.JobStatusRunning, models.JobStatusPaused)
+}
+
+// ListUnqueuedJobs returns IDs of QUEUED executions that are neither queued nor scheduled
+func (p *PostgresDB) ListUnqueuedJobs() ([]string, error) {
+	return queryPostgresIDs(p.db, `SELECT e.id FROM job_executions e
+		WHERE e.status = $1
+			AND NOT EXISTS (SELECT 1 FROM queue q WHERE q.execution_id = e.id)
+			AND NOT EXISTS (SELECT 1 FROM scheduled s WHERE s.execution_id = e.id)
+		ORDER BY e.start_time, e.id`, models.JobStatusQueued)
+}
+
+// queryPostgresIDs runs a query returning a single ID column through a connection or transaction
+func queryPostgresIDs(q sqlQuerier, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// StoreJobExecution saves or replaces a job execution
+// New executions are leased to this instance until they are queued, replacing
+// one leaves its lease alone
+func (p *PostgresDB) StoreJobExecution(je *models.JobExecution) error {
+	buf, err := json.Marshal(je)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.Exec(`INSERT INTO job_executions (id, status, priority, start_time, end_time, data, owner, lease_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, `+leaseUntil(8)+`)
+		ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, priority = EXCLUDED.priority,
+			start_time = EXCLUDED.start_time, end_time = EXCLUDED.end_time, data = EXCLUDED.data`,
+		je.ID, je.Status, je.Priority, je.StartTime, je.EndTime, buf, p.opts.InstanceID, p.lease.Seconds())
+	return err
+}
+
+// UpdateJobExecution updates an existing job execution
+// Wraps StoreJobExecution as the upsert covers create and update
+func (p *PostgresDB) UpdateJobExecution(je *models.JobExecution) error {
+	return p.StoreJobExecution(je)
+}
+
+// UpdateTaskStatus records the status of a single task of an execution
+// Patches the stored record in place instead of rewriting it from the caller's copy
+func (p *PostgresDB) UpdateTaskStatus(executionID, taskID string, status models.TaskStatus) error {
+	_, err := p.db.Exec(`UPDATE job_executions SET data = jsonb_set(data, '{taskStatuses}',
+		CASE WHEN jsonb_typeof(data->'taskStatuses') = 'object' THEN data->'taskStatuses' ELSE '{}'::jsonb END
+		|| jsonb_build_object($2::text, $3::text)) WHERE id = $1`, executionID, taskID, string(status))
+	return err
+}
+
+// decodeExecution parses a stored execution
+// Numbers in its data are decoded as configured by Options.UseNumber
+func (p *PostgresDB) decodeExecution(v []byte, je *models.JobExecution) error {
+	dec := json.NewDecoder(bytes.NewReader(v))
+	if p.opts.UseNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(je)
+}
+
+// GetJobExecution retrieves job execution details by ID
+// Returns ErrNotFound if the execution doesn't exist
+func (p *PostgresDB) GetJobExecution(id string) (*models.JobExecution, error) {
+	var buf []byte
+	err := p.db.QueryRow(`SELECT data FROM job_executions WHERE id = $1`, id).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("job execution %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var je models.JobExecution
+	if err := p.decodeExecution(buf, &je); err != nil {
+		return nil, err
+	}
+	return &je, nil
+}
+
+// forEachExecution calls fn for every execution selected by a query
+func (p *PostgresDB) forEachExecution(fn func(je *models.JobExecution) error, query string, args ...any) error {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var buf []byte
+		if err := rows.Scan(&buf); err != nil {
+			return err
+		}
+		var je models.JobExecution
+		if err := p.decodeExecution(buf, &je); err != nil {
+			return err
+		}
+		if err := fn(&je); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// listExecutions returns the executions selected by a query in query order
+func (p *PostgresDB) listExecutions(query string, args ...any) ([]*models.JobExecution, error) {
+	executions := []*models.JobExecution{}
+	err := p.forEachExecution(func(je *models.JobExecution) error {
+		executions = append(executions, je)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// ForEachJobExecution calls fn for every stored job execution
+// fn runs while the result set is open and must not write to the DB
+func (p *PostgresDB) ForEachJobExecution(fn func(je *models.JobExecution) error) error {
+	return p.forEachExecution(fn, `SELECT data FROM job_executions`)
+}
+
+// ListJobExecutions returns all executions, newest first by start time
+// Only executions with the given status are returned unless status is empty
+func (p *PostgresDB) ListJobExecutions(status models.JobStatus) ([]*models.JobExecution, error) {
+	return p.listExecutions(`SELECT data FROM job_executions WHERE $1 = '' OR status = $1
+		ORDER BY start_time DESC, id`, status)
+}
+
+// ListJobExecutionsByTime returns executions started in [from, to), oldest first
+// Start times are stored with microsecond precision
+func (p *PostgresDB) ListJobExecutionsByTime(from, to time.Time) ([]*models.JobExecution, error) {
+	return p.listExecutions(`SELECT data FROM job_executions WHERE start_time >= $1 AND start_time < $2
+		ORDER BY start_time, id`, from, to)
+}
+
+// ListFinishedExecutions returns up to limit finished executions, most recently ended first
+func (p *PostgresDB) ListFinishedExecutions(limit int) ([]*models.JobExecution, error) {
+	return p.listExecutions(`SELECT data FROM job_executions WHERE status IN ($1, $2, $3)
+		ORDER BY end_time DESC, id LIMIT $4`,
+		models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled, limit)
+}
+
+// CountExecutionsByStatus returns the number of stored executions per status
+func (p *PostgresDB) CountExecutionsByStatus() (map[models.JobStatus]int, error) {
+	return countPostgresStatuses(p.db)
+}
+
+// countPostgresStatuses counts executions per status through a connection or transaction
+func countPostgresStatuses(q sqlQuerier) (map[models.JobStatus]int, error) {
+	rows, err := q.Query(`SELECT status, COUNT(*) FROM job_executions GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[models.JobStatus]int)
+	for rows.Next() {
+		var status models.JobStatus
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// GetQueuedJobs returns all queued job IDs in dequeue order
+func (p *PostgresDB) GetQueuedJobs() ([]string, error) {
+	return queuedPostgresIDs(p.db, p.queueOrder())
+}
+
+// queuedPostgresIDs lists queued job IDs in the given order through a connection or transaction
+func queuedPostgresIDs(q sqlQuerier, order string) ([]string, error) {
+	return queryPostgresIDs(q, `SELECT execution_id FROM queue ORDER BY `+order)
+}
+
+// ListQueuedExecutions returns the queue in dequeue order with execution details
+// Joins the queue with executions and definitions in a single query
+func (p *PostgresDB) ListQueuedExecutions() ([]*models.QueuedExecution, error) {
+	rows, err := p.db.Query(`SELECT q.execution_id, e.data, d.data->>'name'
+		FROM queue q
+		LEFT JOIN job_executions e ON e.id = q.execution_id
+		LEFT JOIN job_definitions d ON d.id = e.data->>'definitionId'
+		ORDER BY ` + p.queueOrder())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queued []*models.QueuedExecution
+	for rows.Next() {
+		var buf []byte
+		var name sql.NullString
+		entry := &models.QueuedExecution{}
+		if err := rows.Scan(&entry.ExecutionID, &buf, &name); err != nil {
+			return nil, err
+		}
+		queued = append(queued, entry)
+		if buf == nil {
+			continue
+		}
+
+		var je models.JobExecution
+		if err := p.decodeExecution(buf, &je); err != nil {
+			return nil, err
+		}
+		entry.DefinitionID = je.DefinitionID
+		entry.QueuedAt = je.QueuedAt
+		entry.Priority = je.Priority
+		entry.Tags = je.Tags
+
+		// Inline executions carry their definition
+		if je.Definition != nil {
+			entry.DefinitionName = je.Definition.Name
+		} else {
+			entry.DefinitionName = name.String
+		}
+	}
+	return queued, rows.Err()
+}
+
+// enqueueSQL adds a job to the queue at the priority of its stored execution
+// Affects no rows if the job is queued already
+const enqueueSQL = `INSERT INTO queue (execution_id, priority)
+	VALUES ($1, COALESCE((SELECT priority FROM job_executions WHERE id = $1), 0))
+	ON CONFLICT (execution_id) DO NOTHING`
+
+// EnqueueJob adds a job to the execution queue
+// Higher priorities dequeue first and equal priorities keep enqueue order
+// Returns ErrAlreadyQueued if the job is queued already, unless allowed by Options
+func (p *PostgresDB) EnqueueJob(jobID string) error {
+	res, err := p.db.Exec(enqueueSQL, jobID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 && !p.opts.AllowDuplicateEnqueue {
+		return fmt.Errorf("%w: %s", ErrAlreadyQueued, jobID)
+	}
+	return nil
+}
+
+// EnqueueJobs adds several jobs to the execution queue in one transaction
+// Used by the buffered queue to flush batches efficiently
+// Jobs that are already queued are skipped so one duplicate can't fail the batch
+func (p *PostgresDB) EnqueueJobs(jobIDs []string) error {
+	return p.inTx(nil, func(tx *sql.Tx) error {
+		for _, jobID := range jobIDs {
+			if _, err := tx.Exec(enqueueSQL, jobID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DequeueJob removes and returns the next job from the queue
+// The claimed row is locked with SKIP LOCKED, so concurrent workers
+// take different jobs instead of waiting on or duplicating each other
+// The execution is leased to this instance in the same statement
+// Returns ErrQueueEmpty if no unclaimed job is waiting
+func (p *PostgresDB) DequeueJob() (string, error) {
+	var jobID string
+	err := p.db.QueryRow(`WITH claimed AS (
+		DELETE FROM queue WHERE seq = (
+			SELECT seq FROM queue ORDER BY `+p.queueOrder()+` LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING execution_id
+	), leased AS (
+		UPDATE job_executions SET owner = $1, lease_until = `+leaseUntil(2)+`
+		FROM claimed WHERE id = claimed.execution_id
+	)
+	SELECT execution_id FROM claimed`, p.opts.InstanceID, p.lease.Seconds()).Scan(&jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrQueueEmpty
+	}
+	return jobID, err
+}
+
+// GetQueuedJobCount returns the number of jobs in queue
+func (p *PostgresDB) GetQueuedJobCount() (int, error) {
+	var count int
+	err := p.db.QueryRow(`SELECT COUNT(*) FROM queue`).Scan(&count)
+	return count, err
+}
+
+// RemoveFromQueue removes a specific job from the queue
+// Removing a job that isn't queued is not an error
+func (p *PostgresDB) RemoveFromQueue(jobID string) error {
+	_, err := p.db.Exec(`DELETE FROM queue WHERE execution_id = $1`, jobID)
+	return err
+}
+
+// ScheduleJob holds a job back until the given time
+// PromoteDueJobs moves it to the queue once that time has passed
+func (p *PostgresDB) ScheduleJob(jobID string, at time.Time) error {
+	_, err := p.db.Exec(`INSERT INTO scheduled (start_at, execution_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, at, jobID)
+	return err
+}
+
+// PromoteDueJobs moves all jobs scheduled at or before now to the queue
+// Runs in one transaction so a job is never lost or in both places, rows
+// promoted by another instance meanwhile are skipped
+// Returns the IDs of the promoted jobs
+func (p *PostgresDB) PromoteDueJobs(now time.Time) ([]string, error) {
+	var promoted []string
+	err := p.inTx(nil, func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT start_at, execution_id FROM scheduled WHERE start_at <= $1
+			ORDER BY start_at, execution_id FOR UPDATE SKIP LOCKED`, now)
+		if err != nil {
+			return err
+		}
+		type due struct {
+			at    time.Time
+			jobID string
+		}
+		var jobs []due
+		for rows.Next() {
+			var d due
+			if err := rows.Scan(&d.at, &d.jobID); err != nil {
+				rows.Close()
+				return err
+			}
+			jobs = append(jobs, d)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, d := range jobs {
+			if _, err := tx.Exec(enqueueSQL, d.jobID); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`DELETE FROM scheduled WHERE start_at = $1 AND execution_id = $2`, d.at, d.jobID); err != nil {
+				return err
+			}
+			promoted = append(promoted, d.jobID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// IncrementExecutedJobsCount increments the executed jobs counter
+// The upsert is atomic, so concurrent instances don't lose increments
+func (p *PostgresDB) IncrementExecutedJobsCount() error {
+	_, err := p.db.Exec(`INSERT INTO stats (name, value) VALUES ($1, 1)
+		ON CONFLICT (name) DO UPDATE SET value = stats.value + 1`, executedJobsCountKey)
+	return err
+}
+
+// GetExecutedJobsCount returns the executed jobs counter
+func (p *PostgresDB) GetExecutedJobsCount() (int, error) {
+	return executedPostgresCount(p.db)
+}
+
+// executedPostgresCount reads the executed jobs counter through a connection or transaction
+func executedPostgresCount(q sqlQuerier) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT value FROM stats WHERE name = $1`, executedJobsCountKey).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil // If the counter doesn't exist, count is 0
+	}
+	return count, err
+}
+
+// Snapshot reads running executions, the queue, and the counters in one
+// read-only repeatable read transaction
+// An execution is reported as running or queued, never both
+func (p *PostgresDB) Snapshot() (*Snapshot, error) {
+	snap := &Snapshot{}
+	err := p.inTx(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(tx *sql.Tx) error {
+		var err error
+		if snap.Queued, err = queuedPostgresIDs(tx, p.queueOrder()); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`SELECT data FROM job_executions e WHERE status IN ($1, $2)
+			AND NOT EXISTS (SELECT 1 FROM queue q WHERE q.execution_id = e.id)`,
+			models.JobStatusRunning, models.JobStatusPaused)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var buf []byte
+			if err := rows.Scan(&buf); err != nil {
+				return err
+			}
+			var je models.JobExecution
+			if err := p.decodeExecution(buf, &je); err != nil {
+				return err
+			}
+			snap.Running = append(snap.Running, &je)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if snap.ExecutedCount, err = executedPostgresCount(tx); err != nil {
+			return err
+		}
+		snap.StatusCounts, err = countPostgresStatuses(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// AppendAuditEntry adds an entry to the audit log
+// Assigns the entry the next sequence ID, which is also its row ID
+func (p *PostgresDB) AppendAuditEntry(entry *models.AuditEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return p.db.QueryRow(`INSERT INTO audit (data) VALUES ($1) RETURNING id`, buf).Scan(&entry.ID)
+}
+
+// ListAuditEntries returns a page of the audit log, newest entries first
+// Skips offset entries and returns at most limit
+func (p *PostgresDB) ListAuditEntries(offset, limit int) ([]*models.AuditEntry, error) {
+	rows, err := p.db.Query(`SELECT id, data FROM audit ORDER BY id DESC OFFSET $1 LIMIT $2`, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var id uint64
+		var buf []byte
+		if err := rows.Scan(&id, &buf); err != nil {
+			return nil, err
+		}
+		var entry models.AuditEntry
+		if err := json.Unmarshal(buf, &entry); err != nil {
+			return nil, err
+		}
+		entry.ID = id // The row ID is assigned after the entry was encoded
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// leaseUntil returns the SQL expression of a lease's expiry
+// param is the number of the parameter holding the lease duration in seconds
+// Expiries are computed and compared by the database so instances' clocks don't matter
+func leaseUntil(param int) string {
+	return fmt.Sprintf("now() + make_interval(secs => $%d)", param)
+}
+
+// LeaseDuration returns how long leases last unless renewed
+func (p *PostgresDB) LeaseDuration() time.Duration {
+	return p.lease
+}
+
+// ClaimExecution leases an execution to this instance if no live lease holds it
+// Leases of this instance count as live too, its own executions are never claimed
+// Returns false if another lease is live or the execution doesn't exist
+func (p *PostgresDB) ClaimExecution(id string) (bool, error) {
+	res, err := p.db.Exec(`UPDATE job_executions SET owner = $1, lease_until = `+leaseUntil(2)+`
+		WHERE id = $3 AND (owner IS NULL OR lease_until IS NULL OR lease_until < now())`,
+		p.opts.InstanceID, p.lease.Seconds(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// RenewLeases extends the leases this instance holds on the given executions
+// Returns the IDs still leased to it, the others were claimed by another instance
+func (p *PostgresDB) RenewLeases(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return queryPostgresIDs(p.db, `UPDATE job_executions SET lease_until = `+leaseUntil(2)+`
+		WHERE owner = $1 AND id = ANY($3) RETURNING id`, p.opts.InstanceID, p.lease.Seconds(), pq.Array(ids))
+}
+
+// LeasedExecutions returns the IDs of unfinished executions under a live lease
+// Includes those of this instance
+func (p *PostgresDB) LeasedExecutions() (map[string]bool, error) {
+	ids, err := queryPostgresIDs(p.db, `SELECT id FROM job_executions
+		WHERE lease_until >= now() AND status IN ($1, $2, $3)`,
+		models.JobStatusQueued, models.JobStatusRunning, models.JobStatusPaused)
+	if err != nil {
+		return nil, err
+	}
+	leased := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		leased[id] = true
+	}
+	return leased, nil
+}
+
+// Close closes the connection pool
+// Should be called when shutting down the system
+func (p *PostgresDB) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,179 @@
+// postgres_test.go tests the PostgreSQL storage implementation against a live database
+// Runs only when POSTGRES_TEST_DSN names a database the tests may empty
+// Covers concurrent dequeuing and the leases of executions shared between instances
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// openTestPostgres connects to the test database as the given instance
+// The first connection of a test empties all tables, skips the test without POSTGRES_TEST_DSN
+func openTestPostgres(t *testing.T, instanceID string, truncate bool) *PostgresDB {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	db, err := NewPostgresDBWithOptions(dsn, Options{InstanceID: instanceID})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if truncate {
+		if _, err := db.db.Exec(`TRUNCATE job_definitions, job_executions, queue, scheduled, stats, audit`); err != nil {
+			t.Fatalf("truncate tables: %v", err)
+		}
+	}
+	return db
+}
+
+// TestPostgresDequeueSkipsLockedRows checks a queue row locked by another
+// transaction is skipped instead of waited for or dequeued twice
+func TestPostgresDequeueSkipsLockedRows(t *testing.T) {
+	db := openTestPostgres(t, "a", true)
+	for _, id := range []string{"first", "second"} {
+		storeExecution(t, db, &models.JobExecution{ID: id, Status: models.JobStatusQueued})
+		if err := db.EnqueueJob(id); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+
+	// Hold the lock on the first row as a concurrent dequeue would
+	tx, err := db.db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	var locked string
+	if err := tx.QueryRow(`SELECT execution_id FROM queue ORDER BY ` + db.queueOrder() + ` LIMIT 1 FOR UPDATE`).Scan(&locked); err != nil {
+		t.Fatalf("lock first row: %v", err)
+	}
+	if locked != "first" {
+		t.Fatalf("locked %s, want first", locked)
+	}
+
+	id, err := db.DequeueJob()
+	if err != nil {
+		t.Fatalf("dequeue: %v", err)
+	}
+	if id != "second" {
+		t.Errorf("dequeued %s, want second past the locked row", id)
+	}
+	if _, err := db.DequeueJob(); !errors.Is(err, ErrQueueEmpty) {
+		t.Errorf("dequeue with only a locked row = %v, want ErrQueueEmpty", err)
+	}
+
+	tx.Rollback()
+	if id, err := db.DequeueJob(); err != nil || id != "first" {
+		t.Errorf("dequeue after unlock = %s, %v, want first", id, err)
+	}
+}
+
+// TestPostgresConcurrentDequeue checks instances dequeuing at the same time
+// take every job exactly once
+func TestPostgresConcurrentDequeue(t *testing.T) {
+	const jobs, workers = 200, 8
+	db := openTestPostgres(t, "setup", true)
+	want := make([]string, 0, jobs)
+	for i := 0; i < jobs; i++ {
+		id := fmt.Sprintf("job-%03d", i)
+		storeExecution(t, db, &models.JobExecution{ID: id, Status: models.JobStatusQueued})
+		want = append(want, id)
+	}
+	if err := db.EnqueueJobs(want); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		worker := openTestPostgres(t, fmt.Sprintf("worker-%d", w), false)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, err := worker.DequeueJob()
+				if errors.Is(err, ErrQueueEmpty) {
+					return
+				}
+				if err != nil {
+					t.Errorf("dequeue: %v", err)
+					return
+				}
+				mu.Lock()
+				got = append(got, id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("dequeued %d jobs, want each of %d exactly once", len(got), jobs)
+	}
+}
+
+// TestPostgresLeases checks an execution leased to one instance can't be
+// claimed by another until its lease expires, and that the first then loses it
+func TestPostgresLeases(t *testing.T) {
+	a := openTestPostgres(t, "a", true)
+	b := openTestPostgres(t, "b", false)
+	storeExecution(t, a, &models.JobExecution{ID: "job", Status: models.JobStatusRunning})
+
+	if claimed, err := b.ClaimExecution("job"); err != nil || claimed {
+		t.Fatalf("claim of a live lease = %v, %v, want false", claimed, err)
+	}
+	if renewed, err := b.RenewLeases([]string{"job"}); err != nil || len(renewed) != 0 {
+		t.Fatalf("renewal by another instance = %v, %v, want none", renewed, err)
+	}
+	if renewed, err := a.RenewLeases([]string{"job"}); err != nil || !slices.Equal(renewed, []string{"job"}) {
+		t.Fatalf("renewal by the owner = %v, %v, want [job]", renewed, err)
+	}
+	if leased, err := b.LeasedExecutions(); err != nil || !leased["job"] {
+		t.Fatalf("leased executions = %v, %v, want job", leased, err)
+	}
+
+	// Let the lease expire as if a stopped renewing
+	if _, err := a.db.Exec(`UPDATE job_executions SET lease_until = now() - interval '1 second'`); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	if leased, err := b.LeasedExecutions(); err != nil || leased["job"] {
+		t.Fatalf("leased executions after expiry = %v, %v, want no job", leased, err)
+	}
+	if claimed, err := b.ClaimExecution("job"); err != nil || !claimed {
+		t.Fatalf("claim of an expired lease = %v, %v, want true", claimed, err)
+	}
+	if renewed, err := a.RenewLeases([]string{"job"}); err != nil || len(renewed) != 0 {
+		t.Errorf("renewal after being taken over = %v, %v, want none", renewed, err)
+	}
+}
+
+// TestPostgresDequeueLeases checks dequeuing leases the execution to the dequeuing instance
+func TestPostgresDequeueLeases(t *testing.T) {
+	a := openTestPostgres(t, "a", true)
+	b := openTestPostgres(t, "b", false)
+	storeExecution(t, a, &models.JobExecution{ID: "job", Status: models.JobStatusQueued})
+	if err := a.EnqueueJob("job"); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if id, err := b.DequeueJob(); err != nil || id != "job" {
+		t.Fatalf("dequeue = %s, %v, want job", id, err)
+	}
+	if renewed, err := b.RenewLeases([]string{"job"}); err != nil || len(renewed) != 1 {
+		t.Errorf("renewal by the dequeuing instance = %v, %v, want [job]", renewed, err)
+	}
+	if renewed, err := a.RenewLeases([]string{"job"}); err != nil || len(renewed) != 0 {
+		t.Errorf("renewal by the creating instance = %v, %v, want none", renewed, err)
+	}
+}
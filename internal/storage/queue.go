@@ -0,0 +1,186 @@
+// queue.go implements the key layout of the BoltDB job queue
+// Queue keys start with the job's priority and an enqueue sequence number so keys
+// sort by priority, then in enqueue order. An index maps job IDs to their queue keys
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+
+	"go.etcd.io/bbolt"
+)
+
+// QueueDiscipline selects which queued job is dequeued next
+type QueueDiscipline int
+
+const (
+	// QueueFIFO dequeues the oldest job first, the default
+	QueueFIFO QueueDiscipline = iota
+	// QueueLIFO dequeues the newest job first
+	// Improves latency of fresh jobs under bursts at the expense of fairness
+	QueueLIFO
+)
+
+// queueKey builds the queue key of a job with the given priority and enqueue sequence number
+// The priority is stored complemented so higher priorities sort first
+func queueKey(priority int, seq uint64, jobID string) []byte {
+	key := make([]byte, 16, 16+len(jobID))
+	binary.BigEndian.PutUint64(key, ^(uint64(priority) ^ 1<<63))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return append(key, jobID...)
+}
+
+// queuedJobID extracts the job ID from a queue key
+func queuedJobID(key []byte) string {
+	return string(key[16:])
+}
+
+// queuedPriority reads the priority of a queued job from its stored execution
+// Jobs without a stored execution are queued with the default priority 0
+func queuedPriority(tx *bbolt.Tx, jobID string) int {
+	v, err := executionJSON(tx.Bucket([]byte(jobExecutionsBucket)).Get([]byte(jobID)))
+	if err != nil || v == nil {
+		return 0
+	}
+	var je struct {
+		Priority int `json:"priority"`
+	}
+	if err := json.Unmarshal(v, &je); err != nil {
+		return 0
+	}
+	return je.Priority
+}
+
+// queuePut adds a job to the queue unless it is queued already
+// The job is placed by the priority of its stored execution
+// Reports whether the job was added
+func queuePut(tx *bbolt.Tx, jobID string) (bool, error) {
+	queue := tx.Bucket([]byte(queueBucket))
+	index := tx.Bucket([]byte(queueIndexBucket))
+	if index.Get([]byte(jobID)) != nil {
+		return false, nil
+	}
+	seq, err := queue.NextSequence()
+	if err != nil {
+		return false, err
+	}
+	key := queueKey(queuedPriority(tx, jobID), seq, jobID)
+	if err := queue.Put(key, []byte{}); err != nil {
+		return false, err
+	}
+	return true, index.Put([]byte(jobID), key)
+}
+
+// queueDelete removes a job from the queue
+// Deleting a job that isn't queued is a no-op
+func queueDelete(tx *bbolt.Tx, jobID string) error {
+	index := tx.Bucket([]byte(queueIndexBucket))
+	key := index.Get([]byte(jobID))
+	if key == nil {
+		return nil
+	}
+	if err := tx.Bucket([]byte(queueBucket)).Delete(key); err != nil {
+		return err
+	}
+	return index.Delete([]byte(jobID))
+}
+
+// queueNext returns the ID of the job dequeued next under the discipline
+// The highest priority comes first, the discipline orders jobs of equal priority
+// Returns an empty string if the queue is empty
+func queueNext(tx *bbolt.Tx, discipline QueueDiscipline) string {
+	cursor := tx.Bucket([]byte(queueBucket)).Cursor()
+	k, _ := cursor.First()
+	if k == nil {
+		return ""
+	}
+	if discipline == QueueLIFO {
+		// Find the newest job of the highest priority, the last key before the next priority
+		next := binary.BigEndian.Uint64(k[:8]) + 1
+		if next == 0 {
+			k, _ = cursor.Last()
+		} else if k, _ = cursor.Seek(binary.BigEndian.AppendUint64(nil, next)); k == nil {
+			k, _ = cursor.Last()
+		} else {
+			k, _ = cursor.Prev()
+		}
+	}
+	return queuedJobID(k)
+}
+
+// queuedJobIDs returns all queued job IDs in dequeue order
+// Sequence numbers are unique, so the order has no ties and matches queueNext
+func queuedJobIDs(tx *bbolt.Tx, discipline QueueDiscipline) ([]string, error) {
+	var keys [][]byte
+	err := tx.Bucket([]byte(queueBucket)).ForEach(func(k, _ []byte) error {
+		keys = append(keys, k)
+		return nil
+	})
+	if discipline == QueueLIFO {
+		// Newest first within each priority
+		slices.Reverse(keys)
+		slices.SortStableFunc(keys, func(a, b []byte) int {
+			return bytes.Compare(a[:8], b[:8])
+		})
+	}
+	ids := make([]string, len(keys))
+	for i, k := range keys {
+		ids[i] = queuedJobID(k)
+	}
+	return ids, err
+}
+
+// migrateQueue re-keys a queue written before queue keys carried a sequence number
+// Old keys were plain job IDs, they keep their order as sequence numbers are assigned in key order
+func migrateQueue(tx *bbolt.Tx) error {
+	queue := tx.Bucket([]byte(queueBucket))
+	var ids []string
+	if err := queue.ForEach(func(k, _ []byte) error {
+		ids = append(ids, string(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := queue.Delete([]byte(id)); err != nil {
+			return err
+		}
+		if _, err := queuePut(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueLayoutKey marks databases whose queue keys carry a priority
+// Stored in the stats bucket
+const queueLayoutKey = "queue_priority_keys"
+
+// migrateQueuePriorities re-keys a queue written before queue keys carried a priority
+// Old keys were a sequence number and the job ID, they keep their order among jobs of equal priority
+func migrateQueuePriorities(tx *bbolt.Tx) error {
+	queue := tx.Bucket([]byte(queueBucket))
+	var keys [][]byte
+	if err := queue.ForEach(func(k, _ []byte) error {
+		keys = append(keys, bytes.Clone(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	index := tx.Bucket([]byte(queueIndexBucket))
+	for _, k := range keys {
+		id := string(k[8:])
+		if err := queue.Delete(k); err != nil {
+			return err
+		}
+		if err := index.Delete([]byte(id)); err != nil {
+			return err
+		}
+		if _, err := queuePut(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
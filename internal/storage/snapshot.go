@@ -0,0 +1,69 @@
+// snapshot.go reads the running and queued state of the store at one point in time
+// Separate reads can catch an execution between the queue and running, counting it
+// twice or not at all, a snapshot reads everything in a single read transaction
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// Snapshot is the running and queued state of the store at one point in time
+type Snapshot struct {
+	Running       []*models.JobExecution   // Running or paused executions not in the queue
+	Queued        []string                 // Queued execution IDs in dequeue order
+	ExecutedCount int                      // Count of successfully executed jobs
+	StatusCounts  map[models.JobStatus]int // Number of stored executions per status
+}
+
+// Snapshot reads running executions, the queue, and the counters in one read transaction
+// An execution is reported as running or queued, never both
+func (b *BoltDB) Snapshot() (*Snapshot, error) {
+	snap := &Snapshot{StatusCounts: make(map[models.JobStatus]int)}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		if snap.Queued, err = queuedJobIDs(tx, b.opts.Discipline); err != nil {
+			return err
+		}
+
+		index := tx.Bucket([]byte(queueIndexBucket))
+		err = tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(k, v []byte) error {
+			status, err := storedStatus(v)
+			if err != nil {
+				return err
+			}
+			if status != models.JobStatusRunning && status != models.JobStatusPaused {
+				return nil
+			}
+			if index.Get(k) != nil {
+				return nil
+			}
+			var je models.JobExecution
+			if err := b.decodeExecution(v, &je); err != nil {
+				return err
+			}
+			mergeTaskStatuses(tx, &je)
+			snap.Running = append(snap.Running, &je)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if v := tx.Bucket([]byte(statsBucket)).Get([]byte(executedJobsCountKey)); v != nil {
+			snap.ExecutedCount = int(binary.BigEndian.Uint64(v))
+		}
+		return tx.Bucket([]byte(statusCountsBucket)).ForEach(func(k, v []byte) error {
+			if n := binary.BigEndian.Uint64(v); n > 0 {
+				snap.StatusCounts[models.JobStatus(k)] = int(n)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
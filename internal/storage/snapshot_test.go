@@ -0,0 +1,103 @@
+// snapshot_test.go tests reading running and queued state as one snapshot
+// Executions keep moving between the queue and running while snapshots are read,
+// and no snapshot may count an execution twice
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestSnapshotCountsOnce reads snapshots while executions are requeued and dequeued
+// Requeued executions are queued while still stored RUNNING, dequeued ones are
+// RUNNING again only after leaving the queue
+func TestSnapshotCountsOnce(t *testing.T) {
+	const executions, rounds = 8, 50
+	db := openTestBoltDB(t, Options{})
+	var ids []string
+	for i := 0; i < executions; i++ {
+		id := fmt.Sprintf("exec-%d", i)
+		storeExecution(t, db, &models.JobExecution{ID: id, Status: models.JobStatusRunning})
+		ids = append(ids, id)
+	}
+
+	// setStatus stores the execution with a new status
+	setStatus := func(id string, status models.JobStatus) error {
+		return db.UpdateJobExecution(&models.JobExecution{ID: id, Status: status})
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(stop)
+		for round := 0; round < rounds; round++ {
+			for _, id := range ids {
+				steps := []func() error{
+					func() error { return db.EnqueueJob(id) },
+					func() error { return setStatus(id, models.JobStatusQueued) },
+					func() error {
+						got, err := db.DequeueJob()
+						if err == nil && got != id {
+							err = fmt.Errorf("dequeued %s, want %s", got, id)
+						}
+						return err
+					},
+					func() error { return setStatus(id, models.JobStatusRunning) },
+				}
+				for _, step := range steps {
+					if err := step(); err != nil {
+						t.Errorf("move %s: %v", id, err)
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	snapshots := 0
+	for done := false; !done; snapshots++ {
+		select {
+		case <-stop:
+			done = true
+		default:
+		}
+		snap, err := db.Snapshot()
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+		seen := make(map[string]bool)
+		for _, je := range snap.Running {
+			seen[je.ID] = true
+		}
+		for _, id := range snap.Queued {
+			if seen[id] {
+				t.Fatalf("snapshot %d counts %s as running and queued", snapshots, id)
+			}
+			seen[id] = true
+		}
+		// Only the execution being moved can be missing, while it is off the queue
+		if len(seen) < executions-1 || len(seen) > executions {
+			t.Fatalf("snapshot %d holds %d executions, want %d or %d", snapshots, len(seen), executions-1, executions)
+		}
+	}
+	wg.Wait()
+
+	snap, err := db.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(snap.Queued) != 0 || len(snap.Running) != executions || snap.StatusCounts[models.JobStatusRunning] != executions {
+		t.Errorf("final snapshot = %d queued, %d running, counts %v, want all %d running",
+			len(snap.Queued), len(snap.Running), snap.StatusCounts, executions)
+	}
+	for _, je := range snap.Running {
+		if !slices.Contains(ids, je.ID) {
+			t.Errorf("snapshot holds unknown execution %s", je.ID)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// status_counts.go maintains the number of executions in each status
+// Counters are adjusted whenever an execution is stored with a new status
+// Lets dashboards read counts without scanning every execution
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// storedStatus returns the status of a stored execution record
+// Decodes only the status instead of the whole record
+func storedStatus(v []byte) (models.JobStatus, error) {
+	v, err := executionJSON(v)
+	if err != nil {
+		return "", err
+	}
+	var je struct {
+		Status models.JobStatus `json:"status"`
+	}
+	err = json.Unmarshal(v, &je)
+	return je.Status, err
+}
+
+// countStatusChange moves an execution from one status counter to another
+// An empty from counts a new execution, equal statuses change nothing
+// Must be called within a transaction
+func countStatusChange(tx *bbolt.Tx, from, to models.JobStatus) error {
+	if from == to {
+		return nil
+	}
+	bucket := tx.Bucket([]byte(statusCountsBucket))
+	if from != "" {
+		if err := addStatusCount(bucket, from, -1); err != nil {
+			return err
+		}
+	}
+	return addStatusCount(bucket, to, 1)
+}
+
+// addStatusCount adds delta to the counter of a status, never going below zero
+func addStatusCount(bucket *bbolt.Bucket, status models.JobStatus, delta int) error {
+	var count uint64
+	if existing := bucket.Get([]byte(status)); existing != nil {
+		count = binary.BigEndian.Uint64(existing)
+	}
+	if delta < 0 && count < uint64(-delta) {
+		count = 0
+	} else {
+		count = uint64(int64(count) + int64(delta))
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, count)
+	return bucket.Put([]byte(status), buf)
+}
+
+// backfillStatusCounts counts the executions stored before counters existed
+// Must be called within a transaction
+func backfillStatusCounts(tx *bbolt.Tx) error {
+	return tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(_, v []byte) error {
+		status, err := storedStatus(v)
+		if err != nil {
+			return err
+		}
+		return countStatusChange(tx, "", status)
+	})
+}
+
+// CountExecutionsByStatus returns the number of stored executions per status
+// Statuses without executions are left out
+func (b *BoltDB) CountExecutionsByStatus() (map[models.JobStatus]int, error) {
+	counts := make(map[models.JobStatus]int)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(statusCountsBucket)).ForEach(func(k, v []byte) error {
+			if n := binary.BigEndian.Uint64(v); n > 0 {
+				counts[models.JobStatus(k)] = int(n)
+			}
+			return nil
+		})
+	})
+	return counts, err
+}
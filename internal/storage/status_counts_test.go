@@ -0,0 +1,82 @@
+// status_counts_test.go tests the per-status execution counters
+// Executions are stored through their status transitions and the counters
+// must follow without scanning, including databases from before they existed
+package storage
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// wantStatusCounts fails the test unless the counters match want
+func wantStatusCounts(t *testing.T, db DB, step string, want map[models.JobStatus]int) {
+	t.Helper()
+	counts, err := db.CountExecutionsByStatus()
+	if err != nil {
+		t.Fatalf("%s: count executions: %v", step, err)
+	}
+	if !maps.Equal(counts, want) {
+		t.Errorf("%s: counts = %v, want %v", step, counts, want)
+	}
+}
+
+// TestCountExecutionsByStatus moves executions through their statuses
+func TestCountExecutionsByStatus(t *testing.T) {
+	db := openTestBoltDB(t, Options{})
+	wantStatusCounts(t, db, "empty", map[models.JobStatus]int{})
+
+	a := &models.JobExecution{ID: "a", DefinitionID: "report", Status: models.JobStatusQueued}
+	b := &models.JobExecution{ID: "b", DefinitionID: "report", Status: models.JobStatusQueued}
+	storeExecution(t, db, a)
+	storeExecution(t, db, b)
+	wantStatusCounts(t, db, "queued", map[models.JobStatus]int{models.JobStatusQueued: 2})
+
+	a.Status = models.JobStatusRunning
+	storeExecution(t, db, a)
+	// Storing an unchanged status again must not count it twice
+	storeExecution(t, db, a)
+	wantStatusCounts(t, db, "one running", map[models.JobStatus]int{models.JobStatusQueued: 1, models.JobStatusRunning: 1})
+
+	a.Status = models.JobStatusCompleted
+	b.Status = models.JobStatusRunning
+	storeExecution(t, db, a)
+	storeExecution(t, db, b)
+	b.Status = models.JobStatusFailed
+	if err := db.UpdateJobExecution(b); err != nil {
+		t.Fatalf("update execution: %v", err)
+	}
+	wantStatusCounts(t, db, "finished", map[models.JobStatus]int{models.JobStatusCompleted: 1, models.JobStatusFailed: 1})
+
+	// A retried execution leaves the finished counters again
+	b.Status = models.JobStatusQueued
+	storeExecution(t, db, b)
+	wantStatusCounts(t, db, "retried", map[models.JobStatus]int{models.JobStatusCompleted: 1, models.JobStatusQueued: 1})
+}
+
+// TestStatusCountsBackfill counts executions stored before the counters existed
+func TestStatusCountsBackfill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewBoltDB(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	for id, status := range map[string]models.JobStatus{"a": models.JobStatusQueued, "b": models.JobStatusCompleted, "c": models.JobStatusCompleted} {
+		storeExecution(t, db, &models.JobExecution{ID: id, DefinitionID: "report", Status: status})
+	}
+
+	// Drop the counters as a database from before they existed wouldn't have them
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte(statusCountsBucket))
+	})
+	if err != nil {
+		t.Fatalf("drop counters: %v", err)
+	}
+	db.Close()
+
+	db = openTestBoltDBAt(t, path)
+	wantStatusCounts(t, db, "reopened", map[models.JobStatus]int{models.JobStatusQueued: 1, models.JobStatusCompleted: 2})
+}
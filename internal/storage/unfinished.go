@@ -0,0 +1,82 @@
+// unfinished.go maintains an index of unfinished executions by definition
+// Executions are indexed while queued or running and dropped once they finish
+// Lets deleting a definition check for executions still needing it without a full scan
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"go.etcd.io/bbolt"
+)
+
+// ErrDefinitionInUse is returned by DeleteJobDefinition while unfinished executions run the definition
+var ErrDefinitionInUse = errors.New("job definition is in use")
+
+// unfinishedKey returns the index key of an execution of a definition
+// Keys of one definition share the prefix unfinishedPrefix returns
+func unfinishedKey(definitionID, executionID string) []byte {
+	return append(unfinishedPrefix(definitionID), executionID...)
+}
+
+// unfinishedPrefix returns the key prefix of a definition's unfinished executions
+func unfinishedPrefix(definitionID string) []byte {
+	return []byte(definitionID + "\x00")
+}
+
+// indexUnfinished adds an unfinished execution to the index or removes a finished one
+// Inline executions carry their own definition and are never indexed
+// Must be called within a read-write transaction
+func indexUnfinished(tx *bbolt.Tx, definitionID, executionID string, status models.JobStatus, inline bool) error {
+	bucket := tx.Bucket([]byte(unfinishedBucket))
+	key := unfinishedKey(definitionID, executionID)
+	if inline || status.Finished() {
+		return bucket.Delete(key)
+	}
+	return bucket.Put(key, []byte{})
+}
+
+// indexStoredUnfinished indexes a stored execution record
+// Decodes only the fields the index needs instead of the whole record
+func indexStoredUnfinished(tx *bbolt.Tx, executionID string, v []byte) error {
+	v, err := executionJSON(v)
+	if err != nil {
+		return err
+	}
+	var je struct {
+		DefinitionID string           `json:"definitionId"`
+		Status       models.JobStatus `json:"status"`
+		Definition   json.RawMessage  `json:"definition"`
+	}
+	if err := json.Unmarshal(v, &je); err != nil {
+		return err
+	}
+	inline := len(je.Definition) > 0 && !bytes.Equal(je.Definition, []byte("null"))
+	return indexUnfinished(tx, je.DefinitionID, executionID, je.Status, inline)
+}
+
+// backfillUnfinished indexes the unfinished executions stored before the index existed
+// Must be called within a read-write transaction
+func backfillUnfinished(tx *bbolt.Tx) error {
+	return tx.Bucket([]byte(jobExecutionsBucket)).ForEach(func(k, v []byte) error {
+		return indexStoredUnfinished(tx, string(k), v)
+	})
+}
+
+// checkUnused returns ErrDefinitionInUse if unfinished executions run a definition
+// Must be called within a transaction
+func checkUnused(tx *bbolt.Tx, definitionID string) error {
+	prefix := unfinishedPrefix(definitionID)
+	unfinished := 0
+	c := tx.Bucket([]byte(unfinishedBucket)).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		unfinished++
+	}
+	if unfinished > 0 {
+		return fmt.Errorf("%w: %d unfinished executions of %s", ErrDefinitionInUse, unfinished, definitionID)
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ package task_functions
 
 import (
 	"context"
-	"log"
+	"encoding/json"
 	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/taskctx"
 )
 
 // TaskFunctions interface defines all available task operations
@@ -18,21 +20,23 @@ type TaskFunctions interface {
 	Task3(ctx context.Context, data map[string]interface{}) error
 	// Add more task function signatures here as needed
 	// Example: ProcessData(ctx context.Context, data map[string]interface{}) error
+	// Tasks producing outputs for later tasks return them along with the error
+	// Example: Fetch(ctx context.Context, data map[string]interface{}) (map[string]interface{}, error)
 }
 
 // Task1 implements a sample task operation
 // Currently simulates work with a delay
 // Can be enhanced to perform actual business logic
 func Task1(ctx context.Context, data map[string]interface{}) error {
-	// Log task execution with input data
+	// Log task execution, input data only at debug level
 	// Useful for debugging and monitoring
-	log.Printf("Executing Task 1 with data %v", data)
+	logger := taskctx.Log(ctx)
+	logger.Infof("Executing Task 1")
+	logger.Debugf("Task 1 data: %v", data)
 
 	// Simulate work with a 10-second delay
 	// In real implementation, would contain actual business logic
-	time.Sleep(10 * time.Second)
-
-	return nil
+	return simulateWork(ctx, data, 10*time.Second)
 }
 
 // Task2 implements another sample task operation
@@ -41,13 +45,11 @@ func Task1(ctx context.Context, data map[string]interface{}) error {
 func Task2(ctx context.Context, data map[string]interface{}) error {
 	// Log the task execution
 	// Helps with execution tracking
-	log.Println("Executing Task 2")
+	taskctx.Log(ctx).Infof("Executing Task 2")
 
 	// Simulate work with an 8-second delay
 	// Would be replaced with real task logic
-	time.Sleep(8 * time.Second)
-
-	return nil
+	return simulateWork(ctx, data, 8*time.Second)
 }
 
 // Task3 implements a third sample task operation
@@ -56,13 +58,44 @@ func Task2(ctx context.Context, data map[string]interface{}) error {
 func Task3(ctx context.Context, data map[string]interface{}) error {
 	// Log task execution
 	// Part of execution audit trail
-	log.Println("Executing Task 3")
+	taskctx.Log(ctx).Infof("Executing Task 3")
 
 	// Simulate work with a 5-second delay
 	// Placeholder for actual implementation
-	time.Sleep(5 * time.Second)
+	return simulateWork(ctx, data, 5*time.Second)
+}
+
+// DelayKey is the job data key overriding the simulated work time of the sample tasks
+// Its value is in milliseconds, so tests can run whole pipelines with "delayMs": 0
+const DelayKey = "delayMs"
+
+// simulateWork stands in for the work of a sample task
+// Waits for the delay given under DelayKey, or def if the data has none
+// Returns early with the context's error if the task is cancelled
+func simulateWork(ctx context.Context, data map[string]interface{}, def time.Duration) error {
+	delay := def
+	switch v := data[DelayKey].(type) {
+	case float64:
+		delay = time.Duration(v * float64(time.Millisecond))
+	case int:
+		delay = time.Duration(v) * time.Millisecond
+	case json.Number:
+		if ms, err := v.Float64(); err == nil {
+			delay = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	if delay <= 0 {
+		return nil
+	}
 
-	return nil
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetTaskFunction returns the implementation for a given task name
@@ -91,12 +124,14 @@ func GetTaskFunction(name string) interface{} {
   - Must accept context.Context for cancellation
   - Must accept map[string]interface{} for flexible data
   - Must return error for status reporting
+  - May also return map[string]interface{} outputs before the error,
+    which are merged into the job data for the tasks that follow
 
 2. Implementation Requirements:
   - Should be idempotent when possible
   - Should respect context cancellation
   - Should handle input validation
-  - Should provide meaningful logs
+  - Should provide meaningful logs via taskctx.Log(ctx)
   - Should handle errors appropriately
 
 3. Adding New Tasks:
@@ -104,5 +139,7 @@ func GetTaskFunction(name string) interface{} {
   2. Implement the function with required signature
   3. Add mapping in GetTaskFunction switch statement
   4. Update job definitions to use new task
+  The server refuses to start if a method has a different signature or
+  GetTaskFunction returns nil or a function of another type for it
 
 */
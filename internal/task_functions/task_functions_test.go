@@ -0,0 +1,89 @@
+// task_functions_test.go tests the sample task functions
+// Runs the example job's pipeline end to end with the simulated delay switched off
+// and checks the default delay still honors cancellation
+package task_functions_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/internal/task_functions"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TestPipelineWithoutDelay runs the example job with "delayMs": 0
+// All three sample tasks must complete well within their default 23 seconds
+func TestPipelineWithoutDelay(t *testing.T) {
+	buf, err := os.ReadFile(filepath.Join("..", "..", "job_definitions", "example-job.json"))
+	if err != nil {
+		t.Fatalf("read example definition: %v", err)
+	}
+	var jd models.JobDefinition
+	if err := json.Unmarshal(buf, &jd); err != nil {
+		t.Fatalf("parse example definition: %v", err)
+	}
+
+	db, err := storage.NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	o, err := orchestrator.New(db, 1)
+	if err != nil {
+		t.Fatalf("new orchestrator: %v", err)
+	}
+	defer o.Close()
+	o.RegisterFunction("task1Function", task_functions.Task1)
+	o.RegisterFunction("task2Function", task_functions.Task2)
+	o.RegisterFunction("task3Function", task_functions.Task3)
+	if err := o.RegisterJobDefinition(&jd); err != nil {
+		t.Fatalf("register definition: %v", err)
+	}
+
+	id, err := o.EnqueueJob(jd.ID, map[string]interface{}{task_functions.DelayKey: 0})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := o.GetJobExecutionState(id)
+		if err != nil {
+			t.Fatalf("get state: %v", err)
+		}
+		if state.Status.Finished() {
+			if state.Status != models.JobStatusCompleted {
+				t.Fatalf("status = %s, want COMPLETED", state.Status)
+			}
+			for _, task := range state.Tasks {
+				if task.Status != models.TaskStatusCompleted {
+					t.Errorf("task %s = %s, want COMPLETED", task.ID, task.Status)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeline still %s after 5s", state.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestDefaultDelayCancelled checks a sample task with its default delay stops when cancelled
+func TestDefaultDelayCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := task_functions.Task1(ctx, map[string]interface{}{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled task took %s", elapsed)
+	}
+}
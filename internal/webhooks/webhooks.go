@@ -0,0 +1,144 @@
+// webhooks.go delivers orchestrator events to an HTTP endpoint
+// Subscribes to the event bus and POSTs each event as JSON
+// Allows external systems to react to events such as a drained queue
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// Default delivery limits, used for options left at zero
+const (
+	defaultWorkers   = 8   // Deliveries in flight at once
+	defaultQueueSize = 100 // Events waiting for a free worker
+)
+
+// OverflowPolicy selects what happens to events arriving while the delivery queue is full
+type OverflowPolicy string
+
+const (
+	OverflowDrop  OverflowPolicy = "drop"  // Discard the new event and log it
+	OverflowDefer OverflowPolicy = "defer" // Hold the event until the queue has room
+)
+
+// Options bounds how events are delivered
+// Zero values select the defaults
+type Options struct {
+	// Workers is the number of deliveries in flight at once
+	Workers int
+
+	// QueueSize is the number of events waiting for a free worker
+	QueueSize int
+
+	// Overflow selects whether events are dropped or deferred when the queue is full
+	// Deferred events back up into the event bus, which drops them once the
+	// subscription buffer is full as well, drop by default
+	Overflow OverflowPolicy
+}
+
+// Dispatcher posts events to a single webhook URL
+// Optionally restricted to a set of event types
+type Dispatcher struct {
+	url    string                    // Endpoint receiving the events
+	types  map[models.EventType]bool // Event types to deliver, empty for all
+	client *http.Client              // Client used for delivery
+	opts   Options                   // Delivery limits
+}
+
+// NewDispatcher creates a dispatcher delivering to url
+// When types are given, only events of those types are delivered
+func NewDispatcher(url string, types ...models.EventType) *Dispatcher {
+	return NewDispatcherWithOptions(url, Options{}, types...)
+}
+
+// NewDispatcherWithOptions creates a dispatcher with bounded delivery
+// Behaves like NewDispatcher otherwise
+func NewDispatcherWithOptions(url string, opts Options, types ...models.EventType) *Dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowDrop
+	}
+	d := &Dispatcher{
+		url:    url,
+		types:  make(map[models.EventType]bool),
+		client: &http.Client{Timeout: 10 * time.Second},
+		opts:   opts,
+	}
+	for _, t := range types {
+		d.types[t] = true
+	}
+	return d
+}
+
+// Run delivers events until the channel is closed
+// A fixed number of workers deliver queued events so a slow endpoint
+// neither stalls other deliveries nor piles up goroutines
+func (d *Dispatcher) Run(events <-chan models.Event) {
+	queue := make(chan models.Event, d.opts.QueueSize)
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range queue {
+				if err := d.deliver(e); err != nil {
+					log.Printf("Failed to deliver %s webhook: %v", e.Type, err)
+				}
+			}
+		}()
+	}
+
+	for e := range events {
+		if len(d.types) > 0 && !d.types[e.Type] {
+			continue
+		}
+		// State changes would flood endpoints that didn't ask for them
+		if len(d.types) == 0 && e.Type == models.EventExecutionStateChanged {
+			continue
+		}
+		if d.opts.Overflow == OverflowDefer {
+			queue <- e
+			continue
+		}
+		select {
+		case queue <- e:
+		default:
+			log.Printf("Dropped %s webhook, delivery queue is full", e.Type)
+		}
+	}
+
+	// Deliver what is still queued before returning
+	close(queue)
+	wg.Wait()
+}
+
+// deliver POSTs a single event to the webhook URL
+// Any non-2xx response is treated as a failed delivery
+func (d *Dispatcher) deliver(e models.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Post(d.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// webhooks_test.go tests bounded delivery of events to a webhook endpoint
+// A blocking endpoint holds deliveries in flight to check the worker bound
+// and what happens to events once the delivery queue is full
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// testTimeout bounds how long a test waits for deliveries
+const testTimeout = 10 * time.Second
+
+// settle is how long an endpoint is watched for deliveries that must not arrive
+const settle = 100 * time.Millisecond
+
+// endpoint is a webhook receiver holding every request until released
+type endpoint struct {
+	*httptest.Server
+	arrived chan struct{} // Receives a value per request reaching the endpoint
+	release chan struct{} // Closed to let held requests respond
+
+	mu          sync.Mutex
+	inFlight    int // Requests currently held
+	maxInFlight int // Most requests held at once
+	delivered   int // Requests answered
+}
+
+// newEndpoint starts an endpoint that is closed when the test ends
+func newEndpoint(t *testing.T) *endpoint {
+	t.Helper()
+	ep := &endpoint{arrived: make(chan struct{}, 100), release: make(chan struct{})}
+	ep.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ep.mu.Lock()
+		ep.inFlight++
+		ep.maxInFlight = max(ep.maxInFlight, ep.inFlight)
+		ep.mu.Unlock()
+		ep.arrived <- struct{}{}
+
+		<-ep.release
+		ep.mu.Lock()
+		ep.inFlight--
+		ep.delivered++
+		ep.mu.Unlock()
+	}))
+	t.Cleanup(ep.Close)
+	return ep
+}
+
+// awaitArrivals waits until n more requests reached the endpoint
+func (ep *endpoint) awaitArrivals(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ep.arrived:
+		case <-time.After(testTimeout):
+			t.Fatalf("timed out waiting for delivery %d of %d", i+1, n)
+		}
+	}
+}
+
+// expectNoArrival fails if another request reaches the endpoint soon
+func (ep *endpoint) expectNoArrival(t *testing.T) {
+	t.Helper()
+	select {
+	case <-ep.arrived:
+		t.Fatal("delivery arrived beyond the bound")
+	case <-time.After(settle):
+	}
+}
+
+// counts returns the most requests held at once and the requests answered
+func (ep *endpoint) counts() (maxInFlight, delivered int) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.maxInFlight, ep.delivered
+}
+
+// startDispatcher runs a dispatcher on an unbuffered channel of events
+// The returned channel is closed once Run returned
+func startDispatcher(d *Dispatcher) (chan<- models.Event, <-chan struct{}) {
+	events, done := make(chan models.Event), make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(events)
+	}()
+	return events, done
+}
+
+// stopDispatcher closes the events and waits for queued deliveries to finish
+func stopDispatcher(t *testing.T, events chan<- models.Event, done <-chan struct{}) {
+	t.Helper()
+	close(events)
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the dispatcher to stop")
+	}
+}
+
+// TestDeliveriesBoundedByWorkers sends more events than workers to a slow endpoint
+// Only as many deliveries as workers are in flight, the rest wait their turn
+func TestDeliveriesBoundedByWorkers(t *testing.T) {
+	ep := newEndpoint(t)
+	d := NewDispatcherWithOptions(ep.URL, Options{Workers: 2, QueueSize: 10})
+	events, done := startDispatcher(d)
+
+	for i := 0; i < 6; i++ {
+		events <- models.Event{Type: models.EventQueueDrained}
+	}
+	ep.awaitArrivals(t, 2)
+	ep.expectNoArrival(t)
+
+	close(ep.release)
+	ep.awaitArrivals(t, 4)
+	stopDispatcher(t, events, done)
+	if maxInFlight, delivered := ep.counts(); maxInFlight != 2 || delivered != 6 {
+		t.Errorf("max in flight = %d, delivered = %d, want 2 and 6", maxInFlight, delivered)
+	}
+}
+
+// TestOverflowDropsEvents fills the queue behind a busy worker
+// Events arriving while the queue is full are dropped
+func TestOverflowDropsEvents(t *testing.T) {
+	ep := newEndpoint(t)
+	d := NewDispatcherWithOptions(ep.URL, Options{Workers: 1, QueueSize: 1})
+	events, done := startDispatcher(d)
+
+	events <- models.Event{Type: models.EventQueueDrained}
+	ep.awaitArrivals(t, 1)
+	events <- models.Event{Type: models.EventQueueDrained} // Waits in the queue
+	events <- models.Event{Type: models.EventQueueDrained} // Dropped
+
+	close(ep.release)
+	stopDispatcher(t, events, done)
+	if _, delivered := ep.counts(); delivered != 2 {
+		t.Errorf("delivered = %d, want 2 with the third event dropped", delivered)
+	}
+}
+
+// TestOverflowDefersEvents fills the queue behind a busy worker
+// Events arriving while the queue is full wait for room instead of being dropped
+func TestOverflowDefersEvents(t *testing.T) {
+	ep := newEndpoint(t)
+	d := NewDispatcherWithOptions(ep.URL, Options{Workers: 1, QueueSize: 1, Overflow: OverflowDefer})
+	events, done := startDispatcher(d)
+
+	events <- models.Event{Type: models.EventQueueDrained}
+	ep.awaitArrivals(t, 1)
+	events <- models.Event{Type: models.EventQueueDrained} // Waits in the queue
+	deferred := make(chan struct{})
+	go func() {
+		defer close(deferred)
+		events <- models.Event{Type: models.EventQueueDrained} // Waits for room in the queue
+		events <- models.Event{Type: models.EventQueueDrained}
+	}()
+	select {
+	case <-deferred:
+		t.Fatal("events accepted while the queue was full")
+	case <-time.After(settle):
+	}
+
+	close(ep.release)
+	<-deferred
+	stopDispatcher(t, events, done)
+	if maxInFlight, delivered := ep.counts(); maxInFlight != 1 || delivered != 4 {
+		t.Errorf("max in flight = %d, delivered = %d, want 1 and 4", maxInFlight, delivered)
+	}
+}
@@ -0,0 +1,19 @@
+// audit.go defines entries of the administrative audit log
+// Records who performed which action on what and how it ended
+// Stored by the orchestrator and listed through the admin API
+package models
+
+import (
+	"time"
+)
+
+// AuditEntry records a single administrative action
+// Entries are append-only and ordered by their sequence ID
+type AuditEntry struct {
+	ID     uint64    `json:"id"`     // Sequence number, increasing with each entry
+	Time   time.Time `json:"time"`   // When the action was performed
+	Action string    `json:"action"` // Kind of action, e.g. "cancel-operation"
+	Target string    `json:"target"` // ID of the affected execution, definition, or operation
+	Actor  string    `json:"actor"`  // Who performed the action
+	Result string    `json:"result"` // "ok" or the error the action failed with
+}
@@ -0,0 +1,22 @@
+// cancel.go defines the results of cancelling executions in a batch
+// Each requested execution gets its own result so callers can confirm what happened
+// Used by the batch cancellation endpoint
+package models
+
+// CancelOutcome describes what cancelling one execution of a batch did
+type CancelOutcome string
+
+const (
+	CancelOutcomeCancelled       CancelOutcome = "cancelled"        // The execution was queued or running and is cancelled
+	CancelOutcomeAlreadyTerminal CancelOutcome = "already-terminal" // The execution had finished already
+	CancelOutcomeNotFound        CancelOutcome = "not-found"        // No execution has the ID
+	CancelOutcomeFailed          CancelOutcome = "failed"           // The execution couldn't be cancelled, see Error
+)
+
+// CancelResult is the result of cancelling one execution of a batch
+type CancelResult struct {
+	ExecutionID string        `json:"executionId"`      // Execution the result is for
+	Outcome     CancelOutcome `json:"outcome"`          // What cancelling did
+	Status      JobStatus     `json:"status,omitempty"` // Status of the execution, unless not found
+	Error       string        `json:"error,omitempty"`  // Reason the execution wasn't cancelled
+}
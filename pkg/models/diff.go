@@ -0,0 +1,38 @@
+// diff.go defines structures for comparing two job executions
+// Used to explain why similar runs produced different outcomes
+// Only differing values are included in a diff
+package models
+
+// ExecutionDiff describes the differences between two job executions
+// Returned by the execution comparison endpoint
+// Fields suffixed with A or B refer to the respective execution
+type ExecutionDiff struct {
+	A              string      `json:"a"`                // First execution identifier
+	B              string      `json:"b"`                // Second execution identifier
+	SameDefinition bool        `json:"sameDefinition"`   // Whether both executions share a definition
+	StatusA        JobStatus   `json:"statusA"`          // Status of the first execution
+	StatusB        JobStatus   `json:"statusB"`          // Status of the second execution
+	DurationMsA    int64       `json:"durationMsA"`      // Run time of the first execution, 0 if unfinished
+	DurationMsB    int64       `json:"durationMsB"`      // Run time of the second execution, 0 if unfinished
+	ErrorA         string      `json:"errorA,omitempty"` // Failure reason of the first execution
+	ErrorB         string      `json:"errorB,omitempty"` // Failure reason of the second execution
+	Data           []ValueDiff `json:"data"`             // Input data keys whose values differ
+	Tasks          []TaskDiff  `json:"tasks"`            // Tasks whose status or error differ
+}
+
+// ValueDiff represents a single data key with differing values
+// A missing key is reported as a null value
+type ValueDiff struct {
+	Key string      `json:"key"` // Data key
+	A   interface{} `json:"a"`   // Value in the first execution
+	B   interface{} `json:"b"`   // Value in the second execution
+}
+
+// TaskDiff represents a task whose outcome differs between executions
+type TaskDiff struct {
+	ID      string     `json:"id"`               // Task identifier
+	StatusA TaskStatus `json:"statusA"`          // Status in the first execution
+	StatusB TaskStatus `json:"statusB"`          // Status in the second execution
+	ErrorA  string     `json:"errorA,omitempty"` // Error in the first execution
+	ErrorB  string     `json:"errorB,omitempty"` // Error in the second execution
+}
@@ -0,0 +1,34 @@
+// event.go defines events published by the orchestrator
+// Events describe notable changes in job processing
+// Delivered to subscribers through the orchestrator's event bus
+package models
+
+import (
+	"time"
+)
+
+// EventType identifies the kind of an event
+// Subscribers use it to filter the events they care about
+type EventType string
+
+const (
+	EventAlertTriggered EventType = "ALERT_TRIGGERED" // A definition crossed its alert threshold
+	EventQueueDrained   EventType = "QUEUE_DRAINED"   // The queue is empty and all jobs finished
+	EventTaskSlow       EventType = "TASK_SLOW"       // A task runs longer than its warning threshold
+	EventJobDeferred    EventType = "JOB_DEFERRED"    // A job was held back by an unhealthy dependency
+
+	// EventExecutionStateChanged is published whenever an execution or one of its tasks
+	// changes status, it is frequent so webhooks only receive it when listed explicitly
+	EventExecutionStateChanged EventType = "EXECUTION_STATE_CHANGED"
+)
+
+// Event represents a single occurrence published on the event bus
+// Carries enough context for subscribers to act without extra lookups
+type Event struct {
+	Type         EventType              `json:"type"`                   // Kind of event
+	Time         time.Time              `json:"time"`                   // When the event occurred
+	DefinitionID string                 `json:"definitionId,omitempty"` // Related job definition
+	ExecutionID  string                 `json:"executionId,omitempty"`  // Related job execution
+	Message      string                 `json:"message,omitempty"`      // Human-readable description
+	Details      map[string]interface{} `json:"details,omitempty"`      // Event specific attributes
+}
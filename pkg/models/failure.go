@@ -0,0 +1,15 @@
+// failure.go defines structures for aggregated task failure reasons
+// Groups identical failures of a definition's executions
+// Used to spot systemic issues across many runs
+package models
+
+import "time"
+
+// FailureReason is one distinct failure message and how often it occurred
+// TaskID is empty for failures of the job itself rather than of a task
+type FailureReason struct {
+	TaskID   string    `json:"taskId,omitempty"` // Task that failed
+	Error    string    `json:"error"`            // Failure message
+	Count    int       `json:"count"`            // Number of executions failing this way
+	LastSeen time.Time `json:"lastSeen"`         // End time of the latest such failure
+}
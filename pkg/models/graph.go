@@ -0,0 +1,24 @@
+// graph.go defines the task dependency graph of a job definition
+// Lets UIs render which tasks wait for which
+// Edges point from a task to a task that waits for it
+package models
+
+// TaskGraph describes the tasks of a definition and the order they run in
+type TaskGraph struct {
+	Nodes []GraphNode `json:"nodes"` // Tasks in definition order
+	Edges []GraphEdge `json:"edges"` // Dependencies between tasks
+	Order []string    `json:"order"` // Task IDs in the order they run
+}
+
+// GraphNode is one task of a graph
+type GraphNode struct {
+	ID           string `json:"id"`           // Task identifier
+	Name         string `json:"name"`         // Task name
+	FunctionName string `json:"functionName"` // Function the task runs
+}
+
+// GraphEdge is a dependency between two tasks
+type GraphEdge struct {
+	From string `json:"from"` // Task that must finish first
+	To   string `json:"to"`   // Task waiting for it
+}
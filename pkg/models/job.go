@@ -16,28 +16,171 @@ const (
 	JobStatusRunning   JobStatus = "RUNNING"   // Job is currently executing
 	JobStatusCompleted JobStatus = "COMPLETED" // Job finished successfully
 	JobStatusFailed    JobStatus = "FAILED"    // Job encountered an error
+	JobStatusCancelled JobStatus = "CANCELLED" // Job was cancelled before finishing
+	JobStatusPaused    JobStatus = "PAUSED"    // Job is held between tasks while processing is paused
 )
 
+// Finished reports whether the status is terminal
+// Finished executions are never run again unless retried or replayed
+func (s JobStatus) Finished() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed || s == JobStatusCancelled
+}
+
+// Valid reports whether the status is one of the known job statuses
+func (s JobStatus) Valid() bool {
+	switch s {
+	case JobStatusQueued, JobStatusRunning, JobStatusCompleted, JobStatusFailed, JobStatusCancelled, JobStatusPaused:
+		return true
+	}
+	return false
+}
+
+// Strategy selects the order in which a job's tasks run
+type Strategy string
+
+const (
+	StrategySequential  Strategy = "sequential"   // Tasks run one after another in definition order
+	StrategyParallelAll Strategy = "parallel-all" // All tasks run at the same time
+	StrategyDAG         Strategy = "dag"          // Tasks run after the tasks they depend on
+)
+
+// FailureMode selects how a failing task affects the rest of a job
+// The job fails in every mode, they differ in which tasks still run
+type FailureMode string
+
+const (
+	FailureModeFailFast     FailureMode = "fail-fast"            // Stop at the first failing task
+	FailureModeCollect      FailureMode = "collect-and-continue" // Run all tasks, report every failure
+	FailureModeFirstFailure FailureMode = "first-failure-only"   // Run all tasks, report the first failure
+)
+
+// Continues reports whether tasks keep running after one failed
+func (m FailureMode) Continues() bool {
+	return m == FailureModeCollect || m == FailureModeFirstFailure
+}
+
 // JobDefinition represents the template for a job
 // Defines the sequence of tasks to be executed
 // Used to create job executions
 type JobDefinition struct {
-	ID    string  `json:"id"`    // Unique identifier for the job definition
-	Name  string  `json:"name"`  // Human-readable name
-	Tasks []*Task `json:"tasks"` // Ordered list of tasks to execute
+	ID       string          `json:"id"`                 // Unique identifier for the job definition
+	Name     string          `json:"name"`               // Human-readable name
+	Tasks    []*Task         `json:"tasks"`              // Ordered list of tasks to execute
+	Alert    *AlertThreshold `json:"alert,omitempty"`    // Optional failure rate alerting
+	LogLevel string          `json:"logLevel,omitempty"` // Default log verbosity of the tasks
+	Tags     []string        `json:"tags,omitempty"`     // Labels used to group and discover definitions
+	Strategy Strategy        `json:"strategy,omitempty"` // Task execution order, sequential by default
+
+	// OutputNamespace stores each task's outputs under its task ID
+	// instead of merging them into the top level of the job data
+	// A pointer so a definition can turn off the setting of its base
+	OutputNamespace *bool `json:"outputNamespace,omitempty"`
+
+	// MaxAgeSeconds cancels executions older than this, measured from when
+	// they were queued, whether still waiting or running, zero for no limit
+	MaxAgeSeconds int `json:"maxAgeSeconds,omitempty"`
+
+	// RetryBudget caps the retries of all tasks of an execution combined
+	// Applies on top of each task's maxRetry, zero for no job-wide limit
+	RetryBudget int `json:"retryBudget,omitempty"`
+
+	// StartJitterSeconds delays each execution's start by a random amount
+	// up to this many seconds, spreading out jobs enqueued at the same time
+	StartJitterSeconds int `json:"startJitterSeconds,omitempty"`
+
+	// TimeoutSeconds limits how long each run of an execution may take, zero for no limit
+	// The running task fails with a timeout error once it is exceeded
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// TaskTimeoutPercent limits each attempt of a task without its own timeout to this
+	// percentage of the time the execution has left, zero to only enforce the job's limit
+	// Takes effect when the execution has a timeout or deadline
+	TaskTimeoutPercent int `json:"taskTimeoutPercent,omitempty"`
+
+	// MaxQueueTimeSeconds rejects new executions expected to wait longer than
+	// this in the queue, based on the current queue depth and recent throughput
+	MaxQueueTimeSeconds int `json:"maxQueueTimeSeconds,omitempty"`
+
+	// MaxConcurrency limits how many executions of the definition run at once
+	// Further executions wait without holding up other definitions, 0 for no limit
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+
+	// PoolLabel pins executions to the worker pool configured under this label
+	// Empty to run on the default pool
+	PoolLabel string `json:"poolLabel,omitempty"`
+
+	// BaseDefinition names a registered definition this one extends
+	// Its tasks come first and its settings apply unless set here, resolved once at registration
+	BaseDefinition string `json:"baseDefinition,omitempty"`
+
+	// FailureMode selects whether the remaining tasks run after one fails
+	// Defaults to fail-fast
+	FailureMode FailureMode `json:"failureMode,omitempty"`
+
+	// Dependencies names external systems that must be healthy for executions to start
+	// Each is checked by the health check registered under its name before the job runs
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// Environments restricts loading the definition at startup to these environments
+	// Matched against ORCHESTRATOR_ENV, empty to load it in every environment
+	Environments []string `json:"environments,omitempty"`
+
+	// Chain lists definitions enqueued once an execution completes successfully
+	// Chained executions start from its final data and inherit its priority
+	Chain []*ChainedJob `json:"chain,omitempty"`
+}
+
+// NamespacesOutputs reports whether task outputs are stored under their task IDs
+func (jd *JobDefinition) NamespacesOutputs() bool {
+	return jd.OutputNamespace != nil && *jd.OutputNamespace
+}
+
+// ChainedJob names a definition enqueued when an execution of another completes
+type ChainedJob struct {
+	DefinitionID string `json:"definitionId"`       // Definition to enqueue
+	Priority     *int   `json:"priority,omitempty"` // Overrides the inherited priority, nil to inherit it
+}
+
+// AlertThreshold configures failure rate alerting for a job definition
+// The failure rate is computed over the most recent finished executions
+// An alert event is emitted when the rate rises above the threshold
+type AlertThreshold struct {
+	WindowSize         int     `json:"windowSize"`         // Number of recent executions considered
+	FailureRatePercent float64 `json:"failureRatePercent"` // Failure rate that triggers an alert
 }
 
 // JobExecution represents a single run of a job
 // Tracks the state and progress of job execution
 // Maintains task status and execution metadata
 type JobExecution struct {
-	ID           string                 `json:"id"`                // Unique execution identifier
-	DefinitionID string                 `json:"definitionId"`      // Reference to job definition
-	Status       JobStatus              `json:"status"`            // Current execution status
-	StartTime    time.Time              `json:"startTime"`         // When execution began
-	EndTime      time.Time              `json:"endTime,omitempty"` // When execution finished
-	Data         map[string]interface{} `json:"data"`              // Input data for tasks
-	TaskStatuses map[string]TaskStatus  `json:"taskStatuses"`      // Status of each task
+	ID                string                 `json:"id"`                          // Unique execution identifier
+	DefinitionID      string                 `json:"definitionId"`                // Reference to job definition
+	Status            JobStatus              `json:"status"`                      // Current execution status
+	QueuedAt          time.Time              `json:"queuedAt,omitempty"`          // When execution was last queued
+	ScheduledAt       time.Time              `json:"scheduledAt,omitempty"`       // Earliest time the execution may start
+	StartTime         time.Time              `json:"startTime"`                   // When execution began
+	EndTime           time.Time              `json:"endTime,omitempty"`           // When execution finished
+	Deadline          time.Time              `json:"deadline,omitempty"`          // Time by which execution must finish
+	Data              map[string]interface{} `json:"data"`                        // Job data, including the outputs of finished tasks
+	Input             map[string]interface{} `json:"input"`                       // Data the execution was enqueued with
+	TaskStatuses      map[string]TaskStatus  `json:"taskStatuses"`                // Status of each task
+	TaskAttempts      map[string][]Attempt   `json:"taskAttempts,omitempty"`      // Attempts of each task by task ID
+	TaskTimes         map[string]TaskTiming  `json:"taskTimes,omitempty"`         // Start and end of each task by task ID
+	TaskErrors        map[string]string      `json:"taskErrors,omitempty"`        // Error message of each failed task
+	Error             string                 `json:"error,omitempty"`             // Reason the execution failed
+	Tags              []string               `json:"tags,omitempty"`              // Labels to find the execution by
+	Logs              string                 `json:"logs,omitempty"`              // Captured task log output
+	RetriesUsed       int                    `json:"retriesUsed,omitempty"`       // Task retries consumed so far
+	ParentExecutionID string                 `json:"parentExecutionId,omitempty"` // Execution this one replays
+	Annotations       map[string]string      `json:"annotations,omitempty"`       // Notes set by tasks while running
+	AffinityKey       string                 `json:"affinityKey,omitempty"`       // Executions with the same key prefer the same worker
+	Worker            string                 `json:"worker,omitempty"`            // Worker that last ran the execution
+	Priority          int                    `json:"priority,omitempty"`          // Higher priorities are dequeued first
+	TriggeredBy       string                 `json:"triggeredBy,omitempty"`       // Execution whose completion chained this one
+
+	// Definition is the definition an inline job was submitted with
+	// Run instead of a registered definition, nil for registered definitions
+	Definition *JobDefinition `json:"definition,omitempty"`
 }
 
 // JobExecutionState provides a snapshot of job execution
@@ -49,4 +192,21 @@ type JobExecutionState struct {
 	Status       JobStatus   `json:"status"`       // Current status
 	StartTime    time.Time   `json:"startTime"`    // Execution start time
 	Tasks        []TaskState `json:"tasks"`        // State of all tasks
+
+	// Annotations are notes the tasks attached, e.g. progress of a long task
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TaskCounts summarizes tasks by status when the task list is left out
+	TaskCounts map[TaskStatus]int `json:"taskCounts,omitempty"`
+
+	// Timing splits the time spent on tasks into running and waiting to retry
+	Timing *ExecutionTiming `json:"timing,omitempty"`
+}
+
+// ExecutionTiming sums the attempts of all tasks of an execution
+// Helps tuning retries by showing how much time backoff costs
+type ExecutionTiming struct {
+	TaskMs    int64 `json:"taskMs"`    // Time task functions ran, over all attempts
+	BackoffMs int64 `json:"backoffMs"` // Time waited between attempts
+	Retries   int   `json:"retries"`   // Attempts after the first of each task
 }
@@ -0,0 +1,33 @@
+// operation.go defines long-running bulk operations
+// Operations run asynchronously and report their progress
+// Used for bulk enqueues and backfills of many jobs
+package models
+
+import (
+	"time"
+)
+
+// OperationStatus represents the state of a bulk operation
+type OperationStatus string
+
+const (
+	OperationStatusRunning   OperationStatus = "RUNNING"   // Operation is still processing items
+	OperationStatusCompleted OperationStatus = "COMPLETED" // All items were processed
+	OperationStatusCancelled OperationStatus = "CANCELLED" // Operation was cancelled partway
+)
+
+// Operation represents the progress of a bulk operation
+// Executions enqueued before a cancellation are kept and keep running
+type Operation struct {
+	ID           string          `json:"id"`                // Unique operation identifier
+	Type         string          `json:"type"`              // Kind of operation, e.g. "bulk-enqueue"
+	DefinitionID string          `json:"definitionId"`      // Definition the jobs are enqueued for
+	Status       OperationStatus `json:"status"`            // Current status
+	Total        int             `json:"total"`             // Number of items to process
+	Completed    int             `json:"completed"`         // Items processed successfully
+	Failed       int             `json:"failed"`            // Items that could not be processed
+	ExecutionIDs []string        `json:"executionIds"`      // Executions created so far
+	Errors       []string        `json:"errors,omitempty"`  // Errors of the failed items
+	StartTime    time.Time       `json:"startTime"`         // When the operation started
+	EndTime      time.Time       `json:"endTime,omitempty"` // When the operation finished
+}
@@ -0,0 +1,19 @@
+// outcome.go defines the terminal outcome of a job execution
+// Published to external systems once an execution finishes
+// Carries the execution's final status and timing
+package models
+
+import (
+	"time"
+)
+
+// ExecutionOutcome describes how a job execution ended
+// Consumers may receive the same outcome more than once
+type ExecutionOutcome struct {
+	ExecutionID  string    `json:"executionId"`     // Finished execution
+	DefinitionID string    `json:"definitionId"`    // Definition the execution ran
+	Status       JobStatus `json:"status"`          // COMPLETED, FAILED, or CANCELLED
+	Error        string    `json:"error,omitempty"` // Reason the execution didn't complete
+	StartTime    time.Time `json:"startTime"`       // When the execution began
+	EndTime      time.Time `json:"endTime"`         // When the execution finished
+}
@@ -0,0 +1,17 @@
+// queue.go defines structures for inspecting the job queue
+// Joins queue entries with their execution and definition details
+// Lets clients render the queue without looking up every entry
+package models
+
+import "time"
+
+// QueuedExecution is a queue entry enriched with execution details
+// Fields other than ExecutionID are empty if the execution is missing
+type QueuedExecution struct {
+	ExecutionID    string    `json:"executionId"`              // Queued execution identifier
+	DefinitionID   string    `json:"definitionId,omitempty"`   // Definition the execution runs
+	DefinitionName string    `json:"definitionName,omitempty"` // Human readable definition name
+	QueuedAt       time.Time `json:"queuedAt,omitempty"`       // When the execution was queued
+	Tags           []string  `json:"tags,omitempty"`           // Labels of the execution
+	Priority       int       `json:"priority,omitempty"`       // Higher priorities are dequeued first
+}
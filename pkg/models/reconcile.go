@@ -0,0 +1,29 @@
+// reconcile.go defines the report of a state reconciliation
+// Compares the orchestrator's in-memory state with persisted state
+// Used to verify a warm standby after recovery
+package models
+
+// DiscrepancyKind identifies a kind of mismatch between memory and storage
+type DiscrepancyKind string
+
+const (
+	DiscrepancyRunningNotTracked DiscrepancyKind = "RUNNING_NOT_TRACKED" // RUNNING in storage but not executing
+	DiscrepancyTrackedNotRunning DiscrepancyKind = "TRACKED_NOT_RUNNING" // Executing in memory but not RUNNING in storage
+	DiscrepancyQueuedFinished    DiscrepancyKind = "QUEUED_FINISHED"     // In the queue but finished or missing
+	DiscrepancyQueuedNotInQueue  DiscrepancyKind = "QUEUED_NOT_IN_QUEUE" // QUEUED in storage but not in the queue
+)
+
+// Discrepancy describes one mismatch found by a reconciliation
+type Discrepancy struct {
+	Kind        DiscrepancyKind `json:"kind"`               // Kind of mismatch
+	ExecutionID string          `json:"executionId"`        // Affected execution
+	Detail      string          `json:"detail"`             // Human-readable description
+	Fixed       bool            `json:"fixed"`              // Whether the mismatch was repaired
+	FixError    string          `json:"fixError,omitempty"` // Why repairing failed
+}
+
+// ReconcileReport lists the discrepancies found by a reconciliation
+type ReconcileReport struct {
+	Discrepancies []Discrepancy `json:"discrepancies"` // Mismatches found, empty when consistent
+	Fix           bool          `json:"fix"`           // Whether repairs were attempted
+}
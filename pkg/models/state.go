@@ -3,12 +3,33 @@
 // Provides overview of all jobs and queue state
 package models
 
+import "time"
+
 // SystemState represents the current state of the entire system
 // Used for system monitoring and status reporting
 // Provides overview of active and queued jobs
 type SystemState struct {
 	ActiveJobs   []JobExecutionState `json:"activeJobs"`   // Currently executing jobs
-	QueuedJobs   []string            `json:"queuedJobs"`   // Jobs waiting in queue
+	QueuedJobs   []string            `json:"queuedJobs"`   // Jobs waiting in queue, in dequeue order
 	QueuedCount  int                 `json:"queuedCount"`  // Total queue size
 	ExecutedJobs int                 `json:"executedJobs"` // Count of successfully executed jobs
+
+	// StatusCounts is the number of stored executions in each status
+	StatusCounts map[JobStatus]int `json:"statusCounts"`
+
+	// Paused is set while no jobs are taken off the queue
+	Paused bool `json:"paused"`
+}
+
+// ThroughputStats reports how many executions the orchestrator finishes
+// Used for capacity planning
+type ThroughputStats struct {
+	RatePerMinute float64       `json:"ratePerMinute"` // Average over the last complete minutes
+	History       []MinuteCount `json:"history"`       // Per-minute counts, oldest first, the last one still in progress
+}
+
+// MinuteCount is the number of executions finished within one minute
+type MinuteCount struct {
+	Minute   time.Time `json:"minute"`   // Start of the minute
+	Finished int       `json:"finished"` // Executions that stopped running with any outcome
 }
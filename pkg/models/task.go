@@ -3,6 +3,8 @@
 // Used for managing individual units of work within jobs
 package models
 
+import "time"
+
 // TaskStatus represents the possible states of a task
 // Used to track progress of individual tasks
 type TaskStatus string
@@ -12,23 +14,89 @@ const (
 	TaskStatusRunning   TaskStatus = "RUNNING"   // Task is executing
 	TaskStatusCompleted TaskStatus = "COMPLETED" // Task finished successfully
 	TaskStatusFailed    TaskStatus = "FAILED"    // Task encountered an error
+	TaskStatusSkipped   TaskStatus = "SKIPPED"   // Task was disabled by a feature flag
+	TaskStatusCancelled TaskStatus = "CANCELLED" // Task was stopped because its job was cancelled
+)
+
+// Backoff selects how the wait between retries of a task grows
+type Backoff string
+
+const (
+	BackoffExponential Backoff = "exponential" // Wait doubles after each failure
+	BackoffLinear      Backoff = "linear"      // Wait grows by the base delay after each failure
+	BackoffFixed       Backoff = "fixed"       // Wait the base delay every time
 )
 
 // Task defines a single unit of work
 // Represents one step in a job
 // Contains configuration for execution and retries
 type Task struct {
-	ID           string `json:"id"`           // Unique task identifier
-	Name         string `json:"name"`         // Human-readable name
-	MaxRetry     int    `json:"maxRetry"`     // Maximum retry attempts
-	FunctionName string `json:"functionName"` // Name of function to execute
+	ID           string `json:"id"`                 // Unique task identifier
+	Name         string `json:"name"`               // Human-readable name
+	MaxRetry     int    `json:"maxRetry"`           // Maximum retry attempts
+	FunctionName string `json:"functionName"`       // Name of function to execute
+	LogLevel     string `json:"logLevel,omitempty"` // Log verbosity, overrides the definition level
+
+	// InputMapping passes values from the job data under new keys
+	// Maps an input key to a dot separated path, e.g. "taskA.result"
+	InputMapping map[string]string `json:"inputMapping,omitempty"`
+
+	// RequiredOutputs lists output keys the task must produce
+	// A run missing any of them is treated as a failed attempt
+	RequiredOutputs []string `json:"requiredOutputs,omitempty"`
+
+	// SuccessWhen maps output keys to the values they must have
+	// An attempt returning other values fails and is retried even without an error
+	SuccessWhen map[string]interface{} `json:"successWhen,omitempty"`
+
+	// DependsOn lists the IDs of tasks that must complete before this one
+	// Only used by definitions with the dag strategy
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// TimeoutSeconds limits how long a single attempt may run, zero for no limit
+	// MaxConsecutiveTimeouts stops retrying after that many timeouts in a row
+	TimeoutSeconds         int `json:"timeoutSeconds,omitempty"`
+	MaxConsecutiveTimeouts int `json:"maxConsecutiveTimeouts,omitempty"`
+
+	// RetryBackoff selects how the wait between retries grows, exponential by default
+	// RetryBaseDelayMs is the first wait, 1000 by default, and RetryMaxDelayMs
+	// caps every wait, zero for no cap
+	RetryBackoff     Backoff `json:"retryBackoff,omitempty"`
+	RetryBaseDelayMs int     `json:"retryBaseDelayMs,omitempty"`
+	RetryMaxDelayMs  int     `json:"retryMaxDelayMs,omitempty"`
+
+	// WarnAfterSeconds publishes a TASK_SLOW event once the task runs longer than this
+	// The task keeps running, zero for no warning
+	WarnAfterSeconds int `json:"warnAfterSeconds,omitempty"`
 }
 
 // TaskState represents the current state of a task
 // Used for status reporting and monitoring
 // Combined with other tasks to show job progress
 type TaskState struct {
-	ID     string     `json:"id"`     // Task identifier
-	Name   string     `json:"name"`   // Task name
-	Status TaskStatus `json:"status"` // Current status
+	ID       string     `json:"id"`                 // Task identifier
+	Name     string     `json:"name"`               // Task name
+	Status   TaskStatus `json:"status"`             // Current status
+	Attempts []Attempt  `json:"attempts,omitempty"` // Every run of the task, oldest first
+	Error    string     `json:"error,omitempty"`    // Why the task failed, empty unless failed
+
+	StartTime time.Time `json:"startTime,omitempty"` // When the task started running
+	EndTime   time.Time `json:"endTime,omitempty"`   // When the task finished
+}
+
+// TaskTiming records when a task started and finished
+// Covers all attempts of the task including the backoff between them
+type TaskTiming struct {
+	StartTime time.Time `json:"startTime"`         // When the task started running
+	EndTime   time.Time `json:"endTime,omitempty"` // When the task finished, zero while running
+}
+
+// Attempt records a single run of a task function
+// Retries add one attempt each, so a task retried twice has three
+type Attempt struct {
+	Number     int       `json:"number"`              // 1 for the first attempt of the task
+	StartTime  time.Time `json:"startTime"`           // When the attempt started
+	DurationMs int64     `json:"durationMs"`          // How long the attempt ran
+	BackoffMs  int64     `json:"backoffMs,omitempty"` // Wait after the previous attempt before this one
+	Error      string    `json:"error,omitempty"`     // Failure of the attempt, empty if it succeeded
 }
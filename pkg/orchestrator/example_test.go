@@ -0,0 +1,133 @@
+// example_test.go shows how a Go program embeds the orchestrator
+// Each example opens its own database in a temporary directory
+// Examples run as tests and check their printed output
+package orchestrator_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+	"github.com/fawad1985/go-job-orchestrator/pkg/orchestrator"
+)
+
+// greetDefinition is a single task job calling greetFunction
+var greetDefinition = &models.JobDefinition{
+	ID:    "greet",
+	Name:  "Greet",
+	Tasks: []*models.Task{{ID: "greet", Name: "Greet", FunctionName: "greetFunction"}},
+}
+
+// Example registers a function and a definition, runs a job, and follows its
+// state until it finished
+func Example() {
+	dir, err := os.MkdirTemp("", "orchestrator-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orch, err := orchestrator.New(orchestrator.Config{DBPath: filepath.Join(dir, "jobs.db")})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer orch.Close()
+
+	orch.RegisterFunction("greetFunction", func(ctx context.Context, data map[string]interface{}) error {
+		fmt.Println("hello", data["name"])
+		return nil
+	})
+	if err := orch.RegisterDefinition(greetDefinition); err != nil {
+		log.Fatal(err)
+	}
+
+	_, updates, err := orch.EnqueueAndWatch("greet", map[string]interface{}{"name": "world"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var last models.JobExecutionState
+	for state := range updates {
+		last = state
+	}
+	fmt.Println(last.Status)
+	// Output:
+	// hello world
+	// COMPLETED
+}
+
+// ExampleOrchestrator_GetState polls the state of an enqueued job
+func ExampleOrchestrator_GetState() {
+	dir, err := os.MkdirTemp("", "orchestrator-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orch, err := orchestrator.New(orchestrator.Config{DBPath: filepath.Join(dir, "jobs.db")})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer orch.Close()
+
+	orch.RegisterFunction("greetFunction", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	if err := orch.RegisterDefinition(greetDefinition); err != nil {
+		log.Fatal(err)
+	}
+
+	id, err := orch.Enqueue("greet", map[string]interface{}{"name": "world"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for {
+		state, err := orch.GetState(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if state.Status.Finished() {
+			fmt.Println(state.Status, state.Tasks[0].Status)
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Output: COMPLETED COMPLETED
+}
+
+// ExampleOrchestrator_UnregisterFunction fails jobs whose function was removed
+func ExampleOrchestrator_UnregisterFunction() {
+	dir, err := os.MkdirTemp("", "orchestrator-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orch, err := orchestrator.New(orchestrator.Config{DBPath: filepath.Join(dir, "jobs.db")})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer orch.Close()
+
+	orch.RegisterFunction("greetFunction", func(ctx context.Context, data map[string]interface{}) error {
+		return nil
+	})
+	if err := orch.RegisterDefinition(greetDefinition); err != nil {
+		log.Fatal(err)
+	}
+	orch.UnregisterFunction("greetFunction")
+
+	_, updates, err := orch.EnqueueAndWatch("greet", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var last models.JobExecutionState
+	for state := range updates {
+		last = state
+	}
+	fmt.Println(last.Status)
+	// Output: FAILED
+}
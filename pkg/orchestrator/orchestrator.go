@@ -0,0 +1,116 @@
+// orchestrator.go exposes the job orchestrator to programs embedding it
+// Wraps the internal orchestrator behind a small API that is kept stable
+// Everything not exported here remains an implementation detail
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	internal "github.com/fawad1985/go-job-orchestrator/internal/orchestrator"
+	"github.com/fawad1985/go-job-orchestrator/internal/storage"
+	"github.com/fawad1985/go-job-orchestrator/pkg/models"
+)
+
+// TaskFunction implements a task
+// Takes a context for cancellation and the job data, returns an error if the task failed
+type TaskFunction func(ctx context.Context, data map[string]interface{}) error
+
+// Errors returned by the orchestrator, to be checked with errors.Is
+var (
+	// ErrNotFound is returned when a definition or execution does not exist
+	ErrNotFound = internal.ErrNotFound
+
+	// ErrInvalidDefinition is returned when a job definition is malformed
+	ErrInvalidDefinition = internal.ErrInvalidDefinition
+)
+
+// Config holds the settings of an embedded orchestrator
+type Config struct {
+	DBPath        string // Path of the BoltDB file, created if missing
+	MaxConcurrent int    // Maximum number of jobs running at once, defaults to 10
+
+	// HoldMissingFunctions keeps jobs whose task functions aren't registered queued
+	// for up to this long, waiting for the functions to return, zero to fail them
+	HoldMissingFunctions time.Duration
+}
+
+// Orchestrator runs jobs for an embedding program
+// Safe for concurrent use
+type Orchestrator struct {
+	orch *internal.Orchestrator
+}
+
+// New opens the database and starts an orchestrator
+// Jobs interrupted by a previous shutdown are recovered
+// Close must be called to release the database
+func New(cfg Config) (*Orchestrator, error) {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 10
+	}
+	db, err := storage.NewBoltDB(cfg.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	var opts []internal.Option
+	if cfg.HoldMissingFunctions > 0 {
+		opts = append(opts, internal.WithHoldMissingFunctions(cfg.HoldMissingFunctions))
+	}
+	orch, err := internal.New(db, cfg.MaxConcurrent, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Orchestrator{orch: orch}, nil
+}
+
+// RegisterFunction makes a function available to tasks under the given name
+// Tasks reference it through their functionName
+// Register functions before enqueuing jobs that use them
+func (o *Orchestrator) RegisterFunction(name string, fn TaskFunction) {
+	o.orch.RegisterFunction(name, internal.TaskFunction(fn))
+}
+
+// UnregisterFunction removes the function registered under a name
+// Queued jobs using it fail when taken off the queue, or wait for it to be
+// registered again with Config.HoldMissingFunctions
+func (o *Orchestrator) UnregisterFunction(name string) {
+	o.orch.UnregisterFunction(name)
+}
+
+// RegisterDefinition validates and stores a job definition
+// Registering a definition with an existing ID replaces it
+func (o *Orchestrator) RegisterDefinition(jd *models.JobDefinition) error {
+	return o.orch.RegisterJobDefinition(jd)
+}
+
+// Enqueue queues a new execution of a job definition with the given data
+// Returns the execution ID to query its state with
+func (o *Orchestrator) Enqueue(definitionID string, data map[string]interface{}) (string, error) {
+	return o.orch.EnqueueJob(definitionID, data)
+}
+
+// EnqueueAndWatch queues a new execution and streams its state over a channel
+// The channel receives every state change and is closed once the execution
+// finished or the orchestrator is closed. Callers must read until it is closed
+func (o *Orchestrator) EnqueueAndWatch(definitionID string, data map[string]interface{}) (string, <-chan models.JobExecutionState, error) {
+	return o.orch.EnqueueAndWatch(definitionID, data)
+}
+
+// GetState returns the current state of an execution and its tasks
+func (o *Orchestrator) GetState(executionID string) (*models.JobExecutionState, error) {
+	return o.orch.GetJobExecutionState(executionID)
+}
+
+// Close stops taking jobs off the queue and waits for running jobs to finish
+// Then closes the database
+func (o *Orchestrator) Close() error {
+	return o.orch.Close()
+}
+
+// CloseWithTimeout behaves like Close but waits at most the grace period
+// Jobs still running afterwards are cancelled, given a few seconds to record
+// their state, and recovered on the next start
+func (o *Orchestrator) CloseWithTimeout(grace time.Duration) error {
+	return o.orch.CloseWithTimeout(grace)
+}
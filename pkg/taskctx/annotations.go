@@ -0,0 +1,65 @@
+// annotations.go lets task functions attach notes to their execution
+// Notes such as "processed 500/1000 records" show up in the execution state
+// The orchestrator attaches the annotations of the execution to the task context
+package taskctx
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Annotations holds the notes of an execution by key
+// Safe for concurrent use by the tasks of a job
+type Annotations struct {
+	mu      sync.Mutex
+	values  map[string]string
+	changed bool // Whether values changed since the last snapshot
+}
+
+// NewAnnotations creates annotations starting with the given notes
+// initial holds notes set earlier, e.g. by a previous run of a retried execution
+func NewAnnotations(initial map[string]string) *Annotations {
+	values := maps.Clone(initial)
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return &Annotations{values: values}
+}
+
+// Set adds or replaces the note under key
+func (a *Annotations) Set(key, value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if v, ok := a.values[key]; ok && v == value {
+		return
+	}
+	a.values[key] = value
+	a.changed = true
+}
+
+// Snapshot returns a copy of the notes
+// Also reports whether they changed since the previous snapshot
+func (a *Annotations) Snapshot() (map[string]string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	changed := a.changed
+	a.changed = false
+	return maps.Clone(a.values), changed
+}
+
+// annotationsKey is the context key under which the annotations are stored
+type annotationsKey struct{}
+
+// WithAnnotations returns a copy of ctx carrying the given annotations
+func WithAnnotations(ctx context.Context, a *Annotations) context.Context {
+	return context.WithValue(ctx, annotationsKey{}, a)
+}
+
+// Annotate sets a note on the execution of the task running with ctx
+// Does nothing when ctx carries no annotations, e.g. in unit tests of a task
+func Annotate(ctx context.Context, key, value string) {
+	if a, ok := ctx.Value(annotationsKey{}).(*Annotations); ok {
+		a.Set(key, value)
+	}
+}
@@ -0,0 +1,61 @@
+// capture.go collects task log output for an execution
+// The output is bounded so a chatty task can't exhaust memory
+// Lines written past the limit are dropped and a truncation marker is added once
+package taskctx
+
+import (
+	"strings"
+	"sync"
+)
+
+// TruncationMarker is appended once the log output reached its limit
+const TruncationMarker = "[log truncated]\n"
+
+// LogBuffer holds the captured log lines of an execution up to a maximum size
+// Safe for concurrent use by the tasks of a job
+type LogBuffer struct {
+	mu        sync.Mutex
+	buf       strings.Builder
+	max       int  // Maximum size of the captured lines in bytes
+	truncated bool // Whether lines were dropped
+}
+
+// NewLogBuffer creates a buffer capturing at most max bytes of log lines
+// initial is output captured earlier, e.g. by a previous run of a retried execution
+func NewLogBuffer(max int, initial string) *LogBuffer {
+	b := &LogBuffer{max: max}
+	b.buf.WriteString(initial)
+	b.truncated = strings.HasSuffix(initial, TruncationMarker)
+	return b
+}
+
+// WriteLine captures a log line if it fits into the limit
+// The first line that doesn't fit is replaced by the truncation marker
+func (b *LogBuffer) WriteLine(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return
+	}
+	if b.buf.Len()+len(line)+1 > b.max {
+		b.buf.WriteString(TruncationMarker)
+		b.truncated = true
+		return
+	}
+	b.buf.WriteString(line)
+	b.buf.WriteByte('\n')
+}
+
+// String returns the captured output
+func (b *LogBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Truncated reports whether lines were dropped because of the limit
+func (b *LogBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
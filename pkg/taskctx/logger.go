@@ -0,0 +1,127 @@
+// logger.go provides the leveled logger handed to task functions
+// The orchestrator attaches a logger to each task's context
+// Levels are configured per task or per job definition
+package taskctx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level is the minimum severity a logger writes
+type Level int
+
+const (
+	LevelDebug Level = iota // Verbose diagnostics
+	LevelInfo               // Normal progress messages
+	LevelWarn               // Unexpected but recoverable situations
+	LevelError              // Failures
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLevel converts a level name such as "debug" into a Level
+// An empty name yields the default info level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// Logger writes task log messages at or above its level
+// Messages are prefixed to identify the execution and task
+type Logger struct {
+	level   Level      // Minimum level written
+	prefix  string     // Prepended to every message
+	capture *LogBuffer // Also receives written messages, may be nil
+}
+
+// NewLogger creates a logger writing messages at or above level
+func NewLogger(level Level, prefix string) *Logger {
+	return &Logger{level: level, prefix: prefix}
+}
+
+// NewCapturingLogger creates a logger that also captures written messages
+// Messages are captured without the prefix
+func NewCapturingLogger(level Level, prefix string, capture *LogBuffer) *Logger {
+	return &Logger{level: level, prefix: prefix, capture: capture}
+}
+
+// Level returns the minimum level the logger writes
+func (l *Logger) Level() Level {
+	return l.level
+}
+
+// Debugf logs a message at debug level
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
+}
+
+// Infof logs a message at info level
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args...)
+}
+
+// Warnf logs a message at warn level
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args...)
+}
+
+// Errorf logs a message at error level
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args...)
+}
+
+// logf writes the message if its level is enabled
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	line := fmt.Sprintf("%s: %s", strings.ToUpper(level.String()), fmt.Sprintf(format, args...))
+	log.Print(l.prefix + line)
+	if l.capture != nil {
+		l.capture.WriteLine(line)
+	}
+}
+
+// loggerKey is the context key under which the task logger is stored
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying the given logger
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Log returns the logger attached to ctx
+// Falls back to an info level logger when none is attached
+func Log(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return l
+	}
+	return NewLogger(LevelInfo, "")
+}
@@ -0,0 +1,21 @@
+// worker.go identifies the worker running a task
+// Tasks can keep caches per worker and rely on affinity keys to reuse them
+// The orchestrator attaches the worker to the context of each job it runs
+package taskctx
+
+import "context"
+
+// workerKey is the context key under which the worker name is stored
+type workerKey struct{}
+
+// WithWorker returns a copy of ctx naming the worker running the job
+func WithWorker(ctx context.Context, worker string) context.Context {
+	return context.WithValue(ctx, workerKey{}, worker)
+}
+
+// Worker returns the worker running the task, e.g. "default/2" or "gpu/0"
+// Empty for tasks not run by a worker, e.g. of executions resumed after a restart
+func Worker(ctx context.Context) string {
+	worker, _ := ctx.Value(workerKey{}).(string)
+	return worker
+}